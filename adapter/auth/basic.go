@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"errors"
+
+	"github.com/XBozorg/bookstore/adapter/repository"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+const basicUserKey = "basic-user-id"
+
+// UserBasicAuth authenticates users by username or email and password, e-reader apps
+// can't keep the token cookies
+func UserBasicAuth(storage repository.Storage) echo.MiddlewareFunc {
+	return middleware.BasicAuthWithConfig(middleware.BasicAuthConfig{
+		Realm: "Bookstore",
+		Validator: func(username, password string, c echo.Context) (bool, error) {
+
+			u, err := storage.LoginUser(c.Request().Context(), username, username, password)
+			if err != nil {
+				return false, nil
+			}
+
+			c.Set(basicUserKey, u.ID)
+			return true, nil
+		},
+	})
+}
+
+func GetBasicID(c echo.Context) (string, error) {
+
+	id, ok := c.Get(basicUserKey).(string)
+	if !ok || id == "" {
+		return "", errors.New("user is not authenticated")
+	}
+
+	return id, nil
+}
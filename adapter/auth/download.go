@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/XBozorg/bookstore/config"
+)
+
+// SignDownload returns the hex encoded HMAC of a download link, bound to the user, book, format and expiration
+func SignDownload(userID string, bookID uint, format string, exp int64) string {
+
+	mac := hmac.New(sha256.New, []byte(config.Conf.GetDownloadConfig().Secret))
+	mac.Write([]byte(fmt.Sprintf("%s:%d:%s:%d", userID, bookID, format, exp)))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func VerifyDownload(userID string, bookID uint, format string, exp int64, sig string) error {
+
+	if time.Now().Unix() > exp {
+		return errors.New("download link expired")
+	}
+
+	expected := SignDownload(userID, bookID, format, exp)
+	if !hmac.Equal([]byte(expected), []byte(sig)) {
+		return errors.New("invalid download signature")
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package catalog
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/XBozorg/bookstore/entity/book"
+)
+
+var year = regexp.MustCompile(`^\d{4}$`)
+
+// check applies the column constraints of the book table to an imported record
+func check(b book.Book) error {
+
+	switch {
+	case !validISBN13(b.ISBN):
+		return errors.New("invalid isbn")
+	case b.Title == "" || utf8.RuneCountInString(b.Title) > 100:
+		return errors.New("title must be 1 to 100 characters")
+	case len(b.Authors) == 0:
+		return errors.New("at least one author is required")
+	case b.Publisher.Name == "":
+		return errors.New("publisher is required")
+	case len(b.Language.Code) != 2:
+		return errors.New("language must be a two letter code")
+	case !year.MatchString(b.Year):
+		return errors.New("invalid year")
+	case utf8.RuneCountInString(b.Description) > 500:
+		return errors.New("description is longer than 500 characters")
+	}
+
+	for _, a := range b.Authors {
+		if utf8.RuneCountInString(a.Name) > 100 {
+			return errors.New("author name is longer than 100 characters")
+		}
+	}
+	for _, t := range b.Topics {
+		if utf8.RuneCountInString(t.Name) > 30 {
+			return errors.New("topic name is longer than 30 characters")
+		}
+	}
+
+	return nil
+}
+
+func normalizeISBN(isbn string) string {
+	return strings.NewReplacer("-", "", " ", "").Replace(isbn)
+}
+
+func validISBN13(isbn string) bool {
+
+	if len(isbn) != 13 {
+		return false
+	}
+
+	sum := 0
+	for i, r := range isbn {
+		if r < '0' || r > '9' {
+			return false
+		}
+		d := int(r - '0')
+		if i%2 == 1 {
+			d *= 3
+		}
+		sum += d
+	}
+
+	return sum%10 == 0
+}
@@ -0,0 +1,199 @@
+package catalog
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/XBozorg/bookstore/entity/book"
+)
+
+var csvHeader = []string{
+	"isbn", "title", "authors", "publisher", "language", "topics", "pages", "year", "description",
+	"digital_price", "physical_price", "physical_stock", "cover_front", "cover_back",
+	"pdf", "epub", "djvu", "azw", "txt", "docx",
+}
+
+// multiple authors and topics share a column
+const listSeparator = "|"
+
+// ReadCSV parses a catalog with the export header, the columns may come in any order
+// and only isbn and title are mandatory
+func ReadCSV(r io.Reader) ([]book.ImportRow, []book.ImportError, error) {
+
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cols := map[string]int{}
+	for i, name := range header {
+		cols[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, name := range []string{"isbn", "title"} {
+		if _, ok := cols[name]; !ok {
+			return nil, nil, fmt.Errorf("missing %s column", name)
+		}
+	}
+
+	rows := []book.ImportRow{}
+	errs := []book.ImportError{}
+
+	for line := uint(2); ; line++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			errs = append(errs, book.ImportError{Row: line, Message: err.Error()})
+			continue
+		}
+
+		get := func(name string) string {
+			if i, ok := cols[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		b := book.Book{
+			ISBN:        normalizeISBN(get("isbn")),
+			Title:       get("title"),
+			Authors:     []book.Author{},
+			Topics:      []book.Topic{},
+			Publisher:   book.Publisher{Name: get("publisher")},
+			Language:    book.Language{Code: strings.ToLower(get("language"))},
+			Year:        get("year"),
+			Description: get("description"),
+			CoverFront:  get("cover_front"),
+			CoverBack:   get("cover_back"),
+			Digital: book.Digital{
+				PDF:  get("pdf"),
+				EPUB: get("epub"),
+				DJVU: get("djvu"),
+				AZW:  get("azw"),
+				TXT:  get("txt"),
+				DOCX: get("docx"),
+			},
+		}
+
+		for _, name := range split(get("authors")) {
+			b.Authors = append(b.Authors, book.Author{Name: name})
+		}
+		for _, name := range split(get("topics")) {
+			b.Topics = append(b.Topics, book.Topic{Name: name})
+		}
+
+		if err = parseUints(map[string]*uint{
+			"pages":          &b.Pages,
+			"digital_price":  &b.Digital.Price,
+			"physical_price": &b.Physical.Price,
+			"physical_stock": &b.Physical.Stock,
+		}, get); err != nil {
+			errs = append(errs, book.ImportError{Row: line, ISBN: b.ISBN, Message: err.Error()})
+			continue
+		}
+
+		if err = check(b); err != nil {
+			errs = append(errs, book.ImportError{Row: line, ISBN: b.ISBN, Message: err.Error()})
+			continue
+		}
+
+		b.Availability = b.ComputeAvailability()
+		rows = append(rows, book.ImportRow{
+			Row:  line,
+			Book: b,
+			Fields: book.ImportFields{
+				DigitalPrice:  get("digital_price") != "",
+				PhysicalPrice: get("physical_price") != "",
+				PhysicalStock: get("physical_stock") != "",
+			},
+		})
+	}
+
+	return rows, errs, nil
+}
+
+func WriteCSV(w io.Writer, books []book.Book) error {
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, b := range books {
+		authors := make([]string, len(b.Authors))
+		for i, a := range b.Authors {
+			authors[i] = a.Name
+		}
+		topics := make([]string, len(b.Topics))
+		for i, t := range b.Topics {
+			topics[i] = t.Name
+		}
+
+		if err := cw.Write([]string{
+			b.ISBN,
+			b.Title,
+			strings.Join(authors, listSeparator),
+			b.Publisher.Name,
+			b.Language.Code,
+			strings.Join(topics, listSeparator),
+			strconv.FormatUint(uint64(b.Pages), 10),
+			b.Year,
+			b.Description,
+			strconv.FormatUint(uint64(b.Digital.Price), 10),
+			strconv.FormatUint(uint64(b.Physical.Price), 10),
+			strconv.FormatUint(uint64(b.Physical.Stock), 10),
+			b.CoverFront,
+			b.CoverBack,
+			b.Digital.PDF,
+			b.Digital.EPUB,
+			b.Digital.DJVU,
+			b.Digital.AZW,
+			b.Digital.TXT,
+			b.Digital.DOCX,
+		}); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func parseUints(fields map[string]*uint, get func(string) string) error {
+
+	for name, field := range fields {
+		value := get(name)
+		if value == "" {
+			continue
+		}
+
+		n, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return errors.New("invalid " + name)
+		}
+		*field = uint(n)
+	}
+
+	return nil
+}
+
+func split(s string) []string {
+
+	parts := []string{}
+	for _, p := range strings.Split(s, listSeparator) {
+		if p = strings.TrimSpace(p); p != "" {
+			parts = append(parts, p)
+		}
+	}
+
+	return parts
+}
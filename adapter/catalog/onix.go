@@ -0,0 +1,459 @@
+package catalog
+
+import (
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/XBozorg/bookstore/entity/book"
+)
+
+// ONIX 3.0 reference tags, limited to the fields the catalog keeps.
+// A book is exported as a physical product (form BC) and, when sold digitally,
+// a digital product (form EA) with the same ISBN; both are merged back on import.
+
+const (
+	onixNamespace = "http://ns.editeur.org/onix/3.0/reference"
+	onixCurrency  = "IRR"
+
+	onixISBN13       = "15"
+	onixMainTitle    = "01"
+	onixAuthor       = "A01"
+	onixTextLang     = "01"
+	onixPageCount    = "00"
+	onixKeywords     = "20"
+	onixDescription  = "03"
+	onixPublished    = "01"
+	onixMainPub      = "01"
+	onixFrontCover   = "01"
+	onixBackCover    = "02"
+	onixRRP          = "02"
+	onixDigitalForm  = "EA"
+	onixPhysicalForm = "BC"
+)
+
+type onixMessage struct {
+	XMLName  xml.Name      `xml:"ONIXMessage"`
+	Xmlns    string        `xml:"xmlns,attr,omitempty"`
+	Release  string        `xml:"release,attr"`
+	Header   onixHeader    `xml:"Header"`
+	Products []onixProduct `xml:"Product"`
+}
+
+type onixHeader struct {
+	SenderName string `xml:"Sender>SenderName"`
+	SentDate   string `xml:"SentDateTime"`
+}
+
+type onixProduct struct {
+	RecordReference   string                `xml:"RecordReference"`
+	NotificationType  string                `xml:"NotificationType"`
+	Identifiers       []onixIdentifier      `xml:"ProductIdentifier"`
+	DescriptiveDetail onixDescriptiveDetail `xml:"DescriptiveDetail"`
+	CollateralDetail  *onixCollateralDetail `xml:"CollateralDetail,omitempty"`
+	PublishingDetail  onixPublishingDetail  `xml:"PublishingDetail"`
+	ProductSupply     *onixProductSupply    `xml:"ProductSupply,omitempty"`
+}
+
+type onixIdentifier struct {
+	Type  string `xml:"ProductIDType"`
+	Value string `xml:"IDValue"`
+}
+
+type onixDescriptiveDetail struct {
+	Composition  string            `xml:"ProductComposition"`
+	Form         string            `xml:"ProductForm"`
+	Titles       []onixTitle       `xml:"TitleDetail"`
+	Contributors []onixContributor `xml:"Contributor"`
+	Languages    []onixLanguage    `xml:"Language"`
+	Extents      []onixExtent      `xml:"Extent"`
+	Subjects     []onixSubject     `xml:"Subject"`
+}
+
+type onixTitle struct {
+	Type     string `xml:"TitleType"`
+	Level    string `xml:"TitleElement>TitleElementLevel"`
+	Text     string `xml:"TitleElement>TitleText"`
+	Prefix   string `xml:"TitleElement>TitlePrefix,omitempty"`
+	NoPrefix string `xml:"TitleElement>TitleWithoutPrefix,omitempty"`
+}
+
+type onixContributor struct {
+	Sequence   uint   `xml:"SequenceNumber"`
+	Role       string `xml:"ContributorRole"`
+	PersonName string `xml:"PersonName,omitempty"`
+	Corporate  string `xml:"CorporateName,omitempty"`
+}
+
+type onixLanguage struct {
+	Role string `xml:"LanguageRole"`
+	Code string `xml:"LanguageCode"`
+}
+
+type onixExtent struct {
+	Type  string `xml:"ExtentType"`
+	Value string `xml:"ExtentValue"`
+	Unit  string `xml:"ExtentUnit"`
+}
+
+type onixSubject struct {
+	Scheme  string `xml:"SubjectSchemeIdentifier"`
+	Heading string `xml:"SubjectHeadingText"`
+}
+
+type onixCollateralDetail struct {
+	Texts     []onixText     `xml:"TextContent"`
+	Resources []onixResource `xml:"SupportingResource"`
+}
+
+type onixText struct {
+	Type     string `xml:"TextType"`
+	Audience string `xml:"ContentAudience"`
+	Text     string `xml:"Text"`
+}
+
+type onixResource struct {
+	ContentType string `xml:"ResourceContentType"`
+	Audience    string `xml:"ContentAudience"`
+	Mode        string `xml:"ResourceMode"`
+	Link        string `xml:"ResourceVersion>ResourceLink"`
+}
+
+type onixPublishingDetail struct {
+	Publishers []onixPublisher   `xml:"Publisher"`
+	Dates      []onixPublishDate `xml:"PublishingDate"`
+}
+
+type onixPublisher struct {
+	Role string `xml:"PublishingRole"`
+	Name string `xml:"PublisherName"`
+}
+
+type onixPublishDate struct {
+	Role string `xml:"PublishingDateRole"`
+	Date string `xml:"Date"`
+}
+
+type onixProductSupply struct {
+	SupplyDetails []onixSupplyDetail `xml:"SupplyDetail"`
+}
+
+type onixSupplyDetail struct {
+	Supplier     string      `xml:"Supplier>SupplierName"`
+	SupplierRole string      `xml:"Supplier>SupplierRole"`
+	Availability string      `xml:"ProductAvailability"`
+	OnHand       *uint       `xml:"Stock>OnHand,omitempty"`
+	Prices       []onixPrice `xml:"Price"`
+}
+
+type onixPrice struct {
+	Type     string `xml:"PriceType"`
+	Amount   uint   `xml:"PriceAmount"`
+	Currency string `xml:"CurrencyCode"`
+}
+
+// ReadONIX parses an ONIX 3.0 reference tag message, products sharing an ISBN are merged into one book
+func ReadONIX(r io.Reader) ([]book.ImportRow, []book.ImportError, error) {
+
+	msg := onixMessage{}
+	if err := xml.NewDecoder(r).Decode(&msg); err != nil {
+		return nil, nil, err
+	}
+
+	rows := []book.ImportRow{}
+	errs := []book.ImportError{}
+	index := map[string]int{}
+
+	for i, p := range msg.Products {
+		row := uint(i + 1)
+		b, fields := onixToBook(p)
+
+		if j, ok := index[b.ISBN]; ok && b.ISBN != "" {
+			mergeProduct(&rows[j], b, fields)
+			continue
+		}
+
+		if err := check(b); err != nil {
+			errs = append(errs, book.ImportError{Row: row, ISBN: b.ISBN, Message: err.Error()})
+			continue
+		}
+
+		index[b.ISBN] = len(rows)
+		rows = append(rows, book.ImportRow{Row: row, Book: b, Fields: fields})
+	}
+
+	for i := range rows {
+		rows[i].Book.Availability = rows[i].Book.ComputeAvailability()
+	}
+
+	return rows, errs, nil
+}
+
+func onixToBook(p onixProduct) (book.Book, book.ImportFields) {
+
+	b := book.Book{Authors: []book.Author{}, Topics: []book.Topic{}}
+	fields := book.ImportFields{}
+	d := p.DescriptiveDetail
+
+	for _, id := range p.Identifiers {
+		if id.Type == onixISBN13 {
+			b.ISBN = normalizeISBN(id.Value)
+		}
+	}
+
+	for _, t := range d.Titles {
+		if t.Type == onixMainTitle {
+			b.Title = strings.TrimSpace(t.Text)
+			if b.Title == "" {
+				b.Title = strings.TrimSpace(t.Prefix + " " + t.NoPrefix)
+			}
+			break
+		}
+	}
+
+	for _, c := range d.Contributors {
+		if c.Role != onixAuthor {
+			continue
+		}
+		name := c.PersonName
+		if name == "" {
+			name = c.Corporate
+		}
+		if name = strings.TrimSpace(name); name != "" {
+			b.Authors = append(b.Authors, book.Author{Name: name})
+		}
+	}
+
+	for _, l := range d.Languages {
+		if l.Role == onixTextLang {
+			b.Language.Code = languageCode(l.Code)
+		}
+	}
+
+	for _, e := range d.Extents {
+		if e.Type == onixPageCount {
+			if n, err := strconv.ParseUint(e.Value, 10, 32); err == nil {
+				b.Pages = uint(n)
+			}
+		}
+	}
+
+	for _, s := range d.Subjects {
+		if s.Scheme != onixKeywords {
+			continue
+		}
+		for _, t := range strings.Split(s.Heading, ";") {
+			if t = strings.TrimSpace(t); t != "" {
+				b.Topics = append(b.Topics, book.Topic{Name: t})
+			}
+		}
+	}
+
+	if p.CollateralDetail != nil {
+		for _, t := range p.CollateralDetail.Texts {
+			if t.Type == onixDescription {
+				b.Description = strings.TrimSpace(t.Text)
+			}
+		}
+		for _, res := range p.CollateralDetail.Resources {
+			switch res.ContentType {
+			case onixFrontCover:
+				b.CoverFront = res.Link
+			case onixBackCover:
+				b.CoverBack = res.Link
+			}
+		}
+	}
+
+	for _, pub := range p.PublishingDetail.Publishers {
+		if pub.Role == onixMainPub {
+			b.Publisher.Name = strings.TrimSpace(pub.Name)
+		}
+	}
+	for _, date := range p.PublishingDetail.Dates {
+		if date.Role == onixPublished && len(date.Date) >= 4 {
+			b.Year = date.Date[:4]
+		}
+	}
+
+	if p.ProductSupply != nil {
+		digital := strings.HasPrefix(d.Form, "E")
+		for _, s := range p.ProductSupply.SupplyDetails {
+			for _, price := range s.Prices {
+				if digital {
+					b.Digital.Price = price.Amount
+					fields.DigitalPrice = true
+				} else {
+					b.Physical.Price = price.Amount
+					fields.PhysicalPrice = true
+				}
+			}
+			if s.OnHand != nil && !digital {
+				b.Physical.Stock = *s.OnHand
+				fields.PhysicalStock = true
+			}
+		}
+	}
+
+	return b, fields
+}
+
+// mergeProduct fills the prices and stock the row lacks from the other form of the same book
+func mergeProduct(row *book.ImportRow, p book.Book, fields book.ImportFields) {
+
+	if !row.Fields.DigitalPrice && fields.DigitalPrice {
+		row.Book.Digital.Price = p.Digital.Price
+		row.Fields.DigitalPrice = true
+	}
+	if !row.Fields.PhysicalPrice && fields.PhysicalPrice {
+		row.Book.Physical.Price = p.Physical.Price
+		row.Fields.PhysicalPrice = true
+	}
+	if !row.Fields.PhysicalStock && fields.PhysicalStock {
+		row.Book.Physical.Stock = p.Physical.Stock
+		row.Fields.PhysicalStock = true
+	}
+}
+
+func WriteONIX(w io.Writer, books []book.Book) error {
+
+	msg := onixMessage{
+		Xmlns:   onixNamespace,
+		Release: "3.0",
+		Header: onixHeader{
+			SenderName: "bookstore",
+			SentDate:   time.Now().Format("20060102T1504"),
+		},
+		Products: []onixProduct{},
+	}
+
+	for _, b := range books {
+		msg.Products = append(msg.Products, bookToONIX(b, onixPhysicalForm))
+		if b.Digital.Price > 0 {
+			msg.Products = append(msg.Products, bookToONIX(b, onixDigitalForm))
+		}
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+
+	return enc.Encode(msg)
+}
+
+func bookToONIX(b book.Book, form string) onixProduct {
+
+	ref := b.ISBN
+	if form == onixDigitalForm {
+		ref += "-digital"
+	}
+
+	p := onixProduct{
+		RecordReference:  ref,
+		NotificationType: "03",
+		Identifiers:      []onixIdentifier{{Type: onixISBN13, Value: b.ISBN}},
+		DescriptiveDetail: onixDescriptiveDetail{
+			Composition: "00",
+			Form:        form,
+			Titles:      []onixTitle{{Type: onixMainTitle, Level: "01", Text: b.Title}},
+			Languages:   []onixLanguage{{Role: onixTextLang, Code: languageCode3(b.Language.Code)}},
+			Extents:     []onixExtent{{Type: onixPageCount, Value: strconv.FormatUint(uint64(b.Pages), 10), Unit: "03"}},
+		},
+		PublishingDetail: onixPublishingDetail{
+			Publishers: []onixPublisher{{Role: onixMainPub, Name: b.Publisher.Name}},
+			Dates:      []onixPublishDate{{Role: onixPublished, Date: b.Year}},
+		},
+	}
+
+	for i, a := range b.Authors {
+		p.DescriptiveDetail.Contributors = append(p.DescriptiveDetail.Contributors,
+			onixContributor{Sequence: uint(i + 1), Role: onixAuthor, PersonName: a.Name})
+	}
+
+	if len(b.Topics) > 0 {
+		topics := make([]string, len(b.Topics))
+		for i, t := range b.Topics {
+			topics[i] = t.Name
+		}
+		p.DescriptiveDetail.Subjects = []onixSubject{{Scheme: onixKeywords, Heading: strings.Join(topics, "; ")}}
+	}
+
+	collateral := onixCollateralDetail{}
+	if b.Description != "" {
+		collateral.Texts = append(collateral.Texts, onixText{Type: onixDescription, Audience: "00", Text: b.Description})
+	}
+	if b.CoverFront != "" {
+		collateral.Resources = append(collateral.Resources,
+			onixResource{ContentType: onixFrontCover, Audience: "00", Mode: "03", Link: b.CoverFront})
+	}
+	if b.CoverBack != "" {
+		collateral.Resources = append(collateral.Resources,
+			onixResource{ContentType: onixBackCover, Audience: "00", Mode: "03", Link: b.CoverBack})
+	}
+	if len(collateral.Texts) > 0 || len(collateral.Resources) > 0 {
+		p.CollateralDetail = &collateral
+	}
+
+	supply := onixSupplyDetail{Supplier: "bookstore", SupplierRole: "00", Availability: "21"}
+	if form == onixDigitalForm {
+		supply.Prices = []onixPrice{{Type: onixRRP, Amount: b.Digital.Price, Currency: onixCurrency}}
+	} else {
+		stock := b.Physical.Stock
+		supply.OnHand = &stock
+		supply.Prices = []onixPrice{{Type: onixRRP, Amount: b.Physical.Price, Currency: onixCurrency}}
+		if stock == 0 {
+			supply.Availability = "31" // out of stock
+		}
+	}
+	p.ProductSupply = &onixProductSupply{SupplyDetails: []onixSupplyDetail{supply}}
+
+	return p
+}
+
+// ONIX uses ISO 639-2/B codes, the catalog stores ISO 639-1
+var languages = map[string]string{
+	"ara": "ar", "chi": "zh", "dut": "nl", "eng": "en", "fre": "fr", "ger": "de", "ita": "it",
+	"jpn": "ja", "kor": "ko", "kur": "ku", "per": "fa", "por": "pt", "rus": "ru", "spa": "es",
+	"swe": "sv", "tur": "tr", "urd": "ur", "aze": "az", "arm": "hy", "heb": "he", "hin": "hi",
+}
+
+func languageCode(code string) string {
+
+	code = strings.ToLower(strings.TrimSpace(code))
+	if len(code) == 2 {
+		return code
+	}
+	// terminology variants of ISO 639-2
+	switch code {
+	case "fas":
+		return "fa"
+	case "zho":
+		return "zh"
+	case "fra":
+		return "fr"
+	case "deu":
+		return "de"
+	case "nld":
+		return "nl"
+	case "hye":
+		return "hy"
+	}
+
+	return languages[code]
+}
+
+func languageCode3(code string) string {
+
+	for c3, c2 := range languages {
+		if c2 == code {
+			return c3
+		}
+	}
+
+	return code
+}
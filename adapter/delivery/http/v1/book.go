@@ -2,12 +2,15 @@ package v1
 
 import (
 	"net/http"
+	"os"
 	"strconv"
 	"strings"
 
 	"github.com/XBozorg/bookstore/adapter/auth"
+	"github.com/XBozorg/bookstore/adapter/metadata"
 	"github.com/XBozorg/bookstore/adapter/repository"
 	"github.com/XBozorg/bookstore/dto"
+	bookEntity "github.com/XBozorg/bookstore/entity/book"
 	"github.com/XBozorg/bookstore/usecase/book"
 	"github.com/go-sql-driver/mysql"
 	"github.com/labstack/echo/v4"
@@ -51,6 +54,9 @@ func GetAuthor(storage repository.Storage, validator book.ValidateGetAuthor) ech
 
 		if err := validator(c.Request().Context(), req); err != nil {
 			if strings.Contains(err.Error(), "does not exist") {
+				if ok, err := redirectMerged(c, storage, bookEntity.KindAuthor, "authorID"); ok {
+					return err
+				}
 				return echo.NewHTTPError(http.StatusNotFound, "author does not exist")
 			}
 			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
@@ -76,6 +82,70 @@ func GetAuthors(storage repository.Storage) echo.HandlerFunc {
 	}
 }
 
+func EditAuthor(storage repository.Storage, validator book.ValidateEditAuthor) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.EditAuthorRequest{}
+
+		if err := c.Bind(&req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		aid, err := strconv.ParseUint(c.Param("authorID"), 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest)
+		}
+
+		req.Author.ID = uint(aid)
+
+		if err := validator(c.Request().Context(), req); err != nil {
+			if strings.Contains(err.Error(), "does not exist") {
+				return echo.NewHTTPError(http.StatusNotFound, "author does not exist")
+			}
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		resp, err := book.New(storage).EditAuthor(c.Request().Context(), req)
+		if err != nil {
+
+			if driverErr, ok := err.(*mysql.MySQLError); ok && driverErr.Number == 1062 {
+				return echo.NewHTTPError(http.StatusConflict, "author already exists")
+			}
+
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
+func GetAuthorPage(storage repository.Storage, validator book.ValidateGetAuthorPage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.GetAuthorPageRequest{}
+
+		aid, err := strconv.ParseUint(c.Param("authorID"), 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest)
+		}
+
+		req.AuthorID = uint(aid)
+
+		if err := validator(c.Request().Context(), req); err != nil {
+			if strings.Contains(err.Error(), "does not exist") {
+				if ok, err := redirectMerged(c, storage, bookEntity.KindAuthor, "authorID"); ok {
+					return err
+				}
+				return echo.NewHTTPError(http.StatusNotFound, "author does not exist")
+			}
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		resp, err := book.New(storage).GetAuthorPage(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
 func DeleteAuthor(storage repository.Storage, validator book.ValidateDeleteAuthor) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		req := dto.DeleteAuthorRequest{}
@@ -140,6 +210,9 @@ func GetPublisher(storage repository.Storage, validator book.ValidateGetPublishe
 
 		if err := validator(c.Request().Context(), req); err != nil {
 			if strings.Contains(err.Error(), "does not exist") {
+				if ok, err := redirectMerged(c, storage, bookEntity.KindPublisher, "publisherID"); ok {
+					return err
+				}
 				return echo.NewHTTPError(http.StatusNotFound, "publisher does not exist")
 			}
 			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
@@ -165,6 +238,70 @@ func GetPublishers(storage repository.Storage) echo.HandlerFunc {
 	}
 }
 
+func EditPublisher(storage repository.Storage, validator book.ValidateEditPublisher) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.EditPublisherRequest{}
+
+		if err := c.Bind(&req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		pid, err := strconv.ParseUint(c.Param("publisherID"), 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest)
+		}
+
+		req.Publisher.ID = uint(pid)
+
+		if err := validator(c.Request().Context(), req); err != nil {
+			if strings.Contains(err.Error(), "does not exist") {
+				return echo.NewHTTPError(http.StatusNotFound, "publisher does not exist")
+			}
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		resp, err := book.New(storage).EditPublisher(c.Request().Context(), req)
+		if err != nil {
+
+			if driverErr, ok := err.(*mysql.MySQLError); ok && driverErr.Number == 1062 {
+				return echo.NewHTTPError(http.StatusConflict, "publisher already exists")
+			}
+
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
+func GetPublisherPage(storage repository.Storage, validator book.ValidateGetPublisherPage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.GetPublisherPageRequest{}
+
+		pid, err := strconv.ParseUint(c.Param("publisherID"), 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest)
+		}
+
+		req.PublisherID = uint(pid)
+
+		if err := validator(c.Request().Context(), req); err != nil {
+			if strings.Contains(err.Error(), "does not exist") {
+				if ok, err := redirectMerged(c, storage, bookEntity.KindPublisher, "publisherID"); ok {
+					return err
+				}
+				return echo.NewHTTPError(http.StatusNotFound, "publisher does not exist")
+			}
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		resp, err := book.New(storage).GetPublisherPage(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
 func DeletePublisher(storage repository.Storage, validator book.ValidateDeletePublisher) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		req := dto.DeletePublisherRequest{}
@@ -229,6 +366,9 @@ func GetTopic(storage repository.Storage, validator book.ValidateGetTopic) echo.
 
 		if err := validator(c.Request().Context(), req); err != nil {
 			if strings.Contains(err.Error(), "does not exist") {
+				if ok, err := redirectMerged(c, storage, bookEntity.KindTopic, "topicID"); ok {
+					return err
+				}
 				return echo.NewHTTPError(http.StatusNotFound, "topic does not exist")
 			}
 			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
@@ -254,6 +394,76 @@ func GetTopics(storage repository.Storage) echo.HandlerFunc {
 	}
 }
 
+func GetTopicTree(storage repository.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.GetTopicTreeRequest{}
+
+		resp, err := book.New(storage).GetTopicTree(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
+func MoveTopic(storage repository.Storage, validator book.ValidateMoveTopic) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.MoveTopicRequest{}
+
+		if err := c.Bind(&req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		tid, err := strconv.ParseUint(c.Param("topicID"), 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest)
+		}
+		req.TopicID = uint(tid)
+
+		if err := validator(c.Request().Context(), req); err != nil {
+			if strings.Contains(err.Error(), "does not exist") {
+				return echo.NewHTTPError(http.StatusNotFound, err.Error())
+			}
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		resp, err := book.New(storage).MoveTopic(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
+func ReorderTopic(storage repository.Storage, validator book.ValidateReorderTopic) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.ReorderTopicRequest{}
+
+		if err := c.Bind(&req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		tid, err := strconv.ParseUint(c.Param("topicID"), 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest)
+		}
+		req.TopicID = uint(tid)
+
+		if err := validator(c.Request().Context(), req); err != nil {
+			if strings.Contains(err.Error(), "does not exist") {
+				return echo.NewHTTPError(http.StatusNotFound, "topic does not exist")
+			}
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		resp, err := book.New(storage).ReorderTopic(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
 func DeleteTopic(storage repository.Storage, validator book.ValidateDeleteTopic) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		req := dto.DeleteTopicRequest{}
@@ -394,6 +604,74 @@ func AddBook(storage repository.Storage, validator book.ValidateAddBook) echo.Ha
 	}
 }
 
+func ExtractBookMetadata(storage repository.Storage, validator book.ValidateExtractBookMetadata) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.ExtractBookMetadataRequest{}
+
+		if err := c.Bind(&req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		if err := validator(c.Request().Context(), req); err != nil {
+			if strings.Contains(err.Error(), "does not exist") {
+				return echo.NewHTTPError(http.StatusNotFound, err.Error())
+			}
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		m, err := metadata.Extract(req.PDF, req.EPUB)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+		}
+		req.Metadata = m
+
+		resp, err := book.New(storage).ExtractBookMetadata(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
+func ConfirmBookMetadata(storage repository.Storage, validator book.ValidateConfirmBookMetadata) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.ConfirmBookMetadataRequest{}
+
+		if err := c.Bind(&req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		if err := validator(c.Request().Context(), req); err != nil {
+			if strings.Contains(err.Error(), "does not exist") {
+				return echo.NewHTTPError(http.StatusNotFound, err.Error())
+			}
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		// the cover extracted from the epub is only written once the book is confirmed
+		cover := req.Proposal.Book.CoverFront
+		written, err := metadata.SaveCover(req.Proposal.Book.Digital.EPUB, cover)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+		}
+
+		resp, err := book.New(storage).ConfirmBookMetadata(c.Request().Context(), req)
+		if err != nil {
+
+			if written {
+				os.Remove(cover)
+			}
+
+			if driverErr, ok := err.(*mysql.MySQLError); ok && driverErr.Number == 1062 {
+				return echo.NewHTTPError(http.StatusConflict, "book already exists")
+			}
+
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
 func GetBook(storage repository.Storage, validator book.ValidateGetBook) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		req := dto.GetBookRequest{}
@@ -420,6 +698,36 @@ func GetBook(storage repository.Storage, validator book.ValidateGetBook) echo.Ha
 	}
 }
 
+func SetAvailabilityOverride(storage repository.Storage, validator book.ValidateSetAvailabilityOverride) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.SetAvailabilityOverrideRequest{}
+
+		if err := c.Bind(&req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		bid, err := strconv.ParseUint(c.Param("bookID"), 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest)
+		}
+
+		req.BookID = uint(bid)
+
+		if err := validator(c.Request().Context(), req); err != nil {
+			if strings.Contains(err.Error(), "does not exist") {
+				return echo.NewHTTPError(http.StatusNotFound, "book does not exist")
+			}
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		resp, err := book.New(storage).SetAvailabilityOverride(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
 func SetBookDiscount(storage repository.Storage, validator book.ValidateSetBookDiscount) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		req := dto.SetBookDiscountRequest{}
@@ -505,6 +813,9 @@ func GetAuthorBooks(storage repository.Storage, validator book.ValidateGetAuthor
 
 		if err := validator(c.Request().Context(), req); err != nil {
 			if strings.Contains(err.Error(), "does not exist") {
+				if ok, err := redirectMerged(c, storage, bookEntity.KindAuthor, "authorID"); ok {
+					return err
+				}
 				return echo.NewHTTPError(http.StatusNotFound, "author does not exist")
 			}
 			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
@@ -531,6 +842,9 @@ func GetPublisherBooks(storage repository.Storage, validator book.ValidateGetPub
 
 		if err := validator(c.Request().Context(), req); err != nil {
 			if strings.Contains(err.Error(), "does not exist") {
+				if ok, err := redirectMerged(c, storage, bookEntity.KindPublisher, "publisherID"); ok {
+					return err
+				}
 				return echo.NewHTTPError(http.StatusNotFound, "publisher does not exist")
 			}
 			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
@@ -555,8 +869,17 @@ func GetTopicBooks(storage repository.Storage, validator book.ValidateGetTopicBo
 
 		req.TopicID = uint(tid)
 
+		if d := c.QueryParam("descendants"); d != "" {
+			if req.Descendants, err = strconv.ParseBool(d); err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest)
+			}
+		}
+
 		if err := validator(c.Request().Context(), req); err != nil {
 			if strings.Contains(err.Error(), "does not exist") {
+				if ok, err := redirectMerged(c, storage, bookEntity.KindTopic, "topicID"); ok {
+					return err
+				}
 				return echo.NewHTTPError(http.StatusNotFound, "topic does not exist")
 			}
 			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
@@ -654,40 +977,3 @@ func GetUserDigitalBooks(storage repository.Storage, validator book.ValidateGetU
 		return c.JSON(http.StatusOK, resp)
 	}
 }
-
-func DownloadBook(storage repository.Storage, validator book.ValidateDownloadBook) echo.HandlerFunc {
-	return func(c echo.Context) error {
-		req := dto.DownloadBookRequest{}
-
-		if err := c.Bind(&req); err != nil {
-			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
-		}
-
-		id, err := auth.GetID(c)
-		if err != nil {
-			return echo.NewHTTPError(http.StatusUnauthorized)
-		}
-		req.UserID = id
-
-		bid, err := strconv.ParseUint(c.Param("bookID"), 10, 64)
-		if err != nil {
-			return echo.NewHTTPError(http.StatusBadRequest)
-		}
-		req.BookID = uint(bid)
-
-		if err := validator(c.Request().Context(), req); err != nil {
-
-			if err.Error() == "book does not exist" {
-				return echo.NewHTTPError(http.StatusNotFound, "book does not exist")
-			}
-
-			if err.Error() == "access denied" {
-				return echo.NewHTTPError(http.StatusForbidden, "you don't have access to this book")
-			}
-
-			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
-		}
-
-		return c.File(req.Path)
-	}
-}
@@ -0,0 +1,125 @@
+package v1
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/XBozorg/bookstore/adapter/repository"
+	"github.com/XBozorg/bookstore/dto"
+	"github.com/XBozorg/bookstore/usecase/book"
+	"github.com/labstack/echo/v4"
+)
+
+func AddBoxSet(storage repository.Storage, validator book.ValidateAddBoxSet) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.AddBoxSetRequest{}
+
+		if err := c.Bind(&req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		if err := validator(c.Request().Context(), req); err != nil {
+			if strings.Contains(err.Error(), "does not exist") {
+				return echo.NewHTTPError(http.StatusNotFound, err.Error())
+			}
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		resp, err := book.New(storage).AddBoxSet(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
+func EditBoxSet(storage repository.Storage, validator book.ValidateEditBoxSet) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.EditBoxSetRequest{}
+
+		if err := c.Bind(&req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		sid, err := strconv.ParseUint(c.Param("boxSetID"), 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest)
+		}
+		req.BoxSet.ID = uint(sid)
+
+		if err := validator(c.Request().Context(), req); err != nil {
+			if strings.Contains(err.Error(), "does not exist") {
+				return echo.NewHTTPError(http.StatusNotFound, err.Error())
+			}
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		resp, err := book.New(storage).EditBoxSet(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
+func DeleteBoxSet(storage repository.Storage, validator book.ValidateDeleteBoxSet) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.DeleteBoxSetRequest{}
+
+		sid, err := strconv.ParseUint(c.Param("boxSetID"), 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest)
+		}
+		req.BoxSetID = uint(sid)
+
+		if err := validator(c.Request().Context(), req); err != nil {
+			if strings.Contains(err.Error(), "does not exist") {
+				return echo.NewHTTPError(http.StatusNotFound, "box set does not exist")
+			}
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		resp, err := book.New(storage).DeleteBoxSet(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
+func GetBoxSet(storage repository.Storage, validator book.ValidateGetBoxSet) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.GetBoxSetRequest{}
+
+		sid, err := strconv.ParseUint(c.Param("boxSetID"), 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest)
+		}
+		req.BoxSetID = uint(sid)
+
+		if err := validator(c.Request().Context(), req); err != nil {
+			if strings.Contains(err.Error(), "does not exist") {
+				return echo.NewHTTPError(http.StatusNotFound, "box set does not exist")
+			}
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		resp, err := book.New(storage).GetBoxSet(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
+func GetBoxSets(storage repository.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+
+		resp, err := book.New(storage).GetBoxSets(c.Request().Context(), dto.GetBoxSetsRequest{})
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		return c.JSON(http.StatusOK, resp)
+	}
+}
@@ -0,0 +1,247 @@
+package v1
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/XBozorg/bookstore/adapter/repository"
+	"github.com/XBozorg/bookstore/dto"
+	"github.com/XBozorg/bookstore/usecase/pricing"
+	"github.com/labstack/echo/v4"
+)
+
+func AddCampaign(storage repository.Storage, validator pricing.ValidateAddCampaign) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.AddCampaignRequest{}
+
+		if err := c.Bind(&req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		if err := validator(c.Request().Context(), req); err != nil {
+			if strings.Contains(err.Error(), "does not exist") {
+				return echo.NewHTTPError(http.StatusNotFound, err.Error())
+			}
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		resp, err := pricing.New(storage).AddCampaign(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
+func EditCampaign(storage repository.Storage, validator pricing.ValidateEditCampaign) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.EditCampaignRequest{}
+
+		if err := c.Bind(&req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		cid, err := strconv.ParseUint(c.Param("campaignID"), 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest)
+		}
+		req.Campaign.ID = uint(cid)
+
+		if err := validator(c.Request().Context(), req); err != nil {
+			if strings.Contains(err.Error(), "does not exist") {
+				return echo.NewHTTPError(http.StatusNotFound, err.Error())
+			}
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		resp, err := pricing.New(storage).EditCampaign(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
+func DeleteCampaign(storage repository.Storage, validator pricing.ValidateDeleteCampaign) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.DeleteCampaignRequest{}
+
+		cid, err := strconv.ParseUint(c.Param("campaignID"), 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest)
+		}
+		req.CampaignID = uint(cid)
+
+		if err := validator(c.Request().Context(), req); err != nil {
+			if strings.Contains(err.Error(), "does not exist") {
+				return echo.NewHTTPError(http.StatusNotFound, "campaign does not exist")
+			}
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		resp, err := pricing.New(storage).DeleteCampaign(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
+func GetCampaign(storage repository.Storage, validator pricing.ValidateGetCampaign) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.GetCampaignRequest{}
+
+		cid, err := strconv.ParseUint(c.Param("campaignID"), 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest)
+		}
+		req.CampaignID = uint(cid)
+
+		if err := validator(c.Request().Context(), req); err != nil {
+			if strings.Contains(err.Error(), "does not exist") {
+				return echo.NewHTTPError(http.StatusNotFound, "campaign does not exist")
+			}
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		resp, err := pricing.New(storage).GetCampaign(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
+func GetCampaigns(storage repository.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+
+		resp, err := pricing.New(storage).GetCampaigns(c.Request().Context(), dto.GetCampaignsRequest{})
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
+// PreviewCampaign prices the books of a campaign before it's saved, or of a saved one
+// when the campaignID param is set
+func PreviewCampaign(storage repository.Storage, validator pricing.ValidatePreviewCampaign) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.PreviewCampaignRequest{At: c.QueryParam("at")}
+
+		if c.Param("campaignID") != "" {
+			cid, err := strconv.ParseUint(c.Param("campaignID"), 10, 64)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest)
+			}
+
+			saved, err := pricing.New(storage).GetCampaign(c.Request().Context(), dto.GetCampaignRequest{CampaignID: uint(cid)})
+			if err != nil {
+				return echo.NewHTTPError(http.StatusNotFound, "campaign does not exist")
+			}
+			req.Campaign = saved.Campaign
+
+		} else if err := c.Bind(&req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		if err := validator(c.Request().Context(), req); err != nil {
+			if strings.Contains(err.Error(), "does not exist") {
+				return echo.NewHTTPError(http.StatusNotFound, err.Error())
+			}
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		resp, err := pricing.New(storage).PreviewCampaign(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
+func GetPriceQuote(storage repository.Storage, validator pricing.ValidateGetPriceQuote) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.GetPriceQuoteRequest{}
+
+		bid, err := strconv.ParseUint(c.Param("bookID"), 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest)
+		}
+		req.BookID = uint(bid)
+		req.Display = c.QueryParam("display")
+
+		if err := validator(c.Request().Context(), req); err != nil {
+			if strings.Contains(err.Error(), "does not exist") {
+				return echo.NewHTTPError(http.StatusNotFound, "book does not exist")
+			}
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		resp, err := pricing.New(storage).GetPriceQuote(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
+func SetBundleRule(storage repository.Storage, validator pricing.ValidateSetBundleRule) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.SetBundleRuleRequest{}
+
+		if err := c.Bind(&req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		if err := validator(c.Request().Context(), req); err != nil {
+			if strings.Contains(err.Error(), "does not exist") {
+				return echo.NewHTTPError(http.StatusNotFound, err.Error())
+			}
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		resp, err := pricing.New(storage).SetBundleRule(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
+func DeleteBundleRule(storage repository.Storage, validator pricing.ValidateDeleteBundleRule) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.DeleteBundleRuleRequest{Scope: c.Param("scope")}
+
+		sid, err := strconv.ParseUint(c.Param("scopeID"), 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest)
+		}
+		req.ScopeID = uint(sid)
+
+		if err := validator(c.Request().Context(), req); err != nil {
+			if strings.Contains(err.Error(), "does not exist") {
+				return echo.NewHTTPError(http.StatusNotFound, "bundle rule does not exist")
+			}
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		resp, err := pricing.New(storage).DeleteBundleRule(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
+func GetBundleRules(storage repository.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+
+		resp, err := pricing.New(storage).GetBundleRules(c.Request().Context(), dto.GetBundleRulesRequest{})
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		return c.JSON(http.StatusOK, resp)
+	}
+}
@@ -0,0 +1,141 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/XBozorg/bookstore/adapter/catalog"
+	"github.com/XBozorg/bookstore/adapter/repository"
+	"github.com/XBozorg/bookstore/dto"
+	bookEntity "github.com/XBozorg/bookstore/entity/book"
+	"github.com/XBozorg/bookstore/usecase/book"
+	"github.com/labstack/echo/v4"
+)
+
+func ImportCatalog(storage repository.Storage, validator book.ValidateImportCatalog) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.ImportCatalogRequest{}
+
+		file, err := c.FormFile("file")
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		req.Format = strings.ToLower(c.FormValue("format"))
+		if req.Format == "" {
+			switch strings.ToLower(filepath.Ext(file.Filename)) {
+			case ".csv":
+				req.Format = bookEntity.CatalogCSV
+			case ".xml", ".onix":
+				req.Format = bookEntity.CatalogONIX
+			}
+		}
+
+		if dryRun := c.FormValue("dryRun"); dryRun != "" {
+			req.DryRun, err = strconv.ParseBool(dryRun)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest)
+			}
+		}
+
+		if err := validator(c.Request().Context(), req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		src, err := file.Open()
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		defer src.Close()
+
+		if req.Format == bookEntity.CatalogCSV {
+			req.Rows, req.Errors, err = catalog.ReadCSV(src)
+		} else {
+			req.Rows, req.Errors, err = catalog.ReadONIX(src)
+		}
+		if err != nil {
+			return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+		}
+
+		resp, err := book.New(storage).ImportCatalog(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+
+		return c.JSON(http.StatusAccepted, resp)
+	}
+}
+
+func GetImportJob(storage repository.Storage, validator book.ValidateGetImportJob) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.GetImportJobRequest{}
+
+		jid, err := strconv.ParseUint(c.Param("jobID"), 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest)
+		}
+		req.JobID = uint(jid)
+
+		if err := validator(c.Request().Context(), req); err != nil {
+			if strings.Contains(err.Error(), "does not exist") {
+				return echo.NewHTTPError(http.StatusNotFound, "import job does not exist")
+			}
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		resp, err := book.New(storage).GetImportJob(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
+func GetImportJobs(storage repository.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.GetImportJobsRequest{}
+
+		resp, err := book.New(storage).GetImportJobs(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
+func ExportCatalog(storage repository.Storage, validator book.ValidateExportCatalog) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.ExportCatalogRequest{}
+		req.Format = strings.ToLower(c.Param("format"))
+
+		if err := validator(c.Request().Context(), req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		resp, err := book.New(storage).ExportCatalog(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+
+		name := "catalog-" + time.Now().Format("20060102")
+		res := c.Response()
+
+		if req.Format == bookEntity.CatalogCSV {
+			res.Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+			res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", name+".csv"))
+			res.WriteHeader(http.StatusOK)
+			return catalog.WriteCSV(res, resp.Books)
+		}
+
+		res.Header().Set(echo.HeaderContentType, echo.MIMEApplicationXMLCharsetUTF8)
+		res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", name+".xml"))
+		res.WriteHeader(http.StatusOK)
+		return catalog.WriteONIX(res, resp.Books)
+	}
+}
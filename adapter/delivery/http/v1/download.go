@@ -0,0 +1,254 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/XBozorg/bookstore/adapter/auth"
+	"github.com/XBozorg/bookstore/adapter/repository"
+	"github.com/XBozorg/bookstore/adapter/watermark"
+	"github.com/XBozorg/bookstore/config"
+	"github.com/XBozorg/bookstore/dto"
+	"github.com/XBozorg/bookstore/usecase/book"
+	"github.com/labstack/echo/v4"
+)
+
+func CreateDownloadLinks(storage repository.Storage, validator book.ValidateCreateDownloadLinks) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.CreateDownloadLinksRequest{}
+
+		id, err := auth.GetID(c)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusUnauthorized)
+		}
+		req.UserID = id
+
+		bid, err := strconv.ParseUint(c.Param("bookID"), 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest)
+		}
+		req.BookID = uint(bid)
+
+		if err := validator(c.Request().Context(), req); err != nil {
+
+			if err.Error() == "book does not exist" {
+				return echo.NewHTTPError(http.StatusNotFound, "book does not exist")
+			}
+
+			if err.Error() == "access denied" {
+				return echo.NewHTTPError(http.StatusForbidden, "you don't have access to this book")
+			}
+
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		resp, err := book.New(storage).CreateDownloadLinks(c.Request().Context(), req)
+		if err != nil {
+
+			if err.Error() == "download limit reached" {
+				return echo.NewHTTPError(http.StatusForbidden, err.Error())
+			}
+
+			if err.Error() == "no downloadable format" {
+				return echo.NewHTTPError(http.StatusNotFound, err.Error())
+			}
+
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+
+		expiration := time.Now().Add(time.Duration(config.Conf.GetDownloadConfig().LinkTTL) * time.Minute)
+		for i, link := range resp.Links {
+			resp.Links[i].URL = downloadURL(c, req.UserID, req.BookID, link.Format, expiration.Unix())
+			resp.Links[i].Expiration = expiration.Format("2006-01-02 15:04:05")
+		}
+
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
+func downloadURL(c echo.Context, userID string, bookID uint, format string, exp int64) string {
+
+	query := url.Values{}
+	query.Set("uid", userID)
+	query.Set("exp", strconv.FormatInt(exp, 10))
+	query.Set("sig", auth.SignDownload(userID, bookID, format, exp))
+
+	return fmt.Sprintf("%s://%s/v1/download/%d/%s?%s",
+		c.Scheme(),
+		c.Request().Host,
+		bookID,
+		format,
+		query.Encode(),
+	)
+}
+
+func DownloadBook(storage repository.Storage, validator book.ValidateDownloadBook) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.DownloadBookRequest{}
+
+		bid, err := strconv.ParseUint(c.Param("bookID"), 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest)
+		}
+		req.BookID = uint(bid)
+		req.Format = c.Param("format")
+		req.UserID = c.QueryParam("uid")
+
+		exp, err := strconv.ParseInt(c.QueryParam("exp"), 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest)
+		}
+
+		if err := auth.VerifyDownload(req.UserID, req.BookID, req.Format, exp, c.QueryParam("sig")); err != nil {
+			return echo.NewHTTPError(http.StatusForbidden, err.Error())
+		}
+
+		req.IP = c.RealIP()
+		req.UserAgent = c.Request().UserAgent()
+
+		// the requests of a link resuming its download are counted once
+		req.Token = c.QueryParam("sig")
+
+		if err := validator(c.Request().Context(), req); err != nil {
+
+			if err.Error() == "book does not exist" {
+				return echo.NewHTTPError(http.StatusNotFound, "book does not exist")
+			}
+
+			if err.Error() == "access denied" {
+				return echo.NewHTTPError(http.StatusForbidden, "you don't have access to this book")
+			}
+
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		resp, err := book.New(storage).DownloadBook(c.Request().Context(), req)
+		if err != nil {
+
+			if err.Error() == "download limit reached" {
+				return echo.NewHTTPError(http.StatusForbidden, err.Error())
+			}
+
+			if err.Error() == "format not available" {
+				return echo.NewHTTPError(http.StatusNotFound, err.Error())
+			}
+
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+
+		path := resp.Path
+		if config.Conf.GetWatermarkConfig().Enabled {
+			path, err = watermark.Stamp(resp.Path, req.Format, req.UserID, req.BookID, resp.Watermark)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+			}
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusNotFound)
+		}
+		defer file.Close()
+
+		info, err := file.Stat()
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+
+		name := fmt.Sprintf("%d.%s", req.BookID, req.Format)
+		if base := filepath.Base(resp.Path); strings.HasSuffix(base, "."+req.Format) {
+			name = base
+		}
+
+		c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", name))
+		http.ServeContent(c.Response(), c.Request(), name, info.ModTime(), file)
+
+		return nil
+	}
+}
+
+func SetDownloadLimit(storage repository.Storage, validator book.ValidateSetDownloadLimit) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.SetDownloadLimitRequest{}
+
+		if err := c.Bind(&req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		if err := validator(c.Request().Context(), req); err != nil {
+			if strings.Contains(err.Error(), "does not exist") {
+				return echo.NewHTTPError(http.StatusNotFound, "book does not exist")
+			}
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		resp, err := book.New(storage).SetDownloadLimit(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
+func GetDownloads(storage repository.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.GetDownloadsRequest{}
+
+		resp, err := book.New(storage).GetDownloads(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
+func GetUserDownloads(storage repository.Storage, validator book.ValidateGetUserDownloads) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.GetUserDownloadsRequest{}
+		req.UserID = c.Param("userID")
+
+		if err := validator(c.Request().Context(), req); err != nil {
+			if strings.Contains(err.Error(), "does not exist") {
+				return echo.NewHTTPError(http.StatusNotFound, "user does not exist")
+			}
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		resp, err := book.New(storage).GetUserDownloads(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
+func GetDownloadActivity(storage repository.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.GetDownloadActivityRequest{}
+
+		req.MinIPs = config.Conf.GetDownloadConfig().SuspiciousIPs
+		if ips := c.QueryParam("ips"); ips != "" {
+			min, err := strconv.ParseUint(ips, 10, 64)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest)
+			}
+			req.MinIPs = uint(min)
+		}
+
+		resp, err := book.New(storage).GetDownloadActivity(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+
+		return c.JSON(http.StatusOK, resp)
+	}
+}
@@ -0,0 +1,252 @@
+package v1
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/XBozorg/bookstore/adapter/auth"
+	"github.com/XBozorg/bookstore/adapter/repository"
+	"github.com/XBozorg/bookstore/dto"
+	eo "github.com/XBozorg/bookstore/entity/order"
+	"github.com/XBozorg/bookstore/usecase/order"
+	"github.com/XBozorg/bookstore/usecase/user"
+	"github.com/go-sql-driver/mysql"
+	"github.com/labstack/echo/v4"
+)
+
+func SetItemGift(storage repository.Storage, validator order.ValidateSetItemGift) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.SetItemGiftRequest{}
+
+		if err := c.Bind(&req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		id, err := auth.GetID(c)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusUnauthorized)
+		}
+		req.UserID = id
+
+		oid, err := strconv.ParseUint(c.Param("orderID"), 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest)
+		}
+		req.OrderID = uint(oid)
+
+		iid, err := strconv.ParseUint(c.Param("itemID"), 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest)
+		}
+		req.ItemID = uint(iid)
+
+		if err := validator(c.Request().Context(), req); err != nil {
+			if strings.Contains(err.Error(), "does not exist") {
+				return echo.NewHTTPError(http.StatusNotFound, err.Error())
+			}
+			if strings.Contains(err.Error(), "order does not open") {
+				return echo.NewHTTPError(http.StatusForbidden, "you don't have any open orders")
+			}
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		resp, err := order.New(storage).SetItemGift(c.Request().Context(), req)
+		if err != nil {
+			if err.Error() == "item can not be a gift" {
+				return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
+func RemoveItemGift(storage repository.Storage, validator order.ValidateRemoveItemGift) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.RemoveItemGiftRequest{}
+
+		id, err := auth.GetID(c)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusUnauthorized)
+		}
+		req.UserID = id
+
+		oid, err := strconv.ParseUint(c.Param("orderID"), 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest)
+		}
+		req.OrderID = uint(oid)
+
+		iid, err := strconv.ParseUint(c.Param("itemID"), 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest)
+		}
+		req.ItemID = uint(iid)
+
+		if err := validator(c.Request().Context(), req); err != nil {
+			if strings.Contains(err.Error(), "does not exist") {
+				return echo.NewHTTPError(http.StatusNotFound, err.Error())
+			}
+			if strings.Contains(err.Error(), "order does not open") {
+				return echo.NewHTTPError(http.StatusForbidden, "you don't have any open orders")
+			}
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		resp, err := order.New(storage).RemoveItemGift(c.Request().Context(), req)
+		if err != nil {
+			if strings.Contains(err.Error(), "does not exist") {
+				return echo.NewHTTPError(http.StatusNotFound, err.Error())
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
+func GetUserGifts(storage repository.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.GetUserGiftsRequest{}
+
+		id, err := auth.GetID(c)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusUnauthorized)
+		}
+		req.UserID = id
+
+		resp, err := order.New(storage).GetUserGifts(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
+// GetGift shows the gift of a claim link before the recipient claims it
+func GetGift(storage repository.Storage, validator order.ValidateGetGift) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.GetGiftRequest{Token: c.Param("token")}
+
+		if err := validator(c.Request().Context(), req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		resp, err := order.New(storage).GetGift(c.Request().Context(), req)
+		if err != nil {
+			if strings.Contains(err.Error(), "does not exist") {
+				return echo.NewHTTPError(http.StatusNotFound, err.Error())
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
+// ClaimGift gives the gifted book to the account of the logged in user
+func ClaimGift(storage repository.Storage, validator order.ValidateClaimGift) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.ClaimGiftRequest{Token: c.Param("token")}
+
+		id, err := auth.GetID(c)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusUnauthorized)
+		}
+		req.UserID = id
+
+		if err := validator(c.Request().Context(), req); err != nil {
+			if strings.Contains(err.Error(), "does not exist") {
+				return echo.NewHTTPError(http.StatusNotFound, err.Error())
+			}
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		resp, err := order.New(storage).ClaimGift(c.Request().Context(), req)
+		if err != nil {
+			return claimGiftError(err)
+		}
+
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
+// ClaimGiftWithSignup creates the account of the recipient with the email the gift was sent to,
+// logs it in and gives it the gifted book
+func ClaimGiftWithSignup(storage repository.Storage, validator order.ValidateGetGift, userValidator user.ValidateCreateUser) echo.HandlerFunc {
+	return func(c echo.Context) error {
+
+		createUserReq := dto.CreateUserRequest{}
+		if err := c.Bind(&createUserReq); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		req := dto.GetGiftRequest{Token: c.Param("token")}
+		if err := validator(c.Request().Context(), req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		giftResp, err := order.New(storage).GetGift(c.Request().Context(), req)
+		if err != nil {
+			if strings.Contains(err.Error(), "does not exist") {
+				return echo.NewHTTPError(http.StatusNotFound, err.Error())
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		if giftResp.Gift.Status == eo.GiftClaimed {
+			return echo.NewHTTPError(http.StatusConflict, "gift already claimed")
+		}
+
+		createUserReq.Email = giftResp.Gift.RecipientEmail
+		if err := userValidator(c.Request().Context(), createUserReq); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		resp, err := order.New(storage).ClaimGiftWithSignup(c.Request().Context(), dto.ClaimGiftWithSignupRequest{
+			Token: req.Token,
+			User:  createUserReq,
+		})
+		if err != nil {
+			if driverErr, ok := err.(*mysql.MySQLError); ok && driverErr.Number == 1062 {
+
+				if strings.Contains(driverErr.Message, "user.email") {
+					return echo.NewHTTPError(http.StatusConflict, "email already exists, log in to claim the gift")
+				}
+				if strings.Contains(driverErr.Message, "user.username") {
+					return echo.NewHTTPError(http.StatusConflict, "username already exists")
+				}
+
+			}
+			if strings.Contains(err.Error(), "referral") {
+				return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+			}
+			return claimGiftError(err)
+		}
+
+		if err := auth.GenerateTokens(c, storage,
+			repository.Token{
+				ID:   resp.User.ID,
+				Role: "user",
+			},
+		); err != nil {
+			return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+		}
+
+		return c.JSON(http.StatusOK, dto.ClaimGiftResponse{Gift: resp.Gift})
+	}
+}
+
+func claimGiftError(err error) error {
+	switch {
+	case strings.Contains(err.Error(), "does not exist"):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	case strings.Contains(err.Error(), "already claimed"):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	case strings.Contains(err.Error(), "is void"):
+		return echo.NewHTTPError(http.StatusGone, err.Error())
+	}
+	return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+}
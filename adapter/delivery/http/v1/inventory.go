@@ -0,0 +1,196 @@
+package v1
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/XBozorg/bookstore/adapter/auth"
+	"github.com/XBozorg/bookstore/adapter/repository"
+	"github.com/XBozorg/bookstore/config"
+	"github.com/XBozorg/bookstore/dto"
+	"github.com/XBozorg/bookstore/usecase/book"
+	"github.com/labstack/echo/v4"
+)
+
+func ReceiveStock(storage repository.Storage, validator book.ValidateReceiveStock) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.ReceiveStockRequest{}
+
+		if err := c.Bind(&req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		bid, err := strconv.ParseUint(c.Param("bookID"), 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest)
+		}
+		req.BookID = uint(bid)
+
+		id, err := auth.GetID(c)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusUnauthorized)
+		}
+		req.AdminID = id
+
+		if err := validator(c.Request().Context(), req); err != nil {
+			if strings.Contains(err.Error(), "does not exist") {
+				return echo.NewHTTPError(http.StatusNotFound, "book does not exist")
+			}
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		resp, err := book.New(storage).ReceiveStock(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
+func AdjustStock(storage repository.Storage, validator book.ValidateAdjustStock) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.AdjustStockRequest{}
+
+		if err := c.Bind(&req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		bid, err := strconv.ParseUint(c.Param("bookID"), 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest)
+		}
+		req.BookID = uint(bid)
+
+		id, err := auth.GetID(c)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusUnauthorized)
+		}
+		req.AdminID = id
+
+		if err := validator(c.Request().Context(), req); err != nil {
+			if strings.Contains(err.Error(), "does not exist") {
+				return echo.NewHTTPError(http.StatusNotFound, "book does not exist")
+			}
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		resp, err := book.New(storage).AdjustStock(c.Request().Context(), req)
+		if err != nil {
+			if strings.Contains(err.Error(), "below zero") {
+				return echo.NewHTTPError(http.StatusConflict, err.Error())
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
+func Stocktake(storage repository.Storage, validator book.ValidateStocktake) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.StocktakeRequest{}
+
+		if err := c.Bind(&req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		id, err := auth.GetID(c)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusUnauthorized)
+		}
+		req.AdminID = id
+
+		if err := validator(c.Request().Context(), req); err != nil {
+			if strings.Contains(err.Error(), "does not exist") {
+				return echo.NewHTTPError(http.StatusNotFound, err.Error())
+			}
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		resp, err := book.New(storage).Stocktake(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
+func GetMovements(storage repository.Storage, validator book.ValidateGetMovements) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.GetMovementsRequest{}
+
+		bid, err := strconv.ParseUint(c.Param("bookID"), 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest)
+		}
+		req.BookID = uint(bid)
+
+		if err := validator(c.Request().Context(), req); err != nil {
+			if strings.Contains(err.Error(), "does not exist") {
+				return echo.NewHTTPError(http.StatusNotFound, "book does not exist")
+			}
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		resp, err := book.New(storage).GetMovements(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
+func SetReorderThreshold(storage repository.Storage, validator book.ValidateSetReorderThreshold) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.SetReorderThresholdRequest{}
+
+		if err := c.Bind(&req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		bid, err := strconv.ParseUint(c.Param("bookID"), 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest)
+		}
+		req.BookID = uint(bid)
+
+		if err := validator(c.Request().Context(), req); err != nil {
+			if strings.Contains(err.Error(), "does not exist") {
+				return echo.NewHTTPError(http.StatusNotFound, "book does not exist")
+			}
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		resp, err := book.New(storage).SetReorderThreshold(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
+func GetLowStockReport(storage repository.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.GetLowStockReportRequest{
+			LeadDays:  config.Conf.GetInventoryConfig().LeadDays,
+			CoverDays: config.Conf.GetInventoryConfig().CoverDays,
+		}
+
+		resp, err := book.New(storage).GetLowStockReport(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
+func GetPreorderDemand(storage repository.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+
+		resp, err := book.New(storage).GetPreorderDemand(c.Request().Context(), dto.GetPreorderDemandRequest{})
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		return c.JSON(http.StatusOK, resp)
+	}
+}
@@ -0,0 +1,93 @@
+package v1
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/XBozorg/bookstore/adapter/repository"
+	"github.com/XBozorg/bookstore/dto"
+	"github.com/XBozorg/bookstore/usecase/book"
+	"github.com/labstack/echo/v4"
+)
+
+func GetDuplicates(storage repository.Storage, validator book.ValidateGetDuplicates) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.GetDuplicatesRequest{}
+		req.Kind = c.Param("kind")
+
+		if err := validator(c.Request().Context(), req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		resp, err := book.New(storage).GetDuplicates(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
+func Merge(storage repository.Storage, validator book.ValidateMerge) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.MergeRequest{}
+
+		if err := c.Bind(&req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		req.Kind = c.Param("kind")
+
+		if err := validator(c.Request().Context(), req); err != nil {
+			if strings.Contains(err.Error(), "does not exist") {
+				return echo.NewHTTPError(http.StatusNotFound, err.Error())
+			}
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		resp, err := book.New(storage).Merge(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
+func GetRedirects(storage repository.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.GetRedirectsRequest{}
+
+		resp, err := book.New(storage).GetRedirects(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
+// redirectMerged answers with a permanent redirect when the record in the param was merged
+// into another one, ok is false when there is nothing to redirect to
+func redirectMerged(c echo.Context, storage repository.Storage, kind, param string) (bool, error) {
+
+	id, err := strconv.ParseUint(c.Param(param), 10, 64)
+	if err != nil {
+		return false, nil
+	}
+
+	resp, err := book.New(storage).GetRedirect(c.Request().Context(), dto.GetRedirectRequest{Kind: kind, OldID: uint(id)})
+	if err != nil {
+		return false, nil
+	}
+
+	pattern := strings.Split(strings.Trim(c.Path(), "/"), "/")
+	segments := strings.Split(strings.Trim(c.Request().URL.Path, "/"), "/")
+	for i := range pattern {
+		if pattern[i] == ":"+param && i < len(segments) {
+			segments[i] = strconv.FormatUint(uint64(resp.Redirect.NewID), 10)
+		}
+	}
+
+	location := *c.Request().URL
+	location.Path = "/" + strings.Join(segments, "/")
+
+	return true, c.Redirect(http.StatusMovedPermanently, location.String())
+}
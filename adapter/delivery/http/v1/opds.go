@@ -0,0 +1,323 @@
+package v1
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/XBozorg/bookstore/adapter/auth"
+	"github.com/XBozorg/bookstore/adapter/opds"
+	"github.com/XBozorg/bookstore/adapter/repository"
+	"github.com/XBozorg/bookstore/config"
+	"github.com/XBozorg/bookstore/dto"
+	bookEntity "github.com/XBozorg/bookstore/entity/book"
+	"github.com/XBozorg/bookstore/usecase/book"
+	"github.com/labstack/echo/v4"
+)
+
+func OPDSRoot() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		catalog := opdsCatalog(c)
+
+		feed := catalog.Feed("/v1/opds", "Bookstore", opds.NavigationType)
+		catalog.Navigation(&feed, "/v1/opds/book", "All Books", "Every book of the store", opds.AcquisitionType)
+		catalog.Navigation(&feed, "/v1/opds/topic", "Topics", "Browse books by topic", opds.NavigationType)
+		catalog.Navigation(&feed, "/v1/opds/author", "Authors", "Browse books by author", opds.NavigationType)
+		catalog.Navigation(&feed, "/v1/opds/publisher", "Publishers", "Browse books by publisher", opds.NavigationType)
+		catalog.Navigation(&feed, "/v1/opds/lang", "Languages", "Browse books by language", opds.NavigationType)
+		catalog.Navigation(&feed, "/v1/opds/shelf", "My Books", "Books you have purchased", opds.AcquisitionType)
+		feed.Links = append(feed.Links, opds.Link{Rel: opds.RelShelf, Href: catalog.URL("/v1/opds/shelf"), Type: opds.AcquisitionType})
+
+		return writeFeed(c, feed, opds.NavigationType)
+	}
+}
+
+func OPDSTopics(storage repository.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.GetTopicsRequest{}
+
+		resp, err := book.New(storage).GetTopics(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+
+		catalog := opdsCatalog(c)
+		feed := catalog.Feed("/v1/opds/topic", "Topics", opds.NavigationType)
+		for _, t := range resp.Topics {
+			catalog.Navigation(&feed, fmt.Sprintf("/v1/opds/topic/%d", t.ID), t.Name, "Books about "+t.Name, opds.AcquisitionType)
+		}
+
+		return writeFeed(c, feed, opds.NavigationType)
+	}
+}
+
+func OPDSAuthors(storage repository.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.GetAuthorsRequest{}
+
+		resp, err := book.New(storage).GetAuthors(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+
+		catalog := opdsCatalog(c)
+		feed := catalog.Feed("/v1/opds/author", "Authors", opds.NavigationType)
+		for _, a := range resp.Authors {
+			catalog.Navigation(&feed, fmt.Sprintf("/v1/opds/author/%d", a.ID), a.Name, "Books by "+a.Name, opds.AcquisitionType)
+		}
+
+		return writeFeed(c, feed, opds.NavigationType)
+	}
+}
+
+func OPDSPublishers(storage repository.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.GetPublishersRequest{}
+
+		resp, err := book.New(storage).GetPublishers(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+
+		catalog := opdsCatalog(c)
+		feed := catalog.Feed("/v1/opds/publisher", "Publishers", opds.NavigationType)
+		for _, p := range resp.Publishers {
+			catalog.Navigation(&feed, fmt.Sprintf("/v1/opds/publisher/%d", p.ID), p.Name, "Books published by "+p.Name, opds.AcquisitionType)
+		}
+
+		return writeFeed(c, feed, opds.NavigationType)
+	}
+}
+
+func OPDSLanguages(storage repository.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.GetLanguagesRequest{}
+
+		resp, err := book.New(storage).GetLanguages(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+
+		catalog := opdsCatalog(c)
+		feed := catalog.Feed("/v1/opds/lang", "Languages", opds.NavigationType)
+		for _, l := range resp.Languages {
+			catalog.Navigation(&feed, fmt.Sprintf("/v1/opds/lang/%d", l.ID), l.Code, "Books in "+l.Code, opds.AcquisitionType)
+		}
+
+		return writeFeed(c, feed, opds.NavigationType)
+	}
+}
+
+func OPDSBooks(storage repository.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.GetAllBooksRequest{}
+
+		resp, err := book.New(storage).GetAllBooks(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+
+		return writeBooks(c, "/v1/opds/book", "All Books", resp.Books)
+	}
+}
+
+func OPDSTopicBooks(storage repository.Storage, validator book.ValidateGetTopicBooks) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.GetTopicBooksRequest{}
+
+		tid, err := strconv.ParseUint(c.Param("topicID"), 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest)
+		}
+		req.TopicID = uint(tid)
+		req.Descendants = true
+
+		if err := validator(c.Request().Context(), req); err != nil {
+			return opdsValidationError(err)
+		}
+
+		resp, err := book.New(storage).GetTopicBooks(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+
+		return writeBooks(c, fmt.Sprintf("/v1/opds/topic/%d", req.TopicID), "Topic Books", resp.Books)
+	}
+}
+
+func OPDSAuthorBooks(storage repository.Storage, validator book.ValidateGetAuthorBooks) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.GetAuthorBooksRequest{}
+
+		aid, err := strconv.ParseUint(c.Param("authorID"), 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest)
+		}
+		req.AuthorID = uint(aid)
+
+		if err := validator(c.Request().Context(), req); err != nil {
+			return opdsValidationError(err)
+		}
+
+		resp, err := book.New(storage).GetAuthorBooks(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+
+		return writeBooks(c, fmt.Sprintf("/v1/opds/author/%d", req.AuthorID), "Author Books", resp.Books)
+	}
+}
+
+func OPDSPublisherBooks(storage repository.Storage, validator book.ValidateGetPublisherBooks) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.GetPublisherBooksRequest{}
+
+		pid, err := strconv.ParseUint(c.Param("publisherID"), 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest)
+		}
+		req.PublisherID = uint(pid)
+
+		if err := validator(c.Request().Context(), req); err != nil {
+			return opdsValidationError(err)
+		}
+
+		resp, err := book.New(storage).GetPublisherBooks(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+
+		return writeBooks(c, fmt.Sprintf("/v1/opds/publisher/%d", req.PublisherID), "Publisher Books", resp.Books)
+	}
+}
+
+func OPDSLangBooks(storage repository.Storage, validator book.ValidateGetLangBooks) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.GetLangBooksRequest{}
+
+		lid, err := strconv.ParseUint(c.Param("langID"), 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest)
+		}
+		req.LangID = uint(lid)
+
+		if err := validator(c.Request().Context(), req); err != nil {
+			return opdsValidationError(err)
+		}
+
+		resp, err := book.New(storage).GetLangBooks(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+
+		return writeBooks(c, fmt.Sprintf("/v1/opds/lang/%d", req.LangID), "Language Books", resp.Books)
+	}
+}
+
+func OPDSSearchDescription() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return writeXML(c, opdsCatalog(c).Search(), opds.SearchType)
+	}
+}
+
+func OPDSSearch(storage repository.Storage, validator book.ValidateSearchBooks) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.SearchBooksRequest{}
+		req.Query = strings.TrimSpace(c.QueryParam("q"))
+
+		if err := validator(c.Request().Context(), req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		resp, err := book.New(storage).SearchBooks(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+
+		return writeBooks(c, "/v1/opds/search?q="+url.QueryEscape(req.Query), "Search: "+req.Query, resp.Books)
+	}
+}
+
+// OPDSShelf lists the purchased digital books with signed download links, books that reached
+// their download limit are listed without acquisition links
+func OPDSShelf(storage repository.Storage, validator book.ValidateGetUserDigitalBooks) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.GetUserDigitalBooksRequest{}
+
+		id, err := auth.GetBasicID(c)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusUnauthorized)
+		}
+		req.UserID = id
+
+		if err := validator(c.Request().Context(), req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		resp, err := book.New(storage).GetUserDigitalBooks(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+
+		catalog := opdsCatalog(c)
+		feed := catalog.Feed("/v1/opds/shelf", "My Books", opds.AcquisitionType)
+		expiration := time.Now().Add(time.Duration(config.Conf.GetDownloadConfig().LinkTTL) * time.Minute).Unix()
+
+		for _, b := range resp.Books {
+			links := map[string]string{}
+
+			dl, err := book.New(storage).CreateDownloadLinks(c.Request().Context(), dto.CreateDownloadLinksRequest{UserID: req.UserID, BookID: b.ID})
+			if err == nil {
+				for _, link := range dl.Links {
+					links[link.Format] = downloadURL(c, req.UserID, b.ID, link.Format, expiration)
+				}
+			}
+
+			feed.Entries = append(feed.Entries, catalog.Acquisition(b, links))
+		}
+
+		return writeFeed(c, feed, opds.AcquisitionType)
+	}
+}
+
+func opdsCatalog(c echo.Context) opds.Catalog {
+	return opds.New(c.Scheme() + "://" + c.Request().Host)
+}
+
+func opdsValidationError(err error) error {
+	if strings.Contains(err.Error(), "does not exist") {
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	}
+	return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+}
+
+func writeBooks(c echo.Context, path, title string, books []bookEntity.Book) error {
+
+	catalog := opdsCatalog(c)
+	feed := catalog.Feed(path, title, opds.AcquisitionType)
+	feed.Links = append(feed.Links, opds.Link{Rel: opds.RelUp, Href: catalog.URL("/v1/opds"), Type: opds.NavigationType})
+
+	for _, b := range books {
+		feed.Entries = append(feed.Entries, catalog.Book(b))
+	}
+
+	return writeFeed(c, feed, opds.AcquisitionType)
+}
+
+func writeFeed(c echo.Context, feed opds.Feed, kind string) error {
+	return writeXML(c, feed, strings.Replace(kind, "+xml;", "+xml;charset=utf-8;", 1))
+}
+
+func writeXML(c echo.Context, v interface{}, contentType string) error {
+
+	body, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.Blob(http.StatusOK, contentType, append([]byte(xml.Header), body...))
+}
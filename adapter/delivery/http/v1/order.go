@@ -269,8 +269,8 @@ func CreatePromoCode(storage repository.Storage, validator order.ValidateCreateP
 		resp, err := order.New(storage).CreatePromoCode(c.Request().Context(), req)
 		if err != nil {
 
-			if err.Error() == "percentage cannot be 0" {
-				return errors.New("percentage cannot be 0")
+			if err.Error() == "promo has no discount" {
+				return echo.NewHTTPError(http.StatusBadRequest, "promo has no discount")
 			}
 			if err.Error() == "limit cannot be 0" {
 				return errors.New("limit cannot be 0")
@@ -344,6 +344,11 @@ func SetOrderStatus(storage repository.Storage, validator order.ValidateSetOrder
 
 		resp, err := order.New(storage).SetOrderStatus(c.Request().Context(), req)
 		if err != nil {
+
+			if err.Error() == "cancelled order cannot change status" || err.Error() == "order has a redeemed gift card" {
+				return echo.NewHTTPError(http.StatusConflict, err.Error())
+			}
+
 			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 		}
 
@@ -434,6 +439,11 @@ func SetOrderPromo(storage repository.Storage, validator order.ValidateSetOrderP
 				return echo.NewHTTPError(http.StatusNotFound)
 			}
 
+			// expired, used up, under the minimum or nothing in the order it applies to
+			if strings.Contains(err.Error(), "promo") {
+				return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+			}
+
 			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 		}
 
@@ -765,6 +775,34 @@ func GetPromoByOrder(storage repository.Storage, validator order.ValidateGetProm
 	}
 }
 
+func GetPromoRedemptions(storage repository.Storage, validator order.ValidateGetPromoRedemptions) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.GetPromoRedemptionsRequest{}
+
+		pid, err := strconv.ParseUint(c.Param("promoID"), 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest)
+		}
+		req.PromoID = uint(pid)
+
+		if err := validator(c.Request().Context(), req); err != nil {
+
+			if strings.Contains(err.Error(), "does not exist") {
+				return echo.NewHTTPError(http.StatusNotFound, "promo does not exist")
+			}
+
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		resp, err := order.New(storage).GetPromoRedemptions(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
 func GetUserPromos(storage repository.Storage, validator order.ValidateGetUserPromos) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		req := dto.GetUserPromosRequest{}
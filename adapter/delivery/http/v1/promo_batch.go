@@ -0,0 +1,133 @@
+package v1
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/XBozorg/bookstore/adapter/repository"
+	"github.com/XBozorg/bookstore/dto"
+	eo "github.com/XBozorg/bookstore/entity/order"
+	"github.com/XBozorg/bookstore/usecase/order"
+	"github.com/labstack/echo/v4"
+)
+
+func CreatePromoBatch(storage repository.Storage, validator order.ValidateCreatePromoBatch) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.CreatePromoBatchRequest{}
+
+		if err := c.Bind(&req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		if err := validator(c.Request().Context(), req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		resp, err := order.New(storage).CreatePromoBatch(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
+func GetPromoBatch(storage repository.Storage, validator order.ValidateGetPromoBatch) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.GetPromoBatchRequest{}
+
+		bid, err := strconv.ParseUint(c.Param("batchID"), 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest)
+		}
+		req.BatchID = uint(bid)
+
+		if err := validator(c.Request().Context(), req); err != nil {
+
+			if strings.Contains(err.Error(), "does not exist") {
+				return echo.NewHTTPError(http.StatusNotFound, err.Error())
+			}
+
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		resp, err := order.New(storage).GetPromoBatch(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
+// ExportPromoBatch sends the codes of the batch as a CSV file to hand them out
+func ExportPromoBatch(storage repository.Storage, validator order.ValidateGetPromoBatch) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.GetPromoBatchRequest{}
+
+		bid, err := strconv.ParseUint(c.Param("batchID"), 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest)
+		}
+		req.BatchID = uint(bid)
+
+		if err := validator(c.Request().Context(), req); err != nil {
+
+			if strings.Contains(err.Error(), "does not exist") {
+				return echo.NewHTTPError(http.StatusNotFound, err.Error())
+			}
+
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		resp, err := order.New(storage).GetPromoBatch(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+
+		res := c.Response()
+		res.Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+		res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=\"promo-batch-%d.csv\"", resp.Batch.ID))
+		res.WriteHeader(http.StatusOK)
+		return writePromoBatchCSV(res, resp.Batch)
+	}
+}
+
+func GetPromoBatchReports(storage repository.Storage) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.GetPromoBatchReportsRequest{}
+
+		resp, err := order.New(storage).GetPromoBatchReports(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
+func writePromoBatchCSV(w io.Writer, batch eo.PromoBatch) error {
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"code", "expiration", "left", "redeemed"}); err != nil {
+		return err
+	}
+
+	for _, code := range batch.Codes {
+		if err := cw.Write([]string{
+			code.Code,
+			batch.Promo.Expiration,
+			strconv.FormatUint(uint64(code.Left), 10),
+			strconv.FormatUint(uint64(code.Redeemed), 10),
+		}); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
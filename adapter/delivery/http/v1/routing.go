@@ -25,25 +25,53 @@ func Routing(storage repository.Storage) *echo.Echo {
 
 	e.GET("v1", Home())
 
-	e.POST("v1/user", CreateUser(storage, validator.ValidateCreateUser))                                                    // <Create User>       .../v1/user
+	e.POST("v1/user", CreateUser(storage, validator.ValidateCreateUser(storage)))                                           // <Create User>       .../v1/user
 	e.POST("v1/admin/login", LoginAdmin(storage, validator.ValidateLoginAdmin(storage)), auth.AdminTokenRefresher(storage)) // <LoginAdmin>        .../v1/admin/login
 	e.GET("v1/admin/login", AdminLoginForm())                                                                               // <AdminLoginForm>    .../v1/admin/login
 	e.POST("v1/user/login", LoginUser(storage, validator.ValidateLoginUser(storage)), auth.UserTokenRefresher(storage))     // <LoginUser>         .../v1/user/login
 	e.GET("v1/user/login", UserLoginForm())                                                                                 // <UserLoginForm>     .../v1/user/login
 	e.GET("v1/author/:authorID", GetAuthor(storage, validator.ValidateGetAuthor(storage)))                                  // <GetAuthor>         .../v1/author/:authorID
+	e.GET("v1/author/:authorID/page", GetAuthorPage(storage, validator.ValidateGetAuthorPage(storage)))                     // <GetAuthorPage>     .../v1/author/:authorID/page
 	e.GET("v1/author", GetAuthors(storage))                                                                                 // <GetAuthors>        .../v1/author
 	e.GET("v1/publisher/:publisherID", GetPublisher(storage, validator.ValidateGetPublisher(storage)))                      // <GetPublisher>      .../v1/publisher/:publisherID
+	e.GET("v1/publisher/:publisherID/page", GetPublisherPage(storage, validator.ValidateGetPublisherPage(storage)))         // <GetPublisherPage>  .../v1/publisher/:publisherID/page
 	e.GET("v1/publisher", GetPublishers(storage))                                                                           // <GetPublishers>     .../v1/publisher
 	e.GET("v1/topic/:topicID", GetTopic(storage, validator.ValidateGetTopic(storage)))                                      // <GetTopic>          .../v1/topic/:topicID
 	e.GET("v1/topic", GetTopics(storage))                                                                                   // <GetTopics>         .../v1/topic
+	e.GET("v1/topic/tree", GetTopicTree(storage))                                                                           // <GetTopicTree>      .../v1/topic/tree
 	e.GET("v1/lang/:langID", GetLanguage(storage, validator.ValidateGetLanguage(storage)))                                  // <GetLanguage>       .../v1/lang/:langID
 	e.GET("v1/lang", GetLanguages(storage))                                                                                 // <GetLanguages>      .../v1/lang
 	e.GET("v1/book/:bookID", GetBook(storage, validator.ValidateGetBook(storage)))                                          // <GetBook>           .../v1/book/:bookID
+	e.GET("v1/book/:bookID/price", GetPriceQuote(storage, validator.ValidateGetPriceQuote(storage)))                        // <GetPriceQuote>     .../v1/book/:bookID/price
 	e.GET("v1/book", GetAllBooks(storage))                                                                                  // <GetAllBooks>       .../v1/book
 	e.GET("v1/book/author/:authorID", GetAuthorBooks(storage, validator.ValidateGetAuthorBooks(storage)))                   // <GetAuthorBooks>    .../v1/book/author/:authorID
 	e.GET("v1/book/publisher/:publisherID", GetPublisherBooks(storage, validator.ValidateGetPublisherBooks(storage)))       // <GetPublisherBooks> .../v1/book/publisher/:publisherID
 	e.GET("v1/book/topic/:topicID", GetTopicBooks(storage, validator.ValidateGetTopicBooks(storage)))                       // <GetTopicBooks>     .../v1/book/topic/:topicID
 	e.GET("v1/book/lang/:langID", GetLangBooks(storage, validator.ValidateGetLangBooks(storage)))                           // <GetLangBooks>      .../v1/book/lang/:langID
+	e.GET("v1/download/:bookID/:format", DownloadBook(storage, validator.ValidateDownloadBook(storage)))                    // <DownloadBook>      .../v1/download/:bookID/:format
+	e.GET("v1/work/:workID", GetWork(storage, validator.ValidateGetWork(storage)))                                          // <GetWork>           .../v1/work/:workID
+	e.GET("v1/work", GetWorks(storage))                                                                                     // <GetWorks>          .../v1/work
+	e.GET("v1/series/:seriesID", GetSeries(storage, validator.ValidateGetSeries(storage)))                                  // <GetSeries>         .../v1/series/:seriesID
+	e.GET("v1/series", GetAllSeries(storage))                                                                               // <GetAllSeries>      .../v1/series
+	e.GET("v1/boxset/:boxSetID", GetBoxSet(storage, validator.ValidateGetBoxSet(storage)))                                  // <GetBoxSet>         .../v1/boxset/:boxSetID
+	e.GET("v1/boxset", GetBoxSets(storage))                                                                                 // <GetBoxSets>        .../v1/boxset
+
+	e.GET("v1/gift/:token", GetGift(storage, validator.ValidateGetGift(storage)))                                                            // <GetGift>             .../v1/gift/:token
+	e.POST("v1/gift/:token/signup", ClaimGiftWithSignup(storage, validator.ValidateGetGift(storage), validator.ValidateCreateUser(storage))) // <ClaimGiftWithSignup> .../v1/gift/:token/signup
+
+	e.GET("v1/opds", OPDSRoot())                                                                                            // <OPDSRoot>              .../v1/opds
+	e.GET("v1/opds/book", OPDSBooks(storage))                                                                               // <OPDSBooks>             .../v1/opds/book
+	e.GET("v1/opds/topic", OPDSTopics(storage))                                                                             // <OPDSTopics>            .../v1/opds/topic
+	e.GET("v1/opds/topic/:topicID", OPDSTopicBooks(storage, validator.ValidateGetTopicBooks(storage)))                      // <OPDSTopicBooks>        .../v1/opds/topic/:topicID
+	e.GET("v1/opds/author", OPDSAuthors(storage))                                                                           // <OPDSAuthors>           .../v1/opds/author
+	e.GET("v1/opds/author/:authorID", OPDSAuthorBooks(storage, validator.ValidateGetAuthorBooks(storage)))                  // <OPDSAuthorBooks>       .../v1/opds/author/:authorID
+	e.GET("v1/opds/publisher", OPDSPublishers(storage))                                                                     // <OPDSPublishers>        .../v1/opds/publisher
+	e.GET("v1/opds/publisher/:publisherID", OPDSPublisherBooks(storage, validator.ValidateGetPublisherBooks(storage)))      // <OPDSPublisherBooks>    .../v1/opds/publisher/:publisherID
+	e.GET("v1/opds/lang", OPDSLanguages(storage))                                                                           // <OPDSLanguages>         .../v1/opds/lang
+	e.GET("v1/opds/lang/:langID", OPDSLangBooks(storage, validator.ValidateGetLangBooks(storage)))                          // <OPDSLangBooks>         .../v1/opds/lang/:langID
+	e.GET("v1/opds/search.xml", OPDSSearchDescription())                                                                    // <OPDSSearchDescription> .../v1/opds/search.xml
+	e.GET("v1/opds/search", OPDSSearch(storage, validator.ValidateSearchBooks(storage)))                                    // <OPDSSearch>            .../v1/opds/search?q=
+	e.GET("v1/opds/shelf", OPDSShelf(storage, validator.ValidateGetUserDigitalBooks(storage)), auth.UserBasicAuth(storage)) // <OPDSShelf>             .../v1/opds/shelf
 
 	userGroup.GET("", GetUser(storage, validator.ValidateGetUser(storage)))                                                     // <GetUser>               .../v1/user
 	userGroup.DELETE("", DeleteUser(storage, validator.ValidateDeleteUser(storage)))                                            // <DeleteUser>            .../v1/user
@@ -68,43 +96,119 @@ func Routing(storage repository.Storage) *echo.Echo {
 	userGroup.GET("/order/status/:code", GetUserOrdersByStatus(storage, validator.ValidateGetUserOrdersByStatus(storage)))      // <GetUserOrdersByStatus> .../v1/user/order/status/:code
 	userGroup.GET("/promo", GetUserPromos(storage, validator.ValidateGetUserPromos(storage)))                                   // <GetUserPromos>         .../v1/user/promo
 	userGroup.GET("/dashboard/digital", GetUserDigitalBooks(storage, validator.ValidateGetUserDigitalBooks(storage)))           // <GetUserDigitalBooks>   .../v1/user/dashboard/digital
-	userGroup.GET("/dashboard/download/:bookID", DownloadBook(storage, validator.ValidateDownloadBook(storage)))                // <DownloadBook>          .../v1/user/dashboard/download/:bookID
+	userGroup.POST("/dashboard/download/:bookID", CreateDownloadLinks(storage, validator.ValidateCreateDownloadLinks(storage))) // <CreateDownloadLinks>   .../v1/user/dashboard/download/:bookID
 	userGroup.PATCH("/order/:orderID/phone", SetOrderPhone(storage, validator.ValidateSetOrderPhone(storage)))                  // <SetOrderPhone>         .../v1/user/order/:orderID/phone
 	userGroup.PATCH("/order/:orderID/address", SetOrderAddress(storage, validator.ValidateSetOrderAddress(storage)))            // <SetOrderAddress>       .../v1/user/order/:orderID/address
+	userGroup.GET("/wallet", GetWallet(storage, validator.ValidateGetWallet(storage)))                                          // <GetWallet>             .../v1/user/wallet
+	userGroup.POST("/wallet/giftcard", RedeemGiftCard(storage, validator.ValidateRedeemGiftCard(storage)))                      // <RedeemGiftCard>        .../v1/user/wallet/giftcard
+	userGroup.GET("/giftcard", GetUserGiftCards(storage))                                                                       // <GetUserGiftCards>      .../v1/user/giftcard
+	userGroup.POST("/giftcard", AddGiftCard(storage, validator.ValidateAddGiftCard(storage)))                                   // <AddGiftCard>           .../v1/user/giftcard
+	userGroup.DELETE("/giftcard/:giftCardID", RemoveGiftCard(storage, validator.ValidateRemoveGiftCard(storage)))               // <RemoveGiftCard>        .../v1/user/giftcard/:giftCardID
+	userGroup.PATCH("/order/:orderID/wallet", SetOrderWallet(storage, validator.ValidateSetOrderWallet(storage)))               // <SetOrderWallet>        .../v1/user/order/:orderID/wallet
+	userGroup.PUT("/order/:orderID/item/:itemID/gift", SetItemGift(storage, validator.ValidateSetItemGift(storage)))            // <SetItemGift>           .../v1/user/order/:orderID/item/:itemID/gift
+	userGroup.DELETE("/order/:orderID/item/:itemID/gift", RemoveItemGift(storage, validator.ValidateRemoveItemGift(storage)))   // <RemoveItemGift>        .../v1/user/order/:orderID/item/:itemID/gift
+	userGroup.GET("/gift", GetUserGifts(storage))                                                                               // <GetUserGifts>          .../v1/user/gift
+	userGroup.POST("/gift/:token", ClaimGift(storage, validator.ValidateClaimGift(storage)))                                    // <ClaimGift>             .../v1/user/gift/:token
+	userGroup.GET("/points", GetPoints(storage, validator.ValidateGetPoints(storage)))                                          // <GetPoints>             .../v1/user/points
+	userGroup.PATCH("/order/:orderID/points", SetOrderPoints(storage, validator.ValidateSetOrderPoints(storage)))               // <SetOrderPoints>        .../v1/user/order/:orderID/points
+	userGroup.GET("/referral", GetReferral(storage, validator.ValidateGetReferral(storage)))                                    // <GetReferral>           .../v1/user/referral
 	userGroup.DELETE("/logout", UserLogOut(storage))                                                                            // <UserLogOut>            .../v1/logout
 	userGroup.DELETE("/logout/all", UserLogOutAllDevices(storage))                                                              // <UserLogOutAllDevices>  .../v1/logout/all
 
 	userGroup.POST("/order/:orderID/payment/zarinpal", payment.ZarinpalPayment(storage, validator.ValidateGetOrderPaymentInfo(storage))) // <ZarinpalPayment>             .../v1/user/order/:orderID/payment/zarinpal
+	userGroup.POST("/order/:orderID/payment/wallet", PayOrderWithWallet(storage, validator.ValidatePayOrderWithWallet(storage)))         // <PayOrderWithWallet>          .../v1/user/order/:orderID/payment/wallet
 	e.GET("v1/payment/zarinpal/check", payment.ZarinpalPaymentVerification(storage))                                                     // <ZarinpalPaymentVerification> .../v1/payment/zarinpal/check
 
-	adminGroup.GET("/users", GetUsers(storage))                                                                                  // <GetUsers>              .../v1/admin/users
-	adminGroup.GET("", GetAdmin(storage, validator.ValidateGetAdmin(storage)))                                                   // <GetAdmin>              .../v1/admin
-	adminGroup.GET("s", GetAdmins(storage))                                                                                      // <GetAdmins>             .../v1/admins
-	adminGroup.POST("/author", AddAuthor(storage, validator.ValidateAddAuthor(storage)))                                         // <AddAuthor>             .../v1/admin/author
-	adminGroup.DELETE("/author/:authorID", DeleteAuthor(storage, validator.ValidateDeleteAuthor(storage)))                       // <DeleteAuthor>          .../v1/admin/author/:authorID
-	adminGroup.POST("/publisher", AddPublisher(storage, validator.ValidateAddPublisher(storage)))                                // <AddPublisher>          .../v1/admin/publisher
-	adminGroup.DELETE("/publisher/:publisherID", DeletePublisher(storage, validator.ValidateDeletePublisher(storage)))           // <DeltePublisher>        .../v1/admin/publisher/:publisherID
-	adminGroup.POST("/topic", AddTopic(storage, validator.ValidateAddTopic(storage)))                                            // <AddTopic>              .../v1/admin/topic
-	adminGroup.DELETE("/topic/:topicID", DeleteTopic(storage, validator.ValidateDeleteTopic(storage)))                           // <DeleteTopic>           .../v1/admin/topic/:topicID
-	adminGroup.POST("/lang", AddLanguage(storage, validator.ValidateAddLanguage(storage)))                                       // <AddLanguage>           .../v1/admin/lang
-	adminGroup.DELETE("/lang/:langID", DeleteLanguage(storage, validator.ValidateDeleteLanguage(storage)))                       // <DeleteLanguage>        .../v1/admin/lang/:langID
-	adminGroup.POST("/book", AddBook(storage, validator.ValidateAddBook(storage)))                                               // <AddBook>               .../v1/admin/book
-	adminGroup.PATCH("/discount/:bookID", SetBookDiscount(storage, validator.ValidateSetBookDiscount(storage)))                  // <SetBookDiscount>       .../v1/admin/discount/:bookID
-	adminGroup.PUT("/book/:bookID", EditBook(storage, validator.ValidateEditBook(storage)))                                      // <EditBook>              .../v1/admin/book/:bookID
-	adminGroup.DELETE("/book/:bookID", DeleteBook(storage, validator.ValidateDeleteBook(storage)))                               // <DeleteBook>            .../v1/admin/book/:bookID
-	adminGroup.POST("/promo", CreatePromoCode(storage, validator.ValidateCreatePromoCode(storage)))                              // <CreatePromoCode>       .../v1/admin/promo
-	adminGroup.DELETE("/promo/:promoID", DeletePromoCode(storage, validator.ValidateDeletePromoCode(storage)))                   // <DeletePromoCode>       .../v1/admin/promo/:promoID
-	adminGroup.PATCH("/order/:orderID/status", SetOrderStatus(storage, validator.ValidateSetOrderStatus(storage)))               // <SetOrderStatus>        .../v1/admin/order/:orderID/status
-	adminGroup.PATCH("/order/:orderID/stn", SetOrderSTN(storage, validator.ValidateSetOrderSTN(storage)))                        // <SetOrderSTN>           .../v1/admin/order/:orderID/stn
-	adminGroup.DELETE("/order/:orderID", DeleteOrder(storage, validator.ValidateDeleteOrder(storage)))                           // <DeleteOrder>           .../v1/admin/order/:orderID
-	adminGroup.GET("/order", GetAllOrders(storage))                                                                              // <GetAllOrders>          .../v1/admin/order
-	adminGroup.GET("/order/status/:code", GetAllOrdersByStatus(storage, validator.ValidateGetAllOrdersByStatus(storage)))        // <GetAllOrdersByStatus>  .../v1/admin/order/:status
-	adminGroup.GET("/order/date", GetDateOrders(storage, validator.ValidateGetDateOrders(storage)))                              // <GetDateOrders>         .../v1/admin/order/date
-	adminGroup.GET("/order/date/status/:code", GetDateOrdersByStatus(storage, validator.ValidateGetDateOrdersByStatus(storage))) // <GetDateOrdersByStatus> .../v1/admin/order/date/status/:code
-	adminGroup.GET("/promo", GetAllPromos(storage))                                                                              // <GetAllPromos>          .../v1/admin/promo
-	adminGroup.GET("/promo/order/:orderID", GetPromoByOrder(storage, validator.ValidateGetPromoByOrder(storage)))                // <GetPromoByOrder>       .../v1/admin/promo/order/:orderID
-	adminGroup.DELETE("/logout", AdminLogOut(storage))                                                                           // <AdminLogOut>            .../v1/admin/logout
-	adminGroup.DELETE("/logout/all", AdminLogOutAllDevices(storage))                                                             // <AdminLogOutAllDevices>  .../v1/admin/logout/all
+	adminGroup.GET("/users", GetUsers(storage))                                                                                              // <GetUsers>                .../v1/admin/users
+	adminGroup.GET("", GetAdmin(storage, validator.ValidateGetAdmin(storage)))                                                               // <GetAdmin>                .../v1/admin
+	adminGroup.GET("s", GetAdmins(storage))                                                                                                  // <GetAdmins>               .../v1/admins
+	adminGroup.POST("/author", AddAuthor(storage, validator.ValidateAddAuthor(storage)))                                                     // <AddAuthor>               .../v1/admin/author
+	adminGroup.PUT("/author/:authorID", EditAuthor(storage, validator.ValidateEditAuthor(storage)))                                          // <EditAuthor>              .../v1/admin/author/:authorID
+	adminGroup.DELETE("/author/:authorID", DeleteAuthor(storage, validator.ValidateDeleteAuthor(storage)))                                   // <DeleteAuthor>            .../v1/admin/author/:authorID
+	adminGroup.POST("/publisher", AddPublisher(storage, validator.ValidateAddPublisher(storage)))                                            // <AddPublisher>            .../v1/admin/publisher
+	adminGroup.PUT("/publisher/:publisherID", EditPublisher(storage, validator.ValidateEditPublisher(storage)))                              // <EditPublisher>           .../v1/admin/publisher/:publisherID
+	adminGroup.DELETE("/publisher/:publisherID", DeletePublisher(storage, validator.ValidateDeletePublisher(storage)))                       // <DeltePublisher>          .../v1/admin/publisher/:publisherID
+	adminGroup.POST("/topic", AddTopic(storage, validator.ValidateAddTopic(storage)))                                                        // <AddTopic>                .../v1/admin/topic
+	adminGroup.PUT("/topic/:topicID/move", MoveTopic(storage, validator.ValidateMoveTopic(storage)))                                         // <MoveTopic>               .../v1/admin/topic/:topicID/move
+	adminGroup.PUT("/topic/:topicID/position", ReorderTopic(storage, validator.ValidateReorderTopic(storage)))                               // <ReorderTopic>            .../v1/admin/topic/:topicID/position
+	adminGroup.DELETE("/topic/:topicID", DeleteTopic(storage, validator.ValidateDeleteTopic(storage)))                                       // <DeleteTopic>             .../v1/admin/topic/:topicID
+	adminGroup.POST("/lang", AddLanguage(storage, validator.ValidateAddLanguage(storage)))                                                   // <AddLanguage>             .../v1/admin/lang
+	adminGroup.DELETE("/lang/:langID", DeleteLanguage(storage, validator.ValidateDeleteLanguage(storage)))                                   // <DeleteLanguage>          .../v1/admin/lang/:langID
+	adminGroup.POST("/book", AddBook(storage, validator.ValidateAddBook(storage)))                                                           // <AddBook>                 .../v1/admin/book
+	adminGroup.POST("/book/extract", ExtractBookMetadata(storage, validator.ValidateExtractBookMetadata(storage)))                           // <ExtractBookMetadata>     .../v1/admin/book/extract
+	adminGroup.POST("/book/extract/confirm", ConfirmBookMetadata(storage, validator.ValidateConfirmBookMetadata(storage)))                   // <ConfirmBookMetadata>     .../v1/admin/book/extract/confirm
+	adminGroup.POST("/catalog/import", ImportCatalog(storage, validator.ValidateImportCatalog(storage)))                                     // <ImportCatalog>           .../v1/admin/catalog/import
+	adminGroup.GET("/catalog/import", GetImportJobs(storage))                                                                                // <GetImportJobs>           .../v1/admin/catalog/import
+	adminGroup.GET("/catalog/import/:jobID", GetImportJob(storage, validator.ValidateGetImportJob(storage)))                                 // <GetImportJob>            .../v1/admin/catalog/import/:jobID
+	adminGroup.GET("/catalog/export/:format", ExportCatalog(storage, validator.ValidateExportCatalog(storage)))                              // <ExportCatalog>           .../v1/admin/catalog/export/:format
+	adminGroup.POST("/series", AddSeries(storage, validator.ValidateAddSeries(storage)))                                                     // <AddSeries>               .../v1/admin/series
+	adminGroup.DELETE("/series/:seriesID", DeleteSeries(storage, validator.ValidateDeleteSeries(storage)))                                   // <DeleteSeries>            .../v1/admin/series/:seriesID
+	adminGroup.POST("/work", AddWork(storage, validator.ValidateAddWork(storage)))                                                           // <AddWork>                 .../v1/admin/work
+	adminGroup.PUT("/work/:workID", EditWork(storage, validator.ValidateEditWork(storage)))                                                  // <EditWork>                .../v1/admin/work/:workID
+	adminGroup.DELETE("/work/:workID", DeleteWork(storage, validator.ValidateDeleteWork(storage)))                                           // <DeleteWork>              .../v1/admin/work/:workID
+	adminGroup.PUT("/book/:bookID/work", SetBookWork(storage, validator.ValidateSetBookWork(storage)))                                       // <SetBookWork>             .../v1/admin/book/:bookID/work
+	adminGroup.POST("/book/:bookID/contributor", AddContributor(storage, validator.ValidateAddContributor(storage)))                         // <AddContributor>          .../v1/admin/book/:bookID/contributor
+	adminGroup.DELETE("/book/:bookID/contributor/:role/:authorID", RemoveContributor(storage, validator.ValidateRemoveContributor(storage))) // <RemoveContributor>       .../v1/admin/book/:bookID/contributor/:role/:authorID
+	adminGroup.GET("/duplicate/:kind", GetDuplicates(storage, validator.ValidateGetDuplicates(storage)))                                     // <GetDuplicates>           .../v1/admin/duplicate/:kind
+	adminGroup.POST("/merge/:kind", Merge(storage, validator.ValidateMerge(storage)))                                                        // <Merge>                   .../v1/admin/merge/:kind
+	adminGroup.GET("/merge", GetRedirects(storage))                                                                                          // <GetRedirects>            .../v1/admin/merge
+	adminGroup.GET("/trash/:kind", GetTrash(storage, validator.ValidateGetTrash(storage)))                                                   // <GetTrash>                .../v1/admin/trash/:kind
+	adminGroup.PUT("/trash/:kind/:recordID", Restore(storage, validator.ValidateRestore(storage)))                                           // <Restore>                 .../v1/admin/trash/:kind/:recordID
+	adminGroup.DELETE("/trash/:kind/:recordID", Purge(storage, validator.ValidatePurge(storage)))                                            // <Purge>                   .../v1/admin/trash/:kind/:recordID
+	adminGroup.POST("/inventory/:bookID/receipt", ReceiveStock(storage, validator.ValidateReceiveStock(storage)))                            // <ReceiveStock>            .../v1/admin/inventory/:bookID/receipt
+	adminGroup.POST("/inventory/:bookID/adjustment", AdjustStock(storage, validator.ValidateAdjustStock(storage)))                           // <AdjustStock>             .../v1/admin/inventory/:bookID/adjustment
+	adminGroup.GET("/inventory/:bookID", GetMovements(storage, validator.ValidateGetMovements(storage)))                                     // <GetMovements>            .../v1/admin/inventory/:bookID
+	adminGroup.POST("/inventory/stocktake", Stocktake(storage, validator.ValidateStocktake(storage)))                                        // <Stocktake>               .../v1/admin/inventory/stocktake
+	adminGroup.PUT("/inventory/:bookID/threshold", SetReorderThreshold(storage, validator.ValidateSetReorderThreshold(storage)))             // <SetReorderThreshold>     .../v1/admin/inventory/:bookID/threshold
+	adminGroup.GET("/inventory/lowstock", GetLowStockReport(storage))                                                                        // <GetLowStockReport>       .../v1/admin/inventory/lowstock
+	adminGroup.GET("/preorder", GetPreorderDemand(storage))                                                                                  // <GetPreorderDemand>       .../v1/admin/preorder
+	adminGroup.POST("/boxset", AddBoxSet(storage, validator.ValidateAddBoxSet(storage)))                                                     // <AddBoxSet>               .../v1/admin/boxset
+	adminGroup.PUT("/boxset/:boxSetID", EditBoxSet(storage, validator.ValidateEditBoxSet(storage)))                                          // <EditBoxSet>              .../v1/admin/boxset/:boxSetID
+	adminGroup.DELETE("/boxset/:boxSetID", DeleteBoxSet(storage, validator.ValidateDeleteBoxSet(storage)))                                   // <DeleteBoxSet>            .../v1/admin/boxset/:boxSetID
+	adminGroup.POST("/campaign", AddCampaign(storage, validator.ValidateAddCampaign(storage)))                                               // <AddCampaign>             .../v1/admin/campaign
+	adminGroup.GET("/campaign", GetCampaigns(storage))                                                                                       // <GetCampaigns>            .../v1/admin/campaign
+	adminGroup.GET("/campaign/:campaignID", GetCampaign(storage, validator.ValidateGetCampaign(storage)))                                    // <GetCampaign>             .../v1/admin/campaign/:campaignID
+	adminGroup.PUT("/campaign/:campaignID", EditCampaign(storage, validator.ValidateEditCampaign(storage)))                                  // <EditCampaign>            .../v1/admin/campaign/:campaignID
+	adminGroup.DELETE("/campaign/:campaignID", DeleteCampaign(storage, validator.ValidateDeleteCampaign(storage)))                           // <DeleteCampaign>          .../v1/admin/campaign/:campaignID
+	adminGroup.POST("/campaign/preview", PreviewCampaign(storage, validator.ValidatePreviewCampaign(storage)))                               // <PreviewCampaign>         .../v1/admin/campaign/preview
+	adminGroup.GET("/campaign/:campaignID/preview", PreviewCampaign(storage, validator.ValidatePreviewCampaign(storage)))                    // <PreviewCampaign>         .../v1/admin/campaign/:campaignID/preview
+	adminGroup.PUT("/bundle-rule", SetBundleRule(storage, validator.ValidateSetBundleRule(storage)))                                         // <SetBundleRule>           .../v1/admin/bundle-rule
+	adminGroup.GET("/bundle-rule", GetBundleRules(storage))                                                                                  // <GetBundleRules>          .../v1/admin/bundle-rule
+	adminGroup.DELETE("/bundle-rule/:scope/:scopeID", DeleteBundleRule(storage, validator.ValidateDeleteBundleRule(storage)))                // <DeleteBundleRule>        .../v1/admin/bundle-rule/:scope/:scopeID
+	adminGroup.PUT("/book/:bookID/availability", SetAvailabilityOverride(storage, validator.ValidateSetAvailabilityOverride(storage)))       // <SetAvailabilityOverride> .../v1/admin/book/:bookID/availability
+	adminGroup.PATCH("/discount/:bookID", SetBookDiscount(storage, validator.ValidateSetBookDiscount(storage)))                              // <SetBookDiscount>         .../v1/admin/discount/:bookID
+	adminGroup.PUT("/book/:bookID", EditBook(storage, validator.ValidateEditBook(storage)))                                                  // <EditBook>                .../v1/admin/book/:bookID
+	adminGroup.DELETE("/book/:bookID", DeleteBook(storage, validator.ValidateDeleteBook(storage)))                                           // <DeleteBook>              .../v1/admin/book/:bookID
+	adminGroup.POST("/promo", CreatePromoCode(storage, validator.ValidateCreatePromoCode(storage)))                                          // <CreatePromoCode>         .../v1/admin/promo
+	adminGroup.DELETE("/promo/:promoID", DeletePromoCode(storage, validator.ValidateDeletePromoCode(storage)))                               // <DeletePromoCode>         .../v1/admin/promo/:promoID
+	adminGroup.POST("/promo/batch", CreatePromoBatch(storage, validator.ValidateCreatePromoBatch(storage)))                                  // <CreatePromoBatch>        .../v1/admin/promo/batch
+	adminGroup.PATCH("/order/:orderID/status", SetOrderStatus(storage, validator.ValidateSetOrderStatus(storage)))                           // <SetOrderStatus>          .../v1/admin/order/:orderID/status
+	adminGroup.PATCH("/order/:orderID/stn", SetOrderSTN(storage, validator.ValidateSetOrderSTN(storage)))                                    // <SetOrderSTN>             .../v1/admin/order/:orderID/stn
+	adminGroup.DELETE("/order/:orderID", DeleteOrder(storage, validator.ValidateDeleteOrder(storage)))                                       // <DeleteOrder>             .../v1/admin/order/:orderID
+	adminGroup.GET("/order", GetAllOrders(storage))                                                                                          // <GetAllOrders>            .../v1/admin/order
+	adminGroup.GET("/order/status/:code", GetAllOrdersByStatus(storage, validator.ValidateGetAllOrdersByStatus(storage)))                    // <GetAllOrdersByStatus>    .../v1/admin/order/:status
+	adminGroup.GET("/order/date", GetDateOrders(storage, validator.ValidateGetDateOrders(storage)))                                          // <GetDateOrders>           .../v1/admin/order/date
+	adminGroup.GET("/order/date/status/:code", GetDateOrdersByStatus(storage, validator.ValidateGetDateOrdersByStatus(storage)))             // <GetDateOrdersByStatus>   .../v1/admin/order/date/status/:code
+	adminGroup.GET("/promo", GetAllPromos(storage))                                                                                          // <GetAllPromos>            .../v1/admin/promo
+	adminGroup.GET("/promo/order/:orderID", GetPromoByOrder(storage, validator.ValidateGetPromoByOrder(storage)))                            // <GetPromoByOrder>         .../v1/admin/promo/order/:orderID
+	adminGroup.GET("/promo/:promoID/redemption", GetPromoRedemptions(storage, validator.ValidateGetPromoRedemptions(storage)))               // <GetPromoRedemptions>     .../v1/admin/promo/:promoID/redemption
+	adminGroup.GET("/promo/batch", GetPromoBatchReports(storage))                                                                            // <GetPromoBatchReports>    .../v1/admin/promo/batch
+	adminGroup.GET("/promo/batch/:batchID", GetPromoBatch(storage, validator.ValidateGetPromoBatch(storage)))                                // <GetPromoBatch>           .../v1/admin/promo/batch/:batchID
+	adminGroup.GET("/promo/batch/:batchID/export", ExportPromoBatch(storage, validator.ValidateGetPromoBatch(storage)))                      // <ExportPromoBatch>        .../v1/admin/promo/batch/:batchID/export
+	adminGroup.POST("/order/:orderID/refund", RefundOrder(storage, validator.ValidateRefundOrder(storage)))                                  // <RefundOrder>             .../v1/admin/order/:orderID/refund
+	adminGroup.GET("/wallet/:userID", GetUserWallet(storage, validator.ValidateGetWallet(storage)))                                          // <GetUserWallet>           .../v1/admin/wallet/:userID
+	adminGroup.GET("/points/topic", GetTopicMultipliers(storage))                                                                            // <GetTopicMultipliers>     .../v1/admin/points/topic
+	adminGroup.PUT("/points/topic/:topicID", SetTopicMultiplier(storage, validator.ValidateSetTopicMultiplier(storage)))                     // <SetTopicMultiplier>      .../v1/admin/points/topic/:topicID
+	adminGroup.DELETE("/points/topic/:topicID", DeleteTopicMultiplier(storage, validator.ValidateDeleteTopicMultiplier(storage)))            // <DeleteTopicMultiplier>   .../v1/admin/points/topic/:topicID
+	adminGroup.GET("/points/:userID", GetUserPoints(storage, validator.ValidateGetPoints(storage)))                                          // <GetUserPoints>           .../v1/admin/points/:userID
+	adminGroup.GET("/referral", GetReferralReport(storage))                                                                                  // <GetReferralReport>       .../v1/admin/referral
+	adminGroup.GET("/referral/:userID", GetUserReferrals(storage, validator.ValidateGetUserReferrals(storage)))                              // <GetUserReferrals>        .../v1/admin/referral/:userID
+	adminGroup.GET("/download", GetDownloads(storage))                                                                                       // <GetDownloads>            .../v1/admin/download
+	adminGroup.GET("/download/user/:userID", GetUserDownloads(storage, validator.ValidateGetUserDownloads(storage)))                         // <GetUserDownloads>        .../v1/admin/download/user/:userID
+	adminGroup.GET("/download/activity", GetDownloadActivity(storage))                                                                       // <GetDownloadActivity>     .../v1/admin/download/activity
+	adminGroup.PUT("/download/limit", SetDownloadLimit(storage, validator.ValidateSetDownloadLimit(storage)))                                // <SetDownloadLimit>        .../v1/admin/download/limit
+	adminGroup.DELETE("/logout", AdminLogOut(storage))                                                                                       // <AdminLogOut>             .../v1/admin/logout
+	adminGroup.DELETE("/logout/all", AdminLogOutAllDevices(storage))                                                                         // <AdminLogOutAllDevices>   .../v1/admin/logout/all
 
 	return e
 }
@@ -0,0 +1,84 @@
+package v1
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/XBozorg/bookstore/adapter/repository"
+	"github.com/XBozorg/bookstore/dto"
+	"github.com/XBozorg/bookstore/usecase/book"
+	"github.com/go-sql-driver/mysql"
+	"github.com/labstack/echo/v4"
+)
+
+func GetTrash(storage repository.Storage, validator book.ValidateGetTrash) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.GetTrashRequest{Kind: c.Param("kind")}
+
+		if err := validator(c.Request().Context(), req); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		resp, err := book.New(storage).GetTrash(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
+func Restore(storage repository.Storage, validator book.ValidateRestore) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.RestoreRequest{Kind: c.Param("kind")}
+
+		rid, err := strconv.ParseUint(c.Param("recordID"), 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest)
+		}
+		req.RecordID = uint(rid)
+
+		if err := validator(c.Request().Context(), req); err != nil {
+			if strings.Contains(err.Error(), "does not exist") {
+				return echo.NewHTTPError(http.StatusNotFound, err.Error())
+			}
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		resp, err := book.New(storage).Restore(c.Request().Context(), req)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		return c.JSON(http.StatusOK, resp)
+	}
+}
+
+func Purge(storage repository.Storage, validator book.ValidatePurge) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := dto.PurgeRequest{Kind: c.Param("kind")}
+
+		rid, err := strconv.ParseUint(c.Param("recordID"), 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest)
+		}
+		req.RecordID = uint(rid)
+
+		if err := validator(c.Request().Context(), req); err != nil {
+			if strings.Contains(err.Error(), "does not exist") {
+				return echo.NewHTTPError(http.StatusNotFound, err.Error())
+			}
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		resp, err := book.New(storage).Purge(c.Request().Context(), req)
+		if err != nil {
+
+			if driverErr, ok := err.(*mysql.MySQLError); ok && driverErr.Number == 1451 {
+				return echo.NewHTTPError(http.StatusConflict, req.Kind+" is still referenced by books or orders")
+			}
+
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		return c.JSON(http.StatusOK, resp)
+	}
+}
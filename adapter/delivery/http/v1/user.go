@@ -21,7 +21,7 @@ func CreateUser(storage repository.Storage, validator user.ValidateCreateUser) e
 			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 		}
 
-		if err := validator(createUserReq); err != nil {
+		if err := validator(c.Request().Context(), createUserReq); err != nil {
 			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 		}
 
@@ -38,6 +38,9 @@ func CreateUser(storage repository.Storage, validator user.ValidateCreateUser) e
 				}
 
 			}
+			if strings.Contains(err.Error(), "referral") {
+				return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+			}
 			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 		}
 
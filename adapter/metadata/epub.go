@@ -0,0 +1,237 @@
+package metadata
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/XBozorg/bookstore/config"
+	"github.com/XBozorg/bookstore/entity/book"
+)
+
+type container struct {
+	Rootfiles []struct {
+		FullPath string `xml:"full-path,attr"`
+	} `xml:"rootfiles>rootfile"`
+}
+
+type opfPackage struct {
+	Metadata struct {
+		Titles   []string `xml:"title"`
+		Creators []struct {
+			Name string `xml:",chardata"`
+			Role string `xml:"role,attr"`
+		} `xml:"creator"`
+		Publisher   string   `xml:"publisher"`
+		Languages   []string `xml:"language"`
+		Description string   `xml:"description"`
+		Dates       []string `xml:"date"`
+		Identifiers []string `xml:"identifier"`
+		Metas       []struct {
+			Name    string `xml:"name,attr"`
+			Content string `xml:"content,attr"`
+		} `xml:"meta"`
+	} `xml:"metadata"`
+	Items []struct {
+		ID         string `xml:"id,attr"`
+		Href       string `xml:"href,attr"`
+		MediaType  string `xml:"media-type,attr"`
+		Properties string `xml:"properties,attr"`
+	} `xml:"manifest>item"`
+}
+
+func extractEPUB(epubPath string) (book.Metadata, error) {
+
+	r, err := zip.OpenReader(epubPath)
+	if err != nil {
+		return book.Metadata{}, err
+	}
+	defer r.Close()
+
+	opf, _, err := readPackage(&r.Reader)
+	if err != nil {
+		return book.Metadata{}, err
+	}
+
+	m := book.Metadata{
+		Publisher:   strings.TrimSpace(opf.Metadata.Publisher),
+		Description: opf.Metadata.Description,
+		Authors:     []string{},
+	}
+
+	if len(opf.Metadata.Titles) > 0 {
+		m.Title = opf.Metadata.Titles[0]
+	}
+	for _, creator := range opf.Metadata.Creators {
+		if creator.Role == "" || creator.Role == "aut" {
+			m.Authors = append(m.Authors, strings.TrimSpace(creator.Name))
+		}
+	}
+	if len(opf.Metadata.Languages) > 0 {
+		m.Language = langCode(opf.Metadata.Languages[0])
+	}
+	for _, date := range opf.Metadata.Dates {
+		if y := year.FindString(date); y != "" {
+			m.Year = y
+			break
+		}
+	}
+	for _, id := range opf.Metadata.Identifiers {
+		if isbn := isbn13(id); isbn != "" {
+			m.ISBN = isbn
+			break
+		}
+	}
+
+	if href := coverHref(opf); href != "" {
+		name := m.ISBN
+		if name == "" {
+			name = strings.TrimSuffix(filepath.Base(epubPath), filepath.Ext(epubPath))
+		}
+
+		// the cover is only written once the proposal is confirmed, see SaveCover
+		m.Cover = filepath.Join(config.Conf.GetMetadataConfig().CoverDir, name+"-front"+strings.ToLower(path.Ext(href)))
+	}
+
+	return m, nil
+}
+
+// readPackage returns the package document of the epub and its path in the archive
+func readPackage(r *zip.Reader) (opfPackage, string, error) {
+
+	c := container{}
+	if err := readXML(r, "META-INF/container.xml", &c); err != nil {
+		return opfPackage{}, "", err
+	}
+	if len(c.Rootfiles) == 0 {
+		return opfPackage{}, "", errors.New("epub package document not found")
+	}
+	opfPath := c.Rootfiles[0].FullPath
+
+	opf := opfPackage{}
+	if err := readXML(r, opfPath, &opf); err != nil {
+		return opfPackage{}, "", err
+	}
+
+	return opf, opfPath, nil
+}
+
+// SaveCover writes the cover image of the epub to the path Extract proposed for it. A cover
+// outside the cover directory or already on disk is left alone, it reports whether it wrote one.
+func SaveCover(epubPath, cover string) (bool, error) {
+
+	dir := filepath.Clean(config.Conf.GetMetadataConfig().CoverDir)
+	if epubPath == "" || filepath.Dir(filepath.Clean(cover)) != dir {
+		return false, nil
+	}
+	if _, err := os.Stat(cover); !errors.Is(err, os.ErrNotExist) {
+		return false, err
+	}
+
+	r, err := zip.OpenReader(epubPath)
+	if err != nil {
+		return false, err
+	}
+	defer r.Close()
+
+	opf, opfPath, err := readPackage(&r.Reader)
+	if err != nil {
+		return false, err
+	}
+
+	href := coverHref(opf)
+	if href == "" {
+		return false, errors.New("epub has no cover image")
+	}
+
+	if err = saveCover(&r.Reader, path.Join(path.Dir(opfPath), href), dir, cover); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// coverHref finds the cover image declared by EPUB 2 (<meta name="cover">) or EPUB 3 (properties="cover-image")
+func coverHref(opf opfPackage) string {
+
+	coverID := ""
+	for _, meta := range opf.Metadata.Metas {
+		if meta.Name == "cover" {
+			coverID = meta.Content
+		}
+	}
+
+	for _, item := range opf.Items {
+		if !strings.HasPrefix(item.MediaType, "image/") {
+			continue
+		}
+		if item.ID == coverID || strings.Contains(item.Properties, "cover-image") {
+			if href, err := url.PathUnescape(item.Href); err == nil {
+				return href
+			}
+			return item.Href
+		}
+	}
+
+	return ""
+}
+
+func saveCover(r *zip.Reader, name, dir, cover string) error {
+
+	f, err := findFile(r, name)
+	if err != nil {
+		return err
+	}
+
+	src, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	if err = os.MkdirAll(dir, 0750); err != nil {
+		return err
+	}
+
+	dst, err := os.Create(cover)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	_, err = io.Copy(dst, src)
+	return err
+}
+
+func readXML(r *zip.Reader, name string, v interface{}) error {
+
+	f, err := findFile(r, name)
+	if err != nil {
+		return err
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	return xml.NewDecoder(rc).Decode(v)
+}
+
+func findFile(r *zip.Reader, name string) (*zip.File, error) {
+
+	for _, f := range r.File {
+		if f.Name == name {
+			return f, nil
+		}
+	}
+
+	return nil, errors.New(name + " not found in epub")
+}
@@ -0,0 +1,135 @@
+package metadata
+
+import (
+	"errors"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/XBozorg/bookstore/entity/book"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+)
+
+func init() {
+	pdfcpu.ConfigPath = "disable" // don't create pdfcpu's config.yml in the user config dir
+}
+
+var (
+	tags   = regexp.MustCompile(`<[^>]*>`)
+	spaces = regexp.MustCompile(`\s+`)
+	year   = regexp.MustCompile(`\d{4}`)
+	names  = regexp.MustCompile(`[,;&]| and `)
+)
+
+// Extract reads the metadata of the uploaded book files, preferring the EPUB package document
+// and filling the gaps (page count mostly) from the PDF info dictionary
+func Extract(pdfPath, epubPath string) (book.Metadata, error) {
+
+	if pdfPath == "" && epubPath == "" {
+		return book.Metadata{}, errors.New("no file to extract")
+	}
+
+	m := book.Metadata{}
+
+	if epubPath != "" {
+		e, err := extractEPUB(epubPath)
+		if err != nil {
+			return book.Metadata{}, err
+		}
+		m = e
+	}
+
+	if pdfPath != "" {
+		p, err := extractPDF(pdfPath)
+		if err != nil {
+			return book.Metadata{}, err
+		}
+		merge(&m, p)
+	}
+
+	m.Description = cleanText(m.Description, 500)
+	m.Title = cleanText(m.Title, 100)
+
+	return m, nil
+}
+
+func merge(m *book.Metadata, p book.Metadata) {
+
+	if m.Title == "" {
+		m.Title = p.Title
+	}
+	if m.ISBN == "" {
+		m.ISBN = p.ISBN
+	}
+	if m.Pages == 0 {
+		m.Pages = p.Pages
+	}
+	if len(m.Authors) == 0 {
+		m.Authors = p.Authors
+	}
+	if m.Publisher == "" {
+		m.Publisher = p.Publisher
+	}
+	if m.Language == "" {
+		m.Language = p.Language
+	}
+	if m.Description == "" {
+		m.Description = p.Description
+	}
+	if m.Year == "" {
+		m.Year = p.Year
+	}
+}
+
+// cleanText strips markup and collapses whitespace, cutting the text to the column size
+func cleanText(s string, max int) string {
+
+	s = html.UnescapeString(tags.ReplaceAllString(s, " "))
+	s = strings.TrimSpace(spaces.ReplaceAllString(s, " "))
+
+	if r := []rune(s); len(r) > max {
+		s = string(r[:max])
+	}
+
+	return s
+}
+
+// isbn13 returns the ISBN-13 found in an identifier like "urn:isbn:978-0-13-110362-7"
+func isbn13(id string) string {
+
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, id)
+
+	if len(digits) != 13 || !(strings.HasPrefix(digits, "978") || strings.HasPrefix(digits, "979")) {
+		return ""
+	}
+
+	return digits
+}
+
+// langCode reduces a language tag like "en-US" to the two letter code stored by the catalog
+func langCode(lang string) string {
+
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if len(lang) < 2 {
+		return ""
+	}
+
+	return lang[:2]
+}
+
+func splitAuthors(s string) []string {
+
+	authors := []string{}
+	for _, a := range names.Split(s, -1) {
+		if a = strings.TrimSpace(a); a != "" {
+			authors = append(authors, a)
+		}
+	}
+
+	return authors
+}
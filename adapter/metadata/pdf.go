@@ -0,0 +1,46 @@
+package metadata
+
+import (
+	"os"
+
+	"github.com/XBozorg/bookstore/entity/book"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/validate"
+)
+
+func extractPDF(path string) (book.Metadata, error) {
+
+	f, err := os.Open(path)
+	if err != nil {
+		return book.Metadata{}, err
+	}
+	defer f.Close()
+
+	conf := pdfcpu.NewDefaultConfiguration()
+	conf.ValidationMode = pdfcpu.ValidationRelaxed
+
+	ctx, err := pdfcpu.Read(f, conf)
+	if err != nil {
+		return book.Metadata{}, err
+	}
+
+	// the info dictionary is only parsed while validating
+	if err = validate.XRefTable(ctx.XRefTable); err != nil {
+		return book.Metadata{}, err
+	}
+
+	m := book.Metadata{
+		Title:       ctx.Title,
+		Pages:       uint(ctx.PageCount),
+		Authors:     splitAuthors(ctx.Author),
+		Description: ctx.Subject,
+		ISBN:        isbn13(ctx.Keywords),
+	}
+
+	// creation date looks like "D:20190314120000+03'30'"
+	if y := year.FindString(ctx.CreationDate); y != "" {
+		m.Year = y
+	}
+
+	return m, nil
+}
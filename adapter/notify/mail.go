@@ -0,0 +1,42 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+
+	"github.com/XBozorg/bookstore/config"
+)
+
+var header = strings.NewReplacer("\r", "", "\n", "")
+
+// Mail sends the message to a customer through the configured mail server. Unlike Send
+// it never reaches the staff webhook or the log, the body can carry links and codes.
+func Mail(to, subject, body string) error {
+
+	conf := config.Conf.GetNotifyConfig()
+	if conf.SMTPAddress == "" {
+		return errors.New("mail server is not configured")
+	}
+
+	host, _, err := net.SplitHostPort(conf.SMTPAddress)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if conf.SMTPUser != "" {
+		auth = smtp.PlainAuth("", conf.SMTPUser, conf.SMTPPass, host)
+	}
+
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n",
+		header.Replace(conf.MailFrom),
+		header.Replace(to),
+		header.Replace(subject),
+		body,
+	)
+
+	return smtp.SendMail(conf.SMTPAddress, auth, conf.MailFrom, []string{to}, []byte(msg))
+}
@@ -0,0 +1,61 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/XBozorg/bookstore/config"
+	"github.com/XBozorg/bookstore/log"
+)
+
+// Message is a notification for the staff, data carries the event payload
+type Message struct {
+	Event   string      `json:"event"`
+	Subject string      `json:"subject"`
+	Data    interface{} `json:"data,omitempty"`
+	Date    string      `json:"date"`
+}
+
+var client = &http.Client{Timeout: 10 * time.Second}
+
+// Send logs the message and posts it to the configured webhook if there is one
+func Send(ctx context.Context, m Message) error {
+
+	if m.Date == "" {
+		m.Date = time.Now().Format("2006-01-02 15:04:05")
+	}
+
+	log.I.WithField("event", m.Event).Infoln(m.Subject)
+
+	url := config.Conf.GetNotifyConfig().WebhookURL
+	if url == "" {
+		return nil
+	}
+
+	body, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,242 @@
+package opds
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/XBozorg/bookstore/entity/book"
+)
+
+const (
+	NavigationType  = "application/atom+xml;profile=opds-catalog;kind=navigation"
+	AcquisitionType = "application/atom+xml;profile=opds-catalog;kind=acquisition"
+	SearchType      = "application/opensearchdescription+xml"
+
+	RelStart       = "start"
+	RelSelf        = "self"
+	RelUp          = "up"
+	RelSearch      = "search"
+	RelSubsection  = "subsection"
+	RelAlternate   = "alternate"
+	RelShelf       = "http://opds-spec.org/shelf"
+	RelAcquisition = "http://opds-spec.org/acquisition"
+	RelBuy         = "http://opds-spec.org/acquisition/buy"
+	RelImage       = "http://opds-spec.org/image"
+	RelThumbnail   = "http://opds-spec.org/image/thumbnail"
+
+	atomNS = "http://www.w3.org/2005/Atom"
+	dcNS   = "http://purl.org/dc/terms/"
+	opdsNS = "http://opds-spec.org/2010/catalog"
+
+	currency = "IRR"
+	title    = "Bookstore"
+)
+
+// MIME types of the downloadable formats, keyed by the book.Formats values
+var formatTypes = map[string]string{
+	book.FormatPDF:  "application/pdf",
+	book.FormatEPUB: "application/epub+zip",
+	book.FormatDJVU: "image/vnd.djvu",
+	book.FormatAZW:  "application/vnd.amazon.ebook",
+	book.FormatTXT:  "text/plain",
+	book.FormatDOCX: "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+}
+
+type Feed struct {
+	XMLName   xml.Name `xml:"feed"`
+	Xmlns     string   `xml:"xmlns,attr"`
+	XmlnsDC   string   `xml:"xmlns:dc,attr"`
+	XmlnsOPDS string   `xml:"xmlns:opds,attr"`
+	ID        string   `xml:"id"`
+	Title     string   `xml:"title"`
+	Updated   string   `xml:"updated"`
+	Author    Author   `xml:"author"`
+	Links     []Link   `xml:"link"`
+	Entries   []Entry  `xml:"entry"`
+}
+
+type Entry struct {
+	ID         string     `xml:"id"`
+	Title      string     `xml:"title"`
+	Updated    string     `xml:"updated"`
+	Authors    []Author   `xml:"author,omitempty"`
+	Identifier string     `xml:"dc:identifier,omitempty"`
+	Language   string     `xml:"dc:language,omitempty"`
+	Publisher  string     `xml:"dc:publisher,omitempty"`
+	Issued     string     `xml:"dc:issued,omitempty"`
+	Categories []Category `xml:"category,omitempty"`
+	Summary    *Content   `xml:"summary,omitempty"`
+	Content    *Content   `xml:"content,omitempty"`
+	Links      []Link     `xml:"link"`
+}
+
+type Author struct {
+	Name string `xml:"name"`
+	URI  string `xml:"uri,omitempty"`
+}
+
+type Category struct {
+	Term  string `xml:"term,attr"`
+	Label string `xml:"label,attr"`
+}
+
+type Content struct {
+	Type string `xml:"type,attr"`
+	Text string `xml:",chardata"`
+}
+
+type Link struct {
+	Rel   string `xml:"rel,attr"`
+	Href  string `xml:"href,attr"`
+	Type  string `xml:"type,attr"`
+	Title string `xml:"title,attr,omitempty"`
+	Price *Price `xml:"opds:price,omitempty"`
+}
+
+type Price struct {
+	CurrencyCode string `xml:"currencycode,attr"`
+	Value        uint   `xml:",chardata"`
+}
+
+// Catalog builds the links of a feed on top of the host it is served from
+type Catalog struct {
+	Base string // scheme://host
+}
+
+func New(base string) Catalog {
+	return Catalog{Base: base}
+}
+
+func (c Catalog) URL(path string) string {
+	return c.Base + path
+}
+
+func (c Catalog) Feed(path, feedTitle, kind string) Feed {
+	return Feed{
+		Xmlns:     atomNS,
+		XmlnsDC:   dcNS,
+		XmlnsOPDS: opdsNS,
+		ID:        c.URL(path),
+		Title:     feedTitle,
+		Updated:   now(),
+		Author:    Author{Name: title, URI: c.Base},
+		Links: []Link{
+			{Rel: RelSelf, Href: c.URL(path), Type: kind},
+			{Rel: RelStart, Href: c.URL("/v1/opds"), Type: NavigationType},
+			{Rel: RelSearch, Href: c.URL("/v1/opds/search.xml"), Type: SearchType},
+		},
+		Entries: []Entry{},
+	}
+}
+
+// Navigation adds an entry pointing to another feed
+func (c Catalog) Navigation(f *Feed, path, entryTitle, content, kind string) {
+	f.Entries = append(f.Entries, Entry{
+		ID:      c.URL(path),
+		Title:   entryTitle,
+		Updated: f.Updated,
+		Content: &Content{Type: "text", Text: content},
+		Links:   []Link{{Rel: RelSubsection, Href: c.URL(path), Type: kind}},
+	})
+}
+
+// Book is the catalog entry of a book, the buy links carry the prices of its quote
+// as the catalog shows them, a book without a quote has none
+func (c Catalog) Book(b book.Book) Entry {
+
+	e := c.entry(b)
+	if b.Quote == nil {
+		return e
+	}
+	detail := c.URL(fmt.Sprintf("/v1/book/%d", b.ID))
+
+	if b.Digital.Price > 0 {
+		e.Links = append(e.Links, Link{
+			Rel:   RelBuy,
+			Href:  detail,
+			Type:  "text/html",
+			Title: "Digital",
+			Price: &Price{CurrencyCode: currency, Value: b.Quote.Digital.Shown},
+		})
+	}
+	if b.Physical.Price > 0 && b.Physical.Stock > 0 {
+		e.Links = append(e.Links, Link{
+			Rel:   RelBuy,
+			Href:  detail,
+			Type:  "text/html",
+			Title: "Physical",
+			Price: &Price{CurrencyCode: currency, Value: b.Quote.Physical.Shown},
+		})
+	}
+
+	return e
+}
+
+// Acquisition is the shelf entry of a purchased book, links maps formats to signed download urls
+func (c Catalog) Acquisition(b book.Book, links map[string]string) Entry {
+
+	e := c.entry(b)
+	for _, format := range book.Formats {
+		if href, ok := links[format]; ok {
+			e.Links = append(e.Links, Link{
+				Rel:   RelAcquisition,
+				Href:  href,
+				Type:  formatTypes[format],
+				Title: format,
+			})
+		}
+	}
+
+	return e
+}
+
+func (c Catalog) entry(b book.Book) Entry {
+
+	e := Entry{
+		ID:         "urn:bookstore:book:" + strconv.FormatUint(uint64(b.ID), 10),
+		Title:      b.Title,
+		Updated:    now(),
+		Language:   b.Language.Code,
+		Publisher:  b.Publisher.Name,
+		Issued:     b.Year,
+		Authors:    []Author{},
+		Categories: []Category{},
+		Links: []Link{{
+			Rel:  RelAlternate,
+			Href: c.URL(fmt.Sprintf("/v1/book/%d", b.ID)),
+			Type: "application/json",
+		}},
+	}
+	if b.ISBN != "" {
+		e.Identifier = "urn:isbn:" + b.ISBN
+	}
+
+	for _, a := range b.Authors {
+		e.Authors = append(e.Authors, Author{Name: a.Name})
+	}
+	for _, t := range b.Topics {
+		e.Categories = append(e.Categories, Category{Term: strconv.FormatUint(uint64(t.ID), 10), Label: t.Name})
+	}
+	if b.Description != "" {
+		e.Summary = &Content{Type: "text", Text: b.Description}
+	}
+
+	if b.CoverFront != "" {
+		cover := b.CoverFront
+		if cover[0] == '/' {
+			cover = c.URL(cover)
+		}
+		e.Links = append(e.Links,
+			Link{Rel: RelImage, Href: cover, Type: "image/jpeg"},
+			Link{Rel: RelThumbnail, Href: cover, Type: "image/jpeg"},
+		)
+	}
+
+	return e
+}
+
+func now() string {
+	return time.Now().UTC().Format(time.RFC3339)
+}
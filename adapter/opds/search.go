@@ -0,0 +1,33 @@
+package opds
+
+import "encoding/xml"
+
+type OpenSearch struct {
+	XMLName        xml.Name    `xml:"OpenSearchDescription"`
+	Xmlns          string      `xml:"xmlns,attr"`
+	ShortName      string      `xml:"ShortName"`
+	Description    string      `xml:"Description"`
+	InputEncoding  string      `xml:"InputEncoding"`
+	OutputEncoding string      `xml:"OutputEncoding"`
+	URL            []SearchURL `xml:"Url"`
+}
+
+type SearchURL struct {
+	Type     string `xml:"type,attr"`
+	Template string `xml:"template,attr"`
+}
+
+// Search describes the search endpoint, clients replace {searchTerms} with the query
+func (c Catalog) Search() OpenSearch {
+	return OpenSearch{
+		Xmlns:          "http://a9.com/-/spec/opensearch/1.1/",
+		ShortName:      title,
+		Description:    "Search books by title, author or isbn",
+		InputEncoding:  "UTF-8",
+		OutputEncoding: "UTF-8",
+		URL: []SearchURL{{
+			Type:     AcquisitionType,
+			Template: c.URL("/v1/opds/search?q={searchTerms}"),
+		}},
+	}
+}
@@ -46,12 +46,16 @@ func ZarinpalPayment(storage repository.Storage, validator order.ValidateGetOrde
 			return echo.NewHTTPError(http.StatusInternalServerError, err)
 		}
 
+		if paymentInfo.Due == 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "the wallet covers the order, pay it with the wallet")
+		}
+
 		z := zarinpal.New(zarinpalConfig.MerchantID, zarinpalConfig.Sandbox)
 
 		paymentResponseData, err := z.PaymentRequest(
 			zarinpal.PaymentRequest{
 				MerchantID:  z.MerchantID,
-				Amount:      int(paymentInfo.Total),
+				Amount:      int(paymentInfo.Due),
 				Description: "bookstore",
 				CallbackURL: fmt.Sprintf("http://%s/v1/payment/zarinpal/check", c.Request().Host),
 				Metadata:    map[string]string{"mobile": paymentInfo.Phone, "email": paymentInfo.Email},
@@ -107,9 +111,10 @@ func ZarinpalPaymentVerification(storage repository.Storage) echo.HandlerFunc {
 			return echo.NewHTTPError(http.StatusConflict, "order payment already verified")
 		}
 
-		total, err := order.New(storage).GetOrderTotal(
+		// the gateway is paid what the wallet leaves of the total
+		paymentInfo, err := order.New(storage).GetOrderPaymentInfo(
 			c.Request().Context(),
-			dto.GetOrderTotalRequest{
+			dto.GetOrderPaymentInfoRequest{
 				OrderID: getOrderResp.ZarinpalOrder.OrderID,
 			},
 		)
@@ -122,7 +127,7 @@ func ZarinpalPaymentVerification(storage repository.Storage) echo.HandlerFunc {
 		verificationResp, err := z.PaymentVerification(
 			zarinpal.PaymentVerificationRequest{
 				MerchantID: z.MerchantID,
-				Amount:     int(total.Total),
+				Amount:     int(paymentInfo.Due),
 				Authority:  authority,
 			},
 			zarinpal.ValidatePaymentVerification(),
@@ -2,15 +2,18 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
 
 	"github.com/XBozorg/bookstore/entity/book"
-	"github.com/XBozorg/bookstore/entity/order"
 )
 
 func (storage Storage) DoesAuthorExist(ctx context.Context, authorID uint) (bool, error) {
 
 	stmt, err := storage.MySQL.PrepareContext(ctx,
-		"SELECT EXISTS(SELECT 1 FROM author WHERE id = ?)",
+		"SELECT EXISTS(SELECT 1 FROM author WHERE id = ? AND deleted_at IS NULL)",
 	)
 	if err != nil {
 		return false, err
@@ -30,7 +33,7 @@ func (storage Storage) DoesAuthorExist(ctx context.Context, authorID uint) (bool
 func (storage Storage) DoesPublisherExist(ctx context.Context, publisherID uint) (bool, error) {
 
 	stmt, err := storage.MySQL.PrepareContext(ctx,
-		"SELECT EXISTS(SELECT 1 FROM publisher WHERE id = ?)",
+		"SELECT EXISTS(SELECT 1 FROM publisher WHERE id = ? AND deleted_at IS NULL)",
 	)
 	if err != nil {
 		return false, err
@@ -70,7 +73,7 @@ func (storage Storage) DoesTopicExist(ctx context.Context, topicID uint) (bool,
 func (storage Storage) DoesLanguageExist(ctx context.Context, langID uint) (bool, error) {
 
 	stmt, err := storage.MySQL.PrepareContext(ctx,
-		"SELECT EXISTS(SELECT 1 FROM language WHERE id = ?)",
+		"SELECT EXISTS(SELECT 1 FROM language WHERE id = ? AND deleted_at IS NULL)",
 	)
 	if err != nil {
 		return false, err
@@ -90,7 +93,7 @@ func (storage Storage) DoesLanguageExist(ctx context.Context, langID uint) (bool
 func (storage Storage) DoesBookExist(ctx context.Context, bookID uint) (bool, error) {
 
 	stmt, err := storage.MySQL.PrepareContext(ctx,
-		"SELECT EXISTS(SELECT 1 FROM book WHERE id = ?)",
+		"SELECT EXISTS(SELECT 1 FROM book WHERE id = ? AND deleted_at IS NULL)",
 	)
 	if err != nil {
 		return false, err
@@ -136,7 +139,8 @@ func (storage Storage) AddAuthor(ctx context.Context, authorName string) (book.A
 func (storage Storage) GetAuthor(ctx context.Context, authorID uint) (book.Author, error) {
 
 	stmt, err := storage.MySQL.PrepareContext(ctx,
-		"SELECT name From author WHERE id = ?",
+		`SELECT name , COALESCE(biography, '') , COALESCE(photo, '') , COALESCE(website, '') , COALESCE(country, '') 
+		FROM author WHERE id = ?`,
 	)
 	if err != nil {
 		return book.Author{}, err
@@ -146,7 +150,13 @@ func (storage Storage) GetAuthor(ctx context.Context, authorID uint) (book.Autho
 	result := stmt.QueryRowContext(ctx, authorID)
 
 	author := book.Author{}
-	if err = result.Scan(&author.Name); err != nil {
+	if err = result.Scan(
+		&author.Name,
+		&author.Biography,
+		&author.Photo,
+		&author.Website,
+		&author.Country,
+	); err != nil {
 		return book.Author{}, err
 	}
 
@@ -154,10 +164,30 @@ func (storage Storage) GetAuthor(ctx context.Context, authorID uint) (book.Autho
 	return author, nil
 }
 
+func (storage Storage) GetAuthorByName(ctx context.Context, authorName string) (book.Author, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		"SELECT id , name FROM author WHERE name = ?",
+	)
+	if err != nil {
+		return book.Author{}, err
+	}
+	defer stmt.Close()
+
+	result := stmt.QueryRowContext(ctx, authorName)
+
+	author := book.Author{}
+	if err = result.Scan(&author.ID, &author.Name); err != nil {
+		return book.Author{}, err
+	}
+
+	return author, nil
+}
+
 func (storage Storage) GetAuthors(ctx context.Context) ([]book.Author, error) {
 
 	stmt, err := storage.MySQL.PrepareContext(ctx,
-		"SELECT id , name FROM author",
+		"SELECT id , name FROM author WHERE deleted_at IS NULL",
 	)
 	if err != nil {
 		return []book.Author{}, err
@@ -186,17 +216,88 @@ func (storage Storage) GetAuthors(ctx context.Context) ([]book.Author, error) {
 	return authors, nil
 }
 
+func (storage Storage) EditAuthor(ctx context.Context, a book.Author) (book.Author, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		`UPDATE author SET name = ? , biography = NULLIF(? , '') , photo = NULLIF(? , '') , 
+		website = NULLIF(? , '') , country = NULLIF(? , '') WHERE id = ?`,
+	)
+	if err != nil {
+		return book.Author{}, err
+	}
+	defer stmt.Close()
+
+	if _, err = stmt.ExecContext(ctx,
+		a.Name,
+		a.Biography,
+		a.Photo,
+		a.Website,
+		a.Country,
+		a.ID,
+	); err != nil {
+		return book.Author{}, err
+	}
+
+	return a, nil
+}
+
+// GetAuthorCredits returns every book the author is credited on, once per role, newest first
+func (storage Storage) GetAuthorCredits(ctx context.Context, authorID uint) ([]book.Credit, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		`SELECT b.id , b.title , b.year , b.digital_price , b.digital_discount , b.physical_price , 
+		b.physical_discount , b.physical_stock , b.cover_front , b.availability , ba.role 
+		FROM book_author ba JOIN book b ON b.id = ba.book_id 
+		WHERE ba.author_id = ? AND b.deleted_at IS NULL ORDER BY b.year DESC , b.id`,
+	)
+	if err != nil {
+		return []book.Credit{}, err
+	}
+	defer stmt.Close()
+
+	result, err := stmt.QueryContext(ctx, authorID)
+	if err != nil {
+		return []book.Credit{}, err
+	}
+	defer result.Close()
+
+	credits := []book.Credit{}
+	for result.Next() {
+		var c book.Credit
+
+		if err = result.Scan(
+			&c.Book.ID,
+			&c.Book.Title,
+			&c.Book.Year,
+			&c.Book.Digital.Price,
+			&c.Book.Digital.Discount,
+			&c.Book.Physical.Price,
+			&c.Book.Physical.Discount,
+			&c.Book.Physical.Stock,
+			&c.Book.CoverFront,
+			&c.Book.Availability,
+			&c.Role,
+		); err != nil {
+			return []book.Credit{}, err
+		}
+		credits = append(credits, c)
+	}
+
+	return credits, nil
+}
+
+// DeleteAuthor moves the author to the trash
 func (storage Storage) DeleteAuthor(ctx context.Context, authorID uint) error {
 
 	stmt, err := storage.MySQL.PrepareContext(ctx,
-		"DELETE FROM author WHERE id = ?",
+		"UPDATE author SET deleted_at = ? WHERE id = ?",
 	)
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
-	if _, err = stmt.ExecContext(ctx, authorID); err != nil {
+	if _, err = stmt.ExecContext(ctx, time.Now().Format("2006-01-02 15:04:05"), authorID); err != nil {
 		return err
 	}
 
@@ -232,7 +333,8 @@ func (storage Storage) AddPublisher(ctx context.Context, publisherName string) (
 func (storage Storage) GetPublisher(ctx context.Context, publisherID uint) (book.Publisher, error) {
 
 	stmt, err := storage.MySQL.PrepareContext(ctx,
-		"SELECT name FROM publisher WHERE id = ?",
+		`SELECT name , COALESCE(description, '') , COALESCE(logo, '') , COALESCE(website, '') , COALESCE(country, '') 
+		FROM publisher WHERE id = ?`,
 	)
 	if err != nil {
 		return book.Publisher{}, err
@@ -243,7 +345,13 @@ func (storage Storage) GetPublisher(ctx context.Context, publisherID uint) (book
 
 	publisher := book.Publisher{}
 
-	if err = result.Scan(&publisher.Name); err != nil {
+	if err = result.Scan(
+		&publisher.Name,
+		&publisher.Description,
+		&publisher.Logo,
+		&publisher.Website,
+		&publisher.Country,
+	); err != nil {
 		return book.Publisher{}, err
 	}
 
@@ -251,10 +359,30 @@ func (storage Storage) GetPublisher(ctx context.Context, publisherID uint) (book
 	return publisher, nil
 }
 
+func (storage Storage) GetPublisherByName(ctx context.Context, publisherName string) (book.Publisher, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		"SELECT id , name FROM publisher WHERE name = ?",
+	)
+	if err != nil {
+		return book.Publisher{}, err
+	}
+	defer stmt.Close()
+
+	result := stmt.QueryRowContext(ctx, publisherName)
+
+	publisher := book.Publisher{}
+	if err = result.Scan(&publisher.ID, &publisher.Name); err != nil {
+		return book.Publisher{}, err
+	}
+
+	return publisher, nil
+}
+
 func (storage Storage) GetPublishers(ctx context.Context) ([]book.Publisher, error) {
 
 	stmt, err := storage.MySQL.PrepareContext(ctx,
-		"SELECT id , name FROM publisher",
+		"SELECT id , name FROM publisher WHERE deleted_at IS NULL",
 	)
 	if err != nil {
 		return []book.Publisher{}, err
@@ -283,34 +411,62 @@ func (storage Storage) GetPublishers(ctx context.Context) ([]book.Publisher, err
 	return publishers, nil
 }
 
+func (storage Storage) EditPublisher(ctx context.Context, p book.Publisher) (book.Publisher, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		`UPDATE publisher SET name = ? , description = NULLIF(? , '') , logo = NULLIF(? , '') , 
+		website = NULLIF(? , '') , country = NULLIF(? , '') WHERE id = ?`,
+	)
+	if err != nil {
+		return book.Publisher{}, err
+	}
+	defer stmt.Close()
+
+	if _, err = stmt.ExecContext(ctx,
+		p.Name,
+		p.Description,
+		p.Logo,
+		p.Website,
+		p.Country,
+		p.ID,
+	); err != nil {
+		return book.Publisher{}, err
+	}
+
+	return p, nil
+}
+
+// DeletePublisher moves the publisher to the trash
 func (storage Storage) DeletePublisher(ctx context.Context, publisherId uint) error {
 
 	stmt, err := storage.MySQL.PrepareContext(ctx,
-		"DELETE FROM publisher WHERE id = ?",
+		"UPDATE publisher SET deleted_at = ? WHERE id = ?",
 	)
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
-	if _, err = stmt.ExecContext(ctx, publisherId); err != nil {
+	if _, err = stmt.ExecContext(ctx, time.Now().Format("2006-01-02 15:04:05"), publisherId); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (storage Storage) AddTopic(ctx context.Context, topicName string) (book.Topic, error) {
+// AddTopic appends the topic after its siblings, a zero parentID makes it a root topic
+func (storage Storage) AddTopic(ctx context.Context, topicName string, parentID uint) (book.Topic, error) {
 
 	stmt, err := storage.MySQL.PrepareContext(ctx,
-		"INSERT INTO topic (name) VALUES (?)",
+		`INSERT INTO topic (name , parent_id , position) 
+		SELECT ? , NULLIF(? , 0) , COALESCE(MAX(position) + 1 , 0) FROM topic WHERE parent_id <=> NULLIF(? , 0)`,
 	)
 	if err != nil {
 		return book.Topic{}, err
 	}
 	defer stmt.Close()
 
-	result, err := stmt.ExecContext(ctx, topicName)
+	result, err := stmt.ExecContext(ctx, topicName, parentID, parentID)
 	if err != nil {
 		return book.Topic{}, err
 	}
@@ -320,16 +476,13 @@ func (storage Storage) AddTopic(ctx context.Context, topicName string) (book.Top
 		return book.Topic{}, err
 	}
 
-	return book.Topic{
-		ID:   uint(topicID),
-		Name: topicName,
-	}, nil
+	return storage.GetTopic(ctx, uint(topicID))
 }
 
 func (storage Storage) GetTopic(ctx context.Context, topicID uint) (book.Topic, error) {
 
 	stmt, err := storage.MySQL.PrepareContext(ctx,
-		"SELECT name FROM topic WHERE id = ?",
+		"SELECT name , COALESCE(parent_id, 0) , position FROM topic WHERE id = ?",
 	)
 	if err != nil {
 		return book.Topic{}, err
@@ -339,7 +492,7 @@ func (storage Storage) GetTopic(ctx context.Context, topicID uint) (book.Topic,
 	result := stmt.QueryRowContext(ctx, topicID)
 
 	topic := book.Topic{}
-	if err = result.Scan(&topic.Name); err != nil {
+	if err = result.Scan(&topic.Name, &topic.ParentID, &topic.Position); err != nil {
 		return book.Topic{}, err
 	}
 
@@ -347,10 +500,31 @@ func (storage Storage) GetTopic(ctx context.Context, topicID uint) (book.Topic,
 	return topic, nil
 }
 
+func (storage Storage) GetTopicByName(ctx context.Context, topicName string) (book.Topic, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		"SELECT id , name , COALESCE(parent_id, 0) , position FROM topic WHERE name = ?",
+	)
+	if err != nil {
+		return book.Topic{}, err
+	}
+	defer stmt.Close()
+
+	result := stmt.QueryRowContext(ctx, topicName)
+
+	topic := book.Topic{}
+	if err = result.Scan(&topic.ID, &topic.Name, &topic.ParentID, &topic.Position); err != nil {
+		return book.Topic{}, err
+	}
+
+	return topic, nil
+}
+
+// GetTopics returns every topic flat, siblings in their display order
 func (storage Storage) GetTopics(ctx context.Context) ([]book.Topic, error) {
 
 	stmt, err := storage.MySQL.PrepareContext(ctx,
-		"SELECT id , name FROM topic",
+		"SELECT id , name , COALESCE(parent_id, 0) , position FROM topic ORDER BY position , id",
 	)
 	if err != nil {
 		return []book.Topic{}, err
@@ -370,6 +544,8 @@ func (storage Storage) GetTopics(ctx context.Context) ([]book.Topic, error) {
 		if err = result.Scan(
 			&t.ID,
 			&t.Name,
+			&t.ParentID,
+			&t.Position,
 		); err != nil {
 			return []book.Topic{}, err
 		}
@@ -379,21 +555,90 @@ func (storage Storage) GetTopics(ctx context.Context) ([]book.Topic, error) {
 	return topics, nil
 }
 
+// MoveTopic places the topic under parentID at the given position, closing the gap it
+// leaves among its old siblings, positions past the last sibling are clamped
+func (storage Storage) MoveTopic(ctx context.Context, topicID, parentID, position uint) (book.Topic, error) {
+
+	tx, err := storage.MySQL.BeginTx(ctx, nil)
+	if err != nil {
+		return book.Topic{}, err
+	}
+
+	var oldParentID, oldPosition uint
+	if err = tx.QueryRowContext(ctx,
+		"SELECT COALESCE(parent_id, 0) , position FROM topic WHERE id = ? FOR UPDATE",
+		topicID,
+	).Scan(&oldParentID, &oldPosition); err != nil {
+		tx.Rollback()
+		return book.Topic{}, err
+	}
+
+	if _, err = tx.ExecContext(ctx,
+		"UPDATE topic SET position = position - 1 WHERE parent_id <=> NULLIF(? , 0) AND position > ? AND id != ?",
+		oldParentID, oldPosition, topicID,
+	); err != nil {
+		tx.Rollback()
+		return book.Topic{}, err
+	}
+
+	var siblings uint
+	if err = tx.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM topic WHERE parent_id <=> NULLIF(? , 0) AND id != ?",
+		parentID, topicID,
+	).Scan(&siblings); err != nil {
+		tx.Rollback()
+		return book.Topic{}, err
+	}
+	if position > siblings {
+		position = siblings
+	}
+
+	if _, err = tx.ExecContext(ctx,
+		"UPDATE topic SET position = position + 1 WHERE parent_id <=> NULLIF(? , 0) AND position >= ? AND id != ?",
+		parentID, position, topicID,
+	); err != nil {
+		tx.Rollback()
+		return book.Topic{}, err
+	}
+
+	if _, err = tx.ExecContext(ctx,
+		"UPDATE topic SET parent_id = NULLIF(? , 0) , position = ? WHERE id = ?",
+		parentID, position, topicID,
+	); err != nil {
+		tx.Rollback()
+		return book.Topic{}, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return book.Topic{}, err
+	}
+
+	return storage.GetTopic(ctx, topicID)
+}
+
+// DeleteTopic removes the topic and hands its children over to its parent
 func (storage Storage) DeleteTopic(ctx context.Context, topicID uint) error {
 
-	stmt, err := storage.MySQL.PrepareContext(ctx,
-		"DELETE FROM topic WHERE id = ?",
-	)
+	tx, err := storage.MySQL.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
 
-	if _, err = stmt.ExecContext(ctx, topicID); err != nil {
+	if _, err = tx.ExecContext(ctx,
+		`UPDATE topic c JOIN topic t ON t.id = c.parent_id 
+		SET c.parent_id = t.parent_id WHERE t.id = ?`,
+		topicID,
+	); err != nil {
+		tx.Rollback()
 		return err
 	}
 
-	return nil
+	if _, err = tx.ExecContext(ctx, "DELETE FROM topic WHERE id = ?", topicID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
 }
 
 func (storage Storage) AddLanguage(ctx context.Context, langCode string) (book.Language, error) {
@@ -443,10 +688,30 @@ func (storage Storage) GetLanguage(ctx context.Context, langID uint) (book.Langu
 	return lang, nil
 }
 
+func (storage Storage) GetLanguageByCode(ctx context.Context, langCode string) (book.Language, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		"SELECT id , code FROM language WHERE code = ?",
+	)
+	if err != nil {
+		return book.Language{}, err
+	}
+	defer stmt.Close()
+
+	result := stmt.QueryRowContext(ctx, langCode)
+
+	lang := book.Language{}
+	if err = result.Scan(&lang.ID, &lang.Code); err != nil {
+		return book.Language{}, err
+	}
+
+	return lang, nil
+}
+
 func (storage Storage) GetLanguages(ctx context.Context) ([]book.Language, error) {
 
 	stmt, err := storage.MySQL.PrepareContext(ctx,
-		"SELECT id , code FROM language",
+		"SELECT id , code FROM language WHERE deleted_at IS NULL",
 	)
 	if err != nil {
 		return []book.Language{}, err
@@ -476,17 +741,18 @@ func (storage Storage) GetLanguages(ctx context.Context) ([]book.Language, error
 	return langs, nil
 }
 
+// DeleteLanguage moves the language to the trash
 func (storage Storage) DeleteLanguage(ctx context.Context, langID uint) error {
 
 	stmt, err := storage.MySQL.PrepareContext(ctx,
-		"DELETE FROM language WHERE id = ?",
+		"UPDATE language SET deleted_at = ? WHERE id = ?",
 	)
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
-	if _, err = stmt.ExecContext(ctx, langID); err != nil {
+	if _, err = stmt.ExecContext(ctx, time.Now().Format("2006-01-02 15:04:05"), langID); err != nil {
 		return err
 	}
 
@@ -499,16 +765,125 @@ func (storage Storage) AddBook(ctx context.Context, b book.Book) (book.Book, err
 	if err != nil {
 		return book.Book{}, err
 	}
+	defer tx.Rollback()
+
+	if b, err = storage.addBook(ctx, tx, b); err != nil {
+		return book.Book{}, err
+	}
+
+	return b, tx.Commit()
+}
+
+// AddBookWithNames adds the book like AddBook, creating in the same transaction
+// its authors, topics, publisher and language given by name only
+func (storage Storage) AddBookWithNames(ctx context.Context, b book.Book) (book.Book, error) {
+
+	tx, err := storage.MySQL.BeginTx(ctx, nil)
+	if err != nil {
+		return book.Book{}, err
+	}
+	defer tx.Rollback()
+
+	authors := make([]book.Author, len(b.Authors))
+	for i, author := range b.Authors {
+		if author.ID == 0 {
+			if author.ID, err = nameID(ctx, tx, "author", "name", author.Name); err != nil {
+				return book.Book{}, err
+			}
+		}
+		authors[i] = author
+	}
+	b.Authors = authors
+
+	topics := make([]book.Topic, len(b.Topics))
+	for i, topic := range b.Topics {
+		if topic.ID == 0 {
+			if topic.ID, err = topicID(ctx, tx, topic.Name); err != nil {
+				return book.Book{}, err
+			}
+		}
+		topics[i] = topic
+	}
+	b.Topics = topics
+
+	if b.Publisher.ID == 0 {
+		if b.Publisher.ID, err = nameID(ctx, tx, "publisher", "name", b.Publisher.Name); err != nil {
+			return book.Book{}, err
+		}
+	}
+
+	if b.Language.ID == 0 {
+		if b.Language.ID, err = nameID(ctx, tx, "language", "code", b.Language.Code); err != nil {
+			return book.Book{}, err
+		}
+	}
+
+	if b, err = storage.addBook(ctx, tx, b); err != nil {
+		return book.Book{}, err
+	}
+
+	return b, tx.Commit()
+}
+
+// nameID returns the id of the row of table whose column is name, the row is added when missing
+func nameID(ctx context.Context, tx *sql.Tx, table, column, name string) (uint, error) {
+
+	var id uint
+	err := tx.QueryRowContext(ctx, "SELECT id FROM "+table+" WHERE "+column+" = ?", name).Scan(&id)
+	if err == nil || !errors.Is(err, sql.ErrNoRows) {
+		return id, err
+	}
+
+	result, err := tx.ExecContext(ctx, "INSERT INTO "+table+" ("+column+") VALUES (?)", name)
+	if err != nil {
+		return 0, err
+	}
+
+	inserted, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return uint(inserted), nil
+}
+
+// topicID is nameID for the topics, a missing one is added last among the root topics
+func topicID(ctx context.Context, tx *sql.Tx, name string) (uint, error) {
+
+	var id uint
+	err := tx.QueryRowContext(ctx, "SELECT id FROM topic WHERE name = ?", name).Scan(&id)
+	if err == nil || !errors.Is(err, sql.ErrNoRows) {
+		return id, err
+	}
+
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO topic (name , parent_id , position) 
+		SELECT ? , NULL , COALESCE(MAX(position) + 1 , 0) FROM topic WHERE parent_id IS NULL`,
+		name,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	inserted, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return uint(inserted), nil
+}
+
+func (storage Storage) addBook(ctx context.Context, tx *sql.Tx, b book.Book) (book.Book, error) {
+
 	stmt, err := tx.PrepareContext(ctx,
 		`INSERT INTO book (
 			title , isbn , pages , description , year , date , digital_price , 
 			physical_price , physical_stock , pdf , epub , djvu , azw , txt ,
-			docx , lang_id , cover_front , cover_back , publisher , availability
+			docx , lang_id , cover_front , cover_back , publisher , release_date
 		) 
-		VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)`,
+		VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,NULLIF(?, ''))`,
 	)
 	if err != nil {
-		tx.Rollback()
 		return book.Book{}, err
 	}
 	defer stmt.Close()
@@ -533,10 +908,9 @@ func (storage Storage) AddBook(ctx context.Context, b book.Book) (book.Book, err
 		b.CoverFront,
 		b.CoverBack,
 		b.Publisher.ID,
-		b.Availability,
+		b.ReleaseDate,
 	)
 	if err != nil {
-		tx.Rollback()
 		return book.Book{}, err
 	}
 	bookID, err := result.LastInsertId()
@@ -549,14 +923,12 @@ func (storage Storage) AddBook(ctx context.Context, b book.Book) (book.Book, err
 		"INSERT IGNORE INTO book_author (book_id , author_id) VALUES (? , ?)",
 	)
 	if err != nil {
-		tx.Rollback()
 		return book.Book{}, err
 	}
 	defer stmt.Close()
 
 	for _, author := range b.Authors {
 		if _, err = stmt.ExecContext(ctx, uint(bookID), author.ID); err != nil {
-			tx.Rollback()
 			return book.Book{}, err
 		}
 	}
@@ -566,19 +938,29 @@ func (storage Storage) AddBook(ctx context.Context, b book.Book) (book.Book, err
 		"INSERT IGNORE INTO book_topic (book_id , topic_id) VALUES (? , ?)",
 	)
 	if err != nil {
-		tx.Rollback()
 		return book.Book{}, err
 	}
 	defer stmt.Close()
 
 	for _, topic := range b.Topics {
 		if _, err = stmt.ExecContext(ctx, uint(bookID), topic.ID); err != nil {
-			tx.Rollback()
 			return book.Book{}, err
 		}
 	}
+	b.ID = uint(bookID)
 
-	return b, tx.Commit()
+	if b.Physical.Stock > 0 {
+		if _, err = storage.recordMovement(ctx, tx, book.Movement{
+			BookID:   b.ID,
+			Type:     book.MovementReceipt,
+			Quantity: int(b.Physical.Stock),
+			Reason:   "initial stock",
+		}); err != nil {
+			return book.Book{}, err
+		}
+	}
+
+	return b, nil
 }
 
 func (storage Storage) SetBookDiscount(ctx context.Context, bookID, digital, physical uint) error {
@@ -608,7 +990,8 @@ func (storage Storage) GetBook(ctx context.Context, bookID uint) (book.Book, err
 
 		`SELECT title , isbn , pages , description , year , date , 
 		digital_price , digital_discount , physical_price , physical_discount , physical_stock , 
-		lang_id , cover_front , cover_back , availability 
+		lang_id , cover_front , cover_back , availability , COALESCE(availability_override, '') , 
+		COALESCE(work_id, 0) , COALESCE(edition, '') , COALESCE(DATE_FORMAT(release_date, '%Y-%m-%d'), '') 
 		FROM book 
 		WHERE id = ?`,
 
@@ -634,6 +1017,10 @@ func (storage Storage) GetBook(ctx context.Context, bookID uint) (book.Book, err
 		&b.CoverFront,
 		&b.CoverBack,
 		&b.Availability,
+		&b.Override,
+		&b.WorkID,
+		&b.Edition,
+		&b.ReleaseDate,
 	); err != nil {
 		return book.Book{}, err
 	}
@@ -644,6 +1031,12 @@ func (storage Storage) GetBook(ctx context.Context, bookID uint) (book.Book, err
 	}
 	b.Authors = authors
 
+	contributors, err := storage.GetBookContributors(ctx, bookID)
+	if err != nil {
+		return book.Book{}, err
+	}
+	b.Contributors = contributors
+
 	topics, err := storage.GetBookTopics(ctx, bookID)
 	if err != nil {
 		return book.Book{}, err
@@ -656,14 +1049,14 @@ func (storage Storage) GetBook(ctx context.Context, bookID uint) (book.Book, err
 func (storage Storage) GetBookAuthors(ctx context.Context, bookID uint) ([]book.Author, error) {
 
 	stmt, err := storage.MySQL.PrepareContext(ctx,
-		"SELECT * FROM author WHERE id IN ( SELECT author_id FROM book_author WHERE book_id = ? )",
+		"SELECT id , name FROM author WHERE id IN ( SELECT author_id FROM book_author WHERE book_id = ? AND role = ? )",
 	)
 	if err != nil {
 		return []book.Author{}, err
 	}
 	defer stmt.Close()
 
-	result, err := stmt.QueryContext(ctx, bookID)
+	result, err := stmt.QueryContext(ctx, bookID, book.RoleAuthor)
 	if err != nil {
 		return []book.Author{}, err
 	}
@@ -690,7 +1083,7 @@ func (storage Storage) GetBookAuthors(ctx context.Context, bookID uint) ([]book.
 func (storage Storage) GetBookTopics(ctx context.Context, bookID uint) ([]book.Topic, error) {
 
 	stmt, err := storage.MySQL.PrepareContext(ctx,
-		"SELECT * FROM topic WHERE id IN ( SELECT topic_id FROM book_topic WHERE book_id = ? )",
+		"SELECT id , name , COALESCE(parent_id, 0) , position FROM topic WHERE id IN ( SELECT topic_id FROM book_topic WHERE book_id = ? )",
 	)
 	if err != nil {
 		return []book.Topic{}, err
@@ -711,6 +1104,8 @@ func (storage Storage) GetBookTopics(ctx context.Context, bookID uint) ([]book.T
 		if err = result.Scan(
 			&topic.ID,
 			&topic.Name,
+			&topic.ParentID,
+			&topic.Position,
 		); err != nil {
 			return []book.Topic{}, err
 		}
@@ -721,13 +1116,29 @@ func (storage Storage) GetBookTopics(ctx context.Context, bookID uint) ([]book.T
 	return topics, nil
 }
 
+// EditBook updates the book, a changed stock is recorded as an adjustment
 func (storage Storage) EditBook(ctx context.Context, b book.Book) (book.Book, error) {
 
-	stmt, err := storage.MySQL.PrepareContext(ctx,
+	tx, err := storage.MySQL.BeginTx(ctx, nil)
+	if err != nil {
+		return book.Book{}, err
+	}
+	defer tx.Rollback()
+
+	var stock uint
+	if err = tx.QueryRowContext(ctx,
+		"SELECT physical_stock FROM book WHERE id = ? FOR UPDATE",
+		b.ID,
+	).Scan(&stock); err != nil {
+		return book.Book{}, err
+	}
+
+	stmt, err := tx.PrepareContext(ctx,
 		`UPDATE book SET
 		title=? , isbn=? , pages=? , description=? , year=? , digital_price=? , 
 		physical_price=? , physical_stock=? , pdf=? , epub=? , djvu=? , azw=? , 
-		txt=? , docx=? , lang_id=? , cover_front=? , cover_back=? , publisher=? , availability=?
+		txt=? , docx=? , lang_id=? , cover_front=? , cover_back=? , publisher=? ,
+		release_date=NULLIF(?, '')
 		WHERE id=? `,
 	)
 	if err != nil {
@@ -754,13 +1165,43 @@ func (storage Storage) EditBook(ctx context.Context, b book.Book) (book.Book, er
 		b.CoverFront,
 		b.CoverBack,
 		b.Publisher.ID,
-		b.Availability,
+		b.ReleaseDate,
 		b.ID,
 	); err != nil {
 		return book.Book{}, err
 	}
 
-	return b, nil
+	if b.Physical.Stock != stock {
+		if _, err = storage.recordMovement(ctx, tx, book.Movement{
+			BookID:   b.ID,
+			Type:     book.MovementAdjustment,
+			Quantity: int(b.Physical.Stock) - int(stock),
+			Reason:   "book edited",
+		}); err != nil {
+			return book.Book{}, err
+		}
+	}
+
+	return b, tx.Commit()
+}
+
+// SetAvailabilityOverride takes the book off sale with one of the book.Overrides, an empty
+// override lets the availability follow the stock and files again
+func (storage Storage) SetAvailabilityOverride(ctx context.Context, bookID uint, override string) error {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		"UPDATE book SET availability_override = NULLIF(? , '') WHERE id = ?",
+	)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	if _, err = stmt.ExecContext(ctx, override, bookID); err != nil {
+		return err
+	}
+
+	return nil
 }
 
 func (storage Storage) GetAllBooksFull(ctx context.Context) ([]book.Book, error) {
@@ -821,7 +1262,8 @@ func (storage Storage) GetAllBooks(ctx context.Context) ([]book.Book, error) {
 
 	stmt, err := storage.MySQL.PrepareContext(ctx,
 		`SELECT id , title , digital_price , digital_discount , physical_price , 
-		physical_discount , physical_stock , cover_front , availability FROM book`,
+		physical_discount , physical_stock , cover_front , availability FROM book 
+		WHERE deleted_at IS NULL`,
 	)
 	if err != nil {
 		return []book.Book{}, err
@@ -863,7 +1305,7 @@ func (storage Storage) GetAuthorBooks(ctx context.Context, authorID uint) ([]boo
 	stmt, err := storage.MySQL.PrepareContext(ctx,
 		`SELECT id , title , digital_price , digital_discount ,physical_price , 
 		physical_discount , physical_stock , cover_front , availability FROM book 
-		WHERE id IN ( SELECT book_id FROM book_author WHERE author_id = ? )`,
+		WHERE id IN ( SELECT book_id FROM book_author WHERE author_id = ? ) AND deleted_at IS NULL`,
 	)
 	if err != nil {
 		return []book.Book{}, err
@@ -899,13 +1341,24 @@ func (storage Storage) GetAuthorBooks(ctx context.Context, authorID uint) ([]boo
 	return books, nil
 }
 
-func (storage Storage) GetTopicBooks(ctx context.Context, topicID uint) ([]book.Book, error) {
+// GetTopicBooks returns the books of a topic, with descendants set the books of
+// every topic below it are included too
+func (storage Storage) GetTopicBooks(ctx context.Context, topicID uint, descendants bool) ([]book.Book, error) {
 
-	stmt, err := storage.MySQL.PrepareContext(ctx,
-		`SELECT id , title , digital_price , digital_discount , physical_price , 
+	query := `SELECT id , title , digital_price , digital_discount , physical_price , 
 		physical_discount , physical_stock , cover_front , availability FROM book 
-		WHERE id IN ( SELECT book_id FROM book_topic WHERE topic_id = ? )`,
-	)
+		WHERE id IN ( SELECT book_id FROM book_topic WHERE topic_id = ? ) AND deleted_at IS NULL`
+	if descendants {
+		query = `WITH RECURSIVE subtree (id) AS ( 
+			SELECT id FROM topic WHERE id = ? 
+			UNION ALL 
+			SELECT t.id FROM topic t JOIN subtree s ON t.parent_id = s.id ) 
+		SELECT id , title , digital_price , digital_discount , physical_price , 
+		physical_discount , physical_stock , cover_front , availability FROM book 
+		WHERE id IN ( SELECT book_id FROM book_topic WHERE topic_id IN ( SELECT id FROM subtree ) ) AND deleted_at IS NULL`
+	}
+
+	stmt, err := storage.MySQL.PrepareContext(ctx, query)
 	if err != nil {
 		return []book.Book{}, err
 	}
@@ -945,7 +1398,7 @@ func (storage Storage) GetPublisherBooks(ctx context.Context, publisherID uint)
 	stmt, err := storage.MySQL.PrepareContext(ctx,
 		`SELECT id , title , digital_price , digital_discount , physical_price , 
 		physical_discount , physical_stock , cover_front , availability FROM book 
-		WHERE publisher = ?`,
+		WHERE publisher = ? AND deleted_at IS NULL`,
 	)
 	if err != nil {
 		return []book.Book{}, err
@@ -987,7 +1440,7 @@ func (storage Storage) GetLangBooks(ctx context.Context, langID uint) ([]book.Bo
 	stmt, err := storage.MySQL.PrepareContext(ctx,
 		`SELECT id , title , digital_price , digital_discount , physical_price , 
 		physical_discount , physical_stock , cover_front , availability FROM book 
-		WHERE lang_id = ?`,
+		WHERE lang_id = ? AND deleted_at IS NULL`,
 	)
 	if err != nil {
 		return []book.Book{}, err
@@ -1024,17 +1477,64 @@ func (storage Storage) GetLangBooks(ctx context.Context, langID uint) ([]book.Bo
 	return books, nil
 }
 
+func (storage Storage) SearchBooks(ctx context.Context, query string) ([]book.Book, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		`SELECT id , title , digital_price , digital_discount , physical_price , 
+		physical_discount , physical_stock , cover_front , availability FROM book 
+		WHERE ( title LIKE ? OR isbn = ? OR id IN 
+		( SELECT book_id FROM book_author JOIN author ON author.id = book_author.author_id WHERE author.name LIKE ? ) ) 
+		AND deleted_at IS NULL`,
+	)
+	if err != nil {
+		return []book.Book{}, err
+	}
+	defer stmt.Close()
+
+	pattern := "%" + strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(query) + "%"
+
+	result, err := stmt.QueryContext(ctx, pattern, query, pattern)
+	if err != nil {
+		return []book.Book{}, err
+	}
+	defer result.Close()
+
+	books := []book.Book{}
+	for result.Next() {
+		var b book.Book
+
+		if err = result.Scan(
+			&b.ID,
+			&b.Title,
+			&b.Digital.Price,
+			&b.Digital.Discount,
+			&b.Physical.Price,
+			&b.Physical.Discount,
+			&b.Physical.Stock,
+			&b.CoverFront,
+			&b.Availability,
+		); err != nil {
+			return []book.Book{}, err
+		}
+
+		books = append(books, b)
+	}
+
+	return books, nil
+}
+
+// DeleteBook moves the book to the trash
 func (storage Storage) DeleteBook(ctx context.Context, bookID uint) error {
 
 	stmt, err := storage.MySQL.PrepareContext(ctx,
-		"DELETE FROM book WHERE id = ?",
+		"UPDATE book SET deleted_at = ? WHERE id = ?",
 	)
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
-	if _, err = stmt.ExecContext(ctx, bookID); err != nil {
+	if _, err = stmt.ExecContext(ctx, time.Now().Format("2006-01-02 15:04:05"), bookID); err != nil {
 		return err
 	}
 
@@ -1044,14 +1544,15 @@ func (storage Storage) DeleteBook(ctx context.Context, bookID uint) error {
 func (storage Storage) GetUserDigitalBooks(ctx context.Context, userID string) ([]book.Book, error) {
 
 	stmt, err := storage.MySQL.PrepareContext(ctx,
-		`SELECT id , title , isbn , pages , description , year , 
-		pdf , epub , djvu , azw , txt , docx , 
+		`SELECT id , title , isbn , pages , COALESCE(description, '') , year , 
+		COALESCE(pdf, '') , COALESCE(epub, '') , COALESCE(djvu, '') , 
+		COALESCE(azw, '') , COALESCE(txt, '') , COALESCE(docx, '') , 
 		lang_id , cover_front , publisher FROM book 
 		WHERE book.id IN 
-		(SELECT book_id FROM item 
-			WHERE item.order_id = (SELECT id FROM orders WHERE user_id = ? AND status != ?) 
-			AND 
-			type = 0
+		(SELECT i.book_id FROM item i 
+			JOIN orders o ON o.id = i.order_id 
+			LEFT JOIN book_gift g ON g.item_id = i.id 
+			WHERE `+digitalAccess+`
 		)`,
 	)
 	if err != nil {
@@ -1059,13 +1560,11 @@ func (storage Storage) GetUserDigitalBooks(ctx context.Context, userID string) (
 	}
 	defer stmt.Close()
 
-	result, err := stmt.QueryContext(ctx,
-		userID,
-		order.StatusCreated,
-	)
+	result, err := stmt.QueryContext(ctx, accessArgs(userID)...)
 	if err != nil {
 		return []book.Book{}, err
 	}
+	defer result.Close()
 
 	books := []book.Book{}
 	for result.Next() {
@@ -1100,21 +1599,18 @@ func (storage Storage) GetUserDigitalBooks(ctx context.Context, userID string) (
 func (storage Storage) DoesUserAccessBook(ctx context.Context, userID string, bookID uint) (bool, error) {
 
 	stmt, err := storage.MySQL.PrepareContext(ctx,
-		`SELECT 1 FROM item WHERE type = ? 
-		AND book_id = ? 
-		AND order_id IN ( SELECT id FROM orders WHERE user_id = ? AND status != ? )`,
+		`SELECT 1 FROM item i 
+		JOIN orders o ON o.id = i.order_id 
+		LEFT JOIN book_gift g ON g.item_id = i.id 
+		WHERE i.book_id = ? 
+		AND `+digitalAccess+` LIMIT 1`,
 	)
 	if err != nil {
 		return false, err
 	}
 	defer stmt.Close()
 
-	result := stmt.QueryRowContext(ctx,
-		order.Digital,
-		bookID,
-		userID,
-		order.StatusCreated,
-	)
+	result := stmt.QueryRowContext(ctx, append([]interface{}{bookID}, accessArgs(userID)...)...)
 
 	var access bool
 	if err = result.Scan(&access); err != nil {
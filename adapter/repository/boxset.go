@@ -0,0 +1,442 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/XBozorg/bookstore/entity/book"
+	"github.com/XBozorg/bookstore/entity/order"
+	"github.com/XBozorg/bookstore/entity/pricing"
+)
+
+func (storage Storage) DoesBoxSetExist(ctx context.Context, boxSetID uint) (bool, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM box_set WHERE id = ?)",
+	)
+	if err != nil {
+		return false, err
+	}
+	defer stmt.Close()
+
+	var exist bool
+	if err = stmt.QueryRowContext(ctx, boxSetID).Scan(&exist); err != nil {
+		return false, err
+	}
+
+	return exist, nil
+}
+
+// IsBoxSetInOpenOrders reports whether a cart holds the box set
+func (storage Storage) IsBoxSetInOpenOrders(ctx context.Context, boxSetID uint) (bool, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		`SELECT EXISTS(SELECT 1 FROM item WHERE box_set_id = ?
+		AND order_id IN ( SELECT id FROM orders WHERE status = ? ))`,
+	)
+	if err != nil {
+		return false, err
+	}
+	defer stmt.Close()
+
+	var exist bool
+	if err = stmt.QueryRowContext(ctx, boxSetID, order.StatusCreated).Scan(&exist); err != nil {
+		return false, err
+	}
+
+	return exist, nil
+}
+
+func (storage Storage) AddBoxSet(ctx context.Context, s book.BoxSet) (book.BoxSet, error) {
+
+	tx, err := storage.MySQL.BeginTx(ctx, nil)
+	if err != nil {
+		return book.BoxSet{}, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO box_set (title , description , price , percentage , physical)
+		VALUES (?,NULLIF(?, ''),?,?,?)`,
+		s.Title,
+		s.Description,
+		s.Price,
+		s.Percentage,
+		s.Physical,
+	)
+	if err != nil {
+		return book.BoxSet{}, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return book.BoxSet{}, err
+	}
+	s.ID = uint(id)
+
+	if err = setBoxSetBooks(ctx, tx, s); err != nil {
+		return book.BoxSet{}, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return book.BoxSet{}, err
+	}
+
+	return storage.GetBoxSet(ctx, s.ID)
+}
+
+func (storage Storage) EditBoxSet(ctx context.Context, s book.BoxSet) (book.BoxSet, error) {
+
+	tx, err := storage.MySQL.BeginTx(ctx, nil)
+	if err != nil {
+		return book.BoxSet{}, err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx,
+		`UPDATE box_set SET title = ? , description = NULLIF(?, '') , price = ? , percentage = ? , physical = ?
+		WHERE id = ?`,
+		s.Title,
+		s.Description,
+		s.Price,
+		s.Percentage,
+		s.Physical,
+		s.ID,
+	); err != nil {
+		return book.BoxSet{}, err
+	}
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM box_set_book WHERE box_set_id = ?", s.ID); err != nil {
+		return book.BoxSet{}, err
+	}
+
+	if err = setBoxSetBooks(ctx, tx, s); err != nil {
+		return book.BoxSet{}, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return book.BoxSet{}, err
+	}
+
+	return storage.GetBoxSet(ctx, s.ID)
+}
+
+func setBoxSetBooks(ctx context.Context, tx *sql.Tx, s book.BoxSet) error {
+
+	stmt, err := tx.PrepareContext(ctx,
+		"INSERT IGNORE INTO box_set_book (box_set_id , book_id) VALUES (? , ?)",
+	)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, id := range s.BookIDs {
+		if _, err = stmt.ExecContext(ctx, s.ID, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (storage Storage) DeleteBoxSet(ctx context.Context, boxSetID uint) error {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		"DELETE FROM box_set WHERE id = ?",
+	)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, boxSetID)
+	return err
+}
+
+// GetBoxSet returns the box set with its books, its stock and its prices right now
+func (storage Storage) GetBoxSet(ctx context.Context, boxSetID uint) (book.BoxSet, error) {
+	return getBoxSet(ctx, storage.MySQL, boxSetID, time.Now().Format("2006-01-02 15:04:05"))
+}
+
+func (storage Storage) GetBoxSets(ctx context.Context) ([]book.BoxSet, error) {
+
+	ids, err := getIDs(ctx, storage.MySQL, "SELECT id FROM box_set ORDER BY id")
+	if err != nil {
+		return []book.BoxSet{}, err
+	}
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+
+	sets := []book.BoxSet{}
+	for _, id := range ids {
+		s, err := getBoxSet(ctx, storage.MySQL, id, now)
+		if err != nil {
+			return []book.BoxSet{}, err
+		}
+		sets = append(sets, s)
+	}
+
+	return sets, nil
+}
+
+func getBoxSet(ctx context.Context, q querier, boxSetID uint, now string) (book.BoxSet, error) {
+
+	s := book.BoxSet{ID: boxSetID}
+	if err := q.QueryRowContext(ctx,
+		"SELECT title , COALESCE(description, '') , price , percentage , physical FROM box_set WHERE id = ?",
+		boxSetID,
+	).Scan(
+		&s.Title,
+		&s.Description,
+		&s.Price,
+		&s.Percentage,
+		&s.Physical,
+	); err != nil {
+		return book.BoxSet{}, err
+	}
+
+	result, err := q.QueryContext(ctx,
+		`SELECT b.id , b.title , b.physical_stock , IF(b.deleted_at IS NULL , b.availability , ?)
+		FROM box_set_book sb JOIN book b ON b.id = sb.book_id
+		WHERE sb.box_set_id = ? ORDER BY b.id`,
+		book.NotAvailable,
+		boxSetID,
+	)
+	if err != nil {
+		return book.BoxSet{}, err
+	}
+
+	s.BookIDs, s.Books = []uint{}, []book.BoxSetBook{}
+	for result.Next() {
+		var b book.BoxSetBook
+		if err = result.Scan(&b.ID, &b.Title, &b.Stock, &b.Availability); err != nil {
+			result.Close()
+			return book.BoxSet{}, err
+		}
+		s.BookIDs = append(s.BookIDs, b.ID)
+		s.Books = append(s.Books, b)
+	}
+	result.Close()
+
+	s.ComputeStock()
+
+	for _, id := range s.BookIDs {
+		quote, err := quoteBook(ctx, q, id, now)
+		if err != nil {
+			return book.BoxSet{}, err
+		}
+
+		s.ListPrice += quote.Digital.Price
+		if s.Physical {
+			s.ListPrice += quote.Physical.Price
+		}
+	}
+	s.SetPrice = pricing.BoxSetPrice(s.Price, s.Percentage, 1, s.ListPrice)
+
+	return s, nil
+}
+
+// addBoxSetItem expands the box set into a digital line per book, and a physical line per book
+// for a physical set, the physical lines reserve the stock like any other physical item
+func (storage Storage) addBoxSetItem(ctx context.Context, tx *sql.Tx, item order.Item, orderID uint) error {
+
+	s, err := getBoxSet(ctx, tx, item.BoxSetID, time.Now().Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return err
+	}
+
+	for _, id := range s.BookIDs {
+		preorder, err := storage.CheckPreorder(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if preorder != book.NotAvailable {
+			return errors.New("box set has unreleased books")
+		}
+	}
+
+	if !s.Available {
+		return errors.New("item unavailable")
+	}
+
+	quantity := uint(1)
+	if s.Physical {
+		quantity = item.Quantity
+		if quantity > s.Stock {
+			return errors.New("requested item quantity is bigger than the available stock")
+		}
+	}
+
+	var inCart bool
+	if err = tx.QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM item WHERE order_id = ? AND box_set_id = ?)",
+		orderID, s.ID,
+	).Scan(&inCart); err != nil {
+		return err
+	}
+	if inCart {
+		return errors.New("box set is already in the order")
+	}
+
+	stmt, err := tx.PrepareContext(ctx,
+		`INSERT INTO item (book_id , type , quantity , order_id , box_set_id)
+		VALUES (?,?,?,?,?)`,
+	)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, id := range s.BookIDs {
+		if _, err = stmt.ExecContext(ctx, id, order.Digital, 1, orderID, s.ID); err != nil {
+			return err
+		}
+
+		if !s.Physical {
+			continue
+		}
+
+		if _, err = stmt.ExecContext(ctx, id, order.Physical, quantity, orderID, s.ID); err != nil {
+			return err
+		}
+
+		if err = storage.reserveStock(ctx, tx, id, int(quantity), orderID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// changeBoxSetQuantity adds delta to every physical line of the box set in the order,
+// the digital lines stay one copy
+func (storage Storage) changeBoxSetQuantity(ctx context.Context, tx *sql.Tx, boxSetID, orderID uint, delta int) error {
+
+	lines, err := getBoxSetLines(ctx, tx, boxSetID, orderID)
+	if err != nil {
+		return err
+	}
+
+	changed := false
+	for _, line := range lines {
+		if line.Type != order.Physical {
+			continue
+		}
+		changed = true
+
+		if delta < 0 && line.Quantity <= 1 {
+			return errors.New("cannot decrease box set below one copy")
+		}
+
+		if delta > 0 {
+			if err = storage.CheckQuantity(ctx, tx, uint(delta), line.BookID); err != nil {
+				return err
+			}
+		}
+
+		if _, err = tx.ExecContext(ctx,
+			"UPDATE item SET quantity = quantity + ? WHERE id = ?",
+			delta, line.ID,
+		); err != nil {
+			return err
+		}
+
+		if err = storage.reserveStock(ctx, tx, line.BookID, delta, orderID); err != nil {
+			return err
+		}
+	}
+
+	if !changed {
+		return errors.New("cannot change the quantity of a digital box set")
+	}
+
+	return nil
+}
+
+// removeBoxSet removes every line of the box set from the order and releases their stock
+func (storage Storage) removeBoxSet(ctx context.Context, tx *sql.Tx, boxSetID, orderID uint) error {
+
+	lines, err := getBoxSetLines(ctx, tx, boxSetID, orderID)
+	if err != nil {
+		return err
+	}
+
+	for _, line := range lines {
+		if line.Type == order.Physical {
+			if err = storage.reserveStock(ctx, tx, line.BookID, -int(line.Quantity), orderID); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"DELETE FROM item WHERE order_id = ? AND box_set_id = ?",
+		orderID, boxSetID,
+	)
+	return err
+}
+
+func getBoxSetLines(ctx context.Context, tx *sql.Tx, boxSetID, orderID uint) ([]order.Item, error) {
+
+	result, err := tx.QueryContext(ctx,
+		"SELECT id , book_id , type , quantity FROM item WHERE order_id = ? AND box_set_id = ?",
+		orderID, boxSetID,
+	)
+	if err != nil {
+		return []order.Item{}, err
+	}
+	defer result.Close()
+
+	lines := []order.Item{}
+	for result.Next() {
+		i := order.Item{BoxSetID: boxSetID}
+		if err = result.Scan(&i.ID, &i.BookID, &i.Type, &i.Quantity); err != nil {
+			return []order.Item{}, err
+		}
+		lines = append(lines, i)
+	}
+
+	return lines, nil
+}
+
+// reserveStock takes quantity copies of the book off the shelf for the order,
+// a negative quantity puts them back
+func (storage Storage) reserveStock(ctx context.Context, tx *sql.Tx, bookID uint, quantity int, orderID uint) error {
+
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE book SET physical_stock = physical_stock - ? WHERE id = ?",
+		quantity, bookID,
+	); err != nil {
+		return err
+	}
+
+	movement := book.MovementReservation
+	if quantity < 0 {
+		movement = book.MovementRelease
+	}
+
+	_, err := storage.recordMovement(ctx, tx, book.Movement{
+		BookID:   bookID,
+		Type:     movement,
+		Quantity: -quantity,
+		OrderID:  orderID,
+	})
+	return err
+}
+
+// boxSetTotal prices quantity copies of the box set whose lines cost lines one by one
+func boxSetTotal(ctx context.Context, tx *sql.Tx, boxSetID, quantity, lines uint) (uint, error) {
+
+	var price, percentage uint
+	if err := tx.QueryRowContext(ctx,
+		"SELECT price , percentage FROM box_set WHERE id = ?",
+		boxSetID,
+	).Scan(&price, &percentage); err != nil {
+		return 0, err
+	}
+
+	return pricing.BoxSetPrice(price, percentage, quantity, lines), nil
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/XBozorg/bookstore/entity/pricing"
+)
+
+func (storage Storage) DoesBundleRuleExist(ctx context.Context, scope string, scopeID uint) (bool, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM bundle_rule WHERE scope = ? AND scope_id = ?)",
+	)
+	if err != nil {
+		return false, err
+	}
+	defer stmt.Close()
+
+	var doesExist bool
+	if err = stmt.QueryRowContext(ctx, scope, scopeID).Scan(&doesExist); err != nil {
+		return false, err
+	}
+
+	return doesExist, nil
+}
+
+// SetBundleRule adds the rule or replaces the percentage of the existing rule of the scope
+func (storage Storage) SetBundleRule(ctx context.Context, rule pricing.BundleRule) error {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		`INSERT INTO bundle_rule (scope , scope_id , percentage) VALUES (?,?,?)
+		ON DUPLICATE KEY UPDATE percentage = VALUES(percentage)`,
+	)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, rule.Scope, rule.ScopeID, rule.Percentage)
+	return err
+}
+
+func (storage Storage) DeleteBundleRule(ctx context.Context, scope string, scopeID uint) error {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		"DELETE FROM bundle_rule WHERE scope = ? AND scope_id = ?",
+	)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, scope, scopeID)
+	return err
+}
+
+func (storage Storage) GetBundleRules(ctx context.Context) ([]pricing.BundleRule, error) {
+	return getBundleRules(ctx, storage.MySQL)
+}
+
+func getBundleRules(ctx context.Context, q querier) ([]pricing.BundleRule, error) {
+
+	result, err := q.QueryContext(ctx,
+		"SELECT scope , scope_id , percentage FROM bundle_rule ORDER BY scope , scope_id",
+	)
+	if err != nil {
+		return []pricing.BundleRule{}, err
+	}
+	defer result.Close()
+
+	rules := []pricing.BundleRule{}
+	for result.Next() {
+		var r pricing.BundleRule
+		if err = result.Scan(&r.Scope, &r.ScopeID, &r.Percentage); err != nil {
+			return []pricing.BundleRule{}, err
+		}
+		rules = append(rules, r)
+	}
+
+	return rules, nil
+}
@@ -0,0 +1,531 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/XBozorg/bookstore/config"
+	"github.com/XBozorg/bookstore/entity/book"
+	"github.com/XBozorg/bookstore/entity/order"
+	"github.com/XBozorg/bookstore/entity/pricing"
+)
+
+// querier runs the pricing queries either on the database or inside the transaction of an order
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+const campaignSelect = `SELECT c.id , c.name , c.target , c.format , c.percentage , c.priority , c.start , c.end ,
+	COALESCE(GROUP_CONCAT(t.target_id ORDER BY t.target_id), '')
+	FROM campaign c LEFT JOIN campaign_target t ON t.campaign_id = c.id `
+
+func (storage Storage) DoesCampaignExist(ctx context.Context, campaignID uint) (bool, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM campaign WHERE id = ?)",
+	)
+	if err != nil {
+		return false, err
+	}
+	defer stmt.Close()
+
+	var doesExist bool
+	if err = stmt.QueryRowContext(ctx, campaignID).Scan(&doesExist); err != nil {
+		return false, err
+	}
+
+	return doesExist, nil
+}
+
+func (storage Storage) AddCampaign(ctx context.Context, c pricing.Campaign) (pricing.Campaign, error) {
+
+	tx, err := storage.MySQL.BeginTx(ctx, nil)
+	if err != nil {
+		return pricing.Campaign{}, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO campaign (name , target , format , percentage , priority , start , end)
+		VALUES (?,?,?,?,?,?,?)`,
+		c.Name,
+		c.Target,
+		c.Format,
+		c.Percentage,
+		c.Priority,
+		c.Start,
+		c.End,
+	)
+	if err != nil {
+		return pricing.Campaign{}, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return pricing.Campaign{}, err
+	}
+	c.ID = uint(id)
+
+	if err = setCampaignTargets(ctx, tx, c); err != nil {
+		return pricing.Campaign{}, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return pricing.Campaign{}, err
+	}
+
+	return storage.GetCampaign(ctx, c.ID)
+}
+
+func (storage Storage) EditCampaign(ctx context.Context, c pricing.Campaign) (pricing.Campaign, error) {
+
+	tx, err := storage.MySQL.BeginTx(ctx, nil)
+	if err != nil {
+		return pricing.Campaign{}, err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx,
+		`UPDATE campaign SET name = ? , target = ? , format = ? , percentage = ? , priority = ? ,
+		start = ? , end = ? WHERE id = ?`,
+		c.Name,
+		c.Target,
+		c.Format,
+		c.Percentage,
+		c.Priority,
+		c.Start,
+		c.End,
+		c.ID,
+	); err != nil {
+		return pricing.Campaign{}, err
+	}
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM campaign_target WHERE campaign_id = ?", c.ID); err != nil {
+		return pricing.Campaign{}, err
+	}
+
+	if err = setCampaignTargets(ctx, tx, c); err != nil {
+		return pricing.Campaign{}, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return pricing.Campaign{}, err
+	}
+
+	return storage.GetCampaign(ctx, c.ID)
+}
+
+func setCampaignTargets(ctx context.Context, tx *sql.Tx, c pricing.Campaign) error {
+
+	stmt, err := tx.PrepareContext(ctx,
+		"INSERT IGNORE INTO campaign_target (campaign_id , target_id) VALUES (? , ?)",
+	)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, id := range c.TargetIDs {
+		if _, err = stmt.ExecContext(ctx, c.ID, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (storage Storage) DeleteCampaign(ctx context.Context, campaignID uint) error {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		"DELETE FROM campaign WHERE id = ?",
+	)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, campaignID)
+	return err
+}
+
+func (storage Storage) GetCampaign(ctx context.Context, campaignID uint) (pricing.Campaign, error) {
+
+	campaigns, err := getCampaigns(ctx, storage.MySQL,
+		campaignSelect+"WHERE c.id = ? GROUP BY c.id",
+		campaignID,
+	)
+	if err != nil {
+		return pricing.Campaign{}, err
+	}
+	if len(campaigns) == 0 {
+		return pricing.Campaign{}, sql.ErrNoRows
+	}
+
+	return campaigns[0], nil
+}
+
+// GetCampaigns returns every campaign, latest start first
+func (storage Storage) GetCampaigns(ctx context.Context) ([]pricing.Campaign, error) {
+	return getCampaigns(ctx, storage.MySQL,
+		campaignSelect+"GROUP BY c.id ORDER BY c.start DESC , c.id DESC",
+	)
+}
+
+func getActiveCampaigns(ctx context.Context, q querier, now string) ([]pricing.Campaign, error) {
+	return getCampaigns(ctx, q,
+		campaignSelect+"WHERE c.start <= ? AND c.end > ? GROUP BY c.id",
+		now, now,
+	)
+}
+
+func getCampaigns(ctx context.Context, q querier, query string, args ...interface{}) ([]pricing.Campaign, error) {
+
+	result, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return []pricing.Campaign{}, err
+	}
+	defer result.Close()
+
+	campaigns := []pricing.Campaign{}
+	for result.Next() {
+		var (
+			c       pricing.Campaign
+			targets string
+		)
+
+		if err = result.Scan(
+			&c.ID,
+			&c.Name,
+			&c.Target,
+			&c.Format,
+			&c.Percentage,
+			&c.Priority,
+			&c.Start,
+			&c.End,
+			&targets,
+		); err != nil {
+			return []pricing.Campaign{}, err
+		}
+
+		if c.TargetIDs, err = parseIDs(targets); err != nil {
+			return []pricing.Campaign{}, err
+		}
+
+		campaigns = append(campaigns, c)
+	}
+
+	return campaigns, nil
+}
+
+// getPricingBook reads the prices of a book with everything a campaign can target
+func getPricingBook(ctx context.Context, q querier, bookID uint) (pricing.Book, error) {
+
+	b := pricing.Book{ID: bookID}
+
+	if err := q.QueryRowContext(ctx,
+		`SELECT digital_price , digital_discount , physical_price , physical_discount , publisher
+		FROM book WHERE id = ?`,
+		bookID,
+	).Scan(
+		&b.DigitalPrice,
+		&b.DigitalDiscount,
+		&b.PhysicalPrice,
+		&b.PhysicalDiscount,
+		&b.PublisherID,
+	); err != nil {
+		return pricing.Book{}, err
+	}
+
+	authors, err := getIDs(ctx, q,
+		"SELECT author_id FROM book_author WHERE book_id = ? AND role = ?",
+		bookID, book.RoleAuthor,
+	)
+	if err != nil {
+		return pricing.Book{}, err
+	}
+	b.AuthorIDs = authors
+
+	topics, err := getIDs(ctx, q,
+		`WITH RECURSIVE ancestors (id , parent_id) AS (
+			SELECT t.id , t.parent_id FROM topic t JOIN book_topic bt ON bt.topic_id = t.id WHERE bt.book_id = ?
+			UNION ALL
+			SELECT t.id , t.parent_id FROM topic t JOIN ancestors a ON t.id = a.parent_id )
+		SELECT DISTINCT id FROM ancestors`,
+		bookID,
+	)
+	if err != nil {
+		return pricing.Book{}, err
+	}
+	b.TopicIDs = topics
+
+	return b, nil
+}
+
+// parseIDs splits the comma separated IDs of a GROUP_CONCAT
+func parseIDs(list string) ([]uint, error) {
+
+	ids := []uint{}
+	for _, t := range strings.Split(list, ",") {
+		if t == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(t, 10, 64)
+		if err != nil {
+			return []uint{}, err
+		}
+		ids = append(ids, uint(id))
+	}
+
+	return ids, nil
+}
+
+func getIDs(ctx context.Context, q querier, query string, args ...interface{}) ([]uint, error) {
+
+	result, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return []uint{}, err
+	}
+	defer result.Close()
+
+	ids := []uint{}
+	for result.Next() {
+		var id uint
+		if err = result.Scan(&id); err != nil {
+			return []uint{}, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// quoteBook prices a book with the campaigns running at now
+func quoteBook(ctx context.Context, q querier, bookID uint, now string) (pricing.Quote, error) {
+
+	b, err := getPricingBook(ctx, q, bookID)
+	if err != nil {
+		return pricing.Quote{}, err
+	}
+
+	campaigns, err := getActiveCampaigns(ctx, q, now)
+	if err != nil {
+		return pricing.Quote{}, err
+	}
+
+	rules, err := getBundleRules(ctx, q)
+	if err != nil {
+		return pricing.Quote{}, err
+	}
+
+	return pricing.NewQuote(b, campaigns, rules, now), nil
+}
+
+// GetPriceQuote returns the effective prices of a book right now and their tax,
+// shown as display or as configured when it's empty
+func (storage Storage) GetPriceQuote(ctx context.Context, bookID uint, display string) (pricing.Quote, error) {
+
+	q, err := quoteBook(ctx, storage.MySQL, bookID, time.Now().Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return pricing.Quote{}, err
+	}
+
+	b, err := getPricingBook(ctx, storage.MySQL, bookID)
+	if err != nil {
+		return pricing.Quote{}, err
+	}
+
+	if display == "" {
+		display = config.Conf.GetTaxConfig().Display
+	}
+
+	return pricing.QuoteTax(taxRule(), q, b, display), nil
+}
+
+// GetPriceQuotes prices the books like GetPriceQuote, in the same order
+func (storage Storage) GetPriceQuotes(ctx context.Context, bookIDs []uint, display string) ([]pricing.Quote, error) {
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+
+	campaigns, err := getActiveCampaigns(ctx, storage.MySQL, now)
+	if err != nil {
+		return []pricing.Quote{}, err
+	}
+
+	rules, err := getBundleRules(ctx, storage.MySQL)
+	if err != nil {
+		return []pricing.Quote{}, err
+	}
+
+	if display == "" {
+		display = config.Conf.GetTaxConfig().Display
+	}
+	rule := taxRule()
+
+	quotes := make([]pricing.Quote, 0, len(bookIDs))
+	for _, bookID := range bookIDs {
+		b, err := getPricingBook(ctx, storage.MySQL, bookID)
+		if err != nil {
+			return []pricing.Quote{}, err
+		}
+
+		quotes = append(quotes, pricing.QuoteTax(rule, pricing.NewQuote(b, campaigns, rules, now), b, display))
+	}
+
+	return quotes, nil
+}
+
+// PreviewCampaign prices every live book the campaign targets at the given time,
+// with and without the campaign, the campaign doesn't have to be saved
+func (storage Storage) PreviewCampaign(ctx context.Context, c pricing.Campaign, at string) ([]pricing.Preview, error) {
+
+	previews, err := storage.getCampaignBooks(ctx, c)
+	if err != nil {
+		return []pricing.Preview{}, err
+	}
+
+	running, err := getActiveCampaigns(ctx, storage.MySQL, at)
+	if err != nil {
+		return []pricing.Preview{}, err
+	}
+
+	rules, err := getBundleRules(ctx, storage.MySQL)
+	if err != nil {
+		return []pricing.Preview{}, err
+	}
+
+	others := []pricing.Campaign{}
+	for _, r := range running {
+		if r.ID != c.ID {
+			others = append(others, r)
+		}
+	}
+
+	for i := range previews {
+		b, err := getPricingBook(ctx, storage.MySQL, previews[i].BookID)
+		if err != nil {
+			return []pricing.Preview{}, err
+		}
+
+		previews[i].Before = pricing.NewQuote(b, others, rules, at)
+		previews[i].After = pricing.NewQuote(b, append(others, c), rules, at)
+	}
+
+	return previews, nil
+}
+
+func (storage Storage) getCampaignBooks(ctx context.Context, c pricing.Campaign) ([]pricing.Preview, error) {
+
+	if len(c.TargetIDs) == 0 {
+		return []pricing.Preview{}, nil
+	}
+
+	in := strings.TrimSuffix(strings.Repeat("? , ", len(c.TargetIDs)), " , ")
+	args := []interface{}{}
+
+	var query string
+	switch c.Target {
+	case pricing.TargetBooks:
+		query = "SELECT id , title FROM book WHERE id IN (" + in + ")"
+
+	case pricing.TargetPublisher:
+		query = "SELECT id , title FROM book WHERE publisher IN (" + in + ")"
+
+	case pricing.TargetAuthor:
+		query = `SELECT id , title FROM book WHERE id IN (
+			SELECT book_id FROM book_author WHERE role = ? AND author_id IN (` + in + `) )`
+		args = append(args, book.RoleAuthor)
+
+	case pricing.TargetTopic:
+		query = `WITH RECURSIVE subtree (id) AS (
+			SELECT id FROM topic WHERE id IN (` + in + `)
+			UNION ALL
+			SELECT t.id FROM topic t JOIN subtree s ON t.parent_id = s.id )
+		SELECT id , title FROM book WHERE id IN (
+			SELECT book_id FROM book_topic WHERE topic_id IN ( SELECT id FROM subtree ) )`
+
+	default:
+		return []pricing.Preview{}, errors.New("invalid campaign target")
+	}
+
+	for _, id := range c.TargetIDs {
+		args = append(args, id)
+	}
+
+	result, err := storage.MySQL.QueryContext(ctx, query+" AND deleted_at IS NULL ORDER BY id", args...)
+	if err != nil {
+		return []pricing.Preview{}, err
+	}
+	defer result.Close()
+
+	previews := []pricing.Preview{}
+	for result.Next() {
+		var p pricing.Preview
+		if err = result.Scan(&p.BookID, &p.Title); err != nil {
+			return []pricing.Preview{}, err
+		}
+		previews = append(previews, p)
+	}
+
+	return previews, nil
+}
+
+// CountCampaignBoundaries counts the campaigns that started or ended in (since, until]
+func (storage Storage) CountCampaignBoundaries(ctx context.Context, since, until string) (uint, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		`SELECT COUNT(*) FROM campaign
+		WHERE (start > ? AND start <= ?) OR (end > ? AND end <= ?)`,
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	var count uint
+	if err = stmt.QueryRowContext(ctx, since, until, since, until).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// RefreshOpenOrders recomputes the totals of the open orders so the carts follow
+// the campaigns that started or ended, applied promo codes are applied again
+func (storage Storage) RefreshOpenOrders(ctx context.Context) error {
+
+	orders, err := getIDs(ctx, storage.MySQL,
+		"SELECT id FROM orders WHERE status = ?",
+		order.StatusCreated,
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, orderID := range orders {
+		if err = storage.refreshOrder(ctx, orderID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (storage Storage) refreshOrder(ctx context.Context, orderID uint) error {
+
+	tx, err := storage.MySQL.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = storage.repriceOrder(ctx, tx, orderID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,491 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/XBozorg/bookstore/entity/book"
+)
+
+func (storage Storage) DoesImportJobExist(ctx context.Context, jobID uint) (bool, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM import_job WHERE id = ?)",
+	)
+	if err != nil {
+		return false, err
+	}
+	defer stmt.Close()
+
+	result := stmt.QueryRowContext(ctx, jobID)
+
+	var doesExist bool
+	if err = result.Scan(&doesExist); err != nil {
+		return false, err
+	}
+
+	return doesExist, nil
+}
+
+func (storage Storage) GetBookIDByISBN(ctx context.Context, isbn string) (uint, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		"SELECT id , deleted_at IS NOT NULL FROM book WHERE isbn = ?",
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	result := stmt.QueryRowContext(ctx, isbn)
+
+	var (
+		bookID  uint
+		deleted bool
+	)
+	if err = result.Scan(&bookID, &deleted); err != nil {
+		return 0, err
+	}
+
+	if deleted {
+		return 0, errors.New("book with this isbn is in the trash")
+	}
+
+	return bookID, nil
+}
+
+// ImportBook inserts the book or updates the one with the same ISBN, replacing its authors and topics.
+// Authors, topics, publisher and language must already have their IDs. Empty files and covers keep
+// the stored ones, and so do the prices and stock missing from fields. A book in the trash is left alone.
+func (storage Storage) ImportBook(ctx context.Context, b book.Book, fields book.ImportFields) (bool, error) {
+
+	tx, err := storage.MySQL.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+
+	var (
+		bookID, stock uint
+		deleted       bool
+	)
+	created := false
+
+	err = tx.QueryRowContext(ctx,
+		"SELECT id , physical_stock , deleted_at IS NOT NULL FROM book WHERE isbn = ? FOR UPDATE",
+		b.ISBN,
+	).Scan(&bookID, &stock, &deleted)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		result, err := tx.ExecContext(ctx,
+			`INSERT INTO book (
+				title , isbn , pages , description , year , date , digital_price ,
+				physical_price , physical_stock , pdf , epub , djvu , azw , txt ,
+				docx , lang_id , cover_front , cover_back , publisher
+			)
+			VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)`,
+			b.Title,
+			b.ISBN,
+			b.Pages,
+			b.Description,
+			b.Year,
+			time.Now().Format("2006-01-02 15:04:05"),
+			b.Digital.Price,
+			b.Physical.Price,
+			b.Physical.Stock,
+			b.Digital.PDF,
+			b.Digital.EPUB,
+			b.Digital.DJVU,
+			b.Digital.AZW,
+			b.Digital.TXT,
+			b.Digital.DOCX,
+			b.Language.ID,
+			b.CoverFront,
+			b.CoverBack,
+			b.Publisher.ID,
+		)
+		if err != nil {
+			tx.Rollback()
+			return false, err
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			tx.Rollback()
+			return false, err
+		}
+		bookID = uint(id)
+		created = true
+
+	case err != nil:
+		tx.Rollback()
+		return false, err
+
+	case deleted:
+		tx.Rollback()
+		return false, errors.New("book with this isbn is in the trash")
+
+	default:
+		if _, err = tx.ExecContext(ctx,
+			`UPDATE book SET
+			title=? , pages=? , description=? , year=? , digital_price=IF(?, ?, digital_price) ,
+			physical_price=IF(?, ?, physical_price) , physical_stock=IF(?, ?, physical_stock) ,
+			pdf=COALESCE(NULLIF(?, ''), pdf) , epub=COALESCE(NULLIF(?, ''), epub) , djvu=COALESCE(NULLIF(?, ''), djvu) ,
+			azw=COALESCE(NULLIF(?, ''), azw) , txt=COALESCE(NULLIF(?, ''), txt) , docx=COALESCE(NULLIF(?, ''), docx) ,
+			lang_id=? , cover_front=COALESCE(NULLIF(?, ''), cover_front) , cover_back=COALESCE(NULLIF(?, ''), cover_back) ,
+			publisher=?
+			WHERE id=?`,
+			b.Title,
+			b.Pages,
+			b.Description,
+			b.Year,
+			fields.DigitalPrice,
+			b.Digital.Price,
+			fields.PhysicalPrice,
+			b.Physical.Price,
+			fields.PhysicalStock,
+			b.Physical.Stock,
+			b.Digital.PDF,
+			b.Digital.EPUB,
+			b.Digital.DJVU,
+			b.Digital.AZW,
+			b.Digital.TXT,
+			b.Digital.DOCX,
+			b.Language.ID,
+			b.CoverFront,
+			b.CoverBack,
+			b.Publisher.ID,
+			bookID,
+		); err != nil {
+			tx.Rollback()
+			return false, err
+		}
+
+		if _, err = tx.ExecContext(ctx, "DELETE FROM book_author WHERE book_id = ? AND role = ?", bookID, book.RoleAuthor); err != nil {
+			tx.Rollback()
+			return false, err
+		}
+		if _, err = tx.ExecContext(ctx, "DELETE FROM book_topic WHERE book_id = ?", bookID); err != nil {
+			tx.Rollback()
+			return false, err
+		}
+	}
+
+	for _, author := range b.Authors {
+		if _, err = tx.ExecContext(ctx,
+			"INSERT IGNORE INTO book_author (book_id , author_id) VALUES (? , ?)",
+			bookID, author.ID,
+		); err != nil {
+			tx.Rollback()
+			return false, err
+		}
+	}
+
+	for _, topic := range b.Topics {
+		if _, err = tx.ExecContext(ctx,
+			"INSERT IGNORE INTO book_topic (book_id , topic_id) VALUES (? , ?)",
+			bookID, topic.ID,
+		); err != nil {
+			tx.Rollback()
+			return false, err
+		}
+	}
+
+	if fields.PhysicalStock && b.Physical.Stock != stock {
+		movement := book.MovementAdjustment
+		if created {
+			movement = book.MovementReceipt
+		}
+		if _, err = storage.recordMovement(ctx, tx, book.Movement{
+			BookID:   bookID,
+			Type:     movement,
+			Quantity: int(b.Physical.Stock) - int(stock),
+			Reason:   "catalog import",
+		}); err != nil {
+			tx.Rollback()
+			return false, err
+		}
+	}
+
+	return created, tx.Commit()
+}
+
+// GetCatalog returns every book with its authors, topics, publisher and language resolved to names
+func (storage Storage) GetCatalog(ctx context.Context) ([]book.Book, error) {
+
+	result, err := storage.MySQL.QueryContext(ctx,
+		`SELECT b.id , b.title , b.isbn , b.pages , COALESCE(b.description, '') , b.year , b.date ,
+		b.digital_price , b.digital_discount , b.physical_price , b.physical_discount , b.physical_stock ,
+		COALESCE(b.pdf, '') , COALESCE(b.epub, '') , COALESCE(b.djvu, '') , COALESCE(b.azw, '') ,
+		COALESCE(b.txt, '') , COALESCE(b.docx, '') , l.id , l.code , b.cover_front , b.cover_back ,
+		b.publisher , COALESCE(p.name, '') , b.availability
+		FROM book b
+		JOIN language l ON l.id = b.lang_id
+		LEFT JOIN publisher p ON p.id = b.publisher
+		WHERE b.deleted_at IS NULL
+		ORDER BY b.id`,
+	)
+	if err != nil {
+		return []book.Book{}, err
+	}
+	defer result.Close()
+
+	books := []book.Book{}
+	index := map[uint]int{}
+
+	for result.Next() {
+		b := book.Book{Authors: []book.Author{}, Topics: []book.Topic{}}
+
+		if err = result.Scan(
+			&b.ID,
+			&b.Title,
+			&b.ISBN,
+			&b.Pages,
+			&b.Description,
+			&b.Year,
+			&b.CreationDate,
+			&b.Digital.Price,
+			&b.Digital.Discount,
+			&b.Physical.Price,
+			&b.Physical.Discount,
+			&b.Physical.Stock,
+			&b.Digital.PDF,
+			&b.Digital.EPUB,
+			&b.Digital.DJVU,
+			&b.Digital.AZW,
+			&b.Digital.TXT,
+			&b.Digital.DOCX,
+			&b.Language.ID,
+			&b.Language.Code,
+			&b.CoverFront,
+			&b.CoverBack,
+			&b.Publisher.ID,
+			&b.Publisher.Name,
+			&b.Availability,
+		); err != nil {
+			return []book.Book{}, err
+		}
+
+		index[b.ID] = len(books)
+		books = append(books, b)
+	}
+
+	authors, err := storage.MySQL.QueryContext(ctx,
+		`SELECT ba.book_id , a.id , a.name FROM book_author ba
+		JOIN author a ON a.id = ba.author_id WHERE ba.role = ? ORDER BY ba.id`,
+		book.RoleAuthor,
+	)
+	if err != nil {
+		return []book.Book{}, err
+	}
+	defer authors.Close()
+
+	for authors.Next() {
+		var bookID uint
+		var a book.Author
+		if err = authors.Scan(&bookID, &a.ID, &a.Name); err != nil {
+			return []book.Book{}, err
+		}
+		if i, ok := index[bookID]; ok {
+			books[i].Authors = append(books[i].Authors, a)
+		}
+	}
+
+	topics, err := storage.MySQL.QueryContext(ctx,
+		`SELECT bt.book_id , t.id , t.name FROM book_topic bt
+		JOIN topic t ON t.id = bt.topic_id ORDER BY bt.id`,
+	)
+	if err != nil {
+		return []book.Book{}, err
+	}
+	defer topics.Close()
+
+	for topics.Next() {
+		var bookID uint
+		var t book.Topic
+		if err = topics.Scan(&bookID, &t.ID, &t.Name); err != nil {
+			return []book.Book{}, err
+		}
+		if i, ok := index[bookID]; ok {
+			books[i].Topics = append(books[i].Topics, t)
+		}
+	}
+
+	return books, nil
+}
+
+func (storage Storage) CreateImportJob(ctx context.Context, job book.ImportJob) (book.ImportJob, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		"INSERT INTO import_job (format , dry_run , status , total , start_date) VALUES (?,?,?,?,?)",
+	)
+	if err != nil {
+		return book.ImportJob{}, err
+	}
+	defer stmt.Close()
+
+	job.Status = book.ImportRunning
+	job.StartDate = time.Now().Format("2006-01-02 15:04:05")
+
+	result, err := stmt.ExecContext(ctx,
+		job.Format,
+		job.DryRun,
+		job.Status,
+		job.Total,
+		job.StartDate,
+	)
+	if err != nil {
+		return book.ImportJob{}, err
+	}
+
+	jobID, err := result.LastInsertId()
+	if err != nil {
+		return book.ImportJob{}, err
+	}
+	job.ID = uint(jobID)
+
+	return job, nil
+}
+
+func (storage Storage) FinishImportJob(ctx context.Context, job book.ImportJob) error {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		`UPDATE import_job SET status = ? , created = ? , updated = ? , failed = ? , finish_date = ?
+		WHERE id = ?`,
+	)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	if _, err = stmt.ExecContext(ctx,
+		job.Status,
+		job.Created,
+		job.Updated,
+		job.Failed,
+		time.Now().Format("2006-01-02 15:04:05"),
+		job.ID,
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (storage Storage) AddImportError(ctx context.Context, jobID uint, e book.ImportError) error {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		"INSERT INTO import_error (job_id , `row` , isbn , message) VALUES (?,?,?,?)",
+	)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	message := e.Message
+	if len(message) > 255 {
+		message = message[:255]
+	}
+
+	if _, err = stmt.ExecContext(ctx, jobID, e.Row, e.ISBN, message); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (storage Storage) GetImportJob(ctx context.Context, jobID uint) (book.ImportJob, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		`SELECT id , format , dry_run , status , total , created , updated , failed ,
+		start_date , COALESCE(finish_date, '') FROM import_job WHERE id = ?`,
+	)
+	if err != nil {
+		return book.ImportJob{}, err
+	}
+	defer stmt.Close()
+
+	job, err := scanImportJob(stmt.QueryRowContext(ctx, jobID))
+	if err != nil {
+		return book.ImportJob{}, err
+	}
+
+	stmt, err = storage.MySQL.PrepareContext(ctx,
+		"SELECT `row` , isbn , message FROM import_error WHERE job_id = ? ORDER BY `row`",
+	)
+	if err != nil {
+		return book.ImportJob{}, err
+	}
+	defer stmt.Close()
+
+	result, err := stmt.QueryContext(ctx, jobID)
+	if err != nil {
+		return book.ImportJob{}, err
+	}
+	defer result.Close()
+
+	for result.Next() {
+		var e book.ImportError
+		if err = result.Scan(&e.Row, &e.ISBN, &e.Message); err != nil {
+			return book.ImportJob{}, err
+		}
+		job.Errors = append(job.Errors, e)
+	}
+
+	return job, nil
+}
+
+func (storage Storage) GetImportJobs(ctx context.Context) ([]book.ImportJob, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		`SELECT id , format , dry_run , status , total , created , updated , failed ,
+		start_date , COALESCE(finish_date, '') FROM import_job ORDER BY id DESC`,
+	)
+	if err != nil {
+		return []book.ImportJob{}, err
+	}
+	defer stmt.Close()
+
+	result, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return []book.ImportJob{}, err
+	}
+	defer result.Close()
+
+	jobs := []book.ImportJob{}
+	for result.Next() {
+		job, err := scanImportJob(result)
+		if err != nil {
+			return []book.ImportJob{}, err
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanImportJob(row scanner) (book.ImportJob, error) {
+
+	job := book.ImportJob{Errors: []book.ImportError{}}
+	if err := row.Scan(
+		&job.ID,
+		&job.Format,
+		&job.DryRun,
+		&job.Status,
+		&job.Total,
+		&job.Created,
+		&job.Updated,
+		&job.Failed,
+		&job.StartDate,
+		&job.FinishDate,
+	); err != nil {
+		return book.ImportJob{}, err
+	}
+
+	return job, nil
+}
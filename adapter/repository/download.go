@@ -0,0 +1,322 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/XBozorg/bookstore/config"
+	"github.com/XBozorg/bookstore/entity/book"
+)
+
+func (storage Storage) GetBookFiles(ctx context.Context, bookID uint) (book.Digital, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		"SELECT pdf , epub , djvu , azw , txt , docx FROM book WHERE id = ?",
+	)
+	if err != nil {
+		return book.Digital{}, err
+	}
+	defer stmt.Close()
+
+	result := stmt.QueryRowContext(ctx, bookID)
+
+	var pdf, epub, djvu, azw, txt, docx sql.NullString
+	if err = result.Scan(
+		&pdf,
+		&epub,
+		&djvu,
+		&azw,
+		&txt,
+		&docx,
+	); err != nil {
+		return book.Digital{}, err
+	}
+
+	return book.Digital{
+		PDF:  pdf.String,
+		EPUB: epub.String,
+		DJVU: djvu.String,
+		AZW:  azw.String,
+		TXT:  txt.String,
+		DOCX: docx.String,
+	}, nil
+}
+
+// AddDownload logs the first request of a download link, the requests resuming it
+// carry the same token and aren't counted again. The user rows are locked so that
+// concurrent downloads can't get past the limit, 0 for unlimited downloads.
+func (storage Storage) AddDownload(ctx context.Context, d book.Download, limit uint) error {
+
+	tx, err := storage.MySQL.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var locked string
+	if err = tx.QueryRowContext(ctx,
+		"SELECT id FROM user WHERE id = ? FOR UPDATE",
+		d.UserID,
+	).Scan(&locked); err != nil {
+		return err
+	}
+
+	var counted bool
+	if err = tx.QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM download WHERE token = ? AND user_id = ?)",
+		d.Token,
+		d.UserID,
+	).Scan(&counted); err != nil {
+		return err
+	}
+	if counted {
+		return tx.Commit()
+	}
+
+	if limit != 0 {
+		var count uint
+		if err = tx.QueryRowContext(ctx,
+			"SELECT COUNT(*) FROM download WHERE user_id = ? AND book_id = ?",
+			d.UserID,
+			d.BookID,
+		).Scan(&count); err != nil {
+			return err
+		}
+
+		if count >= limit {
+			return errors.New("download limit reached")
+		}
+	}
+
+	if _, err = tx.ExecContext(ctx,
+		`INSERT INTO download (user_id , book_id , format , ip , user_agent , token , date)
+		VALUES (?,?,?,?,?,?,?)`,
+		d.UserID,
+		d.BookID,
+		d.Format,
+		d.IP,
+		d.UserAgent,
+		d.Token,
+		time.Now().Format("2006-01-02 15:04:05"),
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (storage Storage) CountUserDownloads(ctx context.Context, userID string, bookID uint) (uint, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		"SELECT COUNT(*) FROM download WHERE user_id = ? AND book_id = ?",
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	result := stmt.QueryRowContext(ctx, userID, bookID)
+
+	var count uint
+	if err = result.Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// GetDownloadLimit returns the user specific limit of a book if set, otherwise the book-wide limit,
+// falling back to the configured default. 0 means unlimited.
+func (storage Storage) GetDownloadLimit(ctx context.Context, userID string, bookID uint) (uint, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		`SELECT max_downloads FROM download_limit
+		WHERE book_id = ? AND (user_id = ? OR user_id = '')
+		ORDER BY user_id DESC LIMIT 1`,
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	result := stmt.QueryRowContext(ctx, bookID, userID)
+
+	var max uint
+	if err = result.Scan(&max); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return config.Conf.GetDownloadConfig().MaxDownloads, nil
+		}
+		return 0, err
+	}
+
+	return max, nil
+}
+
+func (storage Storage) SetDownloadLimit(ctx context.Context, limit book.DownloadLimit) error {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		`INSERT INTO download_limit (book_id , user_id , max_downloads) VALUES (?,?,?)
+		ON DUPLICATE KEY UPDATE max_downloads = VALUES(max_downloads)`,
+	)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	if _, err = stmt.ExecContext(ctx,
+		limit.BookID,
+		limit.UserID,
+		limit.Max,
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (storage Storage) GetDownloads(ctx context.Context) ([]book.Download, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		`SELECT id , user_id , book_id , format , ip , user_agent , date
+		FROM download ORDER BY date DESC`,
+	)
+	if err != nil {
+		return []book.Download{}, err
+	}
+	defer stmt.Close()
+
+	result, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return []book.Download{}, err
+	}
+	defer result.Close()
+
+	return scanDownloads(result)
+}
+
+func (storage Storage) GetUserDownloads(ctx context.Context, userID string) ([]book.Download, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		`SELECT id , user_id , book_id , format , ip , user_agent , date
+		FROM download WHERE user_id = ? ORDER BY date DESC`,
+	)
+	if err != nil {
+		return []book.Download{}, err
+	}
+	defer stmt.Close()
+
+	result, err := stmt.QueryContext(ctx, userID)
+	if err != nil {
+		return []book.Download{}, err
+	}
+	defer result.Close()
+
+	return scanDownloads(result)
+}
+
+func scanDownloads(result *sql.Rows) ([]book.Download, error) {
+
+	downloads := []book.Download{}
+	for result.Next() {
+		var d book.Download
+		var ua sql.NullString
+
+		if err := result.Scan(
+			&d.ID,
+			&d.UserID,
+			&d.BookID,
+			&d.Format,
+			&d.IP,
+			&ua,
+			&d.Date,
+		); err != nil {
+			return []book.Download{}, err
+		}
+		d.UserAgent = ua.String
+
+		downloads = append(downloads, d)
+	}
+
+	return downloads, nil
+}
+
+// GetDownloadActivity groups downloads by user and book, returning the pairs downloaded
+// from at least minIPs distinct IP addresses (a hint of account sharing)
+func (storage Storage) GetDownloadActivity(ctx context.Context, minIPs uint) ([]book.DownloadActivity, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		`SELECT user_id , book_id , COUNT(*) , COUNT(DISTINCT ip) , COUNT(DISTINCT user_agent) , MAX(date)
+		FROM download
+		GROUP BY user_id , book_id
+		HAVING COUNT(DISTINCT ip) >= ?
+		ORDER BY COUNT(DISTINCT ip) DESC , COUNT(*) DESC`,
+	)
+	if err != nil {
+		return []book.DownloadActivity{}, err
+	}
+	defer stmt.Close()
+
+	result, err := stmt.QueryContext(ctx, minIPs)
+	if err != nil {
+		return []book.DownloadActivity{}, err
+	}
+	defer result.Close()
+
+	activities := []book.DownloadActivity{}
+	for result.Next() {
+		var a book.DownloadActivity
+
+		if err = result.Scan(
+			&a.UserID,
+			&a.BookID,
+			&a.Downloads,
+			&a.DistinctIPs,
+			&a.DistinctAgents,
+			&a.LastDownload,
+		); err != nil {
+			return []book.DownloadActivity{}, err
+		}
+
+		activities = append(activities, a)
+	}
+
+	return activities, nil
+}
+
+// GetWatermark returns the details of the user with the first paid order granting the user
+// a digital copy of the book, bought or received as a gift
+func (storage Storage) GetWatermark(ctx context.Context, userID string, bookID uint) (book.Watermark, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		`SELECT u.firstname , u.lastname , u.email , o.id
+		FROM item i
+		JOIN orders o ON o.id = i.order_id
+		LEFT JOIN book_gift g ON g.item_id = i.id
+		JOIN user u ON u.id = ?
+		WHERE i.book_id = ? AND `+digitalAccess+`
+		ORDER BY o.id LIMIT 1`,
+	)
+	if err != nil {
+		return book.Watermark{}, err
+	}
+	defer stmt.Close()
+
+	result := stmt.QueryRowContext(ctx, append([]interface{}{userID, bookID}, accessArgs(userID)...)...)
+
+	var firstname, lastname string
+	var w book.Watermark
+	if err = result.Scan(
+		&firstname,
+		&lastname,
+		&w.Email,
+		&w.OrderID,
+	); err != nil {
+		return book.Watermark{}, err
+	}
+	w.Name = strings.TrimSpace(firstname + " " + lastname)
+
+	return w, nil
+}
@@ -0,0 +1,350 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/XBozorg/bookstore/entity/order"
+	"github.com/XBozorg/bookstore/entity/user"
+)
+
+// digitalAccess matches the digital items the user reads: those of the paid orders of the user
+// but the gifted ones, and the gifts the user claimed, pre-orders once released. i is the item,
+// o its order and g its gift, the arguments come from accessArgs
+const digitalAccess = `i.type = ? AND i.preorder = 0 AND o.status IN (? , ? , ?)
+	AND ((o.user_id = ? AND g.id IS NULL) OR (g.recipient_id = ? AND g.status = ?))`
+
+func accessArgs(userID string) []interface{} {
+	return []interface{}{
+		order.Digital,
+		order.StatusPaid,
+		order.StatusVerified,
+		order.StatusShipped,
+		userID,
+		userID,
+		order.GiftClaimed,
+	}
+}
+
+// SetItemGift makes a digital item of the open order of the buyer a gift, or changes
+// the recipient and delivery of the gift it is already
+func (storage Storage) SetItemGift(ctx context.Context, gift order.Gift) (order.Gift, error) {
+
+	tx, err := storage.MySQL.BeginTx(ctx, nil)
+	if err != nil {
+		return order.Gift{}, err
+	}
+	defer tx.Rollback()
+
+	if err = tx.QueryRowContext(ctx,
+		`SELECT i.book_id FROM item i JOIN orders o ON o.id = i.order_id
+		WHERE i.id = ? AND i.order_id = ? AND o.user_id = ? AND o.status = ?
+		AND i.type = ? AND i.bundle = 0 AND i.box_set_id IS NULL FOR UPDATE`,
+		gift.ItemID,
+		gift.OrderID,
+		gift.BuyerID,
+		order.StatusCreated,
+		order.Digital,
+	).Scan(&gift.BookID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return order.Gift{}, errors.New("item can not be a gift")
+		}
+		return order.Gift{}, err
+	}
+
+	if gift.Token, err = order.NewGiftToken(); err != nil {
+		return order.Gift{}, err
+	}
+	gift.Status = order.GiftPending
+
+	if _, err = tx.ExecContext(ctx,
+		`INSERT INTO book_gift (item_id , buyer_id , recipient_email , message , deliver_at , token , status)
+		VALUES (? , ? , ? , ? , NULLIF(? , '') , ? , ?)
+		ON DUPLICATE KEY UPDATE recipient_email = VALUES(recipient_email) , message = VALUES(message) ,
+		deliver_at = VALUES(deliver_at)`,
+		gift.ItemID,
+		gift.BuyerID,
+		gift.RecipientEmail,
+		gift.Message,
+		gift.DeliverAt,
+		gift.Token,
+		gift.Status,
+	); err != nil {
+		return order.Gift{}, err
+	}
+
+	if err = tx.QueryRowContext(ctx,
+		"SELECT id FROM book_gift WHERE item_id = ?",
+		gift.ItemID,
+	).Scan(&gift.ID); err != nil {
+		return order.Gift{}, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return order.Gift{}, err
+	}
+
+	gift.Token = ""
+	return gift, nil
+}
+
+// RemoveItemGift gives the item of the open order back to the buyer
+func (storage Storage) RemoveItemGift(ctx context.Context, itemID, orderID uint, userID string) error {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		`DELETE g FROM book_gift g
+		JOIN item i ON i.id = g.item_id
+		JOIN orders o ON o.id = i.order_id
+		WHERE g.item_id = ? AND i.order_id = ? AND o.user_id = ? AND o.status = ?`,
+	)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(ctx, itemID, orderID, userID, order.StatusCreated)
+	if err != nil {
+		return err
+	}
+
+	removed, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if removed == 0 {
+		return errors.New("gift does not exist")
+	}
+
+	return nil
+}
+
+// settleGifts voids the gifts of a cancelled order that were not claimed,
+// a claimed gift loses its access with the order anyway
+func settleGifts(ctx context.Context, tx *sql.Tx, orderID uint) error {
+
+	_, err := tx.ExecContext(ctx,
+		`UPDATE book_gift g
+		JOIN item i ON i.id = g.item_id
+		JOIN orders o ON o.id = i.order_id
+		SET g.status = ?
+		WHERE i.order_id = ? AND o.status = ? AND g.status IN (? , ?)`,
+		order.GiftVoid,
+		orderID,
+		order.StatusCancelled,
+		order.GiftPending,
+		order.GiftSent,
+	)
+	return err
+}
+
+const giftSelect = `SELECT g.id , g.item_id , i.order_id , i.book_id , g.buyer_id , g.recipient_email ,
+	COALESCE(g.recipient_id, '') , g.message , COALESCE(g.deliver_at, '') , g.token , g.status ,
+	COALESCE(g.sent_at, '') , COALESCE(g.claimed_at, '')
+	FROM book_gift g
+	JOIN item i ON i.id = g.item_id
+	JOIN orders o ON o.id = i.order_id `
+
+func getGifts(ctx context.Context, q querier, query string, args ...interface{}) ([]order.Gift, error) {
+
+	result, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return []order.Gift{}, err
+	}
+	defer result.Close()
+
+	gifts := []order.Gift{}
+	for result.Next() {
+		var g order.Gift
+
+		if err = result.Scan(
+			&g.ID,
+			&g.ItemID,
+			&g.OrderID,
+			&g.BookID,
+			&g.BuyerID,
+			&g.RecipientEmail,
+			&g.RecipientID,
+			&g.Message,
+			&g.DeliverAt,
+			&g.Token,
+			&g.Status,
+			&g.SentAt,
+			&g.ClaimedAt,
+		); err != nil {
+			return []order.Gift{}, err
+		}
+
+		gifts = append(gifts, g)
+	}
+
+	return gifts, nil
+}
+
+// GetUserGifts returns the gifts the user bought and those the user claimed, newest first,
+// the claim link stays with the recipient
+func (storage Storage) GetUserGifts(ctx context.Context, userID string) ([]order.Gift, []order.Gift, error) {
+
+	sent, err := getGifts(ctx, storage.MySQL, giftSelect+"WHERE g.buyer_id = ? ORDER BY g.id DESC", userID)
+	if err != nil {
+		return []order.Gift{}, []order.Gift{}, err
+	}
+
+	received, err := getGifts(ctx, storage.MySQL, giftSelect+"WHERE g.recipient_id = ? ORDER BY g.id DESC", userID)
+	if err != nil {
+		return []order.Gift{}, []order.Gift{}, err
+	}
+
+	for i := range sent {
+		sent[i].Token = ""
+	}
+	for i := range received {
+		received[i].Token = ""
+	}
+
+	return sent, received, nil
+}
+
+// GetDueGifts returns the gifts of the paid orders whose delivery date came
+func (storage Storage) GetDueGifts(ctx context.Context, now string) ([]order.Gift, error) {
+	return getGifts(ctx, storage.MySQL,
+		giftSelect+"WHERE g.status = ? AND o.status IN (? , ? , ?) AND (g.deliver_at IS NULL OR g.deliver_at <= ?) ORDER BY g.id",
+		order.GiftPending,
+		order.StatusPaid,
+		order.StatusVerified,
+		order.StatusShipped,
+		now,
+	)
+}
+
+func (storage Storage) SetGiftSent(ctx context.Context, giftID uint) error {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		"UPDATE book_gift SET status = ? , sent_at = ? WHERE id = ? AND status = ?",
+	)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx,
+		order.GiftSent,
+		time.Now().Format("2006-01-02 15:04:05"),
+		giftID,
+		order.GiftPending,
+	)
+	return err
+}
+
+// GetGift returns the gift of a claim link without the link itself
+func (storage Storage) GetGift(ctx context.Context, token string) (order.Gift, error) {
+
+	gifts, err := getGifts(ctx, storage.MySQL, giftSelect+"WHERE g.token = ? AND g.status IN (? , ?)",
+		token,
+		order.GiftSent,
+		order.GiftClaimed,
+	)
+	if err != nil {
+		return order.Gift{}, err
+	}
+	if len(gifts) == 0 {
+		return order.Gift{}, errors.New("gift does not exist")
+	}
+
+	gifts[0].Token = ""
+	return gifts[0], nil
+}
+
+// ClaimGift gives the book of a sent gift to the user
+func (storage Storage) ClaimGift(ctx context.Context, token, userID string) (order.Gift, error) {
+
+	tx, err := storage.MySQL.BeginTx(ctx, nil)
+	if err != nil {
+		return order.Gift{}, err
+	}
+	defer tx.Rollback()
+
+	gift, err := claimGift(ctx, tx, token, userID)
+	if err != nil {
+		return order.Gift{}, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return order.Gift{}, err
+	}
+
+	return gift, nil
+}
+
+// ClaimGiftWithSignup registers the recipient and gives it the book of the gift at once,
+// no account is left behind when the gift can't be claimed
+func (storage Storage) ClaimGiftWithSignup(ctx context.Context, token string, u user.User, referralCode string) (user.User, order.Gift, error) {
+
+	tx, err := storage.MySQL.BeginTx(ctx, nil)
+	if err != nil {
+		return user.User{}, order.Gift{}, err
+	}
+	defer tx.Rollback()
+
+	u, err = createUser(ctx, tx, u, referralCode)
+	if err != nil {
+		return user.User{}, order.Gift{}, err
+	}
+
+	gift, err := claimGift(ctx, tx, token, u.ID)
+	if err != nil {
+		return user.User{}, order.Gift{}, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return user.User{}, order.Gift{}, err
+	}
+
+	return u, gift, nil
+}
+
+func claimGift(ctx context.Context, tx *sql.Tx, token, userID string) (order.Gift, error) {
+
+	var (
+		giftID uint
+		status string
+	)
+	if err := tx.QueryRowContext(ctx,
+		"SELECT id , status FROM book_gift WHERE token = ? FOR UPDATE",
+		token,
+	).Scan(&giftID, &status); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return order.Gift{}, errors.New("gift does not exist")
+		}
+		return order.Gift{}, err
+	}
+
+	switch status {
+	case order.GiftSent:
+	case order.GiftClaimed:
+		return order.Gift{}, errors.New("gift already claimed")
+	case order.GiftPending:
+		return order.Gift{}, errors.New("gift does not exist")
+	default:
+		return order.Gift{}, errors.New("gift is void")
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE book_gift SET status = ? , recipient_id = ? , claimed_at = ? WHERE id = ?",
+		order.GiftClaimed,
+		userID,
+		time.Now().Format("2006-01-02 15:04:05"),
+		giftID,
+	); err != nil {
+		return order.Gift{}, err
+	}
+
+	gifts, err := getGifts(ctx, tx, giftSelect+"WHERE g.id = ?", giftID)
+	if err != nil {
+		return order.Gift{}, err
+	}
+
+	gifts[0].Token = ""
+	return gifts[0], nil
+}
@@ -0,0 +1,262 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/XBozorg/bookstore/entity/order"
+	"github.com/XBozorg/bookstore/entity/wallet"
+)
+
+func (storage Storage) DoesGiftCardExist(ctx context.Context, giftCardID uint, userID string) (bool, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM gift_card WHERE id = ? AND buyer_id = ?)",
+	)
+	if err != nil {
+		return false, err
+	}
+	defer stmt.Close()
+
+	var exists bool
+	if err = stmt.QueryRowContext(ctx, giftCardID, userID).Scan(&exists); err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
+
+// AddGiftCard puts a gift card in the open order of the buyer, its code is
+// redeemable once the order is paid
+func (storage Storage) AddGiftCard(ctx context.Context, card wallet.GiftCard) (wallet.GiftCard, error) {
+
+	tx, err := storage.MySQL.BeginTx(ctx, nil)
+	if err != nil {
+		return wallet.GiftCard{}, err
+	}
+	defer tx.Rollback()
+
+	if card.OrderID, err = storage.CheckOpenOrder(ctx, tx, card.BuyerID); err != nil {
+		return wallet.GiftCard{}, err
+	}
+
+	if card.Code, err = wallet.NewGiftCardCode(); err != nil {
+		return wallet.GiftCard{}, err
+	}
+	card.Status = wallet.GiftCardPending
+	card.CreationDate = time.Now().Format("2006-01-02 15:04:05")
+
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO gift_card (code , amount , buyer_id , order_id , recipient_email , message , status , creation_date)
+		VALUES (? , ? , ? , ? , ? , ? , ? , ?)`,
+		card.Code,
+		card.Amount,
+		card.BuyerID,
+		card.OrderID,
+		card.RecipientEmail,
+		card.Message,
+		card.Status,
+		card.CreationDate,
+	)
+	if err != nil {
+		return wallet.GiftCard{}, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return wallet.GiftCard{}, err
+	}
+	card.ID = uint(id)
+
+	if err = storage.repriceOrder(ctx, tx, card.OrderID); err != nil {
+		return wallet.GiftCard{}, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return wallet.GiftCard{}, err
+	}
+
+	card.Code = ""
+	return card, nil
+}
+
+// RemoveGiftCard takes a gift card out of the open order of the buyer
+func (storage Storage) RemoveGiftCard(ctx context.Context, giftCardID uint, userID string) error {
+
+	tx, err := storage.MySQL.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var orderID uint
+	if err = tx.QueryRowContext(ctx,
+		`SELECT g.order_id FROM gift_card g JOIN orders o ON o.id = g.order_id
+		WHERE g.id = ? AND g.buyer_id = ? AND g.status = ? AND o.status = ? FOR UPDATE`,
+		giftCardID,
+		userID,
+		wallet.GiftCardPending,
+		order.StatusCreated,
+	).Scan(&orderID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New("gift card is not in an open order")
+		}
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM gift_card WHERE id = ?", giftCardID); err != nil {
+		return err
+	}
+
+	if err = storage.repriceOrder(ctx, tx, orderID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// settleGiftCards activates the gift cards of a paid order, and voids those of
+// a cancelled order, which can't have redeemed ones
+func settleGiftCards(ctx context.Context, tx *sql.Tx, orderID uint) error {
+
+	_, err := tx.ExecContext(ctx,
+		`UPDATE gift_card g JOIN orders o ON o.id = g.order_id
+		SET g.status = IF(o.status = ? , ? , ?)
+		WHERE g.order_id = ? AND o.status != ?
+		AND (g.status = ? OR (g.status = ? AND o.status = ?))`,
+		order.StatusCancelled,
+		wallet.GiftCardVoid,
+		wallet.GiftCardActive,
+		orderID,
+		order.StatusCreated,
+		wallet.GiftCardPending,
+		wallet.GiftCardActive,
+		order.StatusCancelled,
+	)
+	return err
+}
+
+const giftCardSelect = `SELECT id , IF(status = 'pending', '', code) , amount , buyer_id , order_id , recipient_email ,
+	message , status , delivered , creation_date , COALESCE(redeemed_by, '') , COALESCE(redeemed_at, '')
+	FROM gift_card `
+
+func getGiftCards(ctx context.Context, q querier, query string, args ...interface{}) ([]wallet.GiftCard, error) {
+
+	result, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return []wallet.GiftCard{}, err
+	}
+	defer result.Close()
+
+	cards := []wallet.GiftCard{}
+	for result.Next() {
+		var g wallet.GiftCard
+
+		if err = result.Scan(
+			&g.ID,
+			&g.Code,
+			&g.Amount,
+			&g.BuyerID,
+			&g.OrderID,
+			&g.RecipientEmail,
+			&g.Message,
+			&g.Status,
+			&g.Delivered,
+			&g.CreationDate,
+			&g.RedeemedBy,
+			&g.RedeemedAt,
+		); err != nil {
+			return []wallet.GiftCard{}, err
+		}
+
+		cards = append(cards, g)
+	}
+
+	return cards, nil
+}
+
+func (storage Storage) GetUserGiftCards(ctx context.Context, userID string) ([]wallet.GiftCard, error) {
+	return getGiftCards(ctx, storage.MySQL, giftCardSelect+"WHERE buyer_id = ? ORDER BY id DESC", userID)
+}
+
+// GetUndeliveredGiftCards returns the paid gift cards whose code wasn't sent yet
+func (storage Storage) GetUndeliveredGiftCards(ctx context.Context) ([]wallet.GiftCard, error) {
+	return getGiftCards(ctx, storage.MySQL,
+		giftCardSelect+"WHERE status = ? AND delivered = 0 ORDER BY id",
+		wallet.GiftCardActive,
+	)
+}
+
+func (storage Storage) SetGiftCardDelivered(ctx context.Context, giftCardID uint) error {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		"UPDATE gift_card SET delivered = 1 WHERE id = ?",
+	)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, giftCardID)
+	return err
+}
+
+// RedeemGiftCard credits the wallet of the user with an active gift card
+func (storage Storage) RedeemGiftCard(ctx context.Context, code, userID string) (wallet.Entry, error) {
+
+	tx, err := storage.MySQL.BeginTx(ctx, nil)
+	if err != nil {
+		return wallet.Entry{}, err
+	}
+	defer tx.Rollback()
+
+	var (
+		id, amount uint
+		status     string
+	)
+	if err = tx.QueryRowContext(ctx,
+		"SELECT id , amount , status FROM gift_card WHERE code = ? FOR UPDATE",
+		code,
+	).Scan(&id, &amount, &status); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return wallet.Entry{}, errors.New("gift card does not exist")
+		}
+		return wallet.Entry{}, err
+	}
+
+	switch status {
+	case wallet.GiftCardActive:
+	case wallet.GiftCardRedeemed:
+		return wallet.Entry{}, errors.New("gift card already redeemed")
+	default:
+		return wallet.Entry{}, errors.New("gift card is not active")
+	}
+
+	if _, err = tx.ExecContext(ctx,
+		"UPDATE gift_card SET status = ? , redeemed_by = ? , redeemed_at = ? WHERE id = ?",
+		wallet.GiftCardRedeemed,
+		userID,
+		time.Now().Format("2006-01-02 15:04:05"),
+		id,
+	); err != nil {
+		return wallet.Entry{}, err
+	}
+
+	e, err := addEntry(ctx, tx, wallet.Entry{
+		UserID:     userID,
+		Amount:     int(amount),
+		Type:       wallet.EntryGiftCard,
+		GiftCardID: id,
+	})
+	if err != nil {
+		return wallet.Entry{}, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return wallet.Entry{}, err
+	}
+
+	return e, nil
+}
@@ -0,0 +1,365 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/XBozorg/bookstore/entity/book"
+	"github.com/XBozorg/bookstore/entity/order"
+)
+
+// recordMovement appends a ledger entry, it runs after the stock update so the
+// balance is read from the book inside the same transaction
+func (storage Storage) recordMovement(ctx context.Context, tx *sql.Tx, m book.Movement) (uint, error) {
+
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO inventory_movement (book_id , type , quantity , balance , reason , order_id , admin_id , date)
+		SELECT id , ? , ? , physical_stock , NULLIF(? , '') , NULLIF(? , 0) , NULLIF(? , '') , ? FROM book WHERE id = ?`,
+		m.Type,
+		m.Quantity,
+		m.Reason,
+		m.OrderID,
+		m.AdminID,
+		time.Now().Format("2006-01-02 15:04:05"),
+		m.BookID,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return uint(id), nil
+}
+
+// releaseStock puts back on the shelf the copies a cancelled order reserved, the released
+// pre-orders included, as a release or as a return when the order was shipped
+func (storage Storage) releaseStock(ctx context.Context, tx *sql.Tx, orderID uint, movement string) error {
+
+	result, err := tx.QueryContext(ctx,
+		`SELECT book_id , SUM(quantity) FROM item
+		WHERE order_id = ? AND type != ? AND preorder = 0 AND quantity > 0
+		GROUP BY book_id`,
+		orderID,
+		order.Digital,
+	)
+	if err != nil {
+		return err
+	}
+
+	reserved := []order.Item{}
+	for result.Next() {
+		var i order.Item
+
+		if err = result.Scan(&i.BookID, &i.Quantity); err != nil {
+			result.Close()
+			return err
+		}
+		reserved = append(reserved, i)
+	}
+	result.Close()
+
+	for _, i := range reserved {
+
+		if _, err = tx.ExecContext(ctx,
+			"UPDATE book SET physical_stock = physical_stock + ? WHERE id = ?",
+			i.Quantity, i.BookID,
+		); err != nil {
+			return err
+		}
+
+		if _, err = storage.recordMovement(ctx, tx, book.Movement{
+			BookID:   i.BookID,
+			Type:     movement,
+			Quantity: int(i.Quantity),
+			Reason:   "order cancelled",
+			OrderID:  orderID,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MoveStock applies a signed change to the stock of a book and records it,
+// the stock never goes below zero
+func (storage Storage) MoveStock(ctx context.Context, m book.Movement) (book.Movement, error) {
+
+	tx, err := storage.MySQL.BeginTx(ctx, nil)
+	if err != nil {
+		return book.Movement{}, err
+	}
+
+	result, err := tx.ExecContext(ctx,
+		`UPDATE book SET physical_stock = CAST(physical_stock AS SIGNED) + ?
+		WHERE id = ? AND CAST(physical_stock AS SIGNED) + ? >= 0`,
+		m.Quantity, m.BookID, m.Quantity,
+	)
+	if err != nil {
+		tx.Rollback()
+		return book.Movement{}, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return book.Movement{}, err
+	}
+	if affected == 0 {
+		tx.Rollback()
+		return book.Movement{}, errors.New("stock cannot go below zero")
+	}
+
+	id, err := storage.recordMovement(ctx, tx, m)
+	if err != nil {
+		tx.Rollback()
+		return book.Movement{}, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return book.Movement{}, err
+	}
+
+	return storage.getMovement(ctx, id)
+}
+
+// Stocktake sets the stock of every counted book and records the difference
+// as a count movement, zero differences are recorded too as proof of the count
+func (storage Storage) Stocktake(ctx context.Context, counts []book.StockCount, reason, adminID string) ([]book.Movement, error) {
+
+	tx, err := storage.MySQL.BeginTx(ctx, nil)
+	if err != nil {
+		return []book.Movement{}, err
+	}
+
+	ids := []uint{}
+	for _, count := range counts {
+
+		var stock uint
+		if err = tx.QueryRowContext(ctx,
+			"SELECT physical_stock FROM book WHERE id = ? FOR UPDATE",
+			count.BookID,
+		).Scan(&stock); err != nil {
+			tx.Rollback()
+			return []book.Movement{}, err
+		}
+
+		if _, err = tx.ExecContext(ctx,
+			"UPDATE book SET physical_stock = ? WHERE id = ?",
+			count.Counted, count.BookID,
+		); err != nil {
+			tx.Rollback()
+			return []book.Movement{}, err
+		}
+
+		id, err := storage.recordMovement(ctx, tx, book.Movement{
+			BookID:   count.BookID,
+			Type:     book.MovementCount,
+			Quantity: int(count.Counted) - int(stock),
+			Reason:   reason,
+			AdminID:  adminID,
+		})
+		if err != nil {
+			tx.Rollback()
+			return []book.Movement{}, err
+		}
+		ids = append(ids, id)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return []book.Movement{}, err
+	}
+
+	movements := []book.Movement{}
+	for _, id := range ids {
+		m, err := storage.getMovement(ctx, id)
+		if err != nil {
+			return []book.Movement{}, err
+		}
+		movements = append(movements, m)
+	}
+
+	return movements, nil
+}
+
+func (storage Storage) getMovement(ctx context.Context, movementID uint) (book.Movement, error) {
+
+	movements, err := storage.getMovements(ctx,
+		`SELECT id , book_id , type , quantity , balance , COALESCE(reason, '') , COALESCE(order_id, 0) ,
+		COALESCE(admin_id, '') , date FROM inventory_movement WHERE id = ?`,
+		movementID,
+	)
+	if err != nil {
+		return book.Movement{}, err
+	}
+	if len(movements) == 0 {
+		return book.Movement{}, sql.ErrNoRows
+	}
+
+	return movements[0], nil
+}
+
+// GetMovements returns the stock history of a book, latest first
+func (storage Storage) GetMovements(ctx context.Context, bookID uint) ([]book.Movement, error) {
+	return storage.getMovements(ctx,
+		`SELECT id , book_id , type , quantity , balance , COALESCE(reason, '') , COALESCE(order_id, 0) ,
+		COALESCE(admin_id, '') , date FROM inventory_movement WHERE book_id = ? ORDER BY id DESC`,
+		bookID,
+	)
+}
+
+func (storage Storage) getMovements(ctx context.Context, query string, args ...interface{}) ([]book.Movement, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx, query)
+	if err != nil {
+		return []book.Movement{}, err
+	}
+	defer stmt.Close()
+
+	result, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return []book.Movement{}, err
+	}
+	defer result.Close()
+
+	movements := []book.Movement{}
+	for result.Next() {
+		var m book.Movement
+
+		if err = result.Scan(
+			&m.ID,
+			&m.BookID,
+			&m.Type,
+			&m.Quantity,
+			&m.Balance,
+			&m.Reason,
+			&m.OrderID,
+			&m.AdminID,
+			&m.Date,
+		); err != nil {
+			return []book.Movement{}, err
+		}
+		movements = append(movements, m)
+	}
+
+	return movements, nil
+}
+
+// SetReorderThreshold sets the stock level at or below which the book is reported as low
+func (storage Storage) SetReorderThreshold(ctx context.Context, bookID, threshold uint) error {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		`INSERT INTO stock_alert (book_id , threshold) VALUES (? , ?)
+		ON DUPLICATE KEY UPDATE threshold = VALUES(threshold)`,
+	)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, bookID, threshold)
+	return err
+}
+
+// UpdateSalesVelocity recomputes the copies sold per day of every live book
+// from the physical and bundle items of the orders paid in the last days
+func (storage Storage) UpdateSalesVelocity(ctx context.Context, days uint) error {
+
+	now := time.Now()
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		`INSERT INTO stock_alert (book_id , velocity , computed)
+		SELECT b.id , COALESCE(SUM(i.quantity), 0) / ? , ? FROM book b
+		LEFT JOIN item i ON i.book_id = b.id AND i.type IN (? , ?) AND i.order_id IN (
+			SELECT id FROM orders WHERE status IN (? , ? , ?) AND receipt_date >= ?
+		)
+		WHERE b.deleted_at IS NULL
+		GROUP BY b.id
+		ON DUPLICATE KEY UPDATE velocity = VALUES(velocity) , computed = VALUES(computed)`,
+	)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx,
+		days,
+		now.Format("2006-01-02 15:04:05"),
+		order.Physical,
+		order.Bundle,
+		order.StatusPaid,
+		order.StatusVerified,
+		order.StatusShipped,
+		now.AddDate(0, 0, -int(days)).Format("2006-01-02 15:04:05"),
+	)
+	return err
+}
+
+// GetStockAlerts returns the reorder state of every live book sold in print
+func (storage Storage) GetStockAlerts(ctx context.Context) ([]book.StockAlert, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		`SELECT b.id , b.title , b.isbn , b.publisher , COALESCE(p.name, '') , b.physical_stock ,
+		COALESCE(s.threshold, 0) , COALESCE(s.velocity, 0) , COALESCE(s.alerted, 0)
+		FROM book b
+		LEFT JOIN publisher p ON p.id = b.publisher
+		LEFT JOIN stock_alert s ON s.book_id = b.id
+		WHERE b.deleted_at IS NULL AND b.physical_price > 0
+		ORDER BY b.publisher , b.id`,
+	)
+	if err != nil {
+		return []book.StockAlert{}, err
+	}
+	defer stmt.Close()
+
+	result, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return []book.StockAlert{}, err
+	}
+	defer result.Close()
+
+	alerts := []book.StockAlert{}
+	for result.Next() {
+		var a book.StockAlert
+
+		if err = result.Scan(
+			&a.BookID,
+			&a.Title,
+			&a.ISBN,
+			&a.Publisher.ID,
+			&a.Publisher.Name,
+			&a.Stock,
+			&a.Threshold,
+			&a.Velocity,
+			&a.Alerted,
+		); err != nil {
+			return []book.StockAlert{}, err
+		}
+		alerts = append(alerts, a)
+	}
+
+	return alerts, nil
+}
+
+// SetStockAlerted marks that the staff was notified of the low stock of a book,
+// it's cleared once the stock climbs back above the threshold
+func (storage Storage) SetStockAlerted(ctx context.Context, bookID uint, alerted bool) error {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		`INSERT INTO stock_alert (book_id , alerted) VALUES (? , ?)
+		ON DUPLICATE KEY UPDATE alerted = VALUES(alerted)`,
+	)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, bookID, alerted)
+	return err
+}
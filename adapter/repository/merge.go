@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/XBozorg/bookstore/entity/book"
+)
+
+// re-pointing statements of each kind, they take (intoID, fromID) in order
+var mergeQueries = map[string][]string{
+	book.KindAuthor: {
+		// drop the credits the target already holds on the same book and role
+		`DELETE FROM book_author WHERE (book_id , role) IN 
+		( SELECT book_id , role FROM ( SELECT book_id , role FROM book_author WHERE author_id = ? ) AS target ) 
+		AND author_id = ?`,
+		"UPDATE book_author SET author_id = ? WHERE author_id = ?",
+	},
+	book.KindTopic: {
+		`DELETE FROM book_topic WHERE book_id IN 
+		( SELECT book_id FROM ( SELECT book_id FROM book_topic WHERE topic_id = ? ) AS target ) 
+		AND topic_id = ?`,
+		"UPDATE book_topic SET topic_id = ? WHERE topic_id = ?",
+		"UPDATE topic SET parent_id = ? WHERE parent_id = ?",
+	},
+	book.KindPublisher: {
+		"UPDATE book SET publisher = ? WHERE publisher = ?",
+	},
+}
+
+// Merge moves every reference of fromID to intoID, deletes fromID and leaves a redirect
+// from it, redirects that pointed to fromID are moved along
+func (storage Storage) Merge(ctx context.Context, kind string, fromID, intoID uint) error {
+
+	queries, ok := mergeQueries[kind]
+	if !ok {
+		return fmt.Errorf("unknown merge kind %q", kind)
+	}
+
+	tx, err := storage.MySQL.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, query := range queries {
+		if _, err = tx.ExecContext(ctx, query, intoID, fromID); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if _, err = tx.ExecContext(ctx,
+		"UPDATE merge_redirect SET new_id = ? WHERE kind = ? AND new_id = ?",
+		intoID, kind, fromID,
+	); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx,
+		"INSERT INTO merge_redirect (kind , old_id , new_id , date) VALUES (? , ? , ? , ?)",
+		kind, fromID, intoID, time.Now().Format("2006-01-02 15:04:05"),
+	); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// kind is one of the mergeQueries keys, which are also the table names
+	if _, err = tx.ExecContext(ctx, "DELETE FROM "+kind+" WHERE id = ?", fromID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (storage Storage) GetRedirect(ctx context.Context, kind string, oldID uint) (book.Redirect, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		"SELECT new_id , date FROM merge_redirect WHERE kind = ? AND old_id = ?",
+	)
+	if err != nil {
+		return book.Redirect{}, err
+	}
+	defer stmt.Close()
+
+	r := book.Redirect{Kind: kind, OldID: oldID}
+	if err = stmt.QueryRowContext(ctx, kind, oldID).Scan(&r.NewID, &r.Date); err != nil {
+		return book.Redirect{}, err
+	}
+
+	return r, nil
+}
+
+func (storage Storage) GetRedirects(ctx context.Context) ([]book.Redirect, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		"SELECT kind , old_id , new_id , date FROM merge_redirect ORDER BY date DESC",
+	)
+	if err != nil {
+		return []book.Redirect{}, err
+	}
+	defer stmt.Close()
+
+	result, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return []book.Redirect{}, err
+	}
+	defer result.Close()
+
+	redirects := []book.Redirect{}
+	for result.Next() {
+		var r book.Redirect
+
+		if err = result.Scan(
+			&r.Kind,
+			&r.OldID,
+			&r.NewID,
+			&r.Date,
+		); err != nil {
+			return []book.Redirect{}, err
+		}
+		redirects = append(redirects, r)
+	}
+
+	return redirects, nil
+}
@@ -9,18 +9,10 @@ import (
 
 	"github.com/XBozorg/bookstore/entity/book"
 	"github.com/XBozorg/bookstore/entity/order"
+	"github.com/XBozorg/bookstore/entity/pricing"
+	"github.com/XBozorg/bookstore/entity/wallet"
 )
 
-type itemPrice struct {
-	BookID           uint
-	Type             uint
-	Quantity         uint
-	DigitalPrice     uint
-	DigitalDiscount  uint
-	PhysicalPrice    uint
-	PhysicalDiscount uint
-}
-
 func (storage Storage) DoesOrderOpen(ctx context.Context, orderID uint) (bool, error) {
 
 	stmt, err := storage.MySQL.PrepareContext(ctx,
@@ -88,7 +80,8 @@ func (storage Storage) DoesPromoCodeExist(ctx context.Context, promoCode, userID
 
 	stmt, err := storage.MySQL.PrepareContext(ctx,
 		`SELECT 1 FROM promo 
-		WHERE id IN (SELECT promo_id FROM promo_user WHERE user_id = ?) AND promo.code = ?`,
+		WHERE (public = 1 OR id IN (SELECT promo_id FROM promo_user WHERE user_id = ?)) AND promo.code = ?
+		LIMIT 1`,
 	)
 	if err != nil {
 		return false, err
@@ -247,14 +240,20 @@ func (storage Storage) AddPhysicalItem(ctx context.Context, tx *sql.Tx, item ord
 		return err
 	}
 
-	return nil
+	_, err = storage.recordMovement(ctx, tx, book.Movement{
+		BookID:   item.BookID,
+		Type:     book.MovementReservation,
+		Quantity: -int(item.Quantity),
+		OrderID:  orderID,
+	})
+	return err
 }
 
 func (storage Storage) AddBundleItem(ctx context.Context, tx *sql.Tx, item order.Item, orderID uint) error {
 
 	stmt, err := tx.PrepareContext(ctx,
-		`INSERT INTO item (book_id , type , quantity , order_id) 
-		VALUES (?,?,?,?) , (?,?,?,?)`,
+		`INSERT INTO item (book_id , type , quantity , order_id , bundle) 
+		VALUES (?,?,?,?,1) , (?,?,?,?,1)`,
 	)
 	if err != nil {
 		return err
@@ -288,7 +287,13 @@ func (storage Storage) AddBundleItem(ctx context.Context, tx *sql.Tx, item order
 		return err
 	}
 
-	return nil
+	_, err = storage.recordMovement(ctx, tx, book.Movement{
+		BookID:   item.BookID,
+		Type:     book.MovementReservation,
+		Quantity: -int(item.Quantity),
+		OrderID:  orderID,
+	})
+	return err
 }
 
 func (storage Storage) AddItem(ctx context.Context, item order.Item, userID string) error {
@@ -299,6 +304,32 @@ func (storage Storage) AddItem(ctx context.Context, item order.Item, userID stri
 	}
 	defer tx.Rollback()
 
+	if item.Type == order.BoxSet {
+		orderID, err := storage.CheckOpenOrder(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+
+		if err = storage.addBoxSetItem(ctx, tx, item, orderID); err != nil {
+			return err
+		}
+
+		if err = storage.repriceOrder(ctx, tx, orderID); err != nil {
+			return err
+		}
+
+		return tx.Commit()
+	}
+
+	// books not released yet are sold as pre-orders, no stock is reserved until the release
+	preorder, err := storage.CheckPreorder(ctx, tx, item.BookID)
+	if err != nil {
+		return err
+	}
+	if preorder != book.NotAvailable {
+		return storage.addPreorderItem(ctx, tx, item, preorder, userID)
+	}
+
 	err = storage.CheckQuantity(ctx, tx, item.Quantity, item.BookID)
 	if err != nil {
 		return err
@@ -343,12 +374,65 @@ func (storage Storage) AddItem(ctx context.Context, item order.Item, userID stri
 		return errors.New("type / availability does not match")
 	}
 
-	price, err := storage.CalculateItemPrice(ctx, tx, item)
+	if err = storage.repriceOrder(ctx, tx, orderID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// addPreorderItem adds the item flagged as a pre-order and commits, it's charged with the
+// rest of the order while the stock and the download access wait for the release
+func (storage Storage) addPreorderItem(ctx context.Context, tx *sql.Tx, item order.Item, preorder uint, userID string) error {
+
+	orderID, err := storage.CheckOpenOrder(ctx, tx, userID)
 	if err != nil {
 		return err
 	}
 
-	if err = storage.AddTotal(ctx, tx, price, orderID); err != nil {
+	lines := []order.Item{}
+	switch {
+	case item.Type == order.Bundle && preorder == book.BundleAvailable:
+		lines = append(lines,
+			order.Item{Type: order.Digital, Quantity: 1, Bundled: true},
+			order.Item{Type: order.Physical, Quantity: item.Quantity, Bundled: true},
+		)
+
+	case item.Type == order.Physical && preorder&book.PhysicalAvailable != 0:
+		lines = append(lines, order.Item{Type: order.Physical, Quantity: item.Quantity})
+
+	case item.Type == order.Digital && preorder&book.DigitalAvailable != 0:
+		lines = append(lines, order.Item{Type: order.Digital, Quantity: 1})
+
+	case item.Type > 2:
+		return errors.New("invalid item type")
+
+	default:
+		return errors.New("type / availability does not match")
+	}
+
+	stmt, err := tx.PrepareContext(ctx,
+		`INSERT INTO item (book_id , type , quantity , order_id , preorder , bundle) 
+		VALUES (?,?,?,?,1,?)`,
+	)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, line := range lines {
+		if _, err = stmt.ExecContext(ctx,
+			item.BookID,
+			line.Type,
+			line.Quantity,
+			orderID,
+			line.Bundled,
+		); err != nil {
+			return err
+		}
+	}
+
+	if err = storage.repriceOrder(ctx, tx, orderID); err != nil {
 		return err
 	}
 
@@ -358,7 +442,7 @@ func (storage Storage) AddItem(ctx context.Context, item order.Item, userID stri
 func (storage Storage) GetOrderItems(ctx context.Context, orderID uint) ([]order.Item, error) {
 
 	stmt, err := storage.MySQL.PrepareContext(ctx,
-		"SELECT id , book_id , type , quantity FROM item WHERE order_id = ?",
+		"SELECT id , book_id , type , quantity , preorder , bundle , COALESCE(box_set_id, 0) FROM item WHERE order_id = ?",
 	)
 	if err != nil {
 		return []order.Item{}, err
@@ -379,6 +463,9 @@ func (storage Storage) GetOrderItems(ctx context.Context, orderID uint) ([]order
 			&i.BookID,
 			&i.Type,
 			&i.Quantity,
+			&i.Preorder,
+			&i.Bundled,
+			&i.BoxSetID,
 		); err != nil {
 			return []order.Item{}, err
 		}
@@ -415,14 +502,14 @@ func (storage Storage) CheckQuantity(ctx context.Context, tx *sql.Tx, quantity,
 func (storage Storage) CheckAvailability(ctx context.Context, tx *sql.Tx, bookID uint) (uint, error) {
 
 	stmt, err := tx.PrepareContext(ctx,
-		"SELECT availability FROM book WHERE id = ?",
+		"SELECT IF(deleted_at IS NULL , availability , ?) FROM book WHERE id = ?",
 	)
 	if err != nil {
 		return 0, err
 	}
 	defer stmt.Close()
 
-	result := stmt.QueryRowContext(ctx, bookID)
+	result := stmt.QueryRowContext(ctx, book.NotAvailable, bookID)
 
 	var availability uint
 	if err = result.Scan(&availability); err != nil {
@@ -432,6 +519,35 @@ func (storage Storage) CheckAvailability(ctx context.Context, tx *sql.Tx, bookID
 	return availability, nil
 }
 
+// CheckPreorder returns the formats a book can be pre-ordered in, NotAvailable once it's released
+func (storage Storage) CheckPreorder(ctx context.Context, tx *sql.Tx, bookID uint) (uint, error) {
+
+	stmt, err := tx.PrepareContext(ctx,
+		`SELECT IF(deleted_at IS NULL AND availability_override IS NULL AND release_date > ? ,
+		IF(digital_price > 0 , ? , 0) + IF(physical_price > 0 , ? , 0) , ?) 
+		FROM book WHERE id = ?`,
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	result := stmt.QueryRowContext(ctx,
+		time.Now().Format("2006-01-02"),
+		book.DigitalAvailable,
+		book.PhysicalAvailable,
+		book.NotAvailable,
+		bookID,
+	)
+
+	var preorder uint
+	if err = result.Scan(&preorder); err != nil {
+		return 0, err
+	}
+
+	return preorder, nil
+}
+
 func (storage Storage) SetOrderPhone(ctx context.Context, orderID, phoneID uint) error {
 
 	stmt, err := storage.MySQL.PrepareContext(ctx,
@@ -485,7 +601,7 @@ func (storage Storage) IncreaseQuantity(ctx context.Context, itemID, orderID uin
 	defer tx.Rollback()
 
 	stmt, err := tx.PrepareContext(ctx,
-		`SELECT book_id , type , quantity FROM item WHERE id = ?`,
+		`SELECT book_id , type , quantity , preorder , bundle , COALESCE(box_set_id, 0) FROM item WHERE id = ?`,
 	)
 	if err != nil {
 		return err
@@ -493,48 +609,75 @@ func (storage Storage) IncreaseQuantity(ctx context.Context, itemID, orderID uin
 	defer stmt.Close()
 
 	var item order.Item
-	if err := stmt.QueryRowContext(ctx, itemID).Scan(&item.BookID, &item.Type, &item.Quantity); err != nil {
+	if err := stmt.QueryRowContext(ctx, itemID).Scan(&item.BookID, &item.Type, &item.Quantity, &item.Preorder, &item.Bundled, &item.BoxSetID); err != nil {
 		return err
 	}
 	item.ID = itemID
 
+	// the lines of a box set change together
+	if item.BoxSetID != 0 {
+		if err = storage.changeBoxSetQuantity(ctx, tx, item.BoxSetID, orderID, 1); err != nil {
+			return err
+		}
+		if err = storage.repriceOrder(ctx, tx, orderID); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+
 	if item.Type == order.Digital {
 		return errors.New("cannot increase digital item")
 	}
 
-	stmt, err = tx.PrepareContext(ctx,
-		`UPDATE item SET 
+	query := `UPDATE item SET 
 		item.quantity = item.quantity + 1 
 		WHERE item.id = ? 
 		AND
-		item.quantity < ( SELECT book.physical_stock FROM book WHERE book.id = item.book_id )`,
-	)
-	if err != nil {
-		return err
+		item.quantity < ( SELECT book.physical_stock FROM book WHERE book.id = item.book_id )`
+	if item.Preorder {
+		query = "UPDATE item SET quantity = quantity + 1 WHERE id = ?"
 	}
 
-	if _, err = stmt.ExecContext(ctx, itemID); err != nil {
+	stmt, err = tx.PrepareContext(ctx, query)
+	if err != nil {
 		return err
 	}
 
-	stmt, err = tx.PrepareContext(ctx,
-		`UPDATE book SET physical_stock = physical_stock - 1 
-		WHERE id = ?`,
-	)
+	result, err := stmt.ExecContext(ctx, itemID)
 	if err != nil {
 		return err
 	}
 
-	if _, err = stmt.ExecContext(ctx, item.BookID); err != nil {
+	// the stock only moves with the copies the line really took
+	added, err := result.RowsAffected()
+	if err != nil {
 		return err
 	}
 
-	price, err := storage.CalculateItemPrice(ctx, tx, item)
-	if err != nil {
-		return err
+	if added != 0 && !item.Preorder {
+		stmt, err = tx.PrepareContext(ctx,
+			`UPDATE book SET physical_stock = physical_stock - 1 
+			WHERE id = ?`,
+		)
+		if err != nil {
+			return err
+		}
+
+		if _, err = stmt.ExecContext(ctx, item.BookID); err != nil {
+			return err
+		}
+
+		if _, err = storage.recordMovement(ctx, tx, book.Movement{
+			BookID:   item.BookID,
+			Type:     book.MovementReservation,
+			Quantity: -1,
+			OrderID:  orderID,
+		}); err != nil {
+			return err
+		}
 	}
 
-	if err = storage.AddTotal(ctx, tx, price, orderID); err != nil {
+	if err = storage.repriceOrder(ctx, tx, orderID); err != nil {
 		return err
 	}
 
@@ -550,7 +693,7 @@ func (storage Storage) DecreaseQuantity(ctx context.Context, itemID, orderID uin
 	defer tx.Rollback()
 
 	stmt, err := tx.PrepareContext(ctx,
-		`SELECT book_id , type , quantity FROM item WHERE id = ?`,
+		`SELECT book_id , type , quantity , preorder , bundle , COALESCE(box_set_id, 0) FROM item WHERE id = ?`,
 	)
 	if err != nil {
 		return err
@@ -558,11 +701,22 @@ func (storage Storage) DecreaseQuantity(ctx context.Context, itemID, orderID uin
 	defer stmt.Close()
 
 	var item order.Item
-	if err := stmt.QueryRowContext(ctx, itemID).Scan(&item.BookID, &item.Type, &item.Quantity); err != nil {
+	if err := stmt.QueryRowContext(ctx, itemID).Scan(&item.BookID, &item.Type, &item.Quantity, &item.Preorder, &item.Bundled, &item.BoxSetID); err != nil {
 		return err
 	}
 	item.ID = itemID
 
+	// the lines of a box set change together
+	if item.BoxSetID != 0 {
+		if err = storage.changeBoxSetQuantity(ctx, tx, item.BoxSetID, orderID, -1); err != nil {
+			return err
+		}
+		if err = storage.repriceOrder(ctx, tx, orderID); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+
 	stmt, err = tx.PrepareContext(ctx,
 		`UPDATE item SET 
 		quantity = quantity - 1 
@@ -575,29 +729,49 @@ func (storage Storage) DecreaseQuantity(ctx context.Context, itemID, orderID uin
 	}
 	defer stmt.Close()
 
-	if _, err = stmt.ExecContext(ctx, itemID); err != nil {
+	result, err := stmt.ExecContext(ctx, itemID)
+	if err != nil {
 		return err
 	}
 
-	stmt, err = tx.PrepareContext(ctx,
-		`UPDATE book SET 
-		physical_stock = physical_stock + 1 
-		WHERE id = ?`,
-	)
+	removed, err := result.RowsAffected()
 	if err != nil {
 		return err
 	}
 
-	if _, err = stmt.ExecContext(ctx, item.BookID); err != nil {
-		return err
+	// a bundle without its physical copies is no bundle anymore
+	if removed != 0 && item.Bundled && item.Quantity == 1 {
+		if err = unbundle(ctx, tx, orderID, item); err != nil {
+			return err
+		}
 	}
 
-	price, err := storage.CalculateItemPrice(ctx, tx, item)
-	if err != nil {
-		return err
+	// the stock only moves with the physical copies the line really gave back
+	if removed != 0 && item.Type != order.Digital && !item.Preorder {
+		stmt, err = tx.PrepareContext(ctx,
+			`UPDATE book SET 
+			physical_stock = physical_stock + 1 
+			WHERE id = ?`,
+		)
+		if err != nil {
+			return err
+		}
+
+		if _, err = stmt.ExecContext(ctx, item.BookID); err != nil {
+			return err
+		}
+
+		if _, err = storage.recordMovement(ctx, tx, book.Movement{
+			BookID:   item.BookID,
+			Type:     book.MovementRelease,
+			Quantity: 1,
+			OrderID:  orderID,
+		}); err != nil {
+			return err
+		}
 	}
 
-	if err = storage.SubTotal(ctx, tx, price, orderID); err != nil {
+	if err = storage.repriceOrder(ctx, tx, orderID); err != nil {
 		return err
 	}
 
@@ -613,7 +787,7 @@ func (storage Storage) RemoveItem(ctx context.Context, itemID, orderID uint) err
 	defer tx.Rollback()
 
 	stmt, err := tx.PrepareContext(ctx,
-		`SELECT book_id , type , quantity FROM item WHERE id = ?`,
+		`SELECT book_id , type , quantity , preorder , bundle , COALESCE(box_set_id, 0) FROM item WHERE id = ?`,
 	)
 	if err != nil {
 		return err
@@ -621,12 +795,23 @@ func (storage Storage) RemoveItem(ctx context.Context, itemID, orderID uint) err
 	defer stmt.Close()
 
 	var item order.Item
-	if err := stmt.QueryRowContext(ctx, itemID).Scan(&item.BookID, &item.Type, &item.Quantity); err != nil {
+	if err := stmt.QueryRowContext(ctx, itemID).Scan(&item.BookID, &item.Type, &item.Quantity, &item.Preorder, &item.Bundled, &item.BoxSetID); err != nil {
 		return err
 	}
 	item.ID = itemID
 
-	if item.Type == order.Physical || item.Type == order.Bundle {
+	// removing a line of a box set removes the whole set
+	if item.BoxSetID != 0 {
+		if err = storage.removeBoxSet(ctx, tx, item.BoxSetID, orderID); err != nil {
+			return err
+		}
+		if err = storage.repriceOrder(ctx, tx, orderID); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+
+	if (item.Type == order.Physical || item.Type == order.Bundle) && !item.Preorder {
 		stmt, err = tx.PrepareContext(ctx,
 			`UPDATE book SET 
 			physical_stock = physical_stock + (SELECT quantity FROM item WHERE id = ?)
@@ -639,6 +824,15 @@ func (storage Storage) RemoveItem(ctx context.Context, itemID, orderID uint) err
 		if _, err = stmt.ExecContext(ctx, itemID, itemID); err != nil {
 			return err
 		}
+
+		if _, err = storage.recordMovement(ctx, tx, book.Movement{
+			BookID:   item.BookID,
+			Type:     book.MovementRelease,
+			Quantity: int(item.Quantity),
+			OrderID:  orderID,
+		}); err != nil {
+			return err
+		}
 	}
 
 	stmt, err = tx.PrepareContext(ctx,
@@ -652,22 +846,50 @@ func (storage Storage) RemoveItem(ctx context.Context, itemID, orderID uint) err
 		return err
 	}
 
-	price, err := storage.CalculateItemPrice(ctx, tx, item)
-	if err != nil {
-		return err
+	// the other line of a bundle loses the bundle discount
+	if item.Bundled {
+		if err = unbundle(ctx, tx, orderID, item); err != nil {
+			return err
+		}
 	}
 
-	if err = storage.SubTotal(ctx, tx, price, orderID); err != nil {
+	if err = storage.repriceOrder(ctx, tx, orderID); err != nil {
 		return err
 	}
 
 	return tx.Commit()
 }
 
+// unbundle takes the bundle discount off the line and the other line of its bundle, the lines
+// of a bundle are added together so the other one is the line of the book nearest by id
+func unbundle(ctx context.Context, tx *sql.Tx, orderID uint, item order.Item) error {
+
+	other := order.Physical
+	if item.Type == order.Physical {
+		other = order.Digital
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE item SET bundle = 0 WHERE id = ?", item.ID); err != nil {
+		return err
+	}
+
+	_, err := tx.ExecContext(ctx,
+		`UPDATE item SET bundle = 0
+		WHERE order_id = ? AND book_id = ? AND type = ? AND bundle = 1
+		ORDER BY ABS(CAST(id AS SIGNED) - ?) LIMIT 1`,
+		orderID,
+		item.BookID,
+		other,
+		item.ID,
+	)
+	return err
+}
+
+// CreatePromoCode adds the promo, a promo that isn't public is given to the user
 func (storage Storage) CreatePromoCode(ctx context.Context, promo order.Promo, userID string) error {
 
-	if promo.Percentage == 0 {
-		return errors.New("percentage cannot be 0")
+	if promo.Percentage == 0 && promo.Amount == 0 && !promo.FreeShipping {
+		return errors.New("promo has no discount")
 	}
 	if promo.Limit == 0 {
 		return errors.New("limit cannot be 0")
@@ -679,41 +901,28 @@ func (storage Storage) CreatePromoCode(ctx context.Context, promo order.Promo, u
 	}
 	defer tx.Rollback()
 
-	stmt, err := tx.PrepareContext(ctx,
-		`INSERT INTO promo 
-		(code , expiration , promo.limit , percentage , max_price)
-		VALUES (?,?,?,?,?)`,
-	)
+	stmt, err := tx.PrepareContext(ctx, promoInsert)
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
-	result, err := stmt.ExecContext(ctx,
-		promo.Code,
-		promo.Expiration,
-		promo.Limit,
-		promo.Percentage,
-		promo.MaxPrice,
-	)
-	if err != nil {
-		return err
-	}
-
-	promoID, err := result.LastInsertId()
+	promoID, err := insertPromo(ctx, tx, stmt, promo)
 	if err != nil {
 		return err
 	}
 
-	stmt, err = tx.PrepareContext(ctx,
-		"INSERT INTO promo_user (promo_id , user_id) VALUES (?,?)",
-	)
-	if err != nil {
-		return err
-	}
+	if !promo.Public {
+		stmt, err = tx.PrepareContext(ctx,
+			"INSERT INTO promo_user (promo_id , user_id) VALUES (?,?)",
+		)
+		if err != nil {
+			return err
+		}
 
-	if _, err = stmt.ExecContext(ctx, promoID, userID); err != nil {
-		return err
+		if _, err = stmt.ExecContext(ctx, promoID, userID); err != nil {
+			return err
+		}
 	}
 
 	return tx.Commit()
@@ -734,54 +943,56 @@ func (storage Storage) DeletePromoCode(ctx context.Context, promoID uint) error
 	); err != nil {
 		return err
 	}
-	/*
-		_, err = storage.MySQL.ExecContext(
-			ctx,
-			"DELETE FROM promo_user WHERE promo_id = ? AND user_id = ?",
-			promoID,
-			userID,
-		)
-
-		if err != nil {
-			return err
-		}
-	*/
 
 	return nil
 }
 
+// SetOrderStatus moves the order to status and settles what hangs on it in the same transaction
 func (storage Storage) SetOrderStatus(ctx context.Context, status, orderID uint) error {
 
-	stmt, err := storage.MySQL.PrepareContext(ctx,
-		`SELECT 1 FROM item WHERE type != 0 AND order_id = ?`,
-	)
+	tx, err := storage.MySQL.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
+	defer tx.Rollback()
 
-	result := stmt.QueryRowContext(ctx, orderID)
+	if err = storage.setOrderStatus(ctx, tx, status, orderID); err != nil {
+		return err
+	}
 
-	var isShipmentOrder bool
-	if err = result.Scan(&isShipmentOrder); err != nil {
+	return tx.Commit()
+}
+
+func (storage Storage) setOrderStatus(ctx context.Context, tx *sql.Tx, status, orderID uint) error {
+
+	var from uint
+	if err := tx.QueryRowContext(ctx,
+		"SELECT status FROM orders WHERE id = ? FOR UPDATE",
+		orderID,
+	).Scan(&from); err != nil {
 		return err
 	}
 
-	if status != order.StatusCreated && isShipmentOrder {
+	// the stock and the wallet of a cancelled order are given back for good
+	if from == order.StatusCancelled && status != order.StatusCancelled {
+		return errors.New("cancelled order cannot change status")
+	}
 
-		stmt, err = storage.MySQL.PrepareContext(ctx,
-			`UPDATE orders AS o , (SELECT phone_id , address_id FROM orders WHERE id = ?) AS PA
-			SET status = ? 
-			WHERE PA.phone_id IS NOT NULL AND PA.address_id IS NOT NULL
-			AND id = ?
-			`,
-		)
-		if err != nil {
-			return err
-		}
+	var isShipmentOrder bool
+	if err := tx.QueryRowContext(ctx,
+		`SELECT EXISTS(SELECT 1 FROM item WHERE type != 0 AND order_id = ?)`,
+		orderID,
+	).Scan(&isShipmentOrder); err != nil {
+		return err
+	}
 
-		if _, err = stmt.ExecContext(ctx,
-			orderID,
+	// a cancelled order needs no phone or address
+	if status != order.StatusCreated && status != order.StatusCancelled && isShipmentOrder {
+
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE orders SET status = ? 
+			WHERE phone_id IS NOT NULL AND address_id IS NOT NULL
+			AND id = ?`,
 			status,
 			orderID,
 		); err != nil {
@@ -790,23 +1001,70 @@ func (storage Storage) SetOrderStatus(ctx context.Context, status, orderID uint)
 
 	} else {
 
-		stmt, err = storage.MySQL.PrepareContext(ctx,
+		if _, err := tx.ExecContext(ctx,
 			"UPDATE orders SET status = ? WHERE id = ?",
-		)
-		if err != nil {
+			status,
+			orderID,
+		); err != nil {
 			return err
 		}
 
-		if _, err = stmt.ExecContext(ctx,
-			status,
+	}
+
+	if status == order.StatusCancelled && from != order.StatusCancelled {
+
+		// the value of a redeemed gift card is in a wallet already, it can't be refunded too
+		var redeemed bool
+		if err := tx.QueryRowContext(ctx,
+			"SELECT EXISTS(SELECT 1 FROM gift_card WHERE order_id = ? AND status = ?)",
 			orderID,
-		); err != nil {
+			wallet.GiftCardRedeemed,
+		).Scan(&redeemed); err != nil {
 			return err
 		}
+		if redeemed {
+			return errors.New("order has a redeemed gift card")
+		}
+
+		movement := book.MovementRelease
+		if from == order.StatusShipped {
+			movement = book.MovementReturn
+		}
 
+		if err := storage.releaseStock(ctx, tx, orderID, movement); err != nil {
+			return err
+		}
 	}
 
-	return nil
+	return settleOrder(ctx, tx, orderID)
+}
+
+// settleOrder brings what hangs on the order in line with its new status: its promo
+// redemption, its gift cards and gifts, its loyalty points, what it took from the wallet
+// and the referral of its buyer
+func settleOrder(ctx context.Context, tx *sql.Tx, orderID uint) error {
+
+	if err := settleRedemptions(ctx, tx, orderID); err != nil {
+		return err
+	}
+
+	if err := settleGiftCards(ctx, tx, orderID); err != nil {
+		return err
+	}
+
+	if err := settleGifts(ctx, tx, orderID); err != nil {
+		return err
+	}
+
+	if err := settlePoints(ctx, tx, orderID); err != nil {
+		return err
+	}
+
+	if err := settleWallet(ctx, tx, orderID); err != nil {
+		return err
+	}
+
+	return settleReferral(ctx, tx, orderID)
 }
 
 func (storage Storage) GetOrderStatus(ctx context.Context, orderID uint) (uint, error) {
@@ -852,7 +1110,7 @@ func (storage Storage) SetOrderSTN(ctx context.Context, stn string, orderID uint
 func (storage Storage) SetOrderTotal(ctx context.Context, tx *sql.Tx, total, orderID uint) error {
 
 	stmt, err := tx.PrepareContext(ctx,
-		`UPDATE orders SET total = ? WHERE id = ?`,
+		`UPDATE orders SET total = ? , discount = 0 WHERE id = ?`,
 	)
 	if err != nil {
 		return err
@@ -866,44 +1124,15 @@ func (storage Storage) SetOrderTotal(ctx context.Context, tx *sql.Tx, total, ord
 	return nil
 }
 
+// CalculateItemPrice prices the item with the campaigns running now and the bundle rules
 func (storage Storage) CalculateItemPrice(ctx context.Context, tx *sql.Tx, item order.Item) (uint, error) {
 
-	stmt, err := tx.PrepareContext(ctx,
-		`SELECT digital_price , digital_discount , physical_price , physical_discount 
-		FROM book WHERE id = ?`,
-	)
+	q, err := quoteBook(ctx, tx, item.BookID, time.Now().Format("2006-01-02 15:04:05"))
 	if err != nil {
 		return 0, err
 	}
-	defer stmt.Close()
-
-	result := stmt.QueryRowContext(ctx, item.BookID)
-
-	var i itemPrice
-	if err = result.Scan(
-		&i.DigitalPrice,
-		&i.DigitalDiscount,
-		&i.PhysicalPrice,
-		&i.PhysicalDiscount,
-	); err != nil {
-		return 0, err
-	}
-
-	var price uint
 
-	switch item.Type {
-	case order.Digital:
-		price = (i.DigitalPrice * (100 - i.DigitalDiscount)) / 100
-	case order.Physical:
-		price = (i.PhysicalPrice * (100 - i.PhysicalDiscount)) / 100 * item.Quantity
-	case order.Bundle:
-		price = ((i.DigitalPrice*(100-i.DigitalDiscount))/100 +
-			(i.PhysicalPrice*(100-i.PhysicalDiscount))/100*item.Quantity) * 80 / 100
-	default:
-		return 0, errors.New("invalid order type")
-	}
-
-	return price, nil
+	return pricing.ItemPrice(q, item)
 }
 
 func (storage Storage) AddTotal(ctx context.Context, tx *sql.Tx, value, orderID uint) error {
@@ -940,74 +1169,58 @@ func (storage Storage) SubTotal(ctx context.Context, tx *sql.Tx, value, orderID
 	return nil
 }
 
+// CalculateOrderTotal sets the total of the order without its promo code,
+// the shipping fee included, the redeemed points taken off and the tax computed
 func (storage Storage) CalculateOrderTotal(ctx context.Context, tx *sql.Tx, orderID uint) error {
 
-	stmt, err := tx.PrepareContext(ctx,
-		"SELECT book_id , type , quantity FROM item WHERE order_id = ?",
-	)
+	lines, err := storage.orderLines(ctx, tx, orderID)
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
 
-	result, err := stmt.QueryContext(ctx, orderID)
+	t := pricing.NewTotal(lines, nil, shippingFee())
+
+	paid, err := applyPoints(ctx, tx, orderID, lines, t)
 	if err != nil {
 		return err
 	}
 
-	items := []itemPrice{}
-	for result.Next() {
-		var i itemPrice
+	total, err := applyTax(ctx, tx, orderID, lines, nil, t, paid)
+	if err != nil {
+		return err
+	}
 
-		if err = result.Scan(
-			&i.BookID,
-			&i.Type,
-			&i.Quantity,
-		); err != nil {
-			return err
-		}
+	return storage.SetOrderTotal(ctx, tx, total, orderID)
+}
 
-		stmt, err := tx.PrepareContext(ctx,
-			`SELECT digital_price , digital_discount , physical_price , physical_discount 
-			FROM book WHERE id = ?`,
-		)
-		if err != nil {
-			return err
-		}
-		defer stmt.Close()
-
-		priceResult := stmt.QueryRowContext(ctx, i.BookID)
-		if err = priceResult.Scan(
-			&i.DigitalPrice,
-			&i.DigitalDiscount,
-			&i.PhysicalPrice,
-			&i.PhysicalDiscount,
-		); err != nil {
-			return err
-		}
+// repriceOrder recomputes the total of the order after its items changed,
+// an applied promo code is applied again on the new total
+func (storage Storage) repriceOrder(ctx context.Context, tx *sql.Tx, orderID uint) error {
 
-		items = append(items, i)
+	var promoID uint
+	if err := tx.QueryRowContext(ctx,
+		"SELECT COALESCE(promo_id, 0) FROM orders WHERE id = ?",
+		orderID,
+	).Scan(&promoID); err != nil {
+		return err
 	}
 
-	var total uint = 0
-	for _, item := range items {
-		switch item.Type {
-		case order.Digital:
-			total += (item.DigitalPrice * (100 - item.DigitalDiscount)) / 100
-		case order.Physical:
-			total += (item.PhysicalPrice * (100 - item.PhysicalDiscount)) / 100 * item.Quantity
-		default:
-			return errors.New("invalid order type")
-		}
+	if promoID == 0 {
+		return storage.CalculateOrderTotal(ctx, tx, orderID)
 	}
 
-	if err = storage.SetOrderTotal(ctx, tx, total, orderID); err != nil {
+	promo, err := getPromo(ctx, tx, promoSelect+"WHERE p.id = ? GROUP BY p.id", promoID)
+	if err != nil {
 		return err
 	}
 
-	return nil
+	_, err = storage.UpdateOrderWithPromo(ctx, tx, promo, orderID)
+	return err
 }
 
+// SetOrderPromo applies a public code or a code given to the user to the order,
+// the code must cover the order and have redemptions left for the user.
+// The order and the promo stay locked until the redemption is held.
 func (storage Storage) SetOrderPromo(ctx context.Context, orderID uint, promoCode, userID string) error {
 
 	tx, err := storage.MySQL.BeginTx(ctx, nil)
@@ -1016,37 +1229,27 @@ func (storage Storage) SetOrderPromo(ctx context.Context, orderID uint, promoCod
 	}
 	defer tx.Rollback()
 
-	stmt, err := tx.PrepareContext(ctx,
-		`SELECT * FROM promo 
-		WHERE code = ? 
-		AND 
-		(SELECT 1 FROM promo_user WHERE promo_id = promo.id AND user_id = ?)`,
-	)
-	if err != nil {
+	if _, err = lockOrderPromo(ctx, tx, orderID); err != nil {
 		return err
 	}
-	defer stmt.Close()
 
-	result := stmt.QueryRowContext(ctx,
+	promo, err := getPromo(ctx, tx,
+		promoSelect+`WHERE p.code = ?
+		AND (p.public = 1 OR EXISTS(SELECT 1 FROM promo_user WHERE promo_id = p.id AND user_id = ?))
+		GROUP BY p.id ORDER BY p.public LIMIT 1`,
 		promoCode,
 		userID,
 	)
-
-	var promo order.Promo
-
-	if err = result.Scan(
-		&promo.ID,
-		&promo.Code,
-		&promo.Expiration,
-		&promo.Limit,
-		&promo.Percentage,
-		&promo.MaxPrice,
-	); err != nil {
+	if err != nil {
 		return err
 	}
 
-	if promo.Percentage == 0 {
-		return errors.New("percentage cannot be 0")
+	var locked uint
+	if err = tx.QueryRowContext(ctx,
+		"SELECT id FROM promo WHERE id = ? FOR UPDATE",
+		promo.ID,
+	).Scan(&locked); err != nil {
+		return err
 	}
 
 	exp, err := time.Parse("2006-01-02 15:04:05", promo.Expiration)
@@ -1058,65 +1261,74 @@ func (storage Storage) SetOrderPromo(ctx context.Context, orderID uint, promoCod
 		return errors.New("expired promo code")
 	}
 
-	if promo.Limit == 0 {
+	used, usedByUser, err := countRedemptions(ctx, tx, promo.ID, userID, orderID)
+	if err != nil {
+		return err
+	}
+
+	if used >= promo.Limit {
 		return errors.New("promo limit reached")
 	}
 
-	tx, err = storage.UpdateOrderWithPromo(ctx, tx, promo, orderID)
+	if promo.UserLimit != 0 && usedByUser >= promo.UserLimit {
+		return errors.New("promo user limit reached")
+	}
+
+	lines, err := storage.orderLines(ctx, tx, orderID)
 	if err != nil {
 		return err
 	}
 
-	stmt, err = tx.PrepareContext(ctx,
-		"UPDATE promo SET promo.limit = promo.limit - 1 WHERE id = ?",
-	)
+	if err = pricing.CheckPromo(promo, lines, shippingFee()); err != nil {
+		return err
+	}
+
+	tx, err = storage.UpdateOrderWithPromo(ctx, tx, promo, orderID)
 	if err != nil {
 		return err
 	}
 
-	if _, err = stmt.ExecContext(ctx, promo.ID); err != nil {
+	if err = holdRedemption(ctx, tx, promo.ID, orderID, userID); err != nil {
 		return err
 	}
 
 	return tx.Commit()
 }
 
+// UpdateOrderWithPromo sets the promo of the order and its total with the promo applied
+// to the items in its scope, the tax follows the discount
 func (storage Storage) UpdateOrderWithPromo(ctx context.Context, tx *sql.Tx, promo order.Promo, orderID uint) (*sql.Tx, error) {
 
-	stmt, err := tx.PrepareContext(ctx,
-		"SELECT total FROM orders WHERE id = ?",
-	)
+	lines, err := storage.orderLines(ctx, tx, orderID)
 	if err != nil {
 		return tx, err
 	}
-	defer stmt.Close()
 
-	result := stmt.QueryRowContext(ctx, orderID)
+	total := pricing.NewTotal(lines, &promo, shippingFee())
 
-	var total uint
-	if err = result.Scan(&total); err != nil {
+	// the discount includes the waived shipping fee
+	full := pricing.NewTotal(lines, nil, shippingFee())
+
+	paid, err := applyPoints(ctx, tx, orderID, lines, total)
+	if err != nil {
 		return tx, err
 	}
 
-	offer := (total * promo.Percentage) / 100
-
-	if promo.Percentage == 100 {
-		total = 0
-	} else if promo.MaxPrice != 0 && promo.MaxPrice < offer {
-		total -= promo.MaxPrice
-	} else {
-		total -= offer
+	if paid, err = applyTax(ctx, tx, orderID, lines, &promo, total, paid); err != nil {
+		return tx, err
 	}
 
-	stmt, err = tx.PrepareContext(ctx,
-		"UPDATE orders SET total = ?,promo_id = ? WHERE id = ?",
+	stmt, err := tx.PrepareContext(ctx,
+		"UPDATE orders SET total = ? , discount = ? , promo_id = ? WHERE id = ?",
 	)
 	if err != nil {
 		return tx, err
 	}
+	defer stmt.Close()
 
 	if _, err = stmt.ExecContext(ctx,
-		total,
+		paid,
+		full.Total-total.Total,
 		promo.ID,
 		orderID,
 	); err != nil {
@@ -1126,6 +1338,7 @@ func (storage Storage) UpdateOrderWithPromo(ctx context.Context, tx *sql.Tx, pro
 	return tx, nil
 }
 
+// RemoveOrderPromo takes the promo off the order and releases its redemption
 func (storage Storage) RemoveOrderPromo(ctx context.Context, orderID uint) error {
 
 	tx, err := storage.MySQL.BeginTx(ctx, nil)
@@ -1134,26 +1347,21 @@ func (storage Storage) RemoveOrderPromo(ctx context.Context, orderID uint) error
 	}
 	defer tx.Rollback()
 
-	stmt, err := tx.PrepareContext(ctx,
-		`UPDATE promo SET 
-		promo.limit = promo.limit + 1 
-		WHERE id = (SELECT promo_id FROM orders WHERE orders.id = ?)`,
-	)
-	if err != nil {
+	if _, err = lockOrderPromo(ctx, tx, orderID); err != nil {
 		return err
 	}
-	defer stmt.Close()
 
-	if _, err = stmt.ExecContext(ctx, orderID); err != nil {
+	if err = releaseRedemptions(ctx, tx, orderID, 0); err != nil {
 		return err
 	}
 
-	stmt, err = tx.PrepareContext(ctx,
+	stmt, err := tx.PrepareContext(ctx,
 		"UPDATE orders SET promo_id = NULL WHERE id = ?",
 	)
 	if err != nil {
 		return err
 	}
+	defer stmt.Close()
 
 	if _, err = stmt.ExecContext(ctx, orderID); err != nil {
 		return err
@@ -1183,10 +1391,13 @@ func (storage Storage) DeleteOrder(ctx context.Context, orderID uint) error {
 	return nil
 }
 
+const orderSelect = `SELECT id , creation_date , receipt_date , status , total , discount , wallet , points ,
+	points_discount , points_earn , tax , tax_inclusive , stn , user_id , promo_id , phone_id , address_id FROM orders `
+
 func (storage Storage) GetAllOrders(ctx context.Context) ([]order.Order, error) {
 
 	stmt, err := storage.MySQL.PrepareContext(ctx,
-		"SELECT * FROM orders",
+		orderSelect,
 	)
 	if err != nil {
 		return []order.Order{}, err
@@ -1214,6 +1425,13 @@ func (storage Storage) GetAllOrders(ctx context.Context) ([]order.Order, error)
 			&rd,
 			&o.Status,
 			&o.Total,
+			&o.Discount,
+			&o.Wallet,
+			&o.Points,
+			&o.PointsDiscount,
+			&o.PointsEarn,
+			&o.Tax,
+			&o.TaxInclusive,
 			&stn,
 			&o.UserID,
 			&pid,
@@ -1232,13 +1450,17 @@ func (storage Storage) GetAllOrders(ctx context.Context) ([]order.Order, error)
 		orders = append(orders, o)
 	}
 
+	if err = setTaxRates(ctx, storage.MySQL, orders); err != nil {
+		return []order.Order{}, err
+	}
+
 	return orders, nil
 }
 
 func (storage Storage) GetUserOrders(ctx context.Context, userID string) ([]order.Order, error) {
 
 	stmt, err := storage.MySQL.PrepareContext(ctx,
-		"SELECT * FROM orders WHERE user_id = ?",
+		orderSelect+"WHERE user_id = ?",
 	)
 	if err != nil {
 		return []order.Order{}, err
@@ -1266,6 +1488,13 @@ func (storage Storage) GetUserOrders(ctx context.Context, userID string) ([]orde
 			&rd,
 			&o.Status,
 			&o.Total,
+			&o.Discount,
+			&o.Wallet,
+			&o.Points,
+			&o.PointsDiscount,
+			&o.PointsEarn,
+			&o.Tax,
+			&o.TaxInclusive,
 			&stn,
 			&o.UserID,
 			&pid,
@@ -1284,13 +1513,17 @@ func (storage Storage) GetUserOrders(ctx context.Context, userID string) ([]orde
 		orders = append(orders, o)
 	}
 
+	if err = setTaxRates(ctx, storage.MySQL, orders); err != nil {
+		return []order.Order{}, err
+	}
+
 	return orders, nil
 }
 
 func (storage Storage) GetDateOrders(ctx context.Context, date string) ([]order.Order, error) {
 
 	stmt, err := storage.MySQL.PrepareContext(ctx,
-		"SELECT * FROM orders WHERE DATE(creation_date) = ?",
+		orderSelect+"WHERE DATE(creation_date) = ?",
 	)
 	if err != nil {
 		return []order.Order{}, err
@@ -1318,6 +1551,13 @@ func (storage Storage) GetDateOrders(ctx context.Context, date string) ([]order.
 			&rd,
 			&o.Status,
 			&o.Total,
+			&o.Discount,
+			&o.Wallet,
+			&o.Points,
+			&o.PointsDiscount,
+			&o.PointsEarn,
+			&o.Tax,
+			&o.TaxInclusive,
 			&stn,
 			&o.UserID,
 			&pid,
@@ -1336,13 +1576,17 @@ func (storage Storage) GetDateOrders(ctx context.Context, date string) ([]order.
 		orders = append(orders, o)
 	}
 
+	if err = setTaxRates(ctx, storage.MySQL, orders); err != nil {
+		return []order.Order{}, err
+	}
+
 	return orders, nil
 }
 
 func (storage Storage) GetDateOrdersByStatus(ctx context.Context, date string, status uint) ([]order.Order, error) {
 
 	stmt, err := storage.MySQL.PrepareContext(ctx,
-		"SELECT * FROM orders WHERE DATE(creation_date) = ? AND status = ?",
+		orderSelect+"WHERE DATE(creation_date) = ? AND status = ?",
 	)
 	if err != nil {
 		return []order.Order{}, err
@@ -1373,6 +1617,13 @@ func (storage Storage) GetDateOrdersByStatus(ctx context.Context, date string, s
 			&rd,
 			&o.Status,
 			&o.Total,
+			&o.Discount,
+			&o.Wallet,
+			&o.Points,
+			&o.PointsDiscount,
+			&o.PointsEarn,
+			&o.Tax,
+			&o.TaxInclusive,
 			&stn,
 			&o.UserID,
 			&pid,
@@ -1391,13 +1642,17 @@ func (storage Storage) GetDateOrdersByStatus(ctx context.Context, date string, s
 		orders = append(orders, o)
 	}
 
+	if err = setTaxRates(ctx, storage.MySQL, orders); err != nil {
+		return []order.Order{}, err
+	}
+
 	return orders, nil
 }
 
 func (storage Storage) GetAllOrdersByStatus(ctx context.Context, status uint) ([]order.Order, error) {
 
 	stmt, err := storage.MySQL.PrepareContext(ctx,
-		"SELECT * FROM orders WHERE status = ?",
+		orderSelect+"WHERE status = ?",
 	)
 	if err != nil {
 		return []order.Order{}, err
@@ -1425,6 +1680,13 @@ func (storage Storage) GetAllOrdersByStatus(ctx context.Context, status uint) ([
 			&rd,
 			&o.Status,
 			&o.Total,
+			&o.Discount,
+			&o.Wallet,
+			&o.Points,
+			&o.PointsDiscount,
+			&o.PointsEarn,
+			&o.Tax,
+			&o.TaxInclusive,
 			&stn,
 			&o.UserID,
 			&pid,
@@ -1443,13 +1705,17 @@ func (storage Storage) GetAllOrdersByStatus(ctx context.Context, status uint) ([
 		orders = append(orders, o)
 	}
 
+	if err = setTaxRates(ctx, storage.MySQL, orders); err != nil {
+		return []order.Order{}, err
+	}
+
 	return orders, nil
 }
 
 func (storage Storage) GetUserOrdersByStatus(ctx context.Context, userID string, status uint) ([]order.Order, error) {
 
 	stmt, err := storage.MySQL.PrepareContext(ctx,
-		"SELECT * FROM orders WHERE user_id = ? AND status = ?",
+		orderSelect+"WHERE user_id = ? AND status = ?",
 	)
 	if err != nil {
 		return []order.Order{}, err
@@ -1480,6 +1746,13 @@ func (storage Storage) GetUserOrdersByStatus(ctx context.Context, userID string,
 			&rd,
 			&o.Status,
 			&o.Total,
+			&o.Discount,
+			&o.Wallet,
+			&o.Points,
+			&o.PointsDiscount,
+			&o.PointsEarn,
+			&o.Tax,
+			&o.TaxInclusive,
 			&stn,
 			&o.UserID,
 			&pid,
@@ -1498,116 +1771,36 @@ func (storage Storage) GetUserOrdersByStatus(ctx context.Context, userID string,
 		orders = append(orders, o)
 	}
 
+	if err = setTaxRates(ctx, storage.MySQL, orders); err != nil {
+		return []order.Order{}, err
+	}
+
 	return orders, nil
 }
 
+// GetAllPromos leaves out the generated codes, they're listed with their batch
 func (storage Storage) GetAllPromos(ctx context.Context) ([]order.Promo, error) {
-
-	stmt, err := storage.MySQL.PrepareContext(ctx,
-		"SELECT * FROM promo",
-	)
-	if err != nil {
-		return []order.Promo{}, err
-	}
-	defer stmt.Close()
-
-	result, err := stmt.QueryContext(ctx)
-	if err != nil {
-		return []order.Promo{}, err
-	}
-
-	promos := []order.Promo{}
-	for result.Next() {
-		var p order.Promo
-
-		if err = result.Scan(
-			&p.ID,
-			&p.Code,
-			&p.Expiration,
-			&p.Limit,
-			&p.Percentage,
-			&p.MaxPrice,
-		); err != nil {
-			return []order.Promo{}, err
-		}
-
-		promos = append(promos, p)
-	}
-
-	return promos, nil
+	return getPromos(ctx, storage.MySQL, promoSelect+"WHERE p.batch_id IS NULL GROUP BY p.id")
 }
 
 func (storage Storage) GetUserPromos(ctx context.Context, userID string) ([]order.Promo, error) {
-
-	stmt, err := storage.MySQL.PrepareContext(ctx,
-		"SELECT * FROM promo WHERE id IN (SELECT promo_id FROM promo_user WHERE user_id = ?)",
+	return getPromos(ctx, storage.MySQL,
+		promoSelect+"WHERE p.id IN (SELECT promo_id FROM promo_user WHERE user_id = ?) GROUP BY p.id",
+		userID,
 	)
-	if err != nil {
-		return []order.Promo{}, err
-	}
-	defer stmt.Close()
-
-	result, err := stmt.QueryContext(ctx, userID)
-
-	if err != nil {
-		return []order.Promo{}, err
-	}
-
-	promos := []order.Promo{}
-	for result.Next() {
-		var p order.Promo
-
-		if err = result.Scan(
-			&p.ID,
-			&p.Code,
-			&p.Expiration,
-			&p.Limit,
-			&p.Percentage,
-			&p.MaxPrice,
-		); err != nil {
-			return []order.Promo{}, err
-		}
-
-		promos = append(promos, p)
-	}
-
-	return promos, nil
 }
 
 func (storage Storage) GetPromoByOrder(ctx context.Context, orderID uint) (order.Promo, error) {
-
-	stmt, err := storage.MySQL.PrepareContext(ctx,
-		"SELECT * FROM promo WHERE id = (SELECT promo_id FROM orders WHERE orders.id = ?)",
+	return getPromo(ctx, storage.MySQL,
+		promoSelect+"WHERE p.id = (SELECT promo_id FROM orders WHERE orders.id = ?) GROUP BY p.id",
+		orderID,
 	)
-	if err != nil {
-		return order.Promo{}, err
-	}
-	defer stmt.Close()
-
-	result := stmt.QueryRowContext(ctx, orderID)
-
-	var p order.Promo
-	var maxPrice sql.NullInt64
-
-	if err = result.Scan(
-		&p.ID,
-		&p.Code,
-		&p.Expiration,
-		&p.Limit,
-		&p.Percentage,
-		&maxPrice,
-	); err != nil {
-		return order.Promo{}, err
-	}
-	p.MaxPrice = uint(maxPrice.Int64)
-
-	return p, nil
 }
 
 func (storage Storage) GetOrderPaymentInfo(ctx context.Context, orderID uint) (order.OrderPaymentInfo, error) {
 
 	stmt, err := storage.MySQL.PrepareContext(ctx,
-		`SELECT total , user_id , phone_id FROM orders WHERE id = ? AND status = ?`,
+		`SELECT total , wallet , user_id , phone_id FROM orders WHERE id = ? AND status = ?`,
 	)
 	if err != nil {
 		return order.OrderPaymentInfo{}, err
@@ -1626,11 +1819,13 @@ func (storage Storage) GetOrderPaymentInfo(ctx context.Context, orderID uint) (o
 
 	if err = result.Scan(
 		&info.Total,
+		&info.Wallet,
 		&uid,
 		&pid,
 	); err != nil {
 		return order.OrderPaymentInfo{}, err
 	}
+	info.Due = due(info.Total, info.Wallet)
 
 	stmt, err = storage.MySQL.PrepareContext(ctx,
 		`SELECT email FROM user WHERE id = ?`,
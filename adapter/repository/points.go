@@ -0,0 +1,605 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/XBozorg/bookstore/config"
+	"github.com/XBozorg/bookstore/entity/order"
+	"github.com/XBozorg/bookstore/entity/points"
+	"github.com/XBozorg/bookstore/entity/pricing"
+)
+
+// pointsRule reads the earning and redeeming of points from the config
+// and the multipliers of the topics
+func pointsRule(ctx context.Context, q querier) (pricing.PointsRule, error) {
+
+	conf := config.Conf.GetPointsConfig()
+	rule := pricing.PointsRule{Unit: conf.EarnUnit, Value: conf.PointValue, Multipliers: map[uint]uint{}}
+
+	multipliers, err := getTopicMultipliers(ctx, q)
+	if err != nil {
+		return pricing.PointsRule{}, err
+	}
+	for _, m := range multipliers {
+		rule.Multipliers[m.TopicID] = m.Multiplier
+	}
+
+	return rule, nil
+}
+
+// addPoints appends an entry to the points ledger of the user, the user row is locked
+// so the balance can't be spent twice. Added points expire after the expiry days, spent
+// points are taken off the entries expiring first and the spending entry keeps the earliest
+// expiry of them, which the points an order gives back get again.
+func addPoints(ctx context.Context, tx *sql.Tx, e points.Entry) (points.Entry, error) {
+
+	var locked string
+	if err := tx.QueryRowContext(ctx,
+		"SELECT id FROM user WHERE id = ? FOR UPDATE",
+		e.UserID,
+	).Scan(&locked); err != nil {
+		return points.Entry{}, err
+	}
+
+	balance, err := getPointsBalance(ctx, tx, e.UserID)
+	if err != nil {
+		return points.Entry{}, err
+	}
+
+	if e.Amount < 0 && uint(-e.Amount) > balance {
+		return points.Entry{}, errors.New("insufficient points")
+	}
+	e.Balance = uint(int(balance) + e.Amount)
+
+	now := time.Now()
+	e.Date = now.Format("2006-01-02 15:04:05")
+
+	switch {
+	case e.Amount > 0 && e.Type == points.EntryRelease:
+		e.Remaining = uint(e.Amount)
+		if e.ExpiresAt, err = heldExpiry(ctx, tx, e.UserID, e.OrderID); err != nil {
+			return points.Entry{}, err
+		}
+	case e.Amount > 0:
+		e.Remaining = uint(e.Amount)
+		if days := config.Conf.GetPointsConfig().ExpiryDays; days > 0 {
+			e.ExpiresAt = now.AddDate(0, 0, int(days)).Format("2006-01-02 15:04:05")
+		}
+	case e.Type != points.EntryExpire:
+		if e.ExpiresAt, err = spendPoints(ctx, tx, e.UserID, uint(-e.Amount)); err != nil {
+			return points.Entry{}, err
+		}
+	}
+
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO points_entry (user_id , amount , balance , type , order_id , remaining , expires_at , note , date)
+		VALUES (? , ? , ? , ? , NULLIF(? , 0) , ? , NULLIF(? , '') , ? , ?)`,
+		e.UserID,
+		e.Amount,
+		e.Balance,
+		e.Type,
+		e.OrderID,
+		e.Remaining,
+		e.ExpiresAt,
+		e.Note,
+		e.Date,
+	)
+	if err != nil {
+		return points.Entry{}, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return points.Entry{}, err
+	}
+	e.ID = uint(id)
+
+	return e, nil
+}
+
+// spendPoints takes amount off what remains of the entries of the user, the ones
+// expiring first go first. It returns the expiry of the first one, empty if it never expires.
+func spendPoints(ctx context.Context, tx *sql.Tx, userID string, amount uint) (string, error) {
+
+	result, err := tx.QueryContext(ctx,
+		`SELECT id , remaining , COALESCE(expires_at, '') FROM points_entry WHERE user_id = ? AND remaining > 0
+		ORDER BY expires_at IS NULL , expires_at , id`,
+		userID,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	type left struct {
+		id, remaining uint
+		expiresAt     string
+	}
+	entries := []left{}
+	for result.Next() {
+		var l left
+		if err = result.Scan(&l.id, &l.remaining, &l.expiresAt); err != nil {
+			result.Close()
+			return "", err
+		}
+		entries = append(entries, l)
+	}
+	result.Close()
+
+	var expiresAt string
+	for i, l := range entries {
+		if amount == 0 {
+			break
+		}
+		if i == 0 {
+			expiresAt = l.expiresAt
+		}
+
+		take := l.remaining
+		if take > amount {
+			take = amount
+		}
+
+		if _, err = tx.ExecContext(ctx,
+			"UPDATE points_entry SET remaining = remaining - ? WHERE id = ?",
+			take,
+			l.id,
+		); err != nil {
+			return "", err
+		}
+		amount -= take
+	}
+
+	return expiresAt, nil
+}
+
+// heldExpiry returns the earliest expiry of the points the order holds, so giving them
+// back doesn't make them last longer. Empty when they never expire.
+func heldExpiry(ctx context.Context, tx *sql.Tx, userID string, orderID uint) (string, error) {
+
+	var expiresAt string
+	if err := tx.QueryRowContext(ctx,
+		`SELECT COALESCE(MIN(expires_at), '') FROM points_entry
+		WHERE user_id = ? AND order_id = ? AND type = ?`,
+		userID,
+		orderID,
+		points.EntryRedeem,
+	).Scan(&expiresAt); err != nil {
+		return "", err
+	}
+
+	return expiresAt, nil
+}
+
+func getPointsBalance(ctx context.Context, q querier, userID string) (uint, error) {
+
+	var balance uint
+	if err := q.QueryRowContext(ctx,
+		"SELECT COALESCE(SUM(amount), 0) FROM points_entry WHERE user_id = ?",
+		userID,
+	).Scan(&balance); err != nil {
+		return 0, err
+	}
+
+	return balance, nil
+}
+
+func (storage Storage) GetPoints(ctx context.Context, userID string) (points.Points, error) {
+
+	balance, err := getPointsBalance(ctx, storage.MySQL, userID)
+	if err != nil {
+		return points.Points{}, err
+	}
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		`SELECT id , user_id , amount , balance , type , COALESCE(order_id, 0) , remaining ,
+		COALESCE(expires_at, '') , note , date
+		FROM points_entry WHERE user_id = ? ORDER BY id DESC`,
+	)
+	if err != nil {
+		return points.Points{}, err
+	}
+	defer stmt.Close()
+
+	result, err := stmt.QueryContext(ctx, userID)
+	if err != nil {
+		return points.Points{}, err
+	}
+	defer result.Close()
+
+	p := points.Points{UserID: userID, Balance: balance, Entries: []points.Entry{}}
+	for result.Next() {
+		var e points.Entry
+
+		if err = result.Scan(
+			&e.ID,
+			&e.UserID,
+			&e.Amount,
+			&e.Balance,
+			&e.Type,
+			&e.OrderID,
+			&e.Remaining,
+			&e.ExpiresAt,
+			&e.Note,
+			&e.Date,
+		); err != nil {
+			return points.Points{}, err
+		}
+
+		p.Entries = append(p.Entries, e)
+	}
+
+	return p, nil
+}
+
+// applyPoints takes the points held by the open order off its total t and returns the new total,
+// the points the total can't use anymore go back to the user. The points the order earns once
+// paid are set with them.
+func applyPoints(ctx context.Context, tx *sql.Tx, orderID uint, lines []pricing.Line, t pricing.Total) (uint, error) {
+
+	rule, err := pointsRule(ctx, tx)
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		held   uint
+		userID string
+	)
+	if err = tx.QueryRowContext(ctx,
+		"SELECT points , user_id FROM orders WHERE id = ?",
+		orderID,
+	).Scan(&held, &userID); err != nil {
+		return 0, err
+	}
+
+	used := pricing.PointsUsed(rule, held, lines, t)
+	if used < held {
+		if _, err = addPoints(ctx, tx, points.Entry{
+			UserID:  userID,
+			Amount:  int(held - used),
+			Type:    points.EntryRelease,
+			OrderID: orderID,
+		}); err != nil {
+			return 0, err
+		}
+	}
+
+	discount := used * rule.Value
+
+	if _, err = tx.ExecContext(ctx,
+		"UPDATE orders SET points = ? , points_discount = ? , points_earn = ? WHERE id = ?",
+		used,
+		discount,
+		pricing.EarnedPoints(rule, lines, t, discount),
+		orderID,
+	); err != nil {
+		return 0, err
+	}
+
+	return t.Total - discount, nil
+}
+
+// SetOrderPoints makes the open order hold amount points of the user as a discount,
+// up to what its total can use
+func (storage Storage) SetOrderPoints(ctx context.Context, orderID uint, userID string, amount uint) error {
+
+	tx, err := storage.MySQL.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var held uint
+	if err = tx.QueryRowContext(ctx,
+		"SELECT points FROM orders WHERE id = ? AND user_id = ? AND status = ? FOR UPDATE",
+		orderID,
+		userID,
+		order.StatusCreated,
+	).Scan(&held); err != nil {
+		return err
+	}
+
+	e := points.Entry{UserID: userID, OrderID: orderID, Amount: int(held) - int(amount)}
+	switch {
+	case e.Amount < 0:
+		e.Type = points.EntryRedeem
+	case e.Amount > 0:
+		e.Type = points.EntryRelease
+	default:
+		return tx.Commit()
+	}
+
+	if _, err = addPoints(ctx, tx, e); err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx,
+		"UPDATE orders SET points = ? WHERE id = ?",
+		amount,
+		orderID,
+	); err != nil {
+		return err
+	}
+
+	if err = storage.repriceOrder(ctx, tx, orderID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func earnedPoints(ctx context.Context, tx *sql.Tx, orderID uint) (uint, error) {
+
+	var earned uint
+	if err := tx.QueryRowContext(ctx,
+		"SELECT COALESCE(SUM(amount), 0) FROM points_entry WHERE order_id = ? AND type = ?",
+		orderID,
+		points.EntryEarn,
+	).Scan(&earned); err != nil {
+		return 0, err
+	}
+
+	return earned, nil
+}
+
+// settlePoints credits the points of the order once it reaches the earning status,
+// a cancelled order gives back the points it held and takes back those it earned
+func settlePoints(ctx context.Context, tx *sql.Tx, orderID uint) error {
+
+	var (
+		status, held, earn uint
+		userID             string
+	)
+	if err := tx.QueryRowContext(ctx,
+		"SELECT status , points , points_earn , user_id FROM orders WHERE id = ? FOR UPDATE",
+		orderID,
+	).Scan(&status, &held, &earn, &userID); err != nil {
+		return err
+	}
+
+	earned, err := earnedPoints(ctx, tx, orderID)
+	if err != nil {
+		return err
+	}
+
+	earnStatus := config.Conf.GetPointsConfig().EarnStatus
+	if earnStatus == 0 {
+		earnStatus = order.StatusPaid
+	}
+
+	switch {
+	case status == order.StatusCancelled:
+		if held > 0 {
+			if _, err = addPoints(ctx, tx, points.Entry{
+				UserID:  userID,
+				Amount:  int(held),
+				Type:    points.EntryRelease,
+				OrderID: orderID,
+				Note:    "order cancelled",
+			}); err != nil {
+				return err
+			}
+
+			if _, err = tx.ExecContext(ctx, "UPDATE orders SET points = 0 WHERE id = ?", orderID); err != nil {
+				return err
+			}
+		}
+		return reversePoints(ctx, tx, orderID, userID, earned, "order cancelled")
+
+	case status >= earnStatus && earned == 0 && earn > 0:
+		_, err = addPoints(ctx, tx, points.Entry{
+			UserID:  userID,
+			Amount:  int(earn),
+			Type:    points.EntryEarn,
+			OrderID: orderID,
+		})
+		return err
+	}
+
+	return nil
+}
+
+// reversePoints takes back up to amount of the points the order earned and weren't taken back
+// yet, points the user spent already are left alone
+func reversePoints(ctx context.Context, tx *sql.Tx, orderID uint, userID string, amount uint, note string) error {
+
+	var left int
+	if err := tx.QueryRowContext(ctx,
+		"SELECT COALESCE(SUM(amount), 0) FROM points_entry WHERE order_id = ? AND type IN (? , ?)",
+		orderID,
+		points.EntryEarn,
+		points.EntryReverse,
+	).Scan(&left); err != nil {
+		return err
+	}
+
+	balance, err := getPointsBalance(ctx, tx, userID)
+	if err != nil {
+		return err
+	}
+
+	if left < 0 {
+		left = 0
+	}
+	if amount > uint(left) {
+		amount = uint(left)
+	}
+	if amount > balance {
+		amount = balance
+	}
+	if amount == 0 {
+		return nil
+	}
+
+	_, err = addPoints(ctx, tx, points.Entry{
+		UserID:  userID,
+		Amount:  -int(amount),
+		Type:    points.EntryReverse,
+		OrderID: orderID,
+		Note:    note,
+	})
+	return err
+}
+
+// refundPoints takes back the share of the points the order earned for the refunded amount
+func refundPoints(ctx context.Context, tx *sql.Tx, orderID uint, userID string, refund, total uint) error {
+
+	if total == 0 {
+		return nil
+	}
+
+	earned, err := earnedPoints(ctx, tx, orderID)
+	if err != nil {
+		return err
+	}
+
+	return reversePoints(ctx, tx, orderID, userID, uint(uint64(earned)*uint64(refund)/uint64(total)), "order refunded")
+}
+
+// ExpirePoints expires what remains of the entries whose expiry date passed,
+// it returns how many entries expired
+func (storage Storage) ExpirePoints(ctx context.Context, now string) (uint, error) {
+
+	entries, err := getIDs(ctx, storage.MySQL,
+		"SELECT id FROM points_entry WHERE remaining > 0 AND expires_at <= ?",
+		now,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, entryID := range entries {
+		if err = storage.expireEntry(ctx, entryID); err != nil {
+			return 0, err
+		}
+	}
+
+	return uint(len(entries)), nil
+}
+
+func (storage Storage) expireEntry(ctx context.Context, entryID uint) error {
+
+	tx, err := storage.MySQL.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var userID string
+	if err = tx.QueryRowContext(ctx,
+		"SELECT user_id FROM points_entry WHERE id = ?",
+		entryID,
+	).Scan(&userID); err != nil {
+		return err
+	}
+
+	var locked string
+	if err = tx.QueryRowContext(ctx,
+		"SELECT id FROM user WHERE id = ? FOR UPDATE",
+		userID,
+	).Scan(&locked); err != nil {
+		return err
+	}
+
+	// the entry may have been spent since it was listed
+	var remaining uint
+	if err = tx.QueryRowContext(ctx,
+		"SELECT remaining FROM points_entry WHERE id = ? FOR UPDATE",
+		entryID,
+	).Scan(&remaining); err != nil {
+		return err
+	}
+	if remaining == 0 {
+		return nil
+	}
+
+	if _, err = tx.ExecContext(ctx, "UPDATE points_entry SET remaining = 0 WHERE id = ?", entryID); err != nil {
+		return err
+	}
+
+	if _, err = addPoints(ctx, tx, points.Entry{
+		UserID: userID,
+		Amount: -int(remaining),
+		Type:   points.EntryExpire,
+	}); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func getTopicMultipliers(ctx context.Context, q querier) ([]points.TopicMultiplier, error) {
+
+	result, err := q.QueryContext(ctx, "SELECT topic_id , multiplier FROM points_topic ORDER BY topic_id")
+	if err != nil {
+		return []points.TopicMultiplier{}, err
+	}
+	defer result.Close()
+
+	multipliers := []points.TopicMultiplier{}
+	for result.Next() {
+		var m points.TopicMultiplier
+
+		if err = result.Scan(&m.TopicID, &m.Multiplier); err != nil {
+			return []points.TopicMultiplier{}, err
+		}
+
+		multipliers = append(multipliers, m)
+	}
+
+	return multipliers, nil
+}
+
+func (storage Storage) GetTopicMultipliers(ctx context.Context) ([]points.TopicMultiplier, error) {
+	return getTopicMultipliers(ctx, storage.MySQL)
+}
+
+func (storage Storage) SetTopicMultiplier(ctx context.Context, m points.TopicMultiplier) error {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		`INSERT INTO points_topic (topic_id , multiplier) VALUES (? , ?)
+		ON DUPLICATE KEY UPDATE multiplier = VALUES(multiplier)`,
+	)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, m.TopicID, m.Multiplier)
+	return err
+}
+
+func (storage Storage) DeleteTopicMultiplier(ctx context.Context, topicID uint) error {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		"DELETE FROM points_topic WHERE topic_id = ?",
+	)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, topicID)
+	return err
+}
+
+func (storage Storage) DoesTopicMultiplierExist(ctx context.Context, topicID uint) (bool, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM points_topic WHERE topic_id = ?)",
+	)
+	if err != nil {
+		return false, err
+	}
+	defer stmt.Close()
+
+	var exists bool
+	if err = stmt.QueryRowContext(ctx, topicID).Scan(&exists); err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
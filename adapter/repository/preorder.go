@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/XBozorg/bookstore/entity/book"
+	"github.com/XBozorg/bookstore/entity/order"
+)
+
+// ReleasePreorders grants the download access of the books released by today and
+// reserves stock for their physical pre-orders, in payment order. Only the paid orders
+// are released, a book short on stock keeps its remaining pre-orders queued for the next run.
+func (storage Storage) ReleasePreorders(ctx context.Context, today string) (book.PreorderRelease, error) {
+
+	tx, err := storage.MySQL.BeginTx(ctx, nil)
+	if err != nil {
+		return book.PreorderRelease{}, err
+	}
+	defer tx.Rollback()
+
+	release := book.PreorderRelease{}
+
+	result, err := tx.ExecContext(ctx,
+		`UPDATE item i JOIN book b ON b.id = i.book_id JOIN orders o ON o.id = i.order_id
+		SET i.preorder = 0
+		WHERE i.preorder = 1 AND i.type = ? AND b.release_date <= ? AND o.status IN (? , ?)`,
+		order.Digital, today, order.StatusPaid, order.StatusVerified,
+	)
+	if err != nil {
+		return book.PreorderRelease{}, err
+	}
+
+	digital, err := result.RowsAffected()
+	if err != nil {
+		return book.PreorderRelease{}, err
+	}
+	release.Digital = uint(digital)
+
+	rows, err := tx.QueryContext(ctx,
+		`SELECT i.id , i.book_id , i.quantity , i.order_id FROM item i
+		JOIN book b ON b.id = i.book_id
+		JOIN orders o ON o.id = i.order_id
+		WHERE i.preorder = 1 AND i.type = ? AND b.release_date <= ? AND o.status IN (? , ?)
+		ORDER BY o.receipt_date , i.id
+		FOR UPDATE`,
+		order.Physical, today, order.StatusPaid, order.StatusVerified,
+	)
+	if err != nil {
+		return book.PreorderRelease{}, err
+	}
+
+	type queued struct {
+		item    order.Item
+		orderID uint
+	}
+
+	items := []queued{}
+	for rows.Next() {
+		var q queued
+
+		if err = rows.Scan(&q.item.ID, &q.item.BookID, &q.item.Quantity, &q.orderID); err != nil {
+			rows.Close()
+			return book.PreorderRelease{}, err
+		}
+		items = append(items, q)
+	}
+	rows.Close()
+
+	short := map[uint]bool{}
+	for _, q := range items {
+
+		if short[q.item.BookID] {
+			release.Queued += q.item.Quantity
+			continue
+		}
+
+		result, err := tx.ExecContext(ctx,
+			"UPDATE book SET physical_stock = physical_stock - ? WHERE id = ? AND physical_stock >= ?",
+			q.item.Quantity, q.item.BookID, q.item.Quantity,
+		)
+		if err != nil {
+			return book.PreorderRelease{}, err
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return book.PreorderRelease{}, err
+		}
+		if affected == 0 {
+			// later pre-orders of the book don't jump the queue
+			short[q.item.BookID] = true
+			release.Queued += q.item.Quantity
+			continue
+		}
+
+		if _, err = tx.ExecContext(ctx, "UPDATE item SET preorder = 0 WHERE id = ?", q.item.ID); err != nil {
+			return book.PreorderRelease{}, err
+		}
+
+		if _, err = storage.recordMovement(ctx, tx, book.Movement{
+			BookID:   q.item.BookID,
+			Type:     book.MovementReservation,
+			Quantity: -int(q.item.Quantity),
+			Reason:   "pre-order release",
+			OrderID:  q.orderID,
+		}); err != nil {
+			return book.PreorderRelease{}, err
+		}
+		release.Physical += q.item.Quantity
+	}
+
+	return release, tx.Commit()
+}
+
+// GetPreorderDemand returns the pending pre-orders per book, soonest release first
+func (storage Storage) GetPreorderDemand(ctx context.Context) ([]book.PreorderDemand, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		`SELECT b.id , b.title , COALESCE(DATE_FORMAT(b.release_date, '%Y-%m-%d'), '') ,
+		SUM(IF(i.type = ? AND o.status != ? , i.quantity , 0)) ,
+		SUM(IF(i.type = ? AND o.status != ? , i.quantity , 0)) ,
+		COUNT(DISTINCT IF(o.status != ? , o.id , NULL)) ,
+		COUNT(DISTINCT IF(o.status = ? , o.id , NULL))
+		FROM item i
+		JOIN book b ON b.id = i.book_id
+		JOIN orders o ON o.id = i.order_id
+		WHERE i.preorder = 1 AND b.deleted_at IS NULL
+		GROUP BY b.id , b.title , b.release_date
+		ORDER BY b.release_date , b.id`,
+	)
+	if err != nil {
+		return []book.PreorderDemand{}, err
+	}
+	defer stmt.Close()
+
+	result, err := stmt.QueryContext(ctx,
+		order.Digital, order.StatusCreated,
+		order.Physical, order.StatusCreated,
+		order.StatusCreated,
+		order.StatusCreated,
+	)
+	if err != nil {
+		return []book.PreorderDemand{}, err
+	}
+	defer result.Close()
+
+	demand := []book.PreorderDemand{}
+	for result.Next() {
+		var d book.PreorderDemand
+
+		if err = result.Scan(
+			&d.BookID,
+			&d.Title,
+			&d.ReleaseDate,
+			&d.Digital,
+			&d.Physical,
+			&d.Orders,
+			&d.Carts,
+		); err != nil {
+			return []book.PreorderDemand{}, err
+		}
+		demand = append(demand, d)
+	}
+
+	return demand, nil
+}
+
+// HasQueuedPreorders reports whether the order still waits for pre-ordered copies
+func (storage Storage) HasQueuedPreorders(ctx context.Context, orderID uint) (bool, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM item WHERE order_id = ? AND preorder = 1)",
+	)
+	if err != nil {
+		return false, err
+	}
+	defer stmt.Close()
+
+	var queued bool
+	if err = stmt.QueryRowContext(ctx, orderID).Scan(&queued); err != nil {
+		return false, err
+	}
+
+	return queued, nil
+}
@@ -0,0 +1,290 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/XBozorg/bookstore/config"
+	"github.com/XBozorg/bookstore/entity/order"
+	"github.com/XBozorg/bookstore/entity/pricing"
+)
+
+const promoSelect = `SELECT p.id , p.code , p.expiration , p.limit , p.percentage , COALESCE(p.max_price, 0) ,
+	p.public , p.user_limit , p.min_total , p.amount , p.free_shipping , p.scope , p.digital_only , p.stacking ,
+	COALESCE(p.batch_id, 0) ,
+	(SELECT COUNT(*) FROM promo_redemption r WHERE r.promo_id = p.id AND r.status != 'released') ,
+	COALESCE(GROUP_CONCAT(t.target_id ORDER BY t.target_id), '')
+	FROM promo p LEFT JOIN promo_target t ON t.promo_id = p.id `
+
+const promoInsert = `INSERT INTO promo
+	(code , expiration , promo.limit , percentage , max_price , public , user_limit , min_total ,
+	amount , free_shipping , scope , digital_only , stacking , batch_id)
+	VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,NULLIF(?, 0))`
+
+// insertPromo adds the promo and its scope with stmt, prepared from promoInsert
+func insertPromo(ctx context.Context, tx *sql.Tx, stmt *sql.Stmt, promo order.Promo) (uint, error) {
+
+	result, err := stmt.ExecContext(ctx,
+		promo.Code,
+		promo.Expiration,
+		promo.Limit,
+		promo.Percentage,
+		promo.MaxPrice,
+		promo.Public,
+		promo.UserLimit,
+		promo.MinTotal,
+		promo.Amount,
+		promo.FreeShipping,
+		promo.Scope,
+		promo.DigitalOnly,
+		promo.Stacking,
+		promo.BatchID,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	promoID, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	if err = setPromoTargets(ctx, tx, uint(promoID), promo.ScopeIDs); err != nil {
+		return 0, err
+	}
+
+	return uint(promoID), nil
+}
+
+func (storage Storage) IsPromoCodeTaken(ctx context.Context, promoCode string, public bool) (bool, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM promo WHERE code = ? AND (public = 1 OR ?))",
+	)
+	if err != nil {
+		return false, err
+	}
+	defer stmt.Close()
+
+	var taken bool
+	if err = stmt.QueryRowContext(ctx, promoCode, public).Scan(&taken); err != nil {
+		return false, err
+	}
+
+	return taken, nil
+}
+
+func getPromos(ctx context.Context, q querier, query string, args ...interface{}) ([]order.Promo, error) {
+
+	result, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return []order.Promo{}, err
+	}
+	defer result.Close()
+
+	promos := []order.Promo{}
+	for result.Next() {
+		var (
+			p       order.Promo
+			targets string
+		)
+
+		if err = result.Scan(
+			&p.ID,
+			&p.Code,
+			&p.Expiration,
+			&p.Limit,
+			&p.Percentage,
+			&p.MaxPrice,
+			&p.Public,
+			&p.UserLimit,
+			&p.MinTotal,
+			&p.Amount,
+			&p.FreeShipping,
+			&p.Scope,
+			&p.DigitalOnly,
+			&p.Stacking,
+			&p.BatchID,
+			&p.Used,
+			&targets,
+		); err != nil {
+			return []order.Promo{}, err
+		}
+
+		if p.ScopeIDs, err = parseIDs(targets); err != nil {
+			return []order.Promo{}, err
+		}
+
+		promos = append(promos, p)
+	}
+
+	return promos, nil
+}
+
+func getPromo(ctx context.Context, q querier, query string, args ...interface{}) (order.Promo, error) {
+
+	promos, err := getPromos(ctx, q, query, args...)
+	if err != nil {
+		return order.Promo{}, err
+	}
+	if len(promos) == 0 {
+		return order.Promo{}, sql.ErrNoRows
+	}
+
+	return promos[0], nil
+}
+
+func setPromoTargets(ctx context.Context, tx *sql.Tx, promoID uint, ids []uint) error {
+
+	stmt, err := tx.PrepareContext(ctx,
+		"INSERT IGNORE INTO promo_target (promo_id , target_id) VALUES (? , ?)",
+	)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, id := range ids {
+		if _, err = stmt.ExecContext(ctx, promoID, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// orderLines prices the items of the order for the promos, a bundle comes back as its
+// digital and physical lines and the lines of a box set share the price of the set,
+// followed by the gift cards of the order
+func (storage Storage) orderLines(ctx context.Context, tx *sql.Tx, orderID uint) ([]pricing.Line, error) {
+
+	result, err := tx.QueryContext(ctx,
+		"SELECT book_id , type , quantity , bundle , COALESCE(box_set_id, 0) FROM item WHERE order_id = ?",
+		orderID,
+	)
+	if err != nil {
+		return []pricing.Line{}, err
+	}
+
+	items := []order.Item{}
+	for result.Next() {
+		var i order.Item
+
+		if err = result.Scan(
+			&i.BookID,
+			&i.Type,
+			&i.Quantity,
+			&i.Bundled,
+			&i.BoxSetID,
+		); err != nil {
+			result.Close()
+			return []pricing.Line{}, err
+		}
+
+		items = append(items, i)
+	}
+	result.Close()
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+
+	campaigns, err := getActiveCampaigns(ctx, tx, now)
+	if err != nil {
+		return []pricing.Line{}, err
+	}
+
+	rules, err := getBundleRules(ctx, tx)
+	if err != nil {
+		return []pricing.Line{}, err
+	}
+
+	lines := []pricing.Line{}
+	sets := map[uint][]pricing.Line{}
+	setQuantity := map[uint]uint{}
+	setOrder := []uint{}
+
+	for _, item := range items {
+		b, err := getPricingBook(ctx, tx, item.BookID)
+		if err != nil {
+			return []pricing.Line{}, err
+		}
+
+		q := pricing.NewQuote(b, campaigns, rules, now)
+
+		// a bundle is taxed and promoted as its digital and physical lines
+		parts := []order.Item{item}
+		if item.Type == order.Bundle {
+			parts = []order.Item{
+				{Type: order.Digital, Quantity: 1, Bundled: true},
+				{Type: order.Physical, Quantity: item.Quantity, Bundled: true},
+			}
+		}
+
+		for _, part := range parts {
+			price, err := pricing.ItemPrice(q, part)
+			if err != nil {
+				return []pricing.Line{}, err
+			}
+
+			line := pricing.Line{Book: b, Price: price, BoxSetID: item.BoxSetID}
+			if part.Type == order.Physical {
+				line.Format, line.Campaign = pricing.FormatPhysical, q.Physical.CampaignID != 0
+			} else {
+				line.Format, line.Campaign = pricing.FormatDigital, q.Digital.CampaignID != 0
+			}
+
+			if item.BoxSetID == 0 {
+				lines = append(lines, line)
+				continue
+			}
+
+			if _, ok := sets[item.BoxSetID]; !ok {
+				setOrder = append(setOrder, item.BoxSetID)
+			}
+			sets[item.BoxSetID] = append(sets[item.BoxSetID], line)
+		}
+
+		if item.BoxSetID != 0 && item.Quantity > setQuantity[item.BoxSetID] {
+			setQuantity[item.BoxSetID] = item.Quantity
+		}
+	}
+
+	// a box set is already sold at its own price, shared among its books
+	for _, boxSetID := range setOrder {
+		var each uint
+		for _, l := range sets[boxSetID] {
+			each += l.Price
+		}
+
+		price, err := boxSetTotal(ctx, tx, boxSetID, setQuantity[boxSetID], each)
+		if err != nil {
+			return []pricing.Line{}, err
+		}
+
+		lines = append(lines, pricing.BoxSetLines(sets[boxSetID], price)...)
+	}
+
+	cards, err := tx.QueryContext(ctx,
+		"SELECT amount FROM gift_card WHERE order_id = ?",
+		orderID,
+	)
+	if err != nil {
+		return []pricing.Line{}, err
+	}
+	defer cards.Close()
+
+	for cards.Next() {
+		line := pricing.Line{Format: pricing.FormatDigital, GiftCard: true}
+		if err = cards.Scan(&line.Price); err != nil {
+			return []pricing.Line{}, err
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines, nil
+}
+
+func shippingFee() uint {
+	return config.Conf.GetPricingConfig().ShippingFee
+}
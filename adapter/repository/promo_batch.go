@@ -0,0 +1,220 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/XBozorg/bookstore/entity/order"
+)
+
+func (storage Storage) DoesPromoBatchExist(ctx context.Context, batchID uint) (bool, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM promo_batch WHERE id = ?)",
+	)
+	if err != nil {
+		return false, err
+	}
+	defer stmt.Close()
+
+	var exists bool
+	if err = stmt.QueryRowContext(ctx, batchID).Scan(&exists); err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
+
+// CreatePromoBatch generates the codes of the batch as public promos with its rules,
+// a code never repeats one that already exists
+func (storage Storage) CreatePromoBatch(ctx context.Context, batch order.PromoBatch) (order.PromoBatch, error) {
+
+	tx, err := storage.MySQL.BeginTx(ctx, nil)
+	if err != nil {
+		return order.PromoBatch{}, err
+	}
+	defer tx.Rollback()
+
+	batch.CreationDate = time.Now().Format("2006-01-02 15:04:05")
+
+	result, err := tx.ExecContext(ctx,
+		"INSERT INTO promo_batch (name , prefix , count , creation_date) VALUES (? , ? , ? , ?)",
+		batch.Name,
+		batch.Prefix,
+		batch.Count,
+		batch.CreationDate,
+	)
+	if err != nil {
+		return order.PromoBatch{}, err
+	}
+
+	batchID, err := result.LastInsertId()
+	if err != nil {
+		return order.PromoBatch{}, err
+	}
+	batch.ID = uint(batchID)
+
+	rows, err := tx.QueryContext(ctx,
+		"SELECT code FROM promo WHERE code LIKE CONCAT(? , '%')",
+		batch.Prefix,
+	)
+	if err != nil {
+		return order.PromoBatch{}, err
+	}
+
+	taken := map[string]bool{}
+	for rows.Next() {
+		var code string
+		if err = rows.Scan(&code); err != nil {
+			rows.Close()
+			return order.PromoBatch{}, err
+		}
+		taken[code] = true
+	}
+	rows.Close()
+
+	stmt, err := tx.PrepareContext(ctx, promoInsert)
+	if err != nil {
+		return order.PromoBatch{}, err
+	}
+	defer stmt.Close()
+
+	promo := batch.Promo
+	promo.Public, promo.BatchID = true, batch.ID
+
+	batch.Codes = make([]order.PromoBatchCode, 0, batch.Count)
+	for uint(len(batch.Codes)) < batch.Count {
+		if promo.Code, err = order.NewPromoCode(batch.Prefix); err != nil {
+			return order.PromoBatch{}, err
+		}
+		if taken[promo.Code] {
+			continue
+		}
+		taken[promo.Code] = true
+
+		if _, err = insertPromo(ctx, tx, stmt, promo); err != nil {
+			return order.PromoBatch{}, err
+		}
+
+		batch.Codes = append(batch.Codes, order.PromoBatchCode{Code: promo.Code, Left: promo.Limit})
+	}
+
+	if err = tx.Commit(); err != nil {
+		return order.PromoBatch{}, err
+	}
+
+	batch.Promo.Code = ""
+	return batch, nil
+}
+
+// GetPromoBatch returns the batch with the rules of its codes and how much each one was used
+func (storage Storage) GetPromoBatch(ctx context.Context, batchID uint) (order.PromoBatch, error) {
+
+	var batch order.PromoBatch
+	if err := storage.MySQL.QueryRowContext(ctx,
+		"SELECT id , name , prefix , count , creation_date FROM promo_batch WHERE id = ?",
+		batchID,
+	).Scan(
+		&batch.ID,
+		&batch.Name,
+		&batch.Prefix,
+		&batch.Count,
+		&batch.CreationDate,
+	); err != nil {
+		return order.PromoBatch{}, err
+	}
+
+	promo, err := getPromo(ctx, storage.MySQL, promoSelect+"WHERE p.batch_id = ? GROUP BY p.id LIMIT 1", batchID)
+	if err != nil {
+		return order.PromoBatch{}, err
+	}
+	promo.ID, promo.Code, promo.Used = 0, "", 0
+	batch.Promo = promo
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		`SELECT p.code , GREATEST(CAST(p.limit AS SIGNED) - COUNT(r.id), 0) , COALESCE(SUM(r.status = ?), 0)
+		FROM promo p
+		LEFT JOIN promo_redemption r ON r.promo_id = p.id AND r.status != ?
+		WHERE p.batch_id = ?
+		GROUP BY p.id ORDER BY p.id`,
+	)
+	if err != nil {
+		return order.PromoBatch{}, err
+	}
+	defer stmt.Close()
+
+	result, err := stmt.QueryContext(ctx,
+		order.RedemptionRedeemed,
+		order.RedemptionReleased,
+		batchID,
+	)
+	if err != nil {
+		return order.PromoBatch{}, err
+	}
+	defer result.Close()
+
+	batch.Codes = []order.PromoBatchCode{}
+	for result.Next() {
+		var c order.PromoBatchCode
+
+		if err = result.Scan(
+			&c.Code,
+			&c.Left,
+			&c.Redeemed,
+		); err != nil {
+			return order.PromoBatch{}, err
+		}
+
+		batch.Codes = append(batch.Codes, c)
+	}
+
+	return batch, nil
+}
+
+// GetPromoBatchReports sums up the paid orders of each batch, newest batch first
+func (storage Storage) GetPromoBatchReports(ctx context.Context) ([]order.PromoBatchReport, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		`SELECT b.id , b.name , b.count , COUNT(DISTINCT o.promo_id) , COUNT(o.id) ,
+		COALESCE(SUM(o.total), 0) , COALESCE(SUM(o.discount), 0)
+		FROM promo_batch b
+		LEFT JOIN promo p ON p.batch_id = b.id
+		LEFT JOIN orders o ON o.promo_id = p.id AND o.status IN (? , ? , ?)
+		GROUP BY b.id ORDER BY b.id DESC`,
+	)
+	if err != nil {
+		return []order.PromoBatchReport{}, err
+	}
+	defer stmt.Close()
+
+	result, err := stmt.QueryContext(ctx,
+		order.StatusPaid,
+		order.StatusVerified,
+		order.StatusShipped,
+	)
+	if err != nil {
+		return []order.PromoBatchReport{}, err
+	}
+	defer result.Close()
+
+	reports := []order.PromoBatchReport{}
+	for result.Next() {
+		var r order.PromoBatchReport
+
+		if err = result.Scan(
+			&r.BatchID,
+			&r.Name,
+			&r.Codes,
+			&r.Redeemed,
+			&r.Orders,
+			&r.Revenue,
+			&r.Discount,
+		); err != nil {
+			return []order.PromoBatchReport{}, err
+		}
+
+		reports = append(reports, r)
+	}
+
+	return reports, nil
+}
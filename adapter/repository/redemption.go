@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/XBozorg/bookstore/entity/order"
+)
+
+// countRedemptions counts what the other orders hold or redeemed of the promo,
+// over all users and for the user
+func countRedemptions(ctx context.Context, tx *sql.Tx, promoID uint, userID string, orderID uint) (uint, uint, error) {
+
+	var all, user uint
+	if err := tx.QueryRowContext(ctx,
+		`SELECT COUNT(*) , COALESCE(SUM(user_id = ?), 0) FROM promo_redemption
+		WHERE promo_id = ? AND status != ? AND order_id != ?`,
+		userID,
+		promoID,
+		order.RedemptionReleased,
+		orderID,
+	).Scan(&all, &user); err != nil {
+		return 0, 0, err
+	}
+
+	return all, user, nil
+}
+
+// holdRedemption makes the order hold the promo, what it held of another promo is released
+func holdRedemption(ctx context.Context, tx *sql.Tx, promoID, orderID uint, userID string) error {
+
+	if err := releaseRedemptions(ctx, tx, orderID, promoID); err != nil {
+		return err
+	}
+
+	var held bool
+	if err := tx.QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM promo_redemption WHERE order_id = ? AND promo_id = ? AND status = ?)",
+		orderID,
+		promoID,
+		order.RedemptionHeld,
+	).Scan(&held); err != nil {
+		return err
+	}
+
+	if held {
+		return nil
+	}
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO promo_redemption (promo_id , order_id , user_id , status , created , updated)
+		VALUES (? , ? , ? , ? , ? , ?)`,
+		promoID,
+		orderID,
+		userID,
+		order.RedemptionHeld,
+		now,
+		now,
+	)
+	return err
+}
+
+// releaseRedemptions releases the promos the order holds, but keepPromoID
+func releaseRedemptions(ctx context.Context, tx *sql.Tx, orderID, keepPromoID uint) error {
+
+	_, err := tx.ExecContext(ctx,
+		`UPDATE promo_redemption SET status = ? , updated = ?
+		WHERE order_id = ? AND status = ? AND promo_id != ?`,
+		order.RedemptionReleased,
+		time.Now().Format("2006-01-02 15:04:05"),
+		orderID,
+		order.RedemptionHeld,
+		keepPromoID,
+	)
+	return err
+}
+
+// lockOrderPromo locks the order until the end of tx and returns its promo
+func lockOrderPromo(ctx context.Context, tx *sql.Tx, orderID uint) (uint, error) {
+
+	var promoID uint
+	if err := tx.QueryRowContext(ctx,
+		"SELECT COALESCE(promo_id, 0) FROM orders WHERE id = ? FOR UPDATE",
+		orderID,
+	).Scan(&promoID); err != nil {
+		return 0, err
+	}
+
+	return promoID, nil
+}
+
+// settleRedemptions follows the status of the order: a paid order redeems its promo,
+// a cancelled one releases it, even once redeemed, so it gives the use of the promo back
+func settleRedemptions(ctx context.Context, tx *sql.Tx, orderID uint) error {
+
+	_, err := tx.ExecContext(ctx,
+		`UPDATE promo_redemption r JOIN orders o ON o.id = r.order_id
+		SET r.status = IF(o.status = ? , ? , ?) , r.updated = ?
+		WHERE r.order_id = ? AND o.status != ?
+		AND (r.status = ? OR (r.status = ? AND o.status = ?))`,
+		order.StatusCancelled,
+		order.RedemptionReleased,
+		order.RedemptionRedeemed,
+		time.Now().Format("2006-01-02 15:04:05"),
+		orderID,
+		order.StatusCreated,
+		order.RedemptionHeld,
+		order.RedemptionRedeemed,
+		order.StatusCancelled,
+	)
+	return err
+}
+
+// ReleaseAbandonedPromos takes the promos off the open orders that held them since
+// before the date, so an abandoned cart doesn't keep a redemption forever
+func (storage Storage) ReleaseAbandonedPromos(ctx context.Context, before string) (uint, error) {
+
+	orders, err := getIDs(ctx, storage.MySQL,
+		`SELECT DISTINCT r.order_id FROM promo_redemption r JOIN orders o ON o.id = r.order_id
+		WHERE r.status = ? AND o.status = ? AND r.updated < ?`,
+		order.RedemptionHeld,
+		order.StatusCreated,
+		before,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, orderID := range orders {
+		if err = storage.RemoveOrderPromo(ctx, orderID); err != nil {
+			return 0, err
+		}
+	}
+
+	return uint(len(orders)), nil
+}
+
+func (storage Storage) GetPromoRedemptions(ctx context.Context, promoID uint) ([]order.Redemption, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		`SELECT id , promo_id , order_id , user_id , status , created , updated
+		FROM promo_redemption WHERE promo_id = ? ORDER BY id DESC`,
+	)
+	if err != nil {
+		return []order.Redemption{}, err
+	}
+	defer stmt.Close()
+
+	result, err := stmt.QueryContext(ctx, promoID)
+	if err != nil {
+		return []order.Redemption{}, err
+	}
+	defer result.Close()
+
+	redemptions := []order.Redemption{}
+	for result.Next() {
+		var r order.Redemption
+
+		if err = result.Scan(
+			&r.ID,
+			&r.PromoID,
+			&r.OrderID,
+			&r.UserID,
+			&r.Status,
+			&r.Created,
+			&r.Updated,
+		); err != nil {
+			return []order.Redemption{}, err
+		}
+
+		redemptions = append(redemptions, r)
+	}
+
+	return redemptions, nil
+}
@@ -0,0 +1,449 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/XBozorg/bookstore/config"
+	"github.com/XBozorg/bookstore/entity/order"
+	"github.com/XBozorg/bookstore/entity/user"
+	"github.com/XBozorg/bookstore/entity/wallet"
+)
+
+func (storage Storage) DoesReferralCodeExist(ctx context.Context, code string) (bool, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM user WHERE referral_code = ?)",
+	)
+	if err != nil {
+		return false, err
+	}
+	defer stmt.Close()
+
+	var exists bool
+	if err = stmt.QueryRowContext(ctx, code).Scan(&exists); err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
+
+// addReferral attributes the new user to the owner of the code and gives it
+// the welcome promo, a user can't refer another address of its own mailbox
+func addReferral(ctx context.Context, tx *sql.Tx, code, refereeID, refereeEmail string) error {
+
+	var referrerID, referrerEmail string
+	if err := tx.QueryRowContext(ctx,
+		"SELECT id , email FROM user WHERE referral_code = ?",
+		code,
+	).Scan(&referrerID, &referrerEmail); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New("referral code does not exist")
+		}
+		return err
+	}
+
+	if user.NormalizeEmail(referrerEmail) == user.NormalizeEmail(refereeEmail) {
+		return errors.New("self referral is not allowed")
+	}
+
+	now := time.Now()
+
+	promoID, err := welcomePromo(ctx, tx, refereeID, now)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO referral (referrer_id , referee_id , promo_id , status , creation_date)
+		VALUES (? , ? , NULLIF(? , 0) , ? , ?)`,
+		referrerID,
+		refereeID,
+		promoID,
+		user.ReferralPending,
+		now.Format("2006-01-02 15:04:05"),
+	)
+	return err
+}
+
+// welcomePromo gives the referee a single use promo, none when the welcome percentage is 0
+func welcomePromo(ctx context.Context, tx *sql.Tx, refereeID string, now time.Time) (uint, error) {
+
+	conf := config.Conf.GetReferralConfig()
+	if conf.WelcomePercentage == 0 {
+		return 0, nil
+	}
+
+	code, err := order.NewPromoCode("WELCOME")
+	if err != nil {
+		return 0, err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, promoInsert)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	promoID, err := insertPromo(ctx, tx, stmt, order.Promo{
+		Code:       code,
+		Percentage: conf.WelcomePercentage,
+		MaxPrice:   conf.WelcomeMaxPrice,
+		Expiration: now.AddDate(0, 0, int(conf.WelcomeDays)).Format("2006-01-02 15:04:05"),
+		Limit:      1,
+		UserLimit:  1,
+		Stacking:   order.StackCampaigns,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err = tx.ExecContext(ctx,
+		"INSERT INTO promo_user (promo_id , user_id) VALUES (?,?)",
+		promoID,
+		refereeID,
+	); err != nil {
+		return 0, err
+	}
+
+	return promoID, nil
+}
+
+// settleReferral credits the referrer once the referee pays a first order worth the minimum
+// goods. The order going to the phone or the address of the referrer rejects the referral
+// instead, a cancelled order takes back the credit it earned.
+func settleReferral(ctx context.Context, tx *sql.Tx, orderID uint) error {
+
+	var (
+		status                 uint
+		refereeID              string
+		phoneID, addressID     uint
+		referralID             uint
+		referrerID             string
+		samePhone, sameAddress bool
+	)
+	if err := tx.QueryRowContext(ctx,
+		"SELECT status , user_id , COALESCE(phone_id, 0) , COALESCE(address_id, 0) FROM orders WHERE id = ?",
+		orderID,
+	).Scan(&status, &refereeID, &phoneID, &addressID); err != nil {
+		return err
+	}
+
+	switch status {
+	case order.StatusPaid, order.StatusVerified, order.StatusShipped:
+	case order.StatusCancelled:
+		return reverseReferral(ctx, tx, orderID, "order cancelled")
+	default:
+		return nil
+	}
+
+	goods, err := referralGoods(ctx, tx, orderID)
+	if err != nil {
+		return err
+	}
+	if !qualifies(goods) {
+		return nil
+	}
+
+	if err := tx.QueryRowContext(ctx,
+		"SELECT id , referrer_id FROM referral WHERE referee_id = ? AND status = ? FOR UPDATE",
+		refereeID,
+		user.ReferralPending,
+	).Scan(&referralID, &referrerID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	if err := tx.QueryRowContext(ctx,
+		`SELECT EXISTS(SELECT 1 FROM phone p JOIN phone r ON r.phonenumber = p.phonenumber
+		WHERE p.id = ? AND r.userID = ?)`,
+		phoneID,
+		referrerID,
+	).Scan(&samePhone); err != nil {
+		return err
+	}
+
+	if err := tx.QueryRowContext(ctx,
+		`SELECT EXISTS(SELECT 1 FROM address a JOIN address r
+		ON r.country = a.country AND r.city = a.city AND r.street = a.street
+		AND r.postalcode = a.postalcode AND r.no = a.no
+		WHERE a.id = ? AND r.userID = ?)`,
+		addressID,
+		referrerID,
+	).Scan(&sameAddress); err != nil {
+		return err
+	}
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+
+	if samePhone || sameAddress {
+		reason := "same phone as the referrer"
+		if sameAddress {
+			reason = "same address as the referrer"
+		}
+
+		_, err = tx.ExecContext(ctx,
+			"UPDATE referral SET status = ? , reason = ? , order_id = ? , settle_date = ? WHERE id = ?",
+			user.ReferralRejected,
+			reason,
+			orderID,
+			now,
+			referralID,
+		)
+		return err
+	}
+
+	credit := config.Conf.GetReferralConfig().ReferrerCredit
+	if credit > 0 {
+		if _, err := addEntry(ctx, tx, wallet.Entry{
+			UserID:  referrerID,
+			Amount:  int(credit),
+			Type:    wallet.EntryReferral,
+			OrderID: orderID,
+			Note:    "referral",
+		}); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE referral SET status = ? , reason = '' , order_id = ? , credit = ? , settle_date = ? WHERE id = ?",
+		user.ReferralRewarded,
+		orderID,
+		credit,
+		now,
+		referralID,
+	)
+	return err
+}
+
+// referralGoods is what the order paid but its gift cards and its refunds
+func referralGoods(ctx context.Context, tx *sql.Tx, orderID uint) (uint, error) {
+
+	var total, cards, refunded uint
+	if err := tx.QueryRowContext(ctx,
+		`SELECT o.total , COALESCE((SELECT SUM(amount) FROM gift_card WHERE order_id = o.id), 0) ,
+		COALESCE((SELECT SUM(amount) FROM wallet_entry WHERE order_id = o.id AND type = ?), 0)
+		FROM orders o WHERE o.id = ?`,
+		wallet.EntryRefund,
+		orderID,
+	).Scan(&total, &cards, &refunded); err != nil {
+		return 0, err
+	}
+
+	if cards+refunded >= total {
+		return 0, nil
+	}
+	return total - cards - refunded, nil
+}
+
+// qualifies reports whether an order with goods earns the referrer its credit
+func qualifies(goods uint) bool {
+	return goods > 0 && goods >= config.Conf.GetReferralConfig().MinGoods
+}
+
+// refundReferral takes back the credit of the referrer once the refunds leave
+// the order short of the minimum goods
+func refundReferral(ctx context.Context, tx *sql.Tx, orderID uint) error {
+
+	goods, err := referralGoods(ctx, tx, orderID)
+	if err != nil {
+		return err
+	}
+
+	if qualifies(goods) {
+		return nil
+	}
+
+	return reverseReferral(ctx, tx, orderID, "order refunded")
+}
+
+// reverseReferral takes back the credit the order earned the referrer, up to the balance
+// of its wallet, and puts the referral back to pending for a later order of the referee
+func reverseReferral(ctx context.Context, tx *sql.Tx, orderID uint, reason string) error {
+
+	var (
+		referralID, credit uint
+		referrerID         string
+	)
+	if err := tx.QueryRowContext(ctx,
+		"SELECT id , referrer_id , credit FROM referral WHERE order_id = ? AND status = ? FOR UPDATE",
+		orderID,
+		user.ReferralRewarded,
+	).Scan(&referralID, &referrerID, &credit); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	if credit > 0 {
+		var locked string
+		if err := tx.QueryRowContext(ctx,
+			"SELECT id FROM user WHERE id = ? FOR UPDATE",
+			referrerID,
+		).Scan(&locked); err != nil {
+			return err
+		}
+
+		balance, err := getBalance(ctx, tx, referrerID)
+		if err != nil {
+			return err
+		}
+
+		taken := credit
+		if balance < taken {
+			taken = balance
+		}
+
+		if taken > 0 {
+			if _, err = addEntry(ctx, tx, wallet.Entry{
+				UserID:  referrerID,
+				Amount:  -int(taken),
+				Type:    wallet.EntryReferral,
+				OrderID: orderID,
+				Note:    "referral reversed, " + reason,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err := tx.ExecContext(ctx,
+		"UPDATE referral SET status = ? , reason = ? , order_id = NULL , credit = 0 , settle_date = NULL WHERE id = ?",
+		user.ReferralPending,
+		reason,
+		referralID,
+	)
+	return err
+}
+
+// GetReferralInfo returns the referral code of the user and who signed up with it,
+// the users registered before the referral program get their code on the first call
+func (storage Storage) GetReferralInfo(ctx context.Context, userID string) (user.ReferralInfo, error) {
+
+	code, err := user.NewReferralCode()
+	if err != nil {
+		return user.ReferralInfo{}, err
+	}
+
+	if _, err = storage.MySQL.ExecContext(ctx,
+		"UPDATE user SET referral_code = ? WHERE id = ? AND referral_code IS NULL",
+		code,
+		userID,
+	); err != nil {
+		return user.ReferralInfo{}, err
+	}
+
+	info := user.ReferralInfo{}
+	if err = storage.MySQL.QueryRowContext(ctx,
+		"SELECT referral_code FROM user WHERE id = ?",
+		userID,
+	).Scan(&info.Code); err != nil {
+		return user.ReferralInfo{}, err
+	}
+
+	if info.Referrals, err = storage.GetUserReferrals(ctx, userID); err != nil {
+		return user.ReferralInfo{}, err
+	}
+
+	return info, nil
+}
+
+func (storage Storage) GetUserReferrals(ctx context.Context, referrerID string) ([]user.Referral, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		`SELECT r.id , r.referrer_id , r.referee_id , COALESCE(u.username, '') , COALESCE(r.promo_id, 0) ,
+		r.status , r.reason , COALESCE(r.order_id, 0) , r.credit , r.creation_date , COALESCE(r.settle_date, '')
+		FROM referral r JOIN user u ON u.id = r.referee_id
+		WHERE r.referrer_id = ? ORDER BY r.id DESC`,
+	)
+	if err != nil {
+		return []user.Referral{}, err
+	}
+	defer stmt.Close()
+
+	result, err := stmt.QueryContext(ctx, referrerID)
+	if err != nil {
+		return []user.Referral{}, err
+	}
+	defer result.Close()
+
+	referrals := []user.Referral{}
+	for result.Next() {
+		var r user.Referral
+
+		if err = result.Scan(
+			&r.ID,
+			&r.ReferrerID,
+			&r.RefereeID,
+			&r.Referee,
+			&r.PromoID,
+			&r.Status,
+			&r.Reason,
+			&r.OrderID,
+			&r.Credit,
+			&r.CreationDate,
+			&r.SettleDate,
+		); err != nil {
+			return []user.Referral{}, err
+		}
+
+		referrals = append(referrals, r)
+	}
+
+	return referrals, nil
+}
+
+// GetReferralReport sums up the referrals of each referrer, the most rewarded first
+func (storage Storage) GetReferralReport(ctx context.Context) ([]user.ReferralReport, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		`SELECT u.id , COALESCE(u.username, '') , u.email , COUNT(*) ,
+		COALESCE(SUM(r.status = ?), 0) , COALESCE(SUM(r.status = ?), 0) , COALESCE(SUM(r.status = ?), 0) ,
+		COALESCE(SUM(r.credit), 0)
+		FROM referral r JOIN user u ON u.id = r.referrer_id
+		GROUP BY u.id , u.username , u.email
+		ORDER BY 6 DESC , 4 DESC`,
+	)
+	if err != nil {
+		return []user.ReferralReport{}, err
+	}
+	defer stmt.Close()
+
+	result, err := stmt.QueryContext(ctx,
+		user.ReferralPending,
+		user.ReferralRewarded,
+		user.ReferralRejected,
+	)
+	if err != nil {
+		return []user.ReferralReport{}, err
+	}
+	defer result.Close()
+
+	reports := []user.ReferralReport{}
+	for result.Next() {
+		var r user.ReferralReport
+
+		if err = result.Scan(
+			&r.ReferrerID,
+			&r.Username,
+			&r.Email,
+			&r.Invited,
+			&r.Pending,
+			&r.Rewarded,
+			&r.Rejected,
+			&r.Credit,
+		); err != nil {
+			return []user.ReferralReport{}, err
+		}
+
+		reports = append(reports, r)
+	}
+
+	return reports, nil
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/XBozorg/bookstore/config"
+	"github.com/XBozorg/bookstore/entity/order"
+	"github.com/XBozorg/bookstore/entity/pricing"
+)
+
+func taxRule() pricing.TaxRule {
+
+	conf := config.Conf.GetTaxConfig()
+	return pricing.TaxRule{
+		Digital:      conf.DigitalRate,
+		Physical:     conf.PhysicalRate,
+		ExemptTopics: conf.ExemptTopics,
+		Inclusive:    conf.PricesIncludeTax,
+	}
+}
+
+// applyTax stores the tax of the order priced at t with the promo, nil without one, paid is its
+// total once the points are taken off. It returns the total to pay, the tax is added to it
+// unless the prices include it.
+func applyTax(ctx context.Context, tx *sql.Tx, orderID uint, lines []pricing.Line, promo *order.Promo, t pricing.Total, paid uint) (uint, error) {
+
+	rule := taxRule()
+	tax := pricing.NewTax(rule, lines, promo, t.Discount, t.Total-paid)
+
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE orders SET tax = ? , tax_inclusive = ? WHERE id = ?",
+		tax.Total,
+		rule.Inclusive,
+		orderID,
+	); err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM order_tax WHERE order_id = ?", orderID); err != nil {
+		return 0, err
+	}
+
+	stmt, err := tx.PrepareContext(ctx,
+		"INSERT INTO order_tax (order_id , rate , base , tax) VALUES (? , ? , ? , ?)",
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	for _, r := range tax.Rates {
+		if _, err = stmt.ExecContext(ctx, orderID, r.Rate, r.Base, r.Tax); err != nil {
+			return 0, err
+		}
+	}
+
+	if rule.Inclusive {
+		return paid, nil
+	}
+	return paid + tax.Total, nil
+}
+
+// setTaxRates fills in the tax breakdown of the orders
+func setTaxRates(ctx context.Context, q querier, orders []order.Order) error {
+
+	for i := range orders {
+		result, err := q.QueryContext(ctx,
+			"SELECT rate , base , tax FROM order_tax WHERE order_id = ? ORDER BY rate",
+			orders[i].ID,
+		)
+		if err != nil {
+			return err
+		}
+
+		orders[i].TaxRates = []order.TaxRate{}
+		for result.Next() {
+			var r order.TaxRate
+
+			if err = result.Scan(&r.Rate, &r.Base, &r.Tax); err != nil {
+				result.Close()
+				return err
+			}
+
+			orders[i].TaxRates = append(orders[i].TaxRates, r)
+		}
+		result.Close()
+	}
+
+	return nil
+}
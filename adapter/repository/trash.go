@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/XBozorg/bookstore/entity/book"
+)
+
+// column shown as the name of each trashed kind
+var trashNames = map[string]string{
+	book.KindBook:      "title",
+	book.KindAuthor:    "name",
+	book.KindPublisher: "name",
+	book.KindLanguage:  "code",
+}
+
+func (storage Storage) IsTrashed(ctx context.Context, kind string, id uint) (bool, error) {
+
+	if _, ok := trashNames[kind]; !ok {
+		return false, fmt.Errorf("unknown trash kind %q", kind)
+	}
+
+	// kind is one of the trashNames keys, which are also the table names
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM "+kind+" WHERE id = ? AND deleted_at IS NOT NULL)",
+	)
+	if err != nil {
+		return false, err
+	}
+	defer stmt.Close()
+
+	var trashed bool
+	if err = stmt.QueryRowContext(ctx, id).Scan(&trashed); err != nil {
+		return false, err
+	}
+
+	return trashed, nil
+}
+
+// GetTrash returns the soft deleted records of a kind, most recently deleted first
+func (storage Storage) GetTrash(ctx context.Context, kind string) ([]book.Trashed, error) {
+
+	column, ok := trashNames[kind]
+	if !ok {
+		return []book.Trashed{}, fmt.Errorf("unknown trash kind %q", kind)
+	}
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		"SELECT id , "+column+" , deleted_at FROM "+kind+" WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC",
+	)
+	if err != nil {
+		return []book.Trashed{}, err
+	}
+	defer stmt.Close()
+
+	result, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return []book.Trashed{}, err
+	}
+	defer result.Close()
+
+	records := []book.Trashed{}
+	for result.Next() {
+		t := book.Trashed{Kind: kind}
+
+		if err = result.Scan(
+			&t.ID,
+			&t.Name,
+			&t.DeletedAt,
+		); err != nil {
+			return []book.Trashed{}, err
+		}
+		records = append(records, t)
+	}
+
+	return records, nil
+}
+
+func (storage Storage) Restore(ctx context.Context, kind string, id uint) error {
+
+	if _, ok := trashNames[kind]; !ok {
+		return fmt.Errorf("unknown trash kind %q", kind)
+	}
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		"UPDATE "+kind+" SET deleted_at = NULL WHERE id = ?",
+	)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	if _, err = stmt.ExecContext(ctx, id); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Purge removes a trashed record for good, the foreign keys refuse it while
+// books or order items still reference the record
+func (storage Storage) Purge(ctx context.Context, kind string, id uint) error {
+
+	if _, ok := trashNames[kind]; !ok {
+		return fmt.Errorf("unknown trash kind %q", kind)
+	}
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		"DELETE FROM "+kind+" WHERE id = ? AND deleted_at IS NOT NULL",
+	)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	if _, err = stmt.ExecContext(ctx, id); err != nil {
+		return err
+	}
+
+	return nil
+}
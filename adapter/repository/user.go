@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"time"
 
@@ -10,7 +11,29 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
-func (storage Storage) CreateUser(ctx context.Context, u user.User) (user.User, error) {
+// CreateUser registers the user with a referral code of its own, a referralCode
+// attributes the signup to the user it belongs to
+func (storage Storage) CreateUser(ctx context.Context, u user.User, referralCode string) (user.User, error) {
+
+	tx, err := storage.MySQL.BeginTx(ctx, nil)
+	if err != nil {
+		return user.User{}, err
+	}
+	defer tx.Rollback()
+
+	u, err = createUser(ctx, tx, u, referralCode)
+	if err != nil {
+		return user.User{}, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return user.User{}, err
+	}
+
+	return u, nil
+}
+
+func createUser(ctx context.Context, tx *sql.Tx, u user.User, referralCode string) (user.User, error) {
 
 	hashedPassword, err := HashPassword(u.Password)
 	if err != nil {
@@ -20,10 +43,14 @@ func (storage Storage) CreateUser(ctx context.Context, u user.User) (user.User,
 
 	userID := uuid.NewV4().String()
 
-	stmt, err := storage.MySQL.PrepareContext(ctx,
+	if u.ReferralCode, err = user.NewReferralCode(); err != nil {
+		return user.User{}, err
+	}
+
+	stmt, err := tx.PrepareContext(ctx,
 		`INSERT INTO user 
-		(id, email, password, username, firstname, lastname, regdate) 
-		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		(id, email, password, username, firstname, lastname, regdate, referral_code) 
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
 	)
 	if err != nil {
 		return user.User{}, err
@@ -38,10 +65,17 @@ func (storage Storage) CreateUser(ctx context.Context, u user.User) (user.User,
 		u.FirstName,
 		u.LastName,
 		time.Now().Format("2006-01-02 15:04:05"),
+		u.ReferralCode,
 	); err != nil {
 		return user.User{}, err
 	}
 
+	if referralCode != "" {
+		if err = addReferral(ctx, tx, referralCode, userID, u.Email); err != nil {
+			return user.User{}, err
+		}
+	}
+
 	u.ID = userID
 	u.Password = ""
 
@@ -0,0 +1,393 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/XBozorg/bookstore/entity/order"
+	"github.com/XBozorg/bookstore/entity/wallet"
+)
+
+// due is what's left to pay of the total through the gateway
+func due(total, fromWallet uint) uint {
+	if fromWallet >= total {
+		return 0
+	}
+	return total - fromWallet
+}
+
+// addEntry appends an entry to the ledger of the user, the user row is locked
+// so the balance can't be spent twice
+func addEntry(ctx context.Context, tx *sql.Tx, e wallet.Entry) (wallet.Entry, error) {
+
+	var locked string
+	if err := tx.QueryRowContext(ctx,
+		"SELECT id FROM user WHERE id = ? FOR UPDATE",
+		e.UserID,
+	).Scan(&locked); err != nil {
+		return wallet.Entry{}, err
+	}
+
+	balance, err := getBalance(ctx, tx, e.UserID)
+	if err != nil {
+		return wallet.Entry{}, err
+	}
+
+	if e.Amount < 0 && uint(-e.Amount) > balance {
+		return wallet.Entry{}, errors.New("insufficient wallet balance")
+	}
+	e.Balance = uint(int(balance) + e.Amount)
+	e.Date = time.Now().Format("2006-01-02 15:04:05")
+
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO wallet_entry (user_id , amount , balance , type , order_id , gift_card_id , note , date)
+		VALUES (? , ? , ? , ? , NULLIF(? , 0) , NULLIF(? , 0) , ? , ?)`,
+		e.UserID,
+		e.Amount,
+		e.Balance,
+		e.Type,
+		e.OrderID,
+		e.GiftCardID,
+		e.Note,
+		e.Date,
+	)
+	if err != nil {
+		return wallet.Entry{}, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return wallet.Entry{}, err
+	}
+	e.ID = uint(id)
+
+	return e, nil
+}
+
+func getBalance(ctx context.Context, q querier, userID string) (uint, error) {
+
+	var balance uint
+	if err := q.QueryRowContext(ctx,
+		"SELECT COALESCE(SUM(amount), 0) FROM wallet_entry WHERE user_id = ?",
+		userID,
+	).Scan(&balance); err != nil {
+		return 0, err
+	}
+
+	return balance, nil
+}
+
+func (storage Storage) GetWallet(ctx context.Context, userID string) (wallet.Wallet, error) {
+
+	balance, err := getBalance(ctx, storage.MySQL, userID)
+	if err != nil {
+		return wallet.Wallet{}, err
+	}
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		`SELECT id , user_id , amount , balance , type , COALESCE(order_id, 0) , COALESCE(gift_card_id, 0) , note , date
+		FROM wallet_entry WHERE user_id = ? ORDER BY id DESC`,
+	)
+	if err != nil {
+		return wallet.Wallet{}, err
+	}
+	defer stmt.Close()
+
+	result, err := stmt.QueryContext(ctx, userID)
+	if err != nil {
+		return wallet.Wallet{}, err
+	}
+	defer result.Close()
+
+	w := wallet.Wallet{UserID: userID, Balance: balance, Entries: []wallet.Entry{}}
+	for result.Next() {
+		var e wallet.Entry
+
+		if err = result.Scan(
+			&e.ID,
+			&e.UserID,
+			&e.Amount,
+			&e.Balance,
+			&e.Type,
+			&e.OrderID,
+			&e.GiftCardID,
+			&e.Note,
+			&e.Date,
+		); err != nil {
+			return wallet.Wallet{}, err
+		}
+
+		w.Entries = append(w.Entries, e)
+	}
+
+	return w, nil
+}
+
+func (storage Storage) DoesUserOrderOpen(ctx context.Context, orderID uint, userID string) (bool, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM orders WHERE id = ? AND user_id = ? AND status = ?)",
+	)
+	if err != nil {
+		return false, err
+	}
+	defer stmt.Close()
+
+	var open bool
+	if err = stmt.QueryRowContext(ctx, orderID, userID, order.StatusCreated).Scan(&open); err != nil {
+		return false, err
+	}
+
+	return open, nil
+}
+
+func (storage Storage) IsOrderEmpty(ctx context.Context, orderID uint) (bool, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		`SELECT NOT EXISTS(SELECT 1 FROM item WHERE order_id = ?)
+		AND NOT EXISTS(SELECT 1 FROM gift_card WHERE order_id = ?)`,
+	)
+	if err != nil {
+		return false, err
+	}
+	defer stmt.Close()
+
+	var empty bool
+	if err = stmt.QueryRowContext(ctx, orderID, orderID).Scan(&empty); err != nil {
+		return false, err
+	}
+
+	return empty, nil
+}
+
+// SetOrderWallet pays amount of the open order from the wallet, up to its total,
+// the wallet gets back what the order took before
+func (storage Storage) SetOrderWallet(ctx context.Context, orderID uint, userID string, amount uint) error {
+
+	tx, err := storage.MySQL.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var total, taken uint
+	if err = tx.QueryRowContext(ctx,
+		"SELECT total , wallet FROM orders WHERE id = ? AND user_id = ? AND status = ? FOR UPDATE",
+		orderID,
+		userID,
+		order.StatusCreated,
+	).Scan(&total, &taken); err != nil {
+		return err
+	}
+
+	if amount > total {
+		amount = total
+	}
+
+	e := wallet.Entry{UserID: userID, OrderID: orderID, Amount: int(taken) - int(amount)}
+	switch {
+	case e.Amount < 0:
+		e.Type = wallet.EntryOrder
+	case e.Amount > 0:
+		e.Type = wallet.EntryRelease
+	default:
+		return tx.Commit()
+	}
+
+	if _, err = addEntry(ctx, tx, e); err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx,
+		"UPDATE orders SET wallet = ? WHERE id = ?",
+		amount,
+		orderID,
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// PayOrderWithWallet checks out an open order the wallet covers entirely, the order
+// stays locked from the due check to its settlement so the wallet can't take the funds back
+func (storage Storage) PayOrderWithWallet(ctx context.Context, orderID uint) error {
+
+	tx, err := storage.MySQL.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var status, total, fromWallet uint
+	if err = tx.QueryRowContext(ctx,
+		"SELECT status , total , wallet FROM orders WHERE id = ? FOR UPDATE",
+		orderID,
+	).Scan(&status, &total, &fromWallet); err != nil {
+		return err
+	}
+
+	if status != order.StatusCreated {
+		return errors.New("order is not open")
+	}
+
+	if due(total, fromWallet) != 0 {
+		return errors.New("wallet does not cover the order")
+	}
+
+	if err = storage.setOrderStatus(ctx, tx, order.StatusPaid, orderID); err != nil {
+		return err
+	}
+
+	// an order with physical items stays open without a phone and an address
+	if err = tx.QueryRowContext(ctx,
+		"SELECT status FROM orders WHERE id = ?",
+		orderID,
+	).Scan(&status); err != nil {
+		return err
+	}
+	if status != order.StatusPaid {
+		return errors.New("order needs a phone and an address")
+	}
+
+	if _, err = tx.ExecContext(ctx,
+		"UPDATE orders SET receipt_date = ? WHERE id = ?",
+		time.Now().Format("2006-01-02 15:04:05"),
+		orderID,
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// settleWallet gives the wallet back all the order took when it's cancelled but what
+// RefundOrder paid back already, and what the order took over its total when it's paid
+func settleWallet(ctx context.Context, tx *sql.Tx, orderID uint) error {
+
+	var (
+		status, total, taken, refunded uint
+		userID                         string
+	)
+	if err := tx.QueryRowContext(ctx,
+		`SELECT o.status , o.total , o.wallet , o.user_id ,
+		COALESCE((SELECT SUM(amount) FROM wallet_entry WHERE order_id = o.id AND type = ?), 0)
+		FROM orders o WHERE o.id = ? FOR UPDATE`,
+		wallet.EntryRefund,
+		orderID,
+	).Scan(&status, &total, &taken, &userID, &refunded); err != nil {
+		return err
+	}
+
+	keep := taken
+	switch {
+	case status == order.StatusCancelled:
+		keep = 0
+	case status != order.StatusCreated && taken > total:
+		keep = total
+	}
+
+	if keep == taken {
+		return nil
+	}
+
+	e := wallet.Entry{UserID: userID, OrderID: orderID, Amount: int(taken - keep), Type: wallet.EntryRelease}
+	if status == order.StatusCancelled {
+		// the refunds of the order came out of what it cost, they never go over its total
+		e.Type, e.Note = wallet.EntryRefund, "order cancelled"
+		e.Amount = 0
+		if refunded < total {
+			e.Amount = int(taken)
+			if total-refunded < taken {
+				e.Amount = int(total - refunded)
+			}
+		}
+	}
+
+	if e.Amount > 0 {
+		if _, err := addEntry(ctx, tx, e); err != nil {
+			return err
+		}
+	}
+
+	_, err := tx.ExecContext(ctx,
+		"UPDATE orders SET wallet = ? WHERE id = ?",
+		keep,
+		orderID,
+	)
+	return err
+}
+
+// RefundOrder credits the wallet of the buyer, the refunds of an order never go over its total.
+// The loyalty points the order earned are taken back in proportion, and the referral credit
+// once the order falls short of the minimum goods.
+func (storage Storage) RefundOrder(ctx context.Context, orderID, amount uint, note string) (wallet.Entry, error) {
+
+	tx, err := storage.MySQL.BeginTx(ctx, nil)
+	if err != nil {
+		return wallet.Entry{}, err
+	}
+	defer tx.Rollback()
+
+	var (
+		total, refunded uint
+		userID          string
+	)
+	if err = tx.QueryRowContext(ctx,
+		`SELECT o.total , o.user_id , COALESCE((SELECT SUM(amount) FROM wallet_entry WHERE order_id = o.id AND type = ?), 0)
+		FROM orders o WHERE o.id = ? FOR UPDATE`,
+		wallet.EntryRefund,
+		orderID,
+	).Scan(&total, &userID, &refunded); err != nil {
+		return wallet.Entry{}, err
+	}
+
+	if refunded+amount > total {
+		return wallet.Entry{}, errors.New("refund is more than the order total")
+	}
+
+	e, err := addEntry(ctx, tx, wallet.Entry{
+		UserID:  userID,
+		Amount:  int(amount),
+		Type:    wallet.EntryRefund,
+		OrderID: orderID,
+		Note:    note,
+	})
+	if err != nil {
+		return wallet.Entry{}, err
+	}
+
+	if err = refundPoints(ctx, tx, orderID, userID, amount, total); err != nil {
+		return wallet.Entry{}, err
+	}
+
+	if err = refundReferral(ctx, tx, orderID); err != nil {
+		return wallet.Entry{}, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return wallet.Entry{}, err
+	}
+
+	return e, nil
+}
+
+func (storage Storage) IsOrderPaid(ctx context.Context, orderID uint) (bool, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM orders WHERE id = ? AND status IN (? , ? , ?))",
+	)
+	if err != nil {
+		return false, err
+	}
+	defer stmt.Close()
+
+	var paid bool
+	if err = stmt.QueryRowContext(ctx, orderID, order.StatusPaid, order.StatusVerified, order.StatusShipped).Scan(&paid); err != nil {
+		return false, err
+	}
+
+	return paid, nil
+}
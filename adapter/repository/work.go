@@ -0,0 +1,423 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/XBozorg/bookstore/entity/book"
+)
+
+func (storage Storage) DoesWorkExist(ctx context.Context, workID uint) (bool, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM work WHERE id = ?)",
+	)
+	if err != nil {
+		return false, err
+	}
+	defer stmt.Close()
+
+	var exist bool
+	if err = stmt.QueryRowContext(ctx, workID).Scan(&exist); err != nil {
+		return false, err
+	}
+
+	return exist, nil
+}
+
+func (storage Storage) DoesSeriesExist(ctx context.Context, seriesID uint) (bool, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM series WHERE id = ?)",
+	)
+	if err != nil {
+		return false, err
+	}
+	defer stmt.Close()
+
+	var exist bool
+	if err = stmt.QueryRowContext(ctx, seriesID).Scan(&exist); err != nil {
+		return false, err
+	}
+
+	return exist, nil
+}
+
+func (storage Storage) AddSeries(ctx context.Context, seriesName string) (book.Series, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		"INSERT INTO series (name) VALUES (?)",
+	)
+	if err != nil {
+		return book.Series{}, err
+	}
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(ctx, seriesName)
+	if err != nil {
+		return book.Series{}, err
+	}
+
+	seriesID, err := result.LastInsertId()
+	if err != nil {
+		return book.Series{}, err
+	}
+
+	return book.Series{
+		ID:   uint(seriesID),
+		Name: seriesName,
+	}, nil
+}
+
+// GetSeries returns the series with its works in reading order
+func (storage Storage) GetSeries(ctx context.Context, seriesID uint) (book.Series, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		"SELECT name FROM series WHERE id = ?",
+	)
+	if err != nil {
+		return book.Series{}, err
+	}
+	defer stmt.Close()
+
+	series := book.Series{ID: seriesID}
+	if err = stmt.QueryRowContext(ctx, seriesID).Scan(&series.Name); err != nil {
+		return book.Series{}, err
+	}
+
+	works, err := storage.getWorks(ctx,
+		`SELECT id , title , COALESCE(description, '') , COALESCE(series_id, 0) , COALESCE(series_number, 0)
+		FROM work WHERE series_id = ? ORDER BY series_number , id`,
+		seriesID,
+	)
+	if err != nil {
+		return book.Series{}, err
+	}
+	series.Works = works
+
+	return series, nil
+}
+
+func (storage Storage) GetAllSeries(ctx context.Context) ([]book.Series, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		"SELECT id , name FROM series",
+	)
+	if err != nil {
+		return []book.Series{}, err
+	}
+	defer stmt.Close()
+
+	result, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return []book.Series{}, err
+	}
+	defer result.Close()
+
+	series := []book.Series{}
+	for result.Next() {
+		var s book.Series
+
+		if err = result.Scan(
+			&s.ID,
+			&s.Name,
+		); err != nil {
+			return []book.Series{}, err
+		}
+		series = append(series, s)
+	}
+
+	return series, nil
+}
+
+func (storage Storage) DeleteSeries(ctx context.Context, seriesID uint) error {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		"DELETE FROM series WHERE id = ?",
+	)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	if _, err = stmt.ExecContext(ctx, seriesID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (storage Storage) AddWork(ctx context.Context, w book.Work) (book.Work, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		"INSERT INTO work (title , description , series_id , series_number) VALUES (? , ? , NULLIF(? , 0) , NULLIF(? , 0))",
+	)
+	if err != nil {
+		return book.Work{}, err
+	}
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(ctx,
+		w.Title,
+		w.Description,
+		w.SeriesID,
+		w.SeriesNumber,
+	)
+	if err != nil {
+		return book.Work{}, err
+	}
+
+	workID, err := result.LastInsertId()
+	if err != nil {
+		return book.Work{}, err
+	}
+	w.ID = uint(workID)
+
+	return w, nil
+}
+
+func (storage Storage) EditWork(ctx context.Context, w book.Work) (book.Work, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		"UPDATE work SET title = ? , description = ? , series_id = NULLIF(? , 0) , series_number = NULLIF(? , 0) WHERE id = ?",
+	)
+	if err != nil {
+		return book.Work{}, err
+	}
+	defer stmt.Close()
+
+	if _, err = stmt.ExecContext(ctx,
+		w.Title,
+		w.Description,
+		w.SeriesID,
+		w.SeriesNumber,
+		w.ID,
+	); err != nil {
+		return book.Work{}, err
+	}
+
+	return w, nil
+}
+
+// GetWork returns the work with all of its editions, oldest first
+func (storage Storage) GetWork(ctx context.Context, workID uint) (book.Work, error) {
+
+	works, err := storage.getWorks(ctx,
+		`SELECT id , title , COALESCE(description, '') , COALESCE(series_id, 0) , COALESCE(series_number, 0)
+		FROM work WHERE id = ?`,
+		workID,
+	)
+	if err != nil {
+		return book.Work{}, err
+	}
+	if len(works) == 0 {
+		return book.Work{}, sql.ErrNoRows
+	}
+	w := works[0]
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		`SELECT b.id , b.title , b.isbn , b.pages , b.year , COALESCE(b.edition, '') ,
+		l.id , l.code , p.id , p.name , b.digital_price , b.digital_discount ,
+		b.physical_price , b.physical_discount , b.physical_stock , b.cover_front , b.availability
+		FROM book b
+		JOIN language l ON l.id = b.lang_id
+		JOIN publisher p ON p.id = b.publisher
+		WHERE b.work_id = ? AND b.deleted_at IS NULL ORDER BY b.year , b.id`,
+	)
+	if err != nil {
+		return book.Work{}, err
+	}
+	defer stmt.Close()
+
+	result, err := stmt.QueryContext(ctx, workID)
+	if err != nil {
+		return book.Work{}, err
+	}
+	defer result.Close()
+
+	w.Editions = []book.Book{}
+	for result.Next() {
+		b := book.Book{WorkID: workID}
+
+		if err = result.Scan(
+			&b.ID,
+			&b.Title,
+			&b.ISBN,
+			&b.Pages,
+			&b.Year,
+			&b.Edition,
+			&b.Language.ID,
+			&b.Language.Code,
+			&b.Publisher.ID,
+			&b.Publisher.Name,
+			&b.Digital.Price,
+			&b.Digital.Discount,
+			&b.Physical.Price,
+			&b.Physical.Discount,
+			&b.Physical.Stock,
+			&b.CoverFront,
+			&b.Availability,
+		); err != nil {
+			return book.Work{}, err
+		}
+		w.Editions = append(w.Editions, b)
+	}
+	if err = result.Err(); err != nil {
+		return book.Work{}, err
+	}
+
+	for i := range w.Editions {
+		if w.Editions[i].Authors, err = storage.GetBookAuthors(ctx, w.Editions[i].ID); err != nil {
+			return book.Work{}, err
+		}
+		if w.Editions[i].Contributors, err = storage.GetBookContributors(ctx, w.Editions[i].ID); err != nil {
+			return book.Work{}, err
+		}
+	}
+
+	return w, nil
+}
+
+func (storage Storage) GetWorks(ctx context.Context) ([]book.Work, error) {
+	return storage.getWorks(ctx,
+		"SELECT id , title , COALESCE(description, '') , COALESCE(series_id, 0) , COALESCE(series_number, 0) FROM work",
+	)
+}
+
+func (storage Storage) getWorks(ctx context.Context, query string, args ...interface{}) ([]book.Work, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx, query)
+	if err != nil {
+		return []book.Work{}, err
+	}
+	defer stmt.Close()
+
+	result, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return []book.Work{}, err
+	}
+	defer result.Close()
+
+	works := []book.Work{}
+	for result.Next() {
+		var w book.Work
+
+		if err = result.Scan(
+			&w.ID,
+			&w.Title,
+			&w.Description,
+			&w.SeriesID,
+			&w.SeriesNumber,
+		); err != nil {
+			return []book.Work{}, err
+		}
+		works = append(works, w)
+	}
+
+	return works, nil
+}
+
+func (storage Storage) DeleteWork(ctx context.Context, workID uint) error {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		"DELETE FROM work WHERE id = ?",
+	)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	if _, err = stmt.ExecContext(ctx, workID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SetBookWork attaches an edition to a work, a zero workID detaches it
+func (storage Storage) SetBookWork(ctx context.Context, bookID, workID uint, edition string) error {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		"UPDATE book SET work_id = NULLIF(? , 0) , edition = ? WHERE id = ?",
+	)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	if _, err = stmt.ExecContext(ctx, workID, edition, bookID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (storage Storage) AddContributor(ctx context.Context, bookID uint, c book.Contributor) error {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		"INSERT INTO book_author (book_id , author_id , role) VALUES (? , ? , ?)",
+	)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	if _, err = stmt.ExecContext(ctx, bookID, c.Author.ID, c.Role); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (storage Storage) RemoveContributor(ctx context.Context, bookID uint, c book.Contributor) error {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		"DELETE FROM book_author WHERE book_id = ? AND author_id = ? AND role = ?",
+	)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	if _, err = stmt.ExecContext(ctx, bookID, c.Author.ID, c.Role); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetBookContributors returns the translators, illustrators and editors of a book
+func (storage Storage) GetBookContributors(ctx context.Context, bookID uint) ([]book.Contributor, error) {
+
+	stmt, err := storage.MySQL.PrepareContext(ctx,
+		`SELECT a.id , a.name , ba.role FROM book_author ba
+		JOIN author a ON a.id = ba.author_id
+		WHERE ba.book_id = ? AND ba.role != ? ORDER BY ba.id`,
+	)
+	if err != nil {
+		return []book.Contributor{}, err
+	}
+	defer stmt.Close()
+
+	result, err := stmt.QueryContext(ctx, bookID, book.RoleAuthor)
+	if err != nil {
+		return []book.Contributor{}, err
+	}
+	defer result.Close()
+
+	contributors := []book.Contributor{}
+	for result.Next() {
+		var c book.Contributor
+
+		if err = result.Scan(
+			&c.Author.ID,
+			&c.Author.Name,
+			&c.Role,
+		); err != nil {
+			return []book.Contributor{}, err
+		}
+		contributors = append(contributors, c)
+	}
+
+	return contributors, nil
+}
@@ -0,0 +1,60 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/XBozorg/bookstore/adapter/notify"
+	"github.com/XBozorg/bookstore/adapter/repository"
+	"github.com/XBozorg/bookstore/config"
+	"github.com/XBozorg/bookstore/dto"
+	"github.com/XBozorg/bookstore/log"
+	"github.com/XBozorg/bookstore/usecase/order"
+)
+
+// Gifts sends the claim links of the gifted books of paid orders once their
+// delivery date comes
+func Gifts(storage repository.Storage) Job {
+
+	conf := config.Conf.GetGiftConfig()
+
+	return Job{
+		Name:     "gifts",
+		Interval: time.Duration(conf.CheckInterval) * time.Minute,
+		Run: func(ctx context.Context) error {
+
+			resp, err := order.New(storage).DeliverGifts(ctx, dto.DeliverGiftsRequest{
+				Now: time.Now().Format("2006-01-02 15:04:05"),
+			})
+			if err != nil {
+				return err
+			}
+
+			var sent int
+			for _, gift := range resp.Gifts {
+
+				body := fmt.Sprintf("A book was gifted to you, claim it at %s%s", conf.ClaimURL, gift.Token)
+				if gift.Message != "" {
+					body = gift.Message + "\r\n\r\n" + body
+				}
+
+				// a gift failing to send is retried on the next run
+				if err := notify.Mail(gift.RecipientEmail, "A book was gifted to you", body); err != nil {
+					log.E.WithField("gift", gift.ID).Errorln(err)
+					continue
+				}
+
+				if _, err := order.New(storage).SetGiftSent(ctx, dto.SetGiftSentRequest{GiftID: gift.ID}); err != nil {
+					return err
+				}
+				sent++
+			}
+
+			if sent > 0 {
+				log.I.Infof("%d gifted books delivered", sent)
+			}
+			return nil
+		},
+	}
+}
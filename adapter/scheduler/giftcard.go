@@ -0,0 +1,57 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/XBozorg/bookstore/adapter/notify"
+	"github.com/XBozorg/bookstore/adapter/repository"
+	"github.com/XBozorg/bookstore/config"
+	"github.com/XBozorg/bookstore/dto"
+	"github.com/XBozorg/bookstore/log"
+	"github.com/XBozorg/bookstore/usecase/wallet"
+)
+
+// GiftCards sends the codes of the paid gift cards to their recipients,
+// a card failing to send is retried on the next run
+func GiftCards(storage repository.Storage) Job {
+
+	conf := config.Conf.GetGiftCardConfig()
+
+	return Job{
+		Name:     "gift cards",
+		Interval: time.Duration(conf.CheckInterval) * time.Minute,
+		Run: func(ctx context.Context) error {
+
+			resp, err := wallet.New(storage).DeliverGiftCards(ctx, dto.DeliverGiftCardsRequest{})
+			if err != nil {
+				return err
+			}
+
+			var delivered int
+			for _, card := range resp.GiftCards {
+
+				body := fmt.Sprintf("You received a gift card of %d, redeem the code %s to add it to your wallet", card.Amount, card.Code)
+				if card.Message != "" {
+					body = card.Message + "\r\n\r\n" + body
+				}
+
+				if err := notify.Mail(card.RecipientEmail, "You received a gift card", body); err != nil {
+					log.E.WithField("gift_card", card.ID).Errorln(err)
+					continue
+				}
+
+				if _, err := wallet.New(storage).SetGiftCardDelivered(ctx, dto.SetGiftCardDeliveredRequest{GiftCardID: card.ID}); err != nil {
+					return err
+				}
+				delivered++
+			}
+
+			if delivered > 0 {
+				log.I.Infof("%d gift cards delivered", delivered)
+			}
+			return nil
+		},
+	}
+}
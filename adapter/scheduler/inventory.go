@@ -0,0 +1,45 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/XBozorg/bookstore/adapter/notify"
+	"github.com/XBozorg/bookstore/adapter/repository"
+	"github.com/XBozorg/bookstore/config"
+	"github.com/XBozorg/bookstore/dto"
+	"github.com/XBozorg/bookstore/usecase/book"
+)
+
+// LowStock refreshes the sales velocities and notifies the staff of the books
+// whose stock fell to their reorder threshold
+func LowStock(storage repository.Storage) Job {
+	conf := config.Conf.GetInventoryConfig()
+
+	return Job{
+		Name:     "low-stock",
+		Interval: time.Duration(conf.CheckInterval) * time.Minute,
+		Run: func(ctx context.Context) error {
+
+			resp, err := book.New(storage).CheckLowStock(ctx, dto.CheckLowStockRequest{VelocityDays: conf.VelocityDays})
+			if err != nil {
+				return err
+			}
+
+			for _, a := range resp.Crossed {
+				a.Suggested = a.SuggestReorder(conf.LeadDays, conf.CoverDays)
+
+				if err = notify.Send(ctx, notify.Message{
+					Event:   "stock.low",
+					Subject: fmt.Sprintf("%s (%s) is down to %d copies", a.Title, a.ISBN, a.Stock),
+					Data:    a,
+				}); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	}
+}
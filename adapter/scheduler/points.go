@@ -0,0 +1,37 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/XBozorg/bookstore/adapter/repository"
+	"github.com/XBozorg/bookstore/config"
+	"github.com/XBozorg/bookstore/dto"
+	"github.com/XBozorg/bookstore/log"
+	"github.com/XBozorg/bookstore/usecase/points"
+)
+
+// Points expires what is left of the loyalty points past their expiry date
+func Points(storage repository.Storage) Job {
+
+	conf := config.Conf.GetPointsConfig()
+
+	return Job{
+		Name:     "points",
+		Interval: time.Duration(conf.CheckInterval) * time.Minute,
+		Run: func(ctx context.Context) error {
+
+			now := time.Now().Format("2006-01-02 15:04:05")
+
+			resp, err := points.New(storage).ExpirePoints(ctx, dto.ExpirePointsRequest{Now: now})
+			if err != nil {
+				return err
+			}
+
+			if resp.Expired > 0 {
+				log.I.Infof("%d points entries expired", resp.Expired)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,42 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/XBozorg/bookstore/adapter/notify"
+	"github.com/XBozorg/bookstore/adapter/repository"
+	"github.com/XBozorg/bookstore/config"
+	"github.com/XBozorg/bookstore/dto"
+	"github.com/XBozorg/bookstore/usecase/book"
+)
+
+// Preorders grants the download access of the pre-ordered books on their release day
+// and hands the physical copies to the shipping as stock allows
+func Preorders(storage repository.Storage) Job {
+	return Job{
+		Name:     "preorders",
+		Interval: time.Duration(config.Conf.GetPreorderConfig().CheckInterval) * time.Minute,
+		Run: func(ctx context.Context) error {
+
+			resp, err := book.New(storage).ReleasePreorders(ctx, dto.ReleasePreordersRequest{
+				Today: time.Now().Format("2006-01-02"),
+			})
+			if err != nil {
+				return err
+			}
+
+			r := resp.Release
+			if r.Digital == 0 && r.Physical == 0 {
+				return nil
+			}
+
+			return notify.Send(ctx, notify.Message{
+				Event:   "preorder.released",
+				Subject: fmt.Sprintf("%d digital and %d physical pre-ordered copies released, %d waiting for stock", r.Digital, r.Physical, r.Queued),
+				Data:    r,
+			})
+		},
+	}
+}
@@ -0,0 +1,39 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/XBozorg/bookstore/adapter/repository"
+	"github.com/XBozorg/bookstore/config"
+	"github.com/XBozorg/bookstore/dto"
+	"github.com/XBozorg/bookstore/log"
+	"github.com/XBozorg/bookstore/usecase/pricing"
+)
+
+// Campaigns reprices the open carts whenever a campaign starts or ends, so an expired
+// sale never reaches the checkout
+func Campaigns(storage repository.Storage) Job {
+
+	since := time.Now().Format("2006-01-02 15:04:05")
+
+	return Job{
+		Name:     "campaigns",
+		Interval: time.Duration(config.Conf.GetPricingConfig().CheckInterval) * time.Minute,
+		Run: func(ctx context.Context) error {
+
+			until := time.Now().Format("2006-01-02 15:04:05")
+
+			resp, err := pricing.New(storage).RefreshCampaigns(ctx, dto.RefreshCampaignsRequest{Since: since, Until: until})
+			if err != nil {
+				return err
+			}
+			since = until
+
+			if resp.Changed > 0 {
+				log.I.Infof("Open orders repriced, %d campaigns started or ended", resp.Changed)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,38 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/XBozorg/bookstore/adapter/repository"
+	"github.com/XBozorg/bookstore/config"
+	"github.com/XBozorg/bookstore/dto"
+	"github.com/XBozorg/bookstore/log"
+	"github.com/XBozorg/bookstore/usecase/order"
+)
+
+// Promos releases the redemptions of the open orders abandoned with a promo code,
+// they count against the promo limits until then
+func Promos(storage repository.Storage) Job {
+
+	conf := config.Conf.GetPromoConfig()
+
+	return Job{
+		Name:     "promos",
+		Interval: time.Duration(conf.CheckInterval) * time.Minute,
+		Run: func(ctx context.Context) error {
+
+			before := time.Now().Add(-time.Duration(conf.HoldHours) * time.Hour).Format("2006-01-02 15:04:05")
+
+			resp, err := order.New(storage).ReleaseAbandonedPromos(ctx, dto.ReleaseAbandonedPromosRequest{Before: before})
+			if err != nil {
+				return err
+			}
+
+			if resp.Released > 0 {
+				log.I.Infof("Promo codes released from %d abandoned orders", resp.Released)
+			}
+			return nil
+		},
+	}
+}
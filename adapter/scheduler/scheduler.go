@@ -0,0 +1,53 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/XBozorg/bookstore/log"
+)
+
+// Job runs every interval, the first run happens right after Start
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+type Scheduler struct {
+	jobs []Job
+}
+
+func New() *Scheduler { return &Scheduler{} }
+
+func (s *Scheduler) Add(job Job) { s.jobs = append(s.jobs, job) }
+
+// Start runs every job in its own goroutine until ctx is done,
+// a failed run is logged and retried on the next tick
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, job := range s.jobs {
+		if job.Interval <= 0 {
+			log.I.Infof("Job %s disabled", job.Name)
+			continue
+		}
+		go run(ctx, job)
+	}
+}
+
+func run(ctx context.Context, job Job) {
+
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := job.Run(ctx); err != nil {
+			log.E.WithField("job", job.Name).Errorln(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
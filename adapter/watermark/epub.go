@@ -0,0 +1,172 @@
+package watermark
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/XBozorg/bookstore/entity/book"
+)
+
+const (
+	epubPageID   = "bookstore-watermark"
+	epubPageName = "bookstore-watermark.xhtml"
+)
+
+const epubPage = `<?xml version="1.0" encoding="utf-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml">
+<head><title>License</title></head>
+<body>
+<p style="text-align:center;font-size:small">%s</p>
+</body>
+</html>
+`
+
+var (
+	manifestEnd = regexp.MustCompile(`</([\w-]+:)?manifest>`)
+	spineEnd    = regexp.MustCompile(`</([\w-]+:)?spine>`)
+	metadataEnd = regexp.MustCompile(`</([\w-]+:)?metadata>`)
+)
+
+type container struct {
+	Rootfiles []struct {
+		FullPath string `xml:"full-path,attr"`
+	} `xml:"rootfiles>rootfile"`
+}
+
+// stampEPUB copies the archive, appending a license page to the spine and the buyer to the OPF metadata
+func stampEPUB(src, dst string, w book.Watermark) error {
+
+	r, err := zip.OpenReader(src)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	opfPath, err := epubOPFPath(&r.Reader)
+	if err != nil {
+		return err
+	}
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	zw := zip.NewWriter(out)
+
+	for _, f := range r.File {
+		if f.Name != opfPath {
+			// mimetype stays first and stored, as the container requires
+			if err = zw.Copy(f); err != nil {
+				return err
+			}
+			continue
+		}
+
+		opf, err := readZipFile(f)
+		if err != nil {
+			return err
+		}
+
+		fw, err := zw.CreateHeader(&zip.FileHeader{Name: f.Name, Method: zip.Deflate, Modified: f.Modified})
+		if err != nil {
+			return err
+		}
+		if _, err = io.WriteString(fw, stampOPF(opf, w)); err != nil {
+			return err
+		}
+	}
+
+	fw, err := zw.Create(path.Join(path.Dir(opfPath), epubPageName))
+	if err != nil {
+		return err
+	}
+	if _, err = fmt.Fprintf(fw, epubPage, html.EscapeString(w.Text())); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+func epubOPFPath(r *zip.Reader) (string, error) {
+
+	for _, f := range r.File {
+		if f.Name != "META-INF/container.xml" {
+			continue
+		}
+
+		data, err := readZipFile(f)
+		if err != nil {
+			return "", err
+		}
+
+		c := container{}
+		if err = xml.Unmarshal([]byte(data), &c); err != nil {
+			return "", err
+		}
+		if len(c.Rootfiles) == 0 || c.Rootfiles[0].FullPath == "" {
+			break
+		}
+
+		return c.Rootfiles[0].FullPath, nil
+	}
+
+	return "", errors.New("epub package document not found")
+}
+
+func stampOPF(opf string, w book.Watermark) string {
+
+	text := html.EscapeString(w.Text())
+
+	opf = insertBefore(opf, metadataEnd,
+		fmt.Sprintf(`<meta name="bookstore:license" content="%s"/>`, text))
+	opf = insertBefore(opf, manifestEnd,
+		fmt.Sprintf(`<item id="%s" href="%s" media-type="application/xhtml+xml"/>`, epubPageID, epubPageName))
+	opf = insertBefore(opf, spineEnd,
+		fmt.Sprintf(`<itemref idref="%s"/>`, epubPageID))
+
+	return opf
+}
+
+// insertBefore places s before the closing tag matched by re, keeping the tag's namespace prefix
+func insertBefore(doc string, re *regexp.Regexp, s string) string {
+
+	loc := re.FindStringSubmatchIndex(doc)
+	if loc == nil {
+		return doc
+	}
+
+	prefix := ""
+	if loc[2] >= 0 {
+		prefix = doc[loc[2]:loc[3]]
+		s = strings.Replace(s, "<", "<"+prefix, 1)
+	}
+
+	return doc[:loc[0]] + s + "\n" + doc[loc[0]:]
+}
+
+func readZipFile(f *zip.File) (string, error) {
+
+	rc, err := f.Open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
@@ -0,0 +1,18 @@
+package watermark
+
+import (
+	"github.com/XBozorg/bookstore/entity/book"
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+)
+
+// footer text at the bottom center of every page
+const pdfFooter = "font:Helvetica, points:7, position:bc, offset:0 12, scalefactor:1 abs, rotation:0, opacity:0.6, color:0.3 0.3 0.3"
+
+func stampPDF(src, dst string, w book.Watermark) error {
+
+	conf := pdfcpu.NewDefaultConfiguration()
+	conf.ValidationMode = pdfcpu.ValidationRelaxed
+
+	return api.AddTextWatermarksFile(src, dst, nil, true, w.Text(), pdfFooter, conf)
+}
@@ -0,0 +1,63 @@
+package watermark
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/XBozorg/bookstore/config"
+	"github.com/XBozorg/bookstore/entity/book"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+)
+
+func init() {
+	pdfcpu.ConfigPath = "disable" // don't create pdfcpu's config.yml in the user config dir
+}
+
+// Stamp returns the path of a copy of src watermarked for the buyer, cached per user.
+// Formats that can't be watermarked are returned as is.
+func Stamp(src, format, userID string, bookID uint, w book.Watermark) (string, error) {
+
+	var stamp func(src, dst string, w book.Watermark) error
+	switch format {
+	case book.FormatPDF:
+		stamp = stampPDF
+	case book.FormatEPUB:
+		stamp = stampEPUB
+	default:
+		return src, nil
+	}
+
+	dst := filepath.Join(config.Conf.GetWatermarkConfig().CacheDir, userID, fmt.Sprintf("%d.%s", bookID, format))
+
+	srcInfo, err := os.Stat(src)
+	if err != nil {
+		return "", err
+	}
+
+	// a cached copy is valid until the original file gets replaced
+	if dstInfo, err := os.Stat(dst); err == nil && !dstInfo.ModTime().Before(srcInfo.ModTime()) {
+		return dst, nil
+	}
+
+	if err = os.MkdirAll(filepath.Dir(dst), 0750); err != nil {
+		return "", err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), "*."+format)
+	if err != nil {
+		return "", err
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+
+	if err = stamp(src, tmp.Name(), w); err != nil {
+		return "", err
+	}
+
+	if err = os.Rename(tmp.Name(), dst); err != nil {
+		return "", err
+	}
+
+	return dst, nil
+}
@@ -7,11 +7,24 @@ import (
 var Conf Config
 
 type Config struct {
-	mySQL    MySQLConfig    `mapstructure:"mysql"`
-	jwt      JwtConfig      `mapstructure:"jwt"`
-	echo     EchoConfig     `mapstructure:"echo"`
-	zarinpal ZarinpalConfig `mapstructure:"zarinpal"`
-	redis    RedisConfig    `mapstructure:"redis"`
+	mySQL     MySQLConfig     `mapstructure:"mysql"`
+	jwt       JwtConfig       `mapstructure:"jwt"`
+	echo      EchoConfig      `mapstructure:"echo"`
+	zarinpal  ZarinpalConfig  `mapstructure:"zarinpal"`
+	redis     RedisConfig     `mapstructure:"redis"`
+	download  DownloadConfig  `mapstructure:"download"`
+	watermark WatermarkConfig `mapstructure:"watermark"`
+	metadata  MetadataConfig  `mapstructure:"metadata"`
+	inventory InventoryConfig `mapstructure:"inventory"`
+	notify    NotifyConfig    `mapstructure:"notify"`
+	preorder  PreorderConfig  `mapstructure:"preorder"`
+	pricing   PricingConfig   `mapstructure:"pricing"`
+	promo     PromoConfig     `mapstructure:"promo"`
+	giftCard  GiftCardConfig  `mapstructure:"gift_card"`
+	gift      GiftConfig      `mapstructure:"gift"`
+	points    PointsConfig    `mapstructure:"points"`
+	referral  ReferralConfig  `mapstructure:"referral"`
+	tax       TaxConfig       `mapstructure:"tax"`
 }
 
 type MySQLConfig struct {
@@ -39,12 +52,92 @@ type RedisConfig struct {
 	Pass    string `mapstructure:"pass"`
 	DB      int    `mapstructure:"db"`
 }
+type DownloadConfig struct {
+	Secret        string `mapstructure:"secret"`
+	LinkTTL       int    `mapstructure:"link_ttl"`       // minutes
+	MaxDownloads  uint   `mapstructure:"max_downloads"`  // per user per book, 0 = unlimited
+	SuspiciousIPs uint   `mapstructure:"suspicious_ips"` // distinct IPs per user/book to flag as shared
+}
+type WatermarkConfig struct {
+	Enabled  bool   `mapstructure:"enabled"`
+	CacheDir string `mapstructure:"cache_dir"`
+}
+type MetadataConfig struct {
+	CoverDir string `mapstructure:"cover_dir"`
+}
+type InventoryConfig struct {
+	CheckInterval int  `mapstructure:"check_interval"` // minutes
+	VelocityDays  uint `mapstructure:"velocity_days"`  // sales window of the velocity
+	LeadDays      uint `mapstructure:"lead_days"`      // days between ordering and receiving stock
+	CoverDays     uint `mapstructure:"cover_days"`     // days of sales a reorder should cover
+}
+type PreorderConfig struct {
+	CheckInterval int `mapstructure:"check_interval"` // minutes
+}
+type PricingConfig struct {
+	CheckInterval int  `mapstructure:"check_interval"` // minutes
+	ShippingFee   uint `mapstructure:"shipping_fee"`   // per order with physical items
+}
+type PromoConfig struct {
+	CheckInterval int  `mapstructure:"check_interval"` // minutes
+	HoldHours     uint `mapstructure:"hold_hours"`     // an open order keeps its promo this long
+}
+type GiftCardConfig struct {
+	CheckInterval int  `mapstructure:"check_interval"` // minutes
+	MinAmount     uint `mapstructure:"min_amount"`
+	MaxAmount     uint `mapstructure:"max_amount"`
+}
+type GiftConfig struct {
+	CheckInterval int    `mapstructure:"check_interval"` // minutes
+	ClaimURL      string `mapstructure:"claim_url"`      // the token of the gift is appended to it
+}
+type PointsConfig struct {
+	CheckInterval int  `mapstructure:"check_interval"` // minutes
+	EarnUnit      uint `mapstructure:"earn_unit"`      // paid for goods to earn a point, 0 = no points earned
+	PointValue    uint `mapstructure:"point_value"`    // taken off an order per redeemed point
+	EarnStatus    uint `mapstructure:"earn_status"`    // order status crediting the points
+	ExpiryDays    uint `mapstructure:"expiry_days"`    // 0 = never expire
+}
+type ReferralConfig struct {
+	ReferrerCredit    uint `mapstructure:"referrer_credit"`    // put in the wallet of the referrer
+	WelcomePercentage uint `mapstructure:"welcome_percentage"` // promo given to the referee
+	WelcomeMaxPrice   uint `mapstructure:"welcome_max_price"`  // 0 = no cap
+	WelcomeDays       uint `mapstructure:"welcome_days"`       // the welcome promo expires after
+	MinGoods          uint `mapstructure:"min_goods"`          // the first order pays but gift cards
+}
+type TaxConfig struct {
+	DigitalRate      uint   `mapstructure:"digital_rate"`       // percent
+	PhysicalRate     uint   `mapstructure:"physical_rate"`      // percent
+	ExemptTopics     []uint `mapstructure:"exempt_topics"`      // with their subtopics
+	PricesIncludeTax bool   `mapstructure:"prices_include_tax"` // otherwise the tax is added at checkout
+	Display          string `mapstructure:"display"`            // catalog prices, inclusive or exclusive
+}
+type NotifyConfig struct {
+	WebhookURL  string `mapstructure:"webhook_url"`  // empty = log only
+	SMTPAddress string `mapstructure:"smtp_address"` // host:port, empty = no mail to the customers
+	SMTPUser    string `mapstructure:"smtp_user"`    // empty = no authentication
+	SMTPPass    string `mapstructure:"smtp_pass"`
+	MailFrom    string `mapstructure:"mail_from"`
+}
 
-func (c *Config) GetMySQlConfig() *MySQLConfig       { return &c.mySQL }
-func (c *Config) GetJWTConfig() *JwtConfig           { return &c.jwt }
-func (c *Config) GetEchoConfig() *EchoConfig         { return &c.echo }
-func (c *Config) GetZarinpalConfig() *ZarinpalConfig { return &c.zarinpal }
-func (c *Config) GetRedisConfig() *RedisConfig       { return &c.redis }
+func (c *Config) GetMySQlConfig() *MySQLConfig         { return &c.mySQL }
+func (c *Config) GetJWTConfig() *JwtConfig             { return &c.jwt }
+func (c *Config) GetEchoConfig() *EchoConfig           { return &c.echo }
+func (c *Config) GetZarinpalConfig() *ZarinpalConfig   { return &c.zarinpal }
+func (c *Config) GetRedisConfig() *RedisConfig         { return &c.redis }
+func (c *Config) GetDownloadConfig() *DownloadConfig   { return &c.download }
+func (c *Config) GetWatermarkConfig() *WatermarkConfig { return &c.watermark }
+func (c *Config) GetMetadataConfig() *MetadataConfig   { return &c.metadata }
+func (c *Config) GetInventoryConfig() *InventoryConfig { return &c.inventory }
+func (c *Config) GetNotifyConfig() *NotifyConfig       { return &c.notify }
+func (c *Config) GetPreorderConfig() *PreorderConfig   { return &c.preorder }
+func (c *Config) GetPricingConfig() *PricingConfig     { return &c.pricing }
+func (c *Config) GetPromoConfig() *PromoConfig         { return &c.promo }
+func (c *Config) GetGiftCardConfig() *GiftCardConfig   { return &c.giftCard }
+func (c *Config) GetGiftConfig() *GiftConfig           { return &c.gift }
+func (c *Config) GetPointsConfig() *PointsConfig       { return &c.points }
+func (c *Config) GetReferralConfig() *ReferralConfig   { return &c.referral }
+func (c *Config) GetTaxConfig() *TaxConfig             { return &c.tax }
 
 func (c *Config) Read() error {
 	v := viper.New()
@@ -71,6 +164,45 @@ func (c *Config) Read() error {
 	if err := v.UnmarshalKey("redis", &c.redis); err != nil {
 		return err
 	}
+	if err := v.UnmarshalKey("download", &c.download); err != nil {
+		return err
+	}
+	if err := v.UnmarshalKey("watermark", &c.watermark); err != nil {
+		return err
+	}
+	if err := v.UnmarshalKey("metadata", &c.metadata); err != nil {
+		return err
+	}
+	if err := v.UnmarshalKey("inventory", &c.inventory); err != nil {
+		return err
+	}
+	if err := v.UnmarshalKey("notify", &c.notify); err != nil {
+		return err
+	}
+	if err := v.UnmarshalKey("preorder", &c.preorder); err != nil {
+		return err
+	}
+	if err := v.UnmarshalKey("pricing", &c.pricing); err != nil {
+		return err
+	}
+	if err := v.UnmarshalKey("promo", &c.promo); err != nil {
+		return err
+	}
+	if err := v.UnmarshalKey("gift_card", &c.giftCard); err != nil {
+		return err
+	}
+	if err := v.UnmarshalKey("gift", &c.gift); err != nil {
+		return err
+	}
+	if err := v.UnmarshalKey("points", &c.points); err != nil {
+		return err
+	}
+	if err := v.UnmarshalKey("referral", &c.referral); err != nil {
+		return err
+	}
+	if err := v.UnmarshalKey("tax", &c.tax); err != nil {
+		return err
+	}
 
 	return nil
 }
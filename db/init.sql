@@ -17,9 +17,11 @@ CREATE TABLE IF NOT EXISTS `user` (
   `firstname` varchar(80) NOT NULL,
   `lastname` varchar(80) NOT NULL,
   `regdate` datetime NOT NULL,
+  `referral_code` varchar(12) DEFAULT NULL,
   PRIMARY KEY (`id`),
   UNIQUE KEY `email` (`email`),
-  UNIQUE KEY `username` (`username`)
+  UNIQUE KEY `username` (`username`),
+  UNIQUE KEY `referral_code` (`referral_code`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
 
 CREATE TABLE IF NOT EXISTS `address` (
@@ -51,6 +53,7 @@ CREATE TABLE IF NOT EXISTS `phone` (
 CREATE TABLE IF NOT EXISTS `language` (
   `id` int unsigned NOT NULL AUTO_INCREMENT,
   `code` varchar(2) NOT NULL,
+  `deleted_at` datetime DEFAULT NULL,
   PRIMARY KEY (`id`),
   UNIQUE KEY `language_UN` (`code`)
 ) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -58,6 +61,11 @@ CREATE TABLE IF NOT EXISTS `language` (
 CREATE TABLE IF NOT EXISTS `publisher` (
   `id` int unsigned NOT NULL AUTO_INCREMENT,
   `name` varchar(100) NOT NULL,
+  `description` varchar(2000) DEFAULT NULL,
+  `logo` varchar(150) DEFAULT NULL,
+  `website` varchar(150) DEFAULT NULL,
+  `country` varchar(2) DEFAULT NULL,
+  `deleted_at` datetime DEFAULT NULL,
   PRIMARY KEY (`id`),
   UNIQUE KEY `publisher_UN` (`name`)
 ) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -65,6 +73,11 @@ CREATE TABLE IF NOT EXISTS `publisher` (
 CREATE TABLE IF NOT EXISTS `author` (
   `id` int unsigned NOT NULL AUTO_INCREMENT,
   `name` varchar(100) NOT NULL,
+  `biography` varchar(2000) DEFAULT NULL,
+  `photo` varchar(150) DEFAULT NULL,
+  `website` varchar(150) DEFAULT NULL,
+  `country` varchar(2) DEFAULT NULL,
+  `deleted_at` datetime DEFAULT NULL,
   PRIMARY KEY (`id`),
   UNIQUE KEY `author_UN` (`name`)
 ) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
@@ -72,8 +85,30 @@ CREATE TABLE IF NOT EXISTS `author` (
 CREATE TABLE IF NOT EXISTS `topic` (
   `id` int unsigned NOT NULL AUTO_INCREMENT,
   `name` varchar(30) NOT NULL,
+  `parent_id` int unsigned DEFAULT NULL,
+  `position` int unsigned NOT NULL DEFAULT '0',
   PRIMARY KEY (`id`),
-  UNIQUE KEY `topic_UN` (`name`)
+  UNIQUE KEY `topic_UN` (`name`),
+  KEY `topic_FK` (`parent_id`),
+  CONSTRAINT `topic_FK` FOREIGN KEY (`parent_id`) REFERENCES `topic` (`id`) ON DELETE SET NULL
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `series` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `name` varchar(100) NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `series_UN` (`name`)
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `work` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `title` varchar(100) NOT NULL,
+  `description` varchar(500) DEFAULT NULL,
+  `series_id` int unsigned DEFAULT NULL,
+  `series_number` int unsigned DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `work_FK` (`series_id`),
+  CONSTRAINT `work_FK` FOREIGN KEY (`series_id`) REFERENCES `series` (`id`) ON DELETE SET NULL ON UPDATE CASCADE
 ) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
 
 CREATE TABLE IF NOT EXISTS `book` (
@@ -99,24 +134,38 @@ CREATE TABLE IF NOT EXISTS `book` (
   `cover_front` varchar(150) NOT NULL,
   `cover_back` varchar(150) NOT NULL,
   `publisher` int unsigned NOT NULL DEFAULT '0',
-  `availability` int unsigned NOT NULL,
+  `availability` int unsigned GENERATED ALWAYS AS (
+    IF(`availability_override` IS NOT NULL, 0,
+      IF(`digital_price` > 0 AND COALESCE(NULLIF(`pdf`, ''), NULLIF(`epub`, ''), NULLIF(`djvu`, ''),
+        NULLIF(`azw`, ''), NULLIF(`txt`, ''), NULLIF(`docx`, '')) IS NOT NULL, 1, 0) +
+      IF(`physical_price` > 0 AND `physical_stock` > 0, 2, 0))
+  ) STORED,
+  `availability_override` varchar(20) DEFAULT NULL,
+  `release_date` date DEFAULT NULL,
+  `work_id` int unsigned DEFAULT NULL,
+  `edition` varchar(50) DEFAULT NULL,
+  `deleted_at` datetime DEFAULT NULL,
   PRIMARY KEY (`id`),
   UNIQUE KEY `book_UN` (`isbn`),
   KEY `book_FK` (`lang_id`),
   KEY `book_FK_1` (`publisher`),
-  CONSTRAINT `book_FK` FOREIGN KEY (`lang_id`) REFERENCES `language` (`id`) ON DELETE CASCADE ON UPDATE CASCADE,
-  CONSTRAINT `book_FK_1` FOREIGN KEY (`publisher`) REFERENCES `publisher` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
+  KEY `book_FK_2` (`work_id`),
+  CONSTRAINT `book_FK` FOREIGN KEY (`lang_id`) REFERENCES `language` (`id`) ON UPDATE CASCADE,
+  CONSTRAINT `book_FK_1` FOREIGN KEY (`publisher`) REFERENCES `publisher` (`id`) ON UPDATE CASCADE,
+  CONSTRAINT `book_FK_2` FOREIGN KEY (`work_id`) REFERENCES `work` (`id`) ON DELETE SET NULL ON UPDATE CASCADE
 ) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
 
 CREATE TABLE IF NOT EXISTS `book_author` (
   `id` int unsigned NOT NULL AUTO_INCREMENT,
   `book_id` int unsigned NOT NULL,
   `author_id` int unsigned NOT NULL,
+  `role` varchar(20) NOT NULL DEFAULT 'author',
   PRIMARY KEY (`id`),
+  UNIQUE KEY `book_author_UN` (`book_id`,`author_id`,`role`),
   KEY `book_author_FK_1` (`author_id`),
   KEY `book_author_FK` (`book_id`),
   CONSTRAINT `book_author_FK` FOREIGN KEY (`book_id`) REFERENCES `book` (`id`) ON DELETE CASCADE ON UPDATE CASCADE,
-  CONSTRAINT `book_author_FK_1` FOREIGN KEY (`author_id`) REFERENCES `author` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
+  CONSTRAINT `book_author_FK_1` FOREIGN KEY (`author_id`) REFERENCES `author` (`id`) ON UPDATE CASCADE
 ) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
 
 CREATE TABLE IF NOT EXISTS `book_topic` (
@@ -130,6 +179,34 @@ CREATE TABLE IF NOT EXISTS `book_topic` (
   CONSTRAINT `book_topic_FK_1` FOREIGN KEY (`topic_id`) REFERENCES `topic` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
 ) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
 
+CREATE TABLE IF NOT EXISTS `box_set` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `title` varchar(255) NOT NULL,
+  `description` text,
+  `price` int unsigned NOT NULL DEFAULT '0',
+  `percentage` int unsigned NOT NULL DEFAULT '0',
+  `physical` tinyint(1) NOT NULL DEFAULT '0',
+  PRIMARY KEY (`id`)
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `box_set_book` (
+  `box_set_id` int unsigned NOT NULL,
+  `book_id` int unsigned NOT NULL,
+  PRIMARY KEY (`box_set_id`,`book_id`),
+  KEY `box_set_book_FK1` (`book_id`),
+  CONSTRAINT `box_set_book_FK` FOREIGN KEY (`box_set_id`) REFERENCES `box_set` (`id`) ON DELETE CASCADE ON UPDATE CASCADE,
+  CONSTRAINT `box_set_book_FK1` FOREIGN KEY (`book_id`) REFERENCES `book` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `promo_batch` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `name` varchar(100) NOT NULL,
+  `prefix` varchar(8) NOT NULL DEFAULT '',
+  `count` int unsigned NOT NULL,
+  `creation_date` datetime NOT NULL,
+  PRIMARY KEY (`id`)
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
 CREATE TABLE IF NOT EXISTS `promo` (
   `id` int unsigned NOT NULL AUTO_INCREMENT,
   `code` varchar(20) NOT NULL,
@@ -137,9 +214,28 @@ CREATE TABLE IF NOT EXISTS `promo` (
   `limit` int unsigned NOT NULL DEFAULT '1',
   `percentage` int unsigned NOT NULL,
   `max_price` int unsigned DEFAULT '0',
-  PRIMARY KEY (`id`)
+  `public` tinyint(1) NOT NULL DEFAULT '0',
+  `user_limit` int unsigned NOT NULL DEFAULT '0',
+  `min_total` int unsigned NOT NULL DEFAULT '0',
+  `amount` int unsigned NOT NULL DEFAULT '0',
+  `free_shipping` tinyint(1) NOT NULL DEFAULT '0',
+  `scope` varchar(10) NOT NULL DEFAULT '',
+  `digital_only` tinyint(1) NOT NULL DEFAULT '0',
+  `stacking` varchar(10) NOT NULL DEFAULT 'stack',
+  `batch_id` int unsigned DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `promo_code` (`code`),
+  KEY `promo_FK` (`batch_id`),
+  CONSTRAINT `promo_FK` FOREIGN KEY (`batch_id`) REFERENCES `promo_batch` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
 ) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
 
+CREATE TABLE IF NOT EXISTS `promo_target` (
+  `promo_id` int unsigned NOT NULL,
+  `target_id` int unsigned NOT NULL,
+  PRIMARY KEY (`promo_id`,`target_id`),
+  CONSTRAINT `promo_target_FK` FOREIGN KEY (`promo_id`) REFERENCES `promo` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
 CREATE TABLE IF NOT EXISTS `promo_user` (
   `id` int unsigned NOT NULL AUTO_INCREMENT,
   `promo_id` int unsigned NOT NULL,
@@ -157,6 +253,13 @@ CREATE TABLE IF NOT EXISTS `orders` (
   `receipt_date` datetime DEFAULT NULL,
   `status` int unsigned NOT NULL,
   `total` int unsigned NOT NULL,
+  `discount` int unsigned NOT NULL DEFAULT '0',
+  `wallet` int unsigned NOT NULL DEFAULT '0',
+  `points` int unsigned NOT NULL DEFAULT '0',
+  `points_discount` int unsigned NOT NULL DEFAULT '0',
+  `points_earn` int unsigned NOT NULL DEFAULT '0',
+  `tax` int unsigned NOT NULL DEFAULT '0',
+  `tax_inclusive` tinyint(1) NOT NULL DEFAULT '1',
   `stn` varchar(50) DEFAULT NULL,
   `user_id` varchar(60)   NOT NULL,
   `promo_id` int unsigned DEFAULT NULL,
@@ -173,16 +276,160 @@ CREATE TABLE IF NOT EXISTS `orders` (
   CONSTRAINT `orders_FK_2` FOREIGN KEY (`phone_id`) REFERENCES `phone` (`id`)
 ) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
 
+CREATE TABLE IF NOT EXISTS `order_tax` (
+  `order_id` int unsigned NOT NULL,
+  `rate` int unsigned NOT NULL,
+  `base` int unsigned NOT NULL,
+  `tax` int unsigned NOT NULL,
+  PRIMARY KEY (`order_id`,`rate`),
+  CONSTRAINT `order_tax_FK` FOREIGN KEY (`order_id`) REFERENCES `orders` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `promo_redemption` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `promo_id` int unsigned NOT NULL,
+  `order_id` int unsigned NOT NULL,
+  `user_id` varchar(60) NOT NULL,
+  `status` varchar(10) NOT NULL DEFAULT 'held',
+  `created` datetime NOT NULL,
+  `updated` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `promo_redemption_FK` (`promo_id`),
+  KEY `promo_redemption_FK_1` (`order_id`),
+  KEY `promo_redemption_FK_2` (`user_id`),
+  CONSTRAINT `promo_redemption_FK` FOREIGN KEY (`promo_id`) REFERENCES `promo` (`id`) ON DELETE CASCADE ON UPDATE CASCADE,
+  CONSTRAINT `promo_redemption_FK_1` FOREIGN KEY (`order_id`) REFERENCES `orders` (`id`) ON DELETE CASCADE ON UPDATE CASCADE,
+  CONSTRAINT `promo_redemption_FK_2` FOREIGN KEY (`user_id`) REFERENCES `user` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `gift_card` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `code` varchar(16) NOT NULL,
+  `amount` int unsigned NOT NULL,
+  `buyer_id` varchar(60) NOT NULL,
+  `order_id` int unsigned NOT NULL,
+  `recipient_email` varchar(100) NOT NULL,
+  `message` varchar(500) NOT NULL DEFAULT '',
+  `status` varchar(10) NOT NULL DEFAULT 'pending',
+  `delivered` tinyint(1) NOT NULL DEFAULT '0',
+  `creation_date` datetime NOT NULL,
+  `redeemed_by` varchar(60) DEFAULT NULL,
+  `redeemed_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `gift_card_UN` (`code`),
+  KEY `gift_card_FK` (`buyer_id`),
+  KEY `gift_card_FK_1` (`order_id`),
+  KEY `gift_card_FK_2` (`redeemed_by`),
+  CONSTRAINT `gift_card_FK` FOREIGN KEY (`buyer_id`) REFERENCES `user` (`id`) ON DELETE CASCADE ON UPDATE CASCADE,
+  CONSTRAINT `gift_card_FK_1` FOREIGN KEY (`order_id`) REFERENCES `orders` (`id`) ON DELETE CASCADE ON UPDATE CASCADE,
+  CONSTRAINT `gift_card_FK_2` FOREIGN KEY (`redeemed_by`) REFERENCES `user` (`id`) ON DELETE SET NULL ON UPDATE CASCADE
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `wallet_entry` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` varchar(60) NOT NULL,
+  `amount` int NOT NULL,
+  `balance` int unsigned NOT NULL,
+  `type` varchar(10) NOT NULL,
+  `order_id` int unsigned DEFAULT NULL,
+  `gift_card_id` int unsigned DEFAULT NULL,
+  `note` varchar(200) NOT NULL DEFAULT '',
+  `date` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `wallet_entry_FK` (`user_id`),
+  KEY `wallet_entry_FK_1` (`order_id`),
+  KEY `wallet_entry_FK_2` (`gift_card_id`),
+  CONSTRAINT `wallet_entry_FK` FOREIGN KEY (`user_id`) REFERENCES `user` (`id`) ON DELETE CASCADE ON UPDATE CASCADE,
+  CONSTRAINT `wallet_entry_FK_1` FOREIGN KEY (`order_id`) REFERENCES `orders` (`id`) ON DELETE SET NULL ON UPDATE CASCADE,
+  CONSTRAINT `wallet_entry_FK_2` FOREIGN KEY (`gift_card_id`) REFERENCES `gift_card` (`id`) ON DELETE SET NULL ON UPDATE CASCADE
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `points_entry` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` varchar(60) NOT NULL,
+  `amount` int NOT NULL,
+  `balance` int unsigned NOT NULL,
+  `type` varchar(10) NOT NULL,
+  `order_id` int unsigned DEFAULT NULL,
+  `remaining` int unsigned NOT NULL DEFAULT '0',
+  `expires_at` datetime DEFAULT NULL,
+  `note` varchar(200) NOT NULL DEFAULT '',
+  `date` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `points_entry_FK` (`user_id`),
+  KEY `points_entry_FK_1` (`order_id`),
+  KEY `points_entry_expires_at` (`expires_at`),
+  CONSTRAINT `points_entry_FK` FOREIGN KEY (`user_id`) REFERENCES `user` (`id`) ON DELETE CASCADE ON UPDATE CASCADE,
+  CONSTRAINT `points_entry_FK_1` FOREIGN KEY (`order_id`) REFERENCES `orders` (`id`) ON DELETE SET NULL ON UPDATE CASCADE
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `points_topic` (
+  `topic_id` int unsigned NOT NULL,
+  `multiplier` int unsigned NOT NULL,
+  PRIMARY KEY (`topic_id`),
+  CONSTRAINT `points_topic_FK` FOREIGN KEY (`topic_id`) REFERENCES `topic` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
 CREATE TABLE IF NOT EXISTS `item` (
   `id` int unsigned NOT NULL AUTO_INCREMENT,
   `book_id` int unsigned NOT NULL,
   `type` int unsigned NOT NULL,
   `quantity` int unsigned NOT NULL DEFAULT '1',
   `order_id` int unsigned NOT NULL,
+  `preorder` tinyint(1) NOT NULL DEFAULT '0',
+  `bundle` tinyint(1) NOT NULL DEFAULT '0',
+  `box_set_id` int unsigned DEFAULT NULL,
   PRIMARY KEY (`id`),
   UNIQUE KEY `item_UN` (`book_id`,`type`,`quantity`),
   KEY `item_FK1` (`order_id`),
-  CONSTRAINT `item_FK1` FOREIGN KEY (`order_id`) REFERENCES `orders` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
+  KEY `item_FK3` (`box_set_id`),
+  CONSTRAINT `item_FK1` FOREIGN KEY (`order_id`) REFERENCES `orders` (`id`) ON DELETE CASCADE ON UPDATE CASCADE,
+  CONSTRAINT `item_FK2` FOREIGN KEY (`book_id`) REFERENCES `book` (`id`) ON UPDATE CASCADE,
+  CONSTRAINT `item_FK3` FOREIGN KEY (`box_set_id`) REFERENCES `box_set` (`id`) ON DELETE SET NULL ON UPDATE CASCADE
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `book_gift` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `item_id` int unsigned NOT NULL,
+  `buyer_id` varchar(60) NOT NULL,
+  `recipient_email` varchar(100) NOT NULL,
+  `recipient_id` varchar(60) DEFAULT NULL,
+  `message` varchar(500) NOT NULL DEFAULT '',
+  `deliver_at` datetime DEFAULT NULL,
+  `token` char(32) NOT NULL,
+  `status` varchar(10) NOT NULL DEFAULT 'pending',
+  `sent_at` datetime DEFAULT NULL,
+  `claimed_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `book_gift_UN` (`item_id`),
+  UNIQUE KEY `book_gift_UN_1` (`token`),
+  KEY `book_gift_FK_1` (`buyer_id`),
+  KEY `book_gift_FK_2` (`recipient_id`),
+  CONSTRAINT `book_gift_FK` FOREIGN KEY (`item_id`) REFERENCES `item` (`id`) ON DELETE CASCADE ON UPDATE CASCADE,
+  CONSTRAINT `book_gift_FK_1` FOREIGN KEY (`buyer_id`) REFERENCES `user` (`id`) ON DELETE CASCADE ON UPDATE CASCADE,
+  CONSTRAINT `book_gift_FK_2` FOREIGN KEY (`recipient_id`) REFERENCES `user` (`id`) ON DELETE SET NULL ON UPDATE CASCADE
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `referral` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `referrer_id` varchar(60) NOT NULL,
+  `referee_id` varchar(60) NOT NULL,
+  `promo_id` int unsigned DEFAULT NULL,
+  `status` varchar(10) NOT NULL DEFAULT 'pending',
+  `reason` varchar(50) NOT NULL DEFAULT '',
+  `order_id` int unsigned DEFAULT NULL,
+  `credit` int unsigned NOT NULL DEFAULT '0',
+  `creation_date` datetime NOT NULL,
+  `settle_date` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `referral_UN` (`referee_id`),
+  KEY `referral_FK` (`referrer_id`),
+  KEY `referral_FK_2` (`promo_id`),
+  KEY `referral_FK_3` (`order_id`),
+  CONSTRAINT `referral_FK` FOREIGN KEY (`referrer_id`) REFERENCES `user` (`id`) ON DELETE CASCADE ON UPDATE CASCADE,
+  CONSTRAINT `referral_FK_1` FOREIGN KEY (`referee_id`) REFERENCES `user` (`id`) ON DELETE CASCADE ON UPDATE CASCADE,
+  CONSTRAINT `referral_FK_2` FOREIGN KEY (`promo_id`) REFERENCES `promo` (`id`) ON DELETE SET NULL ON UPDATE CASCADE,
+  CONSTRAINT `referral_FK_3` FOREIGN KEY (`order_id`) REFERENCES `orders` (`id`) ON DELETE SET NULL ON UPDATE CASCADE
 ) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
 
 CREATE TABLE IF NOT EXISTS `zarinpal` (
@@ -194,4 +441,119 @@ CREATE TABLE IF NOT EXISTS `zarinpal` (
   PRIMARY KEY (`id`),
   KEY `zarinpal_FK` (`order_id`),
   CONSTRAINT `zarinpal_FK` FOREIGN KEY (`order_id`) REFERENCES `orders` (`id`)
-) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
\ No newline at end of file
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `download` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` varchar(60) NOT NULL,
+  `book_id` int unsigned NOT NULL,
+  `format` varchar(4) NOT NULL,
+  `ip` varchar(45) NOT NULL,
+  `user_agent` varchar(255) DEFAULT NULL,
+  `token` char(64) DEFAULT NULL,
+  `date` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `download_UN` (`token`),
+  KEY `download_FK` (`user_id`),
+  KEY `download_FK_1` (`book_id`),
+  CONSTRAINT `download_FK` FOREIGN KEY (`user_id`) REFERENCES `user` (`id`) ON DELETE CASCADE ON UPDATE CASCADE,
+  CONSTRAINT `download_FK_1` FOREIGN KEY (`book_id`) REFERENCES `book` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `download_limit` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `book_id` int unsigned NOT NULL,
+  `user_id` varchar(60) NOT NULL DEFAULT '',
+  `max_downloads` int unsigned NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `download_limit_UN` (`book_id`,`user_id`),
+  CONSTRAINT `download_limit_FK` FOREIGN KEY (`book_id`) REFERENCES `book` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `import_job` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `format` varchar(4) NOT NULL,
+  `dry_run` tinyint(1) NOT NULL DEFAULT '0',
+  `status` varchar(10) NOT NULL,
+  `total` int unsigned NOT NULL DEFAULT '0',
+  `created` int unsigned NOT NULL DEFAULT '0',
+  `updated` int unsigned NOT NULL DEFAULT '0',
+  `failed` int unsigned NOT NULL DEFAULT '0',
+  `start_date` datetime NOT NULL,
+  `finish_date` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`)
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `import_error` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `job_id` int unsigned NOT NULL,
+  `row` int unsigned NOT NULL,
+  `isbn` varchar(13) NOT NULL DEFAULT '',
+  `message` varchar(255) NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `import_error_FK` (`job_id`),
+  CONSTRAINT `import_error_FK` FOREIGN KEY (`job_id`) REFERENCES `import_job` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `merge_redirect` (
+  `kind` varchar(10) NOT NULL,
+  `old_id` int unsigned NOT NULL,
+  `new_id` int unsigned NOT NULL,
+  `date` datetime NOT NULL,
+  PRIMARY KEY (`kind`,`old_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `inventory_movement` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `book_id` int unsigned NOT NULL,
+  `type` varchar(20) NOT NULL,
+  `quantity` int NOT NULL,
+  `balance` int unsigned NOT NULL,
+  `reason` varchar(200) DEFAULT NULL,
+  `order_id` int unsigned DEFAULT NULL,
+  `admin_id` varchar(60) DEFAULT NULL,
+  `date` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `inventory_movement_FK` (`book_id`),
+  CONSTRAINT `inventory_movement_FK` FOREIGN KEY (`book_id`) REFERENCES `book` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `stock_alert` (
+  `book_id` int unsigned NOT NULL,
+  `threshold` int unsigned NOT NULL DEFAULT '0',
+  `velocity` decimal(10,3) NOT NULL DEFAULT '0.000',
+  `alerted` tinyint(1) NOT NULL DEFAULT '0',
+  `computed` datetime DEFAULT NULL,
+  PRIMARY KEY (`book_id`),
+  CONSTRAINT `stock_alert_FK` FOREIGN KEY (`book_id`) REFERENCES `book` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `campaign` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `name` varchar(100) NOT NULL,
+  `target` varchar(20) NOT NULL,
+  `format` varchar(10) NOT NULL DEFAULT 'all',
+  `percentage` int unsigned NOT NULL,
+  `priority` int unsigned NOT NULL DEFAULT '0',
+  `start` datetime NOT NULL,
+  `end` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `campaign_period` (`start`,`end`)
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `campaign_target` (
+  `campaign_id` int unsigned NOT NULL,
+  `target_id` int unsigned NOT NULL,
+  PRIMARY KEY (`campaign_id`,`target_id`),
+  CONSTRAINT `campaign_target_FK` FOREIGN KEY (`campaign_id`) REFERENCES `campaign` (`id`) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+CREATE TABLE IF NOT EXISTS `bundle_rule` (
+  `scope` varchar(10) NOT NULL,
+  `scope_id` int unsigned NOT NULL DEFAULT '0',
+  `percentage` int unsigned NOT NULL,
+  PRIMARY KEY (`scope`,`scope_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;
+
+INSERT IGNORE INTO bundle_rule (scope,scope_id,percentage) VALUES
+	 ('global',0,20);
@@ -21,6 +21,20 @@ type GetAuthorsResponse struct {
 	Authors []book.Author `json:"authors"`
 }
 
+type EditAuthorRequest struct {
+	Author book.Author `json:"author"`
+}
+type EditAuthorResponse struct {
+	Author book.Author `json:"author"`
+}
+
+type GetAuthorPageRequest struct {
+	AuthorID uint `json:"authorID"`
+}
+type GetAuthorPageResponse struct {
+	Page book.AuthorPage `json:"page"`
+}
+
 type DeleteAuthorRequest struct {
 	AuthorID uint `json:"authorID"`
 }
@@ -45,13 +59,28 @@ type GetPublishersResponse struct {
 	Publishers []book.Publisher `json:"publishers"`
 }
 
+type EditPublisherRequest struct {
+	Publisher book.Publisher `json:"publisher"`
+}
+type EditPublisherResponse struct {
+	Publisher book.Publisher `json:"publisher"`
+}
+
+type GetPublisherPageRequest struct {
+	PublisherID uint `json:"publisherID"`
+}
+type GetPublisherPageResponse struct {
+	Page book.PublisherPage `json:"page"`
+}
+
 type DeletePublisherRequest struct {
 	PublisherID uint `json:"publisherID"`
 }
 type DeletePublisherResponse struct{}
 
 type AddTopicRequest struct {
-	Name string `json:"name"`
+	Name     string `json:"name"`
+	ParentID uint   `json:"parentID"`
 }
 type AddTopicResponse struct {
 	Topic book.Topic `json:"topic"`
@@ -69,6 +98,28 @@ type GetTopicsResponse struct {
 	Topics []book.Topic `json:"topics"`
 }
 
+type GetTopicTreeRequest struct{}
+type GetTopicTreeResponse struct {
+	Topics []book.Topic `json:"topics"`
+}
+
+type MoveTopicRequest struct {
+	TopicID  uint `json:"topicID"`
+	ParentID uint `json:"parentID"`
+	Position uint `json:"position"`
+}
+type MoveTopicResponse struct {
+	Topic book.Topic `json:"topic"`
+}
+
+type ReorderTopicRequest struct {
+	TopicID  uint `json:"topicID"`
+	Position uint `json:"position"`
+}
+type ReorderTopicResponse struct {
+	Topic book.Topic `json:"topic"`
+}
+
 type DeleteTopicRequest struct {
 	TopicID uint `json:"topicID"`
 }
@@ -106,6 +157,52 @@ type AddBookResponse struct {
 	Book book.Book `json:"book"`
 }
 
+type ExtractBookMetadataRequest struct {
+	PDF      string        `json:"pdf"`
+	EPUB     string        `json:"epub"`
+	Metadata book.Metadata `json:"-"` // read from the files by the delivery layer
+}
+type ExtractBookMetadataResponse struct {
+	Proposal AddBookRequest `json:"proposal"` // unknown authors, publisher and language come with a zero ID
+	Metadata book.Metadata  `json:"metadata"`
+}
+
+type ConfirmBookMetadataRequest struct {
+	Proposal AddBookRequest `json:"proposal"`
+}
+type ConfirmBookMetadataResponse struct {
+	Book book.Book `json:"book"`
+}
+
+type ImportCatalogRequest struct {
+	Format string             `json:"format"`
+	DryRun bool               `json:"dryRun"`
+	Rows   []book.ImportRow   `json:"-"` // parsed from the uploaded file by the delivery layer
+	Errors []book.ImportError `json:"-"`
+}
+type ImportCatalogResponse struct {
+	Job book.ImportJob `json:"job"`
+}
+
+type GetImportJobRequest struct {
+	JobID uint `json:"jobID"`
+}
+type GetImportJobResponse struct {
+	Job book.ImportJob `json:"job"`
+}
+
+type GetImportJobsRequest struct{}
+type GetImportJobsResponse struct {
+	Jobs []book.ImportJob `json:"jobs"`
+}
+
+type ExportCatalogRequest struct {
+	Format string `json:"format"`
+}
+type ExportCatalogResponse struct {
+	Books []book.Book `json:"books"`
+}
+
 type SetBookDiscountRequest struct {
 	BookID   uint `json:"bookID"`
 	Digital  uint `json:"digital"`
@@ -127,6 +224,14 @@ type EditBookResponse struct {
 	Book book.Book `json:"book"`
 }
 
+type SetAvailabilityOverrideRequest struct {
+	BookID   uint   `json:"bookID"`
+	Override string `json:"override"`
+}
+type SetAvailabilityOverrideResponse struct {
+	Book book.Book `json:"book"`
+}
+
 type GetAllBooksRequest struct{}
 type GetAllBooksResponse struct {
 	Books []book.Book `json:"books"`
@@ -140,7 +245,8 @@ type GetAuthorBooksResponse struct {
 }
 
 type GetTopicBooksRequest struct {
-	TopicID uint `json:"topicID"`
+	TopicID     uint `json:"topicID"`
+	Descendants bool `json:"descendants"`
 }
 type GetTopicBooksResponse struct {
 	Books []book.Book `json:"books"`
@@ -160,17 +266,63 @@ type GetLangBooksResponse struct {
 	Books []book.Book `json:"books"`
 }
 
+type SearchBooksRequest struct {
+	Query string `json:"query"`
+}
+type SearchBooksResponse struct {
+	Books []book.Book `json:"books"`
+}
+
 type DeleteBookRequest struct {
 	BookID uint `json:"bookID"`
 }
 type DeleteBookResponse struct{}
 
-type DownloadBookRequest struct {
+type CreateDownloadLinksRequest struct {
 	UserID string `json:"userID"`
 	BookID uint   `json:"bookID"`
-	Path   string `json:"path"`
 }
-type DownloadBookResponse struct{}
+type CreateDownloadLinksResponse struct {
+	Links     []book.DownloadLink `json:"links"`
+	Remaining uint                `json:"remaining"` // 0 = unlimited
+}
+
+type DownloadBookRequest struct {
+	UserID    string `json:"userID"`
+	BookID    uint   `json:"bookID"`
+	Format    string `json:"format"`
+	IP        string `json:"ip"`
+	UserAgent string `json:"userAgent"`
+	Token     string `json:"token"` // signature of the link, a download is counted once per link
+}
+type DownloadBookResponse struct {
+	Path      string         `json:"path"`
+	Watermark book.Watermark `json:"watermark"`
+}
+
+type SetDownloadLimitRequest struct {
+	Limit book.DownloadLimit `json:"limit"`
+}
+type SetDownloadLimitResponse struct{}
+
+type GetDownloadsRequest struct{}
+type GetDownloadsResponse struct {
+	Downloads []book.Download `json:"downloads"`
+}
+
+type GetUserDownloadsRequest struct {
+	UserID string `json:"userID"`
+}
+type GetUserDownloadsResponse struct {
+	Downloads []book.Download `json:"downloads"`
+}
+
+type GetDownloadActivityRequest struct {
+	MinIPs uint `json:"minIPs"`
+}
+type GetDownloadActivityResponse struct {
+	Activities []book.DownloadActivity `json:"activities"`
+}
 
 type GetUserDigitalBooksRequest struct {
 	UserID string `json:"userID"`
@@ -178,3 +330,228 @@ type GetUserDigitalBooksRequest struct {
 type GetUserDigitalBooksResponse struct {
 	Books []book.Book `json:"books"`
 }
+
+type AddSeriesRequest struct {
+	Name string `json:"name"`
+}
+type AddSeriesResponse struct {
+	Series book.Series `json:"series"`
+}
+
+type GetSeriesRequest struct {
+	SeriesID uint `json:"seriesID"`
+}
+type GetSeriesResponse struct {
+	Series book.Series `json:"series"`
+}
+
+type GetAllSeriesRequest struct{}
+type GetAllSeriesResponse struct {
+	Series []book.Series `json:"series"`
+}
+
+type DeleteSeriesRequest struct {
+	SeriesID uint `json:"seriesID"`
+}
+type DeleteSeriesResponse struct{}
+
+type AddWorkRequest struct {
+	Work book.Work `json:"work"`
+}
+type AddWorkResponse struct {
+	Work book.Work `json:"work"`
+}
+
+type EditWorkRequest struct {
+	Work book.Work `json:"work"`
+}
+type EditWorkResponse struct {
+	Work book.Work `json:"work"`
+}
+
+type GetWorkRequest struct {
+	WorkID uint `json:"workID"`
+}
+type GetWorkResponse struct {
+	Work book.Work `json:"work"`
+}
+
+type GetWorksRequest struct{}
+type GetWorksResponse struct {
+	Works []book.Work `json:"works"`
+}
+
+type DeleteWorkRequest struct {
+	WorkID uint `json:"workID"`
+}
+type DeleteWorkResponse struct{}
+
+type SetBookWorkRequest struct {
+	BookID  uint   `json:"bookID"`
+	WorkID  uint   `json:"workID"` // 0 detaches the book from its work
+	Edition string `json:"edition"`
+}
+type SetBookWorkResponse struct{}
+
+type AddContributorRequest struct {
+	BookID   uint   `json:"bookID"`
+	AuthorID uint   `json:"authorID"`
+	Role     string `json:"role"`
+}
+type AddContributorResponse struct{}
+
+type RemoveContributorRequest struct {
+	BookID   uint   `json:"bookID"`
+	AuthorID uint   `json:"authorID"`
+	Role     string `json:"role"`
+}
+type RemoveContributorResponse struct{}
+
+type GetDuplicatesRequest struct {
+	Kind string `json:"kind"`
+}
+type GetDuplicatesResponse struct {
+	Groups []book.DuplicateGroup `json:"groups"`
+}
+
+type MergeRequest struct {
+	Kind   string `json:"kind"`
+	FromID uint   `json:"fromID"`
+	IntoID uint   `json:"intoID"`
+}
+type MergeResponse struct {
+	Redirect book.Redirect `json:"redirect"`
+}
+
+type GetRedirectRequest struct {
+	Kind  string `json:"kind"`
+	OldID uint   `json:"oldID"`
+}
+type GetRedirectResponse struct {
+	Redirect book.Redirect `json:"redirect"`
+}
+
+type GetRedirectsRequest struct{}
+type GetRedirectsResponse struct {
+	Redirects []book.Redirect `json:"redirects"`
+}
+
+type GetTrashRequest struct {
+	Kind string `json:"kind"`
+}
+type GetTrashResponse struct {
+	Records []book.Trashed `json:"records"`
+}
+
+type RestoreRequest struct {
+	Kind     string `json:"kind"`
+	RecordID uint   `json:"recordID"`
+}
+type RestoreResponse struct{}
+
+type PurgeRequest struct {
+	Kind     string `json:"kind"`
+	RecordID uint   `json:"recordID"`
+}
+type PurgeResponse struct{}
+
+type ReceiveStockRequest struct {
+	BookID   uint   `json:"bookID"`
+	Quantity uint   `json:"quantity"`
+	Reason   string `json:"reason"`
+	AdminID  string `json:"adminID"`
+}
+type ReceiveStockResponse struct {
+	Movement book.Movement `json:"movement"`
+}
+
+type AdjustStockRequest struct {
+	BookID   uint   `json:"bookID"`
+	Type     string `json:"type"`
+	Quantity int    `json:"quantity"`
+	Reason   string `json:"reason"`
+	AdminID  string `json:"adminID"`
+}
+type AdjustStockResponse struct {
+	Movement book.Movement `json:"movement"`
+}
+
+type StocktakeRequest struct {
+	Counts  []book.StockCount `json:"counts"`
+	Reason  string            `json:"reason"`
+	AdminID string            `json:"adminID"`
+}
+type StocktakeResponse struct {
+	Movements []book.Movement `json:"movements"`
+}
+
+type GetMovementsRequest struct {
+	BookID uint `json:"bookID"`
+}
+type GetMovementsResponse struct {
+	Movements []book.Movement `json:"movements"`
+}
+
+type SetReorderThresholdRequest struct {
+	BookID    uint `json:"bookID"`
+	Threshold uint `json:"threshold"`
+}
+type SetReorderThresholdResponse struct{}
+
+type CheckLowStockRequest struct {
+	VelocityDays uint `json:"velocityDays"`
+}
+type CheckLowStockResponse struct {
+	Crossed []book.StockAlert `json:"crossed"`
+}
+
+type GetLowStockReportRequest struct {
+	LeadDays  uint `json:"leadDays"`
+	CoverDays uint `json:"coverDays"`
+}
+type GetLowStockReportResponse struct {
+	Publishers []book.LowStockGroup `json:"publishers"`
+}
+
+type ReleasePreordersRequest struct {
+	Today string `json:"today"`
+}
+type ReleasePreordersResponse struct {
+	Release book.PreorderRelease `json:"release"`
+}
+
+type GetPreorderDemandRequest struct{}
+type GetPreorderDemandResponse struct {
+	Books []book.PreorderDemand `json:"books"`
+}
+
+type AddBoxSetRequest struct {
+	BoxSet book.BoxSet `json:"boxSet"`
+}
+type AddBoxSetResponse struct {
+	BoxSet book.BoxSet `json:"boxSet"`
+}
+
+type EditBoxSetRequest struct {
+	BoxSet book.BoxSet `json:"boxSet"`
+}
+type EditBoxSetResponse struct {
+	BoxSet book.BoxSet `json:"boxSet"`
+}
+
+type DeleteBoxSetRequest struct {
+	BoxSetID uint `json:"boxSetID"`
+}
+type DeleteBoxSetResponse struct{}
+
+type GetBoxSetRequest struct {
+	BoxSetID uint `json:"boxSetID"`
+}
+type GetBoxSetResponse struct {
+	BoxSet book.BoxSet `json:"boxSet"`
+}
+
+type GetBoxSetsRequest struct{}
+type GetBoxSetsResponse struct {
+	BoxSets []book.BoxSet `json:"boxSets"`
+}
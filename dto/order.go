@@ -2,6 +2,7 @@ package dto
 
 import (
 	"github.com/XBozorg/bookstore/entity/order"
+	"github.com/XBozorg/bookstore/entity/user"
 )
 
 type CheckOpenOrderRequest struct {
@@ -48,6 +49,13 @@ type CreatePromoCodeRequest struct {
 }
 type CreatePromoCodeResponse struct{}
 
+type CreatePromoBatchRequest struct {
+	Batch order.PromoBatch `json:"batch"`
+}
+type CreatePromoBatchResponse struct {
+	Batch order.PromoBatch `json:"batch"`
+}
+
 type DeletePromoCodeRequest struct {
 	PromoID uint `json:"promoID"`
 }
@@ -89,6 +97,13 @@ type RemoveOrderPromoRequest struct {
 }
 type RemoveOrderPromoResponse struct{}
 
+type ReleaseAbandonedPromosRequest struct {
+	Before string `json:"before"`
+}
+type ReleaseAbandonedPromosResponse struct {
+	Released uint `json:"released"`
+}
+
 type DeleteOrderRequest struct {
 	OrderID uint `json:"orderID"`
 }
@@ -160,6 +175,25 @@ type GetUserPromosResponse struct {
 	Promos []order.Promo `json:"promos"`
 }
 
+type GetPromoBatchRequest struct {
+	BatchID uint `json:"batchID"`
+}
+type GetPromoBatchResponse struct {
+	Batch order.PromoBatch `json:"batch"`
+}
+
+type GetPromoBatchReportsRequest struct{}
+type GetPromoBatchReportsResponse struct {
+	Reports []order.PromoBatchReport `json:"reports"`
+}
+
+type GetPromoRedemptionsRequest struct {
+	PromoID uint `json:"promoID"`
+}
+type GetPromoRedemptionsResponse struct {
+	Redemptions []order.Redemption `json:"redemptions"`
+}
+
 type SetOrderPhoneRequest struct {
 	OrderID uint `json:"orderID"`
 	PhoneID uint `json:"phoneID"`
@@ -176,9 +210,11 @@ type GetOrderPaymentInfoRequest struct {
 	OrderID uint `json:"orderID"`
 }
 type GetOrderPaymentInfoResponse struct {
-	Total uint   `json:"total"`
-	Email string `json:"email"`
-	Phone string `json:"phone"`
+	Total  uint   `json:"total"`
+	Wallet uint   `json:"wallet"`
+	Due    uint   `json:"due"` // left for the payment gateway
+	Email  string `json:"email"`
+	Phone  string `json:"phone"`
 }
 
 type GetOrderTotalRequest struct {
@@ -188,6 +224,67 @@ type GetOrderTotalResponse struct {
 	Total uint `json:"total"`
 }
 
+type SetItemGiftRequest struct {
+	UserID  string     `json:"userID"`
+	OrderID uint       `json:"orderID"`
+	ItemID  uint       `json:"itemID"`
+	Gift    order.Gift `json:"gift"`
+}
+type SetItemGiftResponse struct {
+	Gift order.Gift `json:"gift"`
+}
+
+type RemoveItemGiftRequest struct {
+	UserID  string `json:"userID"`
+	OrderID uint   `json:"orderID"`
+	ItemID  uint   `json:"itemID"`
+}
+type RemoveItemGiftResponse struct{}
+
+type GetUserGiftsRequest struct {
+	UserID string `json:"userID"`
+}
+type GetUserGiftsResponse struct {
+	Sent     []order.Gift `json:"sent"`
+	Received []order.Gift `json:"received"`
+}
+
+type GetGiftRequest struct {
+	Token string `json:"token"`
+}
+type GetGiftResponse struct {
+	Gift order.Gift `json:"gift"`
+}
+
+type ClaimGiftRequest struct {
+	Token  string `json:"token"`
+	UserID string `json:"userID"`
+}
+type ClaimGiftResponse struct {
+	Gift order.Gift `json:"gift"`
+}
+
+type ClaimGiftWithSignupRequest struct {
+	Token string            `json:"token"`
+	User  CreateUserRequest `json:"user"`
+}
+type ClaimGiftWithSignupResponse struct {
+	User user.User  `json:"user"`
+	Gift order.Gift `json:"gift"`
+}
+
+type DeliverGiftsRequest struct {
+	Now string `json:"now"`
+}
+type DeliverGiftsResponse struct {
+	Gifts []order.Gift `json:"gifts"`
+}
+
+type SetGiftSentRequest struct {
+	GiftID uint `json:"giftID"`
+}
+type SetGiftSentResponse struct{}
+
 type ZarinpalPaymentRequset struct {
 	OrderID uint `json:"orderID"`
 }
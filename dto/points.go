@@ -0,0 +1,40 @@
+package dto
+
+import "github.com/XBozorg/bookstore/entity/points"
+
+type GetPointsRequest struct {
+	UserID string `json:"userID"`
+}
+type GetPointsResponse struct {
+	Points points.Points `json:"points"`
+}
+
+type SetOrderPointsRequest struct {
+	UserID  string `json:"userID"`
+	OrderID uint   `json:"orderID"`
+	Points  uint   `json:"points"` // zero to take the points off the order
+}
+type SetOrderPointsResponse struct{}
+
+type ExpirePointsRequest struct {
+	Now string `json:"now"`
+}
+type ExpirePointsResponse struct {
+	Expired uint `json:"expired"`
+}
+
+type GetTopicMultipliersRequest struct{}
+type GetTopicMultipliersResponse struct {
+	Multipliers []points.TopicMultiplier `json:"multipliers"`
+}
+
+type SetTopicMultiplierRequest struct {
+	TopicID    uint `json:"topicID"`
+	Multiplier uint `json:"multiplier"` // in percents, 100 earns the base rate
+}
+type SetTopicMultiplierResponse struct{}
+
+type DeleteTopicMultiplierRequest struct {
+	TopicID uint `json:"topicID"`
+}
+type DeleteTopicMultiplierResponse struct{}
@@ -0,0 +1,77 @@
+package dto
+
+import "github.com/XBozorg/bookstore/entity/pricing"
+
+type AddCampaignRequest struct {
+	Campaign pricing.Campaign `json:"campaign"`
+}
+type AddCampaignResponse struct {
+	Campaign pricing.Campaign `json:"campaign"`
+}
+
+type EditCampaignRequest struct {
+	Campaign pricing.Campaign `json:"campaign"`
+}
+type EditCampaignResponse struct {
+	Campaign pricing.Campaign `json:"campaign"`
+}
+
+type DeleteCampaignRequest struct {
+	CampaignID uint `json:"campaignID"`
+}
+type DeleteCampaignResponse struct{}
+
+type GetCampaignRequest struct {
+	CampaignID uint `json:"campaignID"`
+}
+type GetCampaignResponse struct {
+	Campaign pricing.Campaign `json:"campaign"`
+}
+
+type GetCampaignsRequest struct{}
+type GetCampaignsResponse struct {
+	Campaigns []pricing.Campaign `json:"campaigns"`
+}
+
+type PreviewCampaignRequest struct {
+	Campaign pricing.Campaign `json:"campaign"`
+	At       string           `json:"at"`
+}
+type PreviewCampaignResponse struct {
+	At    string            `json:"at"`
+	Books []pricing.Preview `json:"books"`
+}
+
+type GetPriceQuoteRequest struct {
+	BookID  uint   `json:"bookID"`
+	Display string `json:"display"` // inclusive or exclusive of the tax, the configured one when empty
+}
+type GetPriceQuoteResponse struct {
+	Quote pricing.Quote `json:"quote"`
+}
+
+type SetBundleRuleRequest struct {
+	Rule pricing.BundleRule `json:"rule"`
+}
+type SetBundleRuleResponse struct {
+	Rule pricing.BundleRule `json:"rule"`
+}
+
+type DeleteBundleRuleRequest struct {
+	Scope   string `json:"scope"`
+	ScopeID uint   `json:"scopeID"`
+}
+type DeleteBundleRuleResponse struct{}
+
+type GetBundleRulesRequest struct{}
+type GetBundleRulesResponse struct {
+	Rules []pricing.BundleRule `json:"rules"`
+}
+
+type RefreshCampaignsRequest struct {
+	Since string `json:"since"`
+	Until string `json:"until"`
+}
+type RefreshCampaignsResponse struct {
+	Changed uint `json:"changed"`
+}
@@ -10,6 +10,7 @@ type CreateUserRequest struct {
 	LastName     string             `json:"lastName"`
 	PhoneNumbers []user.PhoneNumber `json:"phoneNumbers"`
 	Addresses    []user.Address     `json:"addresses"`
+	ReferralCode string             `json:"referralCode"` // of the user who invited, optional
 }
 
 type CreateUserResponse struct {
@@ -125,3 +126,22 @@ type DeleteAddressRequest struct {
 }
 type DeleteAddressResponse struct {
 }
+
+type GetReferralRequest struct {
+	UserID string `json:"userID"`
+}
+type GetReferralResponse struct {
+	Referral user.ReferralInfo `json:"referral"`
+}
+
+type GetUserReferralsRequest struct {
+	UserID string `json:"userID"`
+}
+type GetUserReferralsResponse struct {
+	Referrals []user.Referral `json:"referrals"`
+}
+
+type GetReferralReportRequest struct{}
+type GetReferralReportResponse struct {
+	Reports []user.ReferralReport `json:"reports"`
+}
@@ -0,0 +1,71 @@
+package dto
+
+import "github.com/XBozorg/bookstore/entity/wallet"
+
+type GetWalletRequest struct {
+	UserID string `json:"userID"`
+}
+type GetWalletResponse struct {
+	Wallet wallet.Wallet `json:"wallet"`
+}
+
+type AddGiftCardRequest struct {
+	UserID   string          `json:"userID"`
+	GiftCard wallet.GiftCard `json:"giftCard"`
+}
+type AddGiftCardResponse struct {
+	GiftCard wallet.GiftCard `json:"giftCard"`
+}
+
+type RemoveGiftCardRequest struct {
+	UserID     string `json:"userID"`
+	GiftCardID uint   `json:"giftCardID"`
+}
+type RemoveGiftCardResponse struct{}
+
+type GetUserGiftCardsRequest struct {
+	UserID string `json:"userID"`
+}
+type GetUserGiftCardsResponse struct {
+	GiftCards []wallet.GiftCard `json:"giftCards"`
+}
+
+type RedeemGiftCardRequest struct {
+	UserID string `json:"userID"`
+	Code   string `json:"code"`
+}
+type RedeemGiftCardResponse struct {
+	Entry wallet.Entry `json:"entry"`
+}
+
+type DeliverGiftCardsRequest struct{}
+type DeliverGiftCardsResponse struct {
+	GiftCards []wallet.GiftCard `json:"giftCards"`
+}
+
+type SetGiftCardDeliveredRequest struct {
+	GiftCardID uint `json:"giftCardID"`
+}
+type SetGiftCardDeliveredResponse struct{}
+
+type SetOrderWalletRequest struct {
+	UserID  string `json:"userID"`
+	OrderID uint   `json:"orderID"`
+	Amount  uint   `json:"amount"` // zero to pay the order through the gateway only
+}
+type SetOrderWalletResponse struct{}
+
+type PayOrderWithWalletRequest struct {
+	UserID  string `json:"userID"`
+	OrderID uint   `json:"orderID"`
+}
+type PayOrderWithWalletResponse struct{}
+
+type RefundOrderRequest struct {
+	OrderID uint   `json:"orderID"`
+	Amount  uint   `json:"amount"`
+	Note    string `json:"note"`
+}
+type RefundOrderResponse struct {
+	Entry wallet.Entry `json:"entry"`
+}
@@ -1,6 +1,23 @@
 package book
 
 type Author struct {
-	ID   uint   `json:"id"`
-	Name string `json:"name"`
+	ID        uint   `json:"id"`
+	Name      string `json:"name"`
+	Biography string `json:"biography,omitempty"`
+	Photo     string `json:"photo,omitempty"`
+	Website   string `json:"website,omitempty"`
+	Country   string `json:"country,omitempty"` // ISO 3166-1 alpha-2
+}
+
+// Credit is a book an author contributed to, with the role they had on it
+type Credit struct {
+	Book Book   `json:"book"`
+	Role string `json:"role"`
+}
+
+type AuthorPage struct {
+	Author     Author          `json:"author"`
+	Credits    []Credit        `json:"credits"`
+	BookCount  uint            `json:"bookCount"`  // distinct books, an author may hold several roles on one
+	RoleCounts map[string]uint `json:"roleCounts"` // books per role
 }
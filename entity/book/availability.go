@@ -0,0 +1,93 @@
+package book
+
+// admin overrides, any of them takes the book off sale, preorder only announces the book,
+// selling it ahead of the release takes a release date instead
+const (
+	OverrideNone         string = ""
+	OverrideHidden       string = "hidden"
+	OverridePreorder     string = "preorder"
+	OverrideDiscontinued string = "discontinued"
+)
+
+var Overrides = []string{OverrideHidden, OverridePreorder, OverrideDiscontinued}
+
+const (
+	StatusAvailable    string = "available"
+	StatusOutOfStock   string = "outOfStock"
+	StatusUnavailable  string = "unavailable" // not priced or no file uploaded
+	StatusHidden       string = "hidden"
+	StatusPreorder     string = "preorder"
+	StatusDiscontinued string = "discontinued"
+)
+
+type FormatStatus struct {
+	Status  string   `json:"status"`
+	Formats []string `json:"formats,omitempty"` // uploaded digital formats
+	Stock   uint     `json:"stock,omitempty"`
+}
+
+// Availability is the per format breakdown of Book.Availability
+type Availability struct {
+	Digital  FormatStatus `json:"digital"`
+	Physical FormatStatus `json:"physical"`
+	Bundle   FormatStatus `json:"bundle"`
+}
+
+// IsPreorder reports whether the book is sold ahead of its release date, today is 2006-01-02
+func (b Book) IsPreorder(today string) bool {
+	return b.Override == OverrideNone && b.ReleaseDate > today
+}
+
+// ComputeStatus builds the availability breakdown, the digital paths of the book
+// are only read to list the uploaded formats
+func (b Book) ComputeStatus(today string) Availability {
+
+	formats := []string{}
+	for _, format := range Formats {
+		if b.Digital.Path(format) != "" {
+			formats = append(formats, format)
+		}
+	}
+
+	a := Availability{
+		Digital:  FormatStatus{Status: StatusUnavailable, Formats: formats},
+		Physical: FormatStatus{Status: StatusUnavailable, Stock: b.Physical.Stock},
+		Bundle:   FormatStatus{Status: StatusUnavailable},
+	}
+
+	if b.Override != OverrideNone {
+		a.Digital.Status = b.Override
+		a.Physical.Status = b.Override
+		a.Bundle.Status = b.Override
+		return a
+	}
+
+	// pre-orders need neither files nor stock
+	if b.IsPreorder(today) {
+		if b.Digital.Price > 0 {
+			a.Digital.Status = StatusPreorder
+		}
+		if b.Physical.Price > 0 {
+			a.Physical.Status = StatusPreorder
+		}
+		if b.Digital.Price > 0 && b.Physical.Price > 0 {
+			a.Bundle.Status = StatusPreorder
+		}
+		return a
+	}
+
+	if b.Digital.Price > 0 && len(formats) > 0 {
+		a.Digital.Status = StatusAvailable
+	}
+	if b.Physical.Price > 0 {
+		a.Physical.Status = StatusOutOfStock
+		if b.Physical.Stock > 0 {
+			a.Physical.Status = StatusAvailable
+		}
+	}
+	if a.Digital.Status == StatusAvailable {
+		a.Bundle.Status = a.Physical.Status
+	}
+
+	return a
+}
@@ -1,5 +1,7 @@
 package book
 
+import "github.com/XBozorg/bookstore/entity/pricing"
+
 const (
 	NotAvailable uint = iota
 	DigitalAvailable
@@ -8,22 +10,29 @@ const (
 )
 
 type Book struct {
-	ID           uint      `json:"id"`
-	Title        string    `json:"title"`
-	ISBN         string    `json:"isbn"`
-	Pages        uint      `json:"pages"`
-	Authors      []Author  `json:"authors"`
-	Publisher    Publisher `json:"pub"`
-	Description  string    `json:"description"`
-	Topics       []Topic   `json:"topics"`
-	Language     Language  `json:"language"`
-	Year         string    `json:"year"`
-	CoverFront   string    `json:"coverFront"`
-	CoverBack    string    `json:"coverBack"`
-	CreationDate string    `json:"creationDate"`
-	Digital      Digital   `json:"digital"`
-	Physical     Physical  `json:"physical"`
-	Availability uint      `json:"availability"`
+	ID           uint           `json:"id"`
+	Title        string         `json:"title"`
+	ISBN         string         `json:"isbn"`
+	Pages        uint           `json:"pages"`
+	Authors      []Author       `json:"authors"`
+	Publisher    Publisher      `json:"pub"`
+	Description  string         `json:"description"`
+	Topics       []Topic        `json:"topics"`
+	Language     Language       `json:"language"`
+	Year         string         `json:"year"`
+	CoverFront   string         `json:"coverFront"`
+	CoverBack    string         `json:"coverBack"`
+	CreationDate string         `json:"creationDate"`
+	Digital      Digital        `json:"digital"`
+	Physical     Physical       `json:"physical"`
+	Availability uint           `json:"availability"` // derived, see ComputeAvailability
+	Override     string         `json:"override"`     // admin availability override, empty for none
+	Status       *Availability  `json:"status,omitempty"`
+	ReleaseDate  string         `json:"releaseDate,omitempty"` // 2006-01-02, sold as a pre-order until then
+	WorkID       uint           `json:"workID"`
+	Edition      string         `json:"edition"`
+	Contributors []Contributor  `json:"contributors"`
+	Quote        *pricing.Quote `json:"quote,omitempty"` // effective prices and their tax, as the catalog shows them
 }
 
 type Digital struct {
@@ -42,3 +51,27 @@ type Physical struct {
 	Discount uint `json:"discount"`
 	Stock    uint `json:"stock"`
 }
+
+// ComputeAvailability derives the availability from the prices, stock and uploaded files,
+// it mirrors the generated availability column of the book table
+func (b Book) ComputeAvailability() uint {
+
+	if b.Override != OverrideNone {
+		return NotAvailable
+	}
+
+	d := b.Digital
+	digital := d.Price > 0 && (d.PDF != "" || d.EPUB != "" || d.DJVU != "" || d.AZW != "" || d.TXT != "" || d.DOCX != "")
+	physical := b.Physical.Price > 0 && b.Physical.Stock > 0
+
+	switch {
+	case digital && physical:
+		return BundleAvailable
+	case digital:
+		return DigitalAvailable
+	case physical:
+		return PhysicalAvailable
+	}
+
+	return NotAvailable
+}
@@ -0,0 +1,51 @@
+package book
+
+// BoxSet is a product made of several books sold together, like a trilogy box set.
+// The digital copies of the books always come with it, the printed books only
+// when Physical is set.
+type BoxSet struct {
+	ID          uint         `json:"id"`
+	Title       string       `json:"title"`
+	Description string       `json:"description"`
+	Price       uint         `json:"price"`      // fixed price of one set, zero to take Percentage off the books
+	Percentage  uint         `json:"percentage"` // off the price of the books bought one by one
+	Physical    bool         `json:"physical"`
+	BookIDs     []uint       `json:"bookIDs"`
+	Books       []BoxSetBook `json:"books,omitempty"`
+	Stock       uint         `json:"stock"`
+	Available   bool         `json:"available"`
+	ListPrice   uint         `json:"listPrice"` // the books bought one by one
+	SetPrice    uint         `json:"setPrice"`
+}
+
+type BoxSetBook struct {
+	ID           uint   `json:"id"`
+	Title        string `json:"title"`
+	Stock        uint   `json:"stock"`
+	Availability uint   `json:"availability"`
+}
+
+// ComputeStock derives the stock and the availability of the set from its books,
+// a physical set has as many copies as its scarcest book
+func (s *BoxSet) ComputeStock() {
+
+	s.Stock, s.Available = 0, len(s.Books) > 0
+	for i, b := range s.Books {
+		if b.Availability != DigitalAvailable && b.Availability != BundleAvailable {
+			s.Available = false
+		}
+		if !s.Physical {
+			continue
+		}
+		if b.Availability != PhysicalAvailable && b.Availability != BundleAvailable {
+			s.Available = false
+		}
+		if i == 0 || b.Stock < s.Stock {
+			s.Stock = b.Stock
+		}
+	}
+
+	if s.Physical && s.Stock == 0 {
+		s.Available = false
+	}
+}
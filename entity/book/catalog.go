@@ -0,0 +1,47 @@
+package book
+
+const (
+	CatalogCSV  string = "csv"
+	CatalogONIX string = "onix"
+)
+
+const (
+	ImportRunning string = "running"
+	ImportDone    string = "done"
+	ImportFailed  string = "failed"
+)
+
+// ImportRow is a parsed catalog record, authors/topics/publisher/language are referenced by name
+type ImportRow struct {
+	Row    uint         `json:"row"`
+	Book   Book         `json:"book"`
+	Fields ImportFields `json:"fields"`
+}
+
+// ImportFields tells which prices and stock the record gives, an update keeps the others
+// as they are while a given zero is set like any value
+type ImportFields struct {
+	DigitalPrice  bool `json:"digitalPrice"`
+	PhysicalPrice bool `json:"physicalPrice"`
+	PhysicalStock bool `json:"physicalStock"`
+}
+
+type ImportError struct {
+	Row     uint   `json:"row"`
+	ISBN    string `json:"isbn"`
+	Message string `json:"message"`
+}
+
+type ImportJob struct {
+	ID         uint          `json:"id"`
+	Format     string        `json:"format"`
+	DryRun     bool          `json:"dryRun"`
+	Status     string        `json:"status"`
+	Total      uint          `json:"total"`
+	Created    uint          `json:"created"`
+	Updated    uint          `json:"updated"`
+	Failed     uint          `json:"failed"`
+	StartDate  string        `json:"startDate"`
+	FinishDate string        `json:"finishDate"`
+	Errors     []ImportError `json:"errors"`
+}
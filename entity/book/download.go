@@ -0,0 +1,93 @@
+package book
+
+import "fmt"
+
+const (
+	FormatPDF  string = "pdf"
+	FormatEPUB string = "epub"
+	FormatDJVU string = "djvu"
+	FormatAZW  string = "azw"
+	FormatTXT  string = "txt"
+	FormatDOCX string = "docx"
+)
+
+var Formats = []string{FormatPDF, FormatEPUB, FormatDJVU, FormatAZW, FormatTXT, FormatDOCX}
+
+type Download struct {
+	ID        uint   `json:"id"`
+	UserID    string `json:"userID"`
+	BookID    uint   `json:"bookID"`
+	Format    string `json:"format"`
+	IP        string `json:"ip"`
+	UserAgent string `json:"userAgent"`
+	Token     string `json:"-"` // of the download link, its requests count once
+	Date      string `json:"date"`
+}
+
+type DownloadLink struct {
+	Format     string `json:"format"`
+	URL        string `json:"url"`
+	Expiration string `json:"expiration"`
+}
+
+type DownloadLimit struct {
+	BookID uint   `json:"bookID"`
+	UserID string `json:"userID"` // empty = all users
+	Max    uint   `json:"max"`
+}
+
+type DownloadActivity struct {
+	UserID         string `json:"userID"`
+	BookID         uint   `json:"bookID"`
+	Downloads      uint   `json:"downloads"`
+	DistinctIPs    uint   `json:"distinctIPs"`
+	DistinctAgents uint   `json:"distinctAgents"`
+	LastDownload   string `json:"lastDownload"`
+}
+
+func (d *Digital) SetPath(format, path string) {
+	switch format {
+	case FormatPDF:
+		d.PDF = path
+	case FormatEPUB:
+		d.EPUB = path
+	case FormatDJVU:
+		d.DJVU = path
+	case FormatAZW:
+		d.AZW = path
+	case FormatTXT:
+		d.TXT = path
+	case FormatDOCX:
+		d.DOCX = path
+	}
+}
+
+// Path returns the stored file path of the given format, empty if not uploaded
+func (d Digital) Path(format string) string {
+	switch format {
+	case FormatPDF:
+		return d.PDF
+	case FormatEPUB:
+		return d.EPUB
+	case FormatDJVU:
+		return d.DJVU
+	case FormatAZW:
+		return d.AZW
+	case FormatTXT:
+		return d.TXT
+	case FormatDOCX:
+		return d.DOCX
+	}
+	return ""
+}
+
+// Watermark identifies the buyer of a digital copy, stamped into the served file
+type Watermark struct {
+	Name    string `json:"name"`
+	Email   string `json:"email"`
+	OrderID uint   `json:"orderID"`
+}
+
+func (w Watermark) Text() string {
+	return fmt.Sprintf("Licensed to %s <%s> - Order #%d", w.Name, w.Email, w.OrderID)
+}
@@ -0,0 +1,74 @@
+package book
+
+import "math"
+
+// stock movement types, reservations and releases come from the carts
+const (
+	MovementReceipt     string = "receipt"
+	MovementReservation string = "reservation"
+	MovementRelease     string = "release"
+	MovementReturn      string = "return"
+	MovementAdjustment  string = "adjustment"
+	MovementCount       string = "count"
+)
+
+// movement types an admin can record by hand
+var AdjustmentTypes = []string{MovementReturn, MovementAdjustment}
+
+// Movement is a ledger entry of the physical stock of a book
+type Movement struct {
+	ID       uint   `json:"id"`
+	BookID   uint   `json:"bookID"`
+	Type     string `json:"type"`
+	Quantity int    `json:"quantity"` // signed change of the stock
+	Balance  uint   `json:"balance"`  // stock after the movement
+	Reason   string `json:"reason,omitempty"`
+	OrderID  uint   `json:"orderID,omitempty"`
+	AdminID  string `json:"adminID,omitempty"`
+	Date     string `json:"date"`
+}
+
+// StockCount is one counted line of a stocktake
+type StockCount struct {
+	BookID  uint `json:"bookID"`
+	Counted uint `json:"counted"`
+}
+
+// StockAlert is the reorder state of a book, velocity is the copies sold per day
+// over the configured window and alerted is set while the stock stays at or below the threshold
+type StockAlert struct {
+	BookID    uint      `json:"bookID"`
+	Title     string    `json:"title"`
+	ISBN      string    `json:"isbn"`
+	Publisher Publisher `json:"publisher"`
+	Stock     uint      `json:"stock"`
+	Threshold uint      `json:"threshold"`
+	Velocity  float64   `json:"velocity"`
+	Alerted   bool      `json:"alerted"`
+	Suggested uint      `json:"suggested"`
+}
+
+// Low reports whether the stock is at or below the threshold or won't last the lead time
+func (a StockAlert) Low(leadDays uint) bool {
+	if a.Threshold > 0 && a.Stock <= a.Threshold {
+		return true
+	}
+	return a.Velocity > 0 && float64(a.Stock) < a.Velocity*float64(leadDays)
+}
+
+// SuggestReorder returns the copies to order so that the stock covers the lead time
+// plus coverDays of sales and still sits above the threshold
+func (a StockAlert) SuggestReorder(leadDays, coverDays uint) uint {
+	target := uint(math.Ceil(a.Velocity*float64(leadDays+coverDays))) + a.Threshold
+	if target <= a.Stock {
+		return 0
+	}
+	return target - a.Stock
+}
+
+// LowStockGroup is the low-stock report of a publisher, suggested is the total to reorder
+type LowStockGroup struct {
+	Publisher Publisher    `json:"publisher"`
+	Books     []StockAlert `json:"books"`
+	Suggested uint         `json:"suggested"`
+}
@@ -0,0 +1,33 @@
+package book
+
+// record kinds, each one is also the name of its table
+const (
+	KindBook      string = "book"
+	KindAuthor    string = "author"
+	KindPublisher string = "publisher"
+	KindTopic     string = "topic"
+	KindLanguage  string = "language"
+)
+
+var MergeKinds = []string{KindAuthor, KindPublisher, KindTopic}
+
+type DuplicateMember struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+// DuplicateGroup holds records whose normalized names are equal or within a small edit distance
+type DuplicateGroup struct {
+	Kind       string            `json:"kind"`
+	Normalized string            `json:"normalized"`
+	Distance   uint              `json:"distance"` // largest distance between two linked members, 0 means same normalized name
+	Members    []DuplicateMember `json:"members"`
+}
+
+// Redirect points the ID of a merged record to the record it was merged into
+type Redirect struct {
+	Kind  string `json:"kind"`
+	OldID uint   `json:"oldID"`
+	NewID uint   `json:"newID"`
+	Date  string `json:"date"`
+}
@@ -0,0 +1,14 @@
+package book
+
+// Metadata holds the book details read from an uploaded digital file
+type Metadata struct {
+	Title       string   `json:"title"`
+	ISBN        string   `json:"isbn"`
+	Pages       uint     `json:"pages"`
+	Authors     []string `json:"authors"`
+	Publisher   string   `json:"publisher"`
+	Language    string   `json:"language"`
+	Description string   `json:"description"`
+	Year        string   `json:"year"`
+	Cover       string   `json:"cover"` // path of the extracted cover image
+}
@@ -0,0 +1,21 @@
+package book
+
+// PreorderDemand is the pre-ordered copies of a book, digital and physical count
+// the paid orders while carts counts the open orders holding the book
+type PreorderDemand struct {
+	BookID      uint   `json:"bookID"`
+	Title       string `json:"title"`
+	ReleaseDate string `json:"releaseDate"`
+	Digital     uint   `json:"digital"`
+	Physical    uint   `json:"physical"`
+	Orders      uint   `json:"orders"`
+	Carts       uint   `json:"carts"`
+}
+
+// PreorderRelease is the outcome of a release run, queued physical copies
+// wait for stock and are allocated on a later run
+type PreorderRelease struct {
+	Digital  uint `json:"digital"`
+	Physical uint `json:"physical"`
+	Queued   uint `json:"queued"`
+}
@@ -1,6 +1,16 @@
 package book
 
 type Publisher struct {
-	ID   uint   `json:"id"`
-	Name string `json:"name"`
+	ID          uint   `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Logo        string `json:"logo,omitempty"`
+	Website     string `json:"website,omitempty"`
+	Country     string `json:"country,omitempty"` // ISO 3166-1 alpha-2
+}
+
+type PublisherPage struct {
+	Publisher Publisher `json:"publisher"`
+	Books     []Book    `json:"books"`
+	BookCount uint      `json:"bookCount"`
 }
@@ -1,6 +1,75 @@
 package book
 
 type Topic struct {
-	ID   uint   `json:"id"`
-	Name string `json:"name"`
+	ID       uint    `json:"id"`
+	Name     string  `json:"name"`
+	ParentID uint    `json:"parentID"` // zero for root topics
+	Position uint    `json:"position"` // order among the siblings
+	Path     []Topic `json:"path,omitempty"`
+	Children []Topic `json:"children,omitempty"`
+}
+
+// TopicTree nests a flat list of topics under their parents and returns the children
+// of rootID, zero for the whole tree, the list is expected to be sorted by position
+// so the siblings keep their order
+func TopicTree(topics []Topic, rootID uint) []Topic {
+
+	children := make(map[uint][]Topic)
+	for _, t := range topics {
+		children[t.ParentID] = append(children[t.ParentID], t)
+	}
+
+	var build func(parentID uint, depth int) []Topic
+	build = func(parentID uint, depth int) []Topic {
+		nodes := children[parentID]
+		if depth > len(topics) {
+			return nil
+		}
+		for i := range nodes {
+			nodes[i].Children = build(nodes[i].ID, depth+1)
+		}
+		return nodes
+	}
+
+	tree := build(rootID, 0)
+	if tree == nil {
+		return []Topic{}
+	}
+	return tree
+}
+
+// TopicPath returns the ancestors of a topic from the root down to its parent
+func TopicPath(topics []Topic, topicID uint) []Topic {
+
+	byID := make(map[uint]Topic, len(topics))
+	for _, t := range topics {
+		byID[t.ID] = t
+	}
+
+	path := []Topic{}
+	parentID := byID[topicID].ParentID
+	for parentID != 0 && len(path) < len(topics) {
+		parent, ok := byID[parentID]
+		if !ok {
+			break
+		}
+		path = append([]Topic{{ID: parent.ID, Name: parent.Name, ParentID: parent.ParentID, Position: parent.Position}}, path...)
+		parentID = parent.ParentID
+	}
+
+	return path
+}
+
+// IsDescendant reports whether topicID is ancestorID itself or lies somewhere below it
+func IsDescendant(topics []Topic, topicID, ancestorID uint) bool {
+
+	if topicID == ancestorID {
+		return true
+	}
+	for _, t := range TopicPath(topics, topicID) {
+		if t.ID == ancestorID {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,11 @@
+package book
+
+var TrashKinds = []string{KindBook, KindAuthor, KindPublisher, KindLanguage}
+
+// Trashed is a soft deleted record, Name holds the title of a book and the code of a language
+type Trashed struct {
+	Kind      string `json:"kind"`
+	ID        uint   `json:"id"`
+	Name      string `json:"name"`
+	DeletedAt string `json:"deletedAt"`
+}
@@ -0,0 +1,34 @@
+package book
+
+const (
+	RoleAuthor      string = "author"
+	RoleTranslator  string = "translator"
+	RoleIllustrator string = "illustrator"
+	RoleEditor      string = "editor"
+	RoleNarrator    string = "narrator"
+)
+
+var Roles = []string{RoleAuthor, RoleTranslator, RoleIllustrator, RoleEditor, RoleNarrator}
+
+// Contributor is an author credited with a role on an edition, Book.Authors only holds RoleAuthor
+type Contributor struct {
+	Author Author `json:"author"`
+	Role   string `json:"role"`
+}
+
+type Series struct {
+	ID    uint   `json:"id"`
+	Name  string `json:"name"`
+	Works []Work `json:"works,omitempty"` // ordered by series number
+}
+
+// Work groups the editions, formats and translations of the same book, each edition is a Book
+// keeping its own ISBN, language, pages and year
+type Work struct {
+	ID           uint   `json:"id"`
+	Title        string `json:"title"`
+	Description  string `json:"description"`
+	SeriesID     uint   `json:"seriesID"`
+	SeriesNumber uint   `json:"seriesNumber"`
+	Editions     []Book `json:"editions,omitempty"`
+}
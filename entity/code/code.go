@@ -0,0 +1,26 @@
+package code
+
+import (
+	"crypto/rand"
+	"math/big"
+)
+
+// Alphabet leaves out the characters that read alike: I, O, 0 and 1
+const Alphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// Random returns length random characters of the Alphabet, for the codes people type in
+func Random(length int) (string, error) {
+
+	code := make([]byte, length)
+	max := big.NewInt(int64(len(Alphabet)))
+
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = Alphabet[n.Int64()]
+	}
+
+	return string(code), nil
+}
@@ -0,0 +1,42 @@
+package order
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+const (
+	GiftPending = "pending" // waiting for the payment or the delivery date
+	GiftSent    = "sent"    // the claim link was sent to the recipient
+	GiftClaimed = "claimed"
+	GiftVoid    = "void" // the order was cancelled
+)
+
+// Gift is a digital item bought for someone else, the book is read by the account
+// claiming it instead of the buyer
+type Gift struct {
+	ID             uint   `json:"id"`
+	ItemID         uint   `json:"itemID"`
+	OrderID        uint   `json:"orderID"`
+	BookID         uint   `json:"bookID"`
+	BuyerID        string `json:"buyerID"`
+	RecipientEmail string `json:"recipientEmail"`
+	RecipientID    string `json:"recipientID,omitempty"`
+	Message        string `json:"message"`
+	DeliverAt      string `json:"deliverAt"`       // empty to send it once the order is paid
+	Token          string `json:"token,omitempty"` // of the claim link, only sent to the recipient
+	Status         string `json:"status"`
+	SentAt         string `json:"sentAt,omitempty"`
+	ClaimedAt      string `json:"claimedAt,omitempty"`
+}
+
+// NewGiftToken returns 32 random hex characters
+func NewGiftToken() (string, error) {
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -4,6 +4,7 @@ const (
 	Digital uint = iota
 	Physical
 	Bundle
+	BoxSet // expands into a digital line, and a physical line for a physical set, per book of the set
 )
 
 type Item struct {
@@ -11,4 +12,7 @@ type Item struct {
 	BookID   uint `json:"bookID"`
 	Type     uint `json:"type"`
 	Quantity uint `json:"quantity"`
+	Preorder bool `json:"preorder"` // waiting for the release, no stock reserved and no download access yet
+	Bundled  bool `json:"bundled"`  // a line of a digital + physical bundle
+	BoxSetID uint `json:"boxSetID"` // the box set the line comes from, or the set to add for a BoxSet item
 }
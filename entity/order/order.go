@@ -5,26 +5,38 @@ const (
 	StatusPaid     uint = 110
 	StatusVerified uint = 120
 	StatusShipped  uint = 200
+
+	StatusCancelled uint = 300
 )
 
 type Order struct {
-	ID             uint   `json:"id"`
-	UserID         string `json:"userID"`
-	Total          uint   `json:"total"`
-	Status         uint   `json:"status"`
-	STN            string `json:"stn"` // Shipment Tracking Number
-	CreationDate   string `json:"creationDate"`
-	ReceiptionDate string `json:"receiptionDate"`
-	Items          []Item `json:"Items"`
-	Promo          Promo  `json:"promo"`
-	PhoneID        uint   `json:"phoneID"`
-	AddressID      uint   `json:"addressID"`
+	ID             uint      `json:"id"`
+	UserID         string    `json:"userID"`
+	Total          uint      `json:"total"`
+	Discount       uint      `json:"discount"` // taken off by the promo
+	Wallet         uint      `json:"wallet"`   // paid from the wallet of the user
+	Points         uint      `json:"points"`   // loyalty points redeemed
+	PointsDiscount uint      `json:"pointsDiscount"`
+	PointsEarn     uint      `json:"pointsEarn"` // loyalty points the order earns once paid
+	Tax            uint      `json:"tax"`
+	TaxInclusive   bool      `json:"taxInclusive"` // the tax is in the total, otherwise it was added to it
+	TaxRates       []TaxRate `json:"taxRates"`
+	Status         uint      `json:"status"`
+	STN            string    `json:"stn"` // Shipment Tracking Number
+	CreationDate   string    `json:"creationDate"`
+	ReceiptionDate string    `json:"receiptionDate"`
+	Items          []Item    `json:"Items"`
+	Promo          Promo     `json:"promo"`
+	PhoneID        uint      `json:"phoneID"`
+	AddressID      uint      `json:"addressID"`
 }
 
 type OrderPaymentInfo struct {
-	Email string `json:"email"`
-	Phone string `json:"phone"`
-	Total uint   `json:"total"`
+	Email  string `json:"email"`
+	Phone  string `json:"phone"`
+	Total  uint   `json:"total"`
+	Wallet uint   `json:"wallet"`
+	Due    uint   `json:"due"` // left to pay through the gateway
 }
 
 type ZarinpalOrder struct {
@@ -34,3 +46,10 @@ type ZarinpalOrder struct {
 	RefID     int    `json:"refID"`
 	Code      int    `json:"code"`
 }
+
+// TaxRate sums the lines of an order taxed at Rate percent, Base is what they cost after the discounts
+type TaxRate struct {
+	Rate uint `json:"rate"`
+	Base uint `json:"base"`
+	Tax  uint `json:"tax"`
+}
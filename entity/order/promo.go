@@ -1,10 +1,95 @@
 package order
 
+import (
+	"github.com/XBozorg/bookstore/entity/code"
+)
+
+// how a promo combines with the campaign discounts
+const (
+	StackCampaigns string = "stack"     // applies on top of the campaign prices
+	StackExclusive string = "exclusive" // leaves out the lines a campaign discounts
+)
+
+var Stackings = []string{StackCampaigns, StackExclusive}
+
+// Promo is a discount code, Scope and ScopeIDs restrict it to books the way a campaign
+// targets them (topic, publisher, author or books), an empty scope covers the whole order
 type Promo struct {
-	ID         uint   `json:"id"`
-	Code       string `json:"code"`
-	Percentage uint   `json:"percentage"`
-	Expiration string `json:"expiration"`
-	Limit      uint   `json:"limit"`
-	MaxPrice   uint   `json:"maxPrice"`
+	ID           uint   `json:"id"`
+	Code         string `json:"code"`
+	Percentage   uint   `json:"percentage"`
+	Expiration   string `json:"expiration"`
+	Limit        uint   `json:"limit"` // redemptions over all users
+	MaxPrice     uint   `json:"maxPrice"`
+	Public       bool   `json:"public"`    // anyone can use it, otherwise only the users it's given to
+	UserLimit    uint   `json:"userLimit"` // redemptions per user, zero for no limit
+	MinTotal     uint   `json:"minTotal"`  // the order must cost at least this much before the promo
+	Amount       uint   `json:"amount"`    // fixed amount off, instead of the percentage
+	FreeShipping bool   `json:"freeShipping"`
+	Scope        string `json:"scope"`
+	ScopeIDs     []uint `json:"scopeIDs"`
+	DigitalOnly  bool   `json:"digitalOnly"`
+	Stacking     string `json:"stacking"`
+	BatchID      uint   `json:"batchID"` // zero for a code created on its own
+	Used         uint   `json:"used"`    // redemptions held or redeemed
+}
+
+// a redemption is held while its order is open, it counts against the limits
+// until it's released
+const (
+	RedemptionHeld     string = "held"
+	RedemptionRedeemed string = "redeemed" // the order was paid
+	RedemptionReleased string = "released" // removed from the order, or the order was cancelled or abandoned
+)
+
+type Redemption struct {
+	ID      uint   `json:"id"`
+	PromoID uint   `json:"promoID"`
+	OrderID uint   `json:"orderID"`
+	UserID  string `json:"userID"`
+	Status  string `json:"status"`
+	Created string `json:"created"`
+	Updated string `json:"updated"`
+}
+
+// PromoBatch is a set of generated codes sharing the rules of Promo, its code is ignored
+type PromoBatch struct {
+	ID           uint             `json:"id"`
+	Name         string           `json:"name"`
+	Prefix       string           `json:"prefix"`
+	Count        uint             `json:"count"`
+	CreationDate string           `json:"creationDate"`
+	Promo        Promo            `json:"promo"`
+	Codes        []PromoBatchCode `json:"codes,omitempty"`
+}
+
+type PromoBatchCode struct {
+	Code     string `json:"code"`
+	Left     uint   `json:"left"` // redemptions left
+	Redeemed uint   `json:"redeemed"`
+}
+
+// PromoBatchReport sums up the paid orders that used a code of the batch
+type PromoBatchReport struct {
+	BatchID  uint   `json:"batchID"`
+	Name     string `json:"name"`
+	Codes    uint   `json:"codes"`
+	Redeemed uint   `json:"redeemed"` // codes used at least once
+	Orders   uint   `json:"orders"`
+	Revenue  uint   `json:"revenue"`
+	Discount uint   `json:"discount"`
+}
+
+// without 0/O and 1/I, the codes are read and typed by people
+const CodeLength = 8
+
+// NewPromoCode returns the prefix followed by CodeLength random characters
+func NewPromoCode(prefix string) (string, error) {
+
+	random, err := code.Random(CodeLength)
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + random, nil
 }
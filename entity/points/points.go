@@ -0,0 +1,38 @@
+package points
+
+// types of the entries of the points ledger
+const (
+	EntryEarn    = "earn"    // a paid order earned them
+	EntryRedeem  = "redeem"  // held by an open order as a discount
+	EntryRelease = "release" // back from an order that didn't use them
+	EntryReverse = "reverse" // taken back from a refunded or cancelled order
+	EntryExpire  = "expire"
+)
+
+// Entry moves Amount points, positive or negative, Balance is the balance after it.
+// The points an entry adds are spent oldest first, Remaining is what is left of them
+// until they expire at ExpiresAt.
+type Entry struct {
+	ID        uint   `json:"id"`
+	UserID    string `json:"userID"`
+	Amount    int    `json:"amount"`
+	Balance   uint   `json:"balance"`
+	Type      string `json:"type"`
+	OrderID   uint   `json:"orderID,omitempty"`
+	Remaining uint   `json:"remaining,omitempty"`
+	ExpiresAt string `json:"expiresAt,omitempty"`
+	Note      string `json:"note,omitempty"`
+	Date      string `json:"date"`
+}
+
+type Points struct {
+	UserID  string  `json:"userID"`
+	Balance uint    `json:"balance"`
+	Entries []Entry `json:"entries"`
+}
+
+// TopicMultiplier scales the points earned by the books of a topic, in percents
+type TopicMultiplier struct {
+	TopicID    uint `json:"topicID"`
+	Multiplier uint `json:"multiplier"`
+}
@@ -0,0 +1,43 @@
+package pricing
+
+// bundle rule scopes, the most specific rule wins: book, then publisher, then global
+const (
+	ScopeGlobal    string = "global"
+	ScopePublisher string = "publisher"
+	ScopeBook      string = "book"
+)
+
+var Scopes = []string{ScopeGlobal, ScopePublisher, ScopeBook}
+
+// BundleRule is the percentage off a digital + physical bundle, ScopeID is
+// the publisher or book ID and zero for the global rule
+type BundleRule struct {
+	Scope      string `json:"scope"`
+	ScopeID    uint   `json:"scopeID"`
+	Percentage uint   `json:"percentage"`
+}
+
+// BundleDiscount returns the bundle percentage of the book, zero without any rule
+func BundleDiscount(rules []BundleRule, b Book) uint {
+
+	var global, publisher *BundleRule
+	for i, r := range rules {
+		switch {
+		case r.Scope == ScopeBook && r.ScopeID == b.ID:
+			return r.Percentage
+		case r.Scope == ScopePublisher && r.ScopeID == b.PublisherID:
+			publisher = &rules[i]
+		case r.Scope == ScopeGlobal:
+			global = &rules[i]
+		}
+	}
+
+	switch {
+	case publisher != nil:
+		return publisher.Percentage
+	case global != nil:
+		return global.Percentage
+	}
+
+	return 0
+}
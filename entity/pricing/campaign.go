@@ -0,0 +1,79 @@
+package pricing
+
+// what a campaign targets, the target IDs are topic, publisher, author or book IDs
+const (
+	TargetTopic     string = "topic" // the topic and its descendants
+	TargetPublisher string = "publisher"
+	TargetAuthor    string = "author"
+	TargetBooks     string = "books"
+)
+
+var Targets = []string{TargetTopic, TargetPublisher, TargetAuthor, TargetBooks}
+
+const (
+	FormatAll      string = "all"
+	FormatDigital  string = "digital"
+	FormatPhysical string = "physical"
+)
+
+var Formats = []string{FormatAll, FormatDigital, FormatPhysical}
+
+// Campaign is a time-boxed sale, it applies from Start up to End (2006-01-02 15:04:05)
+// and stops applying on its own once it ends
+type Campaign struct {
+	ID         uint   `json:"id"`
+	Name       string `json:"name"`
+	Target     string `json:"target"`
+	TargetIDs  []uint `json:"targetIDs"`
+	Format     string `json:"format"`
+	Percentage uint   `json:"percentage"`
+	Priority   uint   `json:"priority"` // the highest priority wins when campaigns overlap
+	Start      string `json:"start"`
+	End        string `json:"end"`
+}
+
+// Active reports whether the campaign runs at now, the fixed date format compares as text
+func (c Campaign) Active(now string) bool {
+	return c.Start <= now && now < c.End
+}
+
+// Matches reports whether the campaign targets the format of the book
+func (c Campaign) Matches(b Book, format string) bool {
+
+	if c.Format != FormatAll && c.Format != format {
+		return false
+	}
+
+	switch c.Target {
+	case TargetTopic:
+		return containsAny(c.TargetIDs, b.TopicIDs)
+	case TargetPublisher:
+		return containsAny(c.TargetIDs, []uint{b.PublisherID})
+	case TargetAuthor:
+		return containsAny(c.TargetIDs, b.AuthorIDs)
+	case TargetBooks:
+		return containsAny(c.TargetIDs, []uint{b.ID})
+	}
+
+	return false
+}
+
+func containsAny(ids, values []uint) bool {
+	for _, id := range ids {
+		for _, v := range values {
+			if id == v {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Preview is the effect of a campaign on one of the books it targets, Before leaves it out
+// and both take the other campaigns running at the same time into account
+type Preview struct {
+	BookID uint   `json:"bookID"`
+	Title  string `json:"title"`
+	Before Quote  `json:"before"`
+	After  Quote  `json:"after"`
+}
@@ -0,0 +1,56 @@
+package pricing
+
+// PointsRule is how an order earns loyalty points and what a redeemed point takes off
+type PointsRule struct {
+	Unit        uint          // paid for goods to earn a point
+	Value       uint          // taken off the total per redeemed point
+	Multipliers map[uint]uint // by topic, percents of the points a line earns
+}
+
+// PointsUsed returns how many of the points held by an order its total t can use,
+// they pay for the goods left after the promo, not for gift cards or shipping
+func PointsUsed(rule PointsRule, points uint, lines []Line, t Total) uint {
+
+	if rule.Value == 0 {
+		return 0
+	}
+
+	if usable := (goods(lines) - t.Discount) / rule.Value; points > usable {
+		return usable
+	}
+	return points
+}
+
+// EarnedPoints returns the points an order with the total t earns once paid, the goods paid
+// with money are shared among the lines and each share earns by the multiplier of its topics
+func EarnedPoints(rule PointsRule, lines []Line, t Total, pointsDiscount uint) uint {
+
+	all := goods(lines)
+	if rule.Unit == 0 || all == 0 || t.Discount+pointsDiscount >= all {
+		return 0
+	}
+	paid := all - t.Discount - pointsDiscount
+
+	var weighted uint64
+	for _, l := range lines {
+		if !l.GiftCard {
+			weighted += uint64(l.Price) * uint64(multiplier(rule, l.Book))
+		}
+	}
+
+	return uint(weighted * uint64(paid) / uint64(all) / 100 / uint64(rule.Unit))
+}
+
+// multiplier returns the highest multiplier among the topics of the book,
+// 100 for a book without one
+func multiplier(rule PointsRule, b Book) uint {
+
+	m, found := uint(100), false
+	for _, topicID := range b.TopicIDs {
+		if v, ok := rule.Multipliers[topicID]; ok && (!found || v > m) {
+			m, found = v, true
+		}
+	}
+
+	return m
+}
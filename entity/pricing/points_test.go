@@ -0,0 +1,63 @@
+package pricing
+
+import "testing"
+
+func TestPointsUsed(t *testing.T) {
+
+	rule := PointsRule{Unit: 100, Value: 10}
+	card := Line{Format: FormatDigital, Price: 10000, GiftCard: true}
+
+	tests := []struct {
+		name   string
+		rule   PointsRule
+		points uint
+		lines  []Line
+		total  Total
+		want   uint
+	}{
+		{"all the points", rule, 100, lines, Total{}, 100},
+		{"up to the goods", rule, 1000, lines, Total{}, 590},
+		{"up to the goods after the promo", rule, 1000, lines, Total{Discount: 400}, 550},
+		{"gift cards are paid in full", rule, 1000, append([]Line{card}, lines...), Total{}, 590},
+		{"points worth nothing", PointsRule{Unit: 100}, 100, lines, Total{}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PointsUsed(tt.rule, tt.points, tt.lines, tt.total); got != tt.want {
+				t.Errorf("PointsUsed() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEarnedPoints(t *testing.T) {
+
+	card := Line{Format: FormatDigital, Price: 10000, GiftCard: true}
+
+	tests := []struct {
+		name           string
+		multipliers    map[uint]uint
+		lines          []Line
+		total          Total
+		pointsDiscount uint
+		want           uint
+	}{
+		{"no multiplier", nil, lines, Total{}, 0, 59},
+		{"topic multiplier", map[uint]uint{5: 200}, lines, Total{}, 0, 98},
+		{"lower topic multiplier", map[uint]uint{1: 50}, lines, Total{}, 0, 39},
+		{"highest topic multiplier", map[uint]uint{1: 50, 5: 200}, lines, Total{}, 0, 98},
+		{"discounts earn nothing", map[uint]uint{5: 200}, lines, Total{Discount: 400}, 500, 83},
+		{"gift cards earn nothing", map[uint]uint{5: 200}, append([]Line{card}, lines...), Total{}, 0, 98},
+		{"nothing paid", nil, lines, Total{Discount: 5000}, 900, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := PointsRule{Unit: 100, Value: 10, Multipliers: tt.multipliers}
+			if got := EarnedPoints(rule, tt.lines, tt.total, tt.pointsDiscount); got != tt.want {
+				t.Errorf("EarnedPoints() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package pricing
+
+import (
+	"errors"
+
+	"github.com/XBozorg/bookstore/entity/order"
+)
+
+// Book is what the engine needs to price a book, TopicIDs include the ancestors
+// of the book topics so topic campaigns reach the whole subtree
+type Book struct {
+	ID               uint
+	DigitalPrice     uint
+	DigitalDiscount  uint
+	PhysicalPrice    uint
+	PhysicalDiscount uint
+	PublisherID      uint
+	AuthorIDs        []uint
+	TopicIDs         []uint
+}
+
+// Price is the effective unit price of a format, discount is the percentage applied
+type Price struct {
+	List       uint `json:"list"`
+	Discount   uint `json:"discount"`
+	Price      uint `json:"price"`
+	CampaignID uint `json:"campaignID,omitempty"`
+	Tax        uint `json:"tax"`   // in the price, or on top of it
+	Shown      uint `json:"shown"` // the price as the catalog displays it
+}
+
+// Quote is the pricing of a book, Bundle is one digital copy with one physical copy
+// and BundleDiscount applies on top of the format prices
+type Quote struct {
+	BookID         uint   `json:"bookID"`
+	Digital        Price  `json:"digital"`
+	Physical       Price  `json:"physical"`
+	Bundle         Price  `json:"bundle"`
+	BundleDiscount uint   `json:"bundleDiscount"`
+	Display        string `json:"display,omitempty"` // DisplayInclusive or DisplayExclusive
+}
+
+// Best returns the campaign that applies to the format of the book at now: the highest
+// priority wins, then the biggest percentage, then the oldest campaign
+func Best(campaigns []Campaign, b Book, format, now string) (Campaign, bool) {
+
+	best, found := Campaign{}, false
+	for _, c := range campaigns {
+		if !c.Active(now) || !c.Matches(b, format) {
+			continue
+		}
+
+		switch {
+		case !found,
+			c.Priority > best.Priority,
+			c.Priority == best.Priority && c.Percentage > best.Percentage,
+			c.Priority == best.Priority && c.Percentage == best.Percentage && c.ID < best.ID:
+			best, found = c, true
+		}
+	}
+
+	return best, found
+}
+
+// NewQuote prices both formats of the book at now. A campaign replaces the standing
+// discount of the book, unless the standing discount is bigger.
+func NewQuote(b Book, campaigns []Campaign, rules []BundleRule, now string) Quote {
+
+	q := Quote{
+		BookID:         b.ID,
+		Digital:        price(b.DigitalPrice, b.DigitalDiscount, campaigns, b, FormatDigital, now),
+		Physical:       price(b.PhysicalPrice, b.PhysicalDiscount, campaigns, b, FormatPhysical, now),
+		BundleDiscount: BundleDiscount(rules, b),
+	}
+
+	bundle, _ := ItemPrice(q, order.Item{Type: order.Bundle, Quantity: 1})
+	q.Bundle = Price{
+		List:     b.DigitalPrice + b.PhysicalPrice,
+		Discount: q.BundleDiscount,
+		Price:    bundle,
+	}
+
+	return q
+}
+
+// ItemPrice prices an order item with the quote of its book. A bundle is priced as its
+// digital and physical lines, and the lines of a bundle take the bundle discount, so
+// a cart prices the same whether its bundles are added or recomputed line by line.
+func ItemPrice(q Quote, item order.Item) (uint, error) {
+
+	switch item.Type {
+	case order.Digital:
+		return line(q.Digital.Price, 1, item.Bundled, q.BundleDiscount), nil
+	case order.Physical:
+		return line(q.Physical.Price, item.Quantity, item.Bundled, q.BundleDiscount), nil
+	case order.Bundle:
+		return line(q.Digital.Price, 1, true, q.BundleDiscount) +
+			line(q.Physical.Price, item.Quantity, true, q.BundleDiscount), nil
+	}
+
+	return 0, errors.New("invalid order type")
+}
+
+func line(unit, quantity uint, bundled bool, discount uint) uint {
+	if bundled {
+		return unit * quantity * (100 - discount) / 100
+	}
+	return unit * quantity
+}
+
+func price(list, discount uint, campaigns []Campaign, b Book, format, now string) Price {
+
+	p := Price{List: list, Discount: discount}
+
+	if c, ok := Best(campaigns, b, format, now); ok && c.Percentage > discount {
+		p.Discount = c.Percentage
+		p.CampaignID = c.ID
+	}
+
+	p.Price = list * (100 - p.Discount) / 100
+	return p
+}
+
+// BoxSetPrice prices quantity box sets whose lines cost lines when bought one by one,
+// a fixed price is per set and otherwise the percentage comes off the lines
+func BoxSetPrice(fixed, percentage, quantity, lines uint) uint {
+	if fixed != 0 {
+		return fixed * quantity
+	}
+	return lines * (100 - percentage) / 100
+}
+
+// BoxSetLines shares price, what the box set costs, among its lines by their own
+// price, the last line gets the rounding remainder
+func BoxSetLines(lines []Line, price uint) []Line {
+
+	var total uint64
+	for _, l := range lines {
+		total += uint64(l.Price)
+	}
+
+	shared := make([]Line, len(lines))
+	left := price
+	for i, l := range lines {
+		l.Campaign = true
+
+		switch {
+		case i == len(lines)-1:
+			l.Price = left
+		case total == 0:
+			l.Price = 0
+		default:
+			l.Price = uint(uint64(price) * uint64(l.Price) / total)
+		}
+		left -= l.Price
+
+		shared[i] = l
+	}
+
+	return shared
+}
@@ -0,0 +1,222 @@
+package pricing
+
+import (
+	"testing"
+
+	"github.com/XBozorg/bookstore/entity/order"
+)
+
+const now = "2026-03-10 12:00:00"
+
+var book = Book{
+	ID:               7,
+	DigitalPrice:     1000,
+	DigitalDiscount:  10,
+	PhysicalPrice:    3000,
+	PhysicalDiscount: 0,
+	PublisherID:      3,
+	AuthorIDs:        []uint{11},
+	TopicIDs:         []uint{5, 1},
+}
+
+func campaign(id, percentage, priority uint) Campaign {
+	return Campaign{
+		ID:         id,
+		Target:     TargetBooks,
+		TargetIDs:  []uint{book.ID},
+		Format:     FormatAll,
+		Percentage: percentage,
+		Priority:   priority,
+		Start:      "2026-03-01 00:00:00",
+		End:        "2026-04-01 00:00:00",
+	}
+}
+
+func TestBest(t *testing.T) {
+
+	ended := campaign(9, 90, 9)
+	ended.End = now
+
+	digitalOnly := campaign(8, 70, 0)
+	digitalOnly.Format = FormatDigital
+
+	otherPublisher := campaign(6, 80, 0)
+	otherPublisher.Target, otherPublisher.TargetIDs = TargetPublisher, []uint{4}
+
+	tests := []struct {
+		name      string
+		campaigns []Campaign
+		format    string
+		want      uint
+		found     bool
+	}{
+		{"none", nil, FormatDigital, 0, false},
+		{"priority beats percentage", []Campaign{campaign(1, 50, 0), campaign(2, 20, 1)}, FormatDigital, 2, true},
+		{"percentage breaks priority ties", []Campaign{campaign(1, 20, 1), campaign(2, 50, 1)}, FormatDigital, 2, true},
+		{"oldest breaks full ties", []Campaign{campaign(3, 20, 1), campaign(2, 20, 1)}, FormatDigital, 2, true},
+		{"ended at End", []Campaign{ended}, FormatDigital, 0, false},
+		{"format", []Campaign{digitalOnly}, FormatPhysical, 0, false},
+		{"target", []Campaign{otherPublisher}, FormatDigital, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, found := Best(tt.campaigns, book, tt.format, now)
+			if found != tt.found || c.ID != tt.want {
+				t.Errorf("Best() = %d, %v, want %d, %v", c.ID, found, tt.want, tt.found)
+			}
+		})
+	}
+}
+
+func TestBundleDiscount(t *testing.T) {
+
+	global := BundleRule{Scope: ScopeGlobal, Percentage: 20}
+	publisher := BundleRule{Scope: ScopePublisher, ScopeID: book.PublisherID, Percentage: 25}
+	otherPublisher := BundleRule{Scope: ScopePublisher, ScopeID: 4, Percentage: 40}
+	own := BundleRule{Scope: ScopeBook, ScopeID: book.ID, Percentage: 30}
+	otherBook := BundleRule{Scope: ScopeBook, ScopeID: 8, Percentage: 50}
+
+	tests := []struct {
+		name  string
+		rules []BundleRule
+		want  uint
+	}{
+		{"no rule", nil, 0},
+		{"global", []BundleRule{global}, 20},
+		{"publisher over global", []BundleRule{global, publisher}, 25},
+		{"book over publisher", []BundleRule{own, publisher, global}, 30},
+		{"rules of other books and publishers", []BundleRule{global, otherPublisher, otherBook}, 20},
+		{"zero book rule disables the bundle discount", []BundleRule{global, {Scope: ScopeBook, ScopeID: book.ID}}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BundleDiscount(tt.rules, book); got != tt.want {
+				t.Errorf("BundleDiscount() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewQuote(t *testing.T) {
+
+	rules := []BundleRule{{Scope: ScopeGlobal, Percentage: 20}}
+
+	tests := []struct {
+		name      string
+		campaigns []Campaign
+		digital   Price
+		physical  Price
+		bundle    Price
+	}{
+		{
+			name:     "standing discounts",
+			digital:  Price{List: 1000, Discount: 10, Price: 900},
+			physical: Price{List: 3000, Discount: 0, Price: 3000},
+			bundle:   Price{List: 4000, Discount: 20, Price: 3120},
+		},
+		{
+			name:      "campaign replaces a smaller standing discount",
+			campaigns: []Campaign{campaign(1, 50, 0)},
+			digital:   Price{List: 1000, Discount: 50, Price: 500, CampaignID: 1},
+			physical:  Price{List: 3000, Discount: 50, Price: 1500, CampaignID: 1},
+			bundle:    Price{List: 4000, Discount: 20, Price: 1600},
+		},
+		{
+			name:      "bigger standing discount stays",
+			campaigns: []Campaign{campaign(1, 5, 0)},
+			digital:   Price{List: 1000, Discount: 10, Price: 900},
+			physical:  Price{List: 3000, Discount: 5, Price: 2850, CampaignID: 1},
+			bundle:    Price{List: 4000, Discount: 20, Price: 3000},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := NewQuote(book, tt.campaigns, rules, now)
+			if q.Digital != tt.digital {
+				t.Errorf("Digital = %+v, want %+v", q.Digital, tt.digital)
+			}
+			if q.Physical != tt.physical {
+				t.Errorf("Physical = %+v, want %+v", q.Physical, tt.physical)
+			}
+			if q.Bundle != tt.bundle {
+				t.Errorf("Bundle = %+v, want %+v", q.Bundle, tt.bundle)
+			}
+		})
+	}
+}
+
+func TestItemPrice(t *testing.T) {
+
+	q := NewQuote(book, nil, []BundleRule{{Scope: ScopeBook, ScopeID: book.ID, Percentage: 25}}, now)
+
+	tests := []struct {
+		name string
+		item order.Item
+		want uint
+	}{
+		{"digital", order.Item{Type: order.Digital, Quantity: 1}, 900},
+		{"physical", order.Item{Type: order.Physical, Quantity: 2}, 6000},
+		{"bundled digital line", order.Item{Type: order.Digital, Quantity: 1, Bundled: true}, 675},
+		{"bundled physical line", order.Item{Type: order.Physical, Quantity: 2, Bundled: true}, 4500},
+		{"bundle", order.Item{Type: order.Bundle, Quantity: 2}, 5175},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ItemPrice(q, tt.item)
+			if err != nil {
+				t.Fatalf("ItemPrice() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ItemPrice() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := ItemPrice(q, order.Item{Type: 9, Quantity: 1}); err == nil {
+		t.Error("ItemPrice() of an invalid type, want an error")
+	}
+}
+
+// a bundle added in one go and the same bundle stored as its two lines cost the same
+func TestItemPriceBundleLines(t *testing.T) {
+
+	q := NewQuote(book, []Campaign{campaign(1, 33, 0)}, []BundleRule{{Scope: ScopeGlobal, Percentage: 15}}, now)
+
+	for quantity := uint(1); quantity <= 5; quantity++ {
+		bundle, _ := ItemPrice(q, order.Item{Type: order.Bundle, Quantity: quantity})
+		digital, _ := ItemPrice(q, order.Item{Type: order.Digital, Quantity: 1, Bundled: true})
+		physical, _ := ItemPrice(q, order.Item{Type: order.Physical, Quantity: quantity, Bundled: true})
+
+		if bundle != digital+physical {
+			t.Errorf("quantity %d: bundle = %d, lines = %d", quantity, bundle, digital+physical)
+		}
+	}
+}
+
+func TestBoxSetLines(t *testing.T) {
+
+	other := Book{ID: 8, TopicIDs: []uint{2}}
+	lines := []Line{
+		{Book: book, Format: FormatDigital, Price: 1000},
+		{Book: other, Format: FormatPhysical, Price: 2000},
+	}
+
+	shared := BoxSetLines(lines, 2000)
+	if shared[0].Price != 666 || shared[1].Price != 1334 {
+		t.Errorf("BoxSetLines() = %d + %d, want 666 + 1334", shared[0].Price, shared[1].Price)
+	}
+
+	for i, l := range shared {
+		if l.Book.ID != lines[i].Book.ID || l.Format != lines[i].Format || !l.Campaign {
+			t.Errorf("line %d = %+v, want its book and format as campaign priced", i, l)
+		}
+	}
+
+	if lines[0].Price != 1000 {
+		t.Error("BoxSetLines() changed the lines it was given")
+	}
+}
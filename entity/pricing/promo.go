@@ -0,0 +1,125 @@
+package pricing
+
+import (
+	"errors"
+
+	"github.com/XBozorg/bookstore/entity/order"
+)
+
+// Line is an order line as the promos see it, a gift card is a single line without a book
+// and the books of a box set are lines sharing the price of the set
+type Line struct {
+	Book     Book
+	Format   string // FormatDigital or FormatPhysical
+	Price    uint
+	Campaign bool // the price already has a campaign discount
+	GiftCard bool // never discounted, nor counted for the promo minimum
+	BoxSetID uint // the line is a book of a box set, out of the scoped promos
+}
+
+// Total is the breakdown of what an order costs
+type Total struct {
+	Items    uint `json:"items"`
+	Discount uint `json:"discount"`
+	Shipping uint `json:"shipping"`
+	Total    uint `json:"total"`
+}
+
+// NewTotal prices the lines with the promo, nil for an order without one.
+// The shipping fee is charged once when a line is physical.
+func NewTotal(lines []Line, promo *order.Promo, shippingFee uint) Total {
+
+	t := Total{}
+	for _, l := range lines {
+		t.Items += l.Price
+		if l.Format == FormatPhysical {
+			t.Shipping = shippingFee
+		}
+	}
+
+	if promo != nil && goods(lines) >= promo.MinTotal {
+		t.Discount = PromoDiscount(*promo, lines)
+		if promo.FreeShipping {
+			t.Shipping = 0
+		}
+	}
+
+	t.Total = t.Items - t.Discount + t.Shipping
+	return t
+}
+
+// goods sums the price of the lines but the gift cards
+func goods(lines []Line) uint {
+
+	var total uint
+	for _, l := range lines {
+		if !l.GiftCard {
+			total += l.Price
+		}
+	}
+
+	return total
+}
+
+// PromoDiscount returns what the promo takes off the lines in its scope: a fixed amount
+// up to their price, or a percentage capped at MaxPrice unless it's the whole price
+func PromoDiscount(p order.Promo, lines []Line) uint {
+
+	var eligible uint
+	for _, l := range lines {
+		if InPromo(p, l) {
+			eligible += l.Price
+		}
+	}
+
+	if p.Amount != 0 {
+		if p.Amount > eligible {
+			return eligible
+		}
+		return p.Amount
+	}
+
+	offer := eligible * p.Percentage / 100
+	if p.Percentage != 100 && p.MaxPrice != 0 && p.MaxPrice < offer {
+		return p.MaxPrice
+	}
+	return offer
+}
+
+// InPromo reports whether the promo covers the line
+func InPromo(p order.Promo, l Line) bool {
+
+	if l.GiftCard {
+		return false
+	}
+
+	if p.Stacking == order.StackExclusive && l.Campaign {
+		return false
+	}
+
+	if p.DigitalOnly && l.Format != FormatDigital {
+		return false
+	}
+
+	if p.Scope == "" {
+		return true
+	}
+
+	scope := Campaign{Target: p.Scope, TargetIDs: p.ScopeIDs, Format: FormatAll}
+	return l.Book.ID != 0 && l.BoxSetID == 0 && scope.Matches(l.Book, l.Format)
+}
+
+// CheckPromo tells why the promo can't be applied to the lines, if it can't
+func CheckPromo(p order.Promo, lines []Line, shippingFee uint) error {
+
+	t := NewTotal(lines, nil, shippingFee)
+	if goods(lines) < p.MinTotal {
+		return errors.New("order total is below the promo minimum")
+	}
+
+	if PromoDiscount(p, lines) == 0 && !(p.FreeShipping && t.Shipping != 0) {
+		return errors.New("promo does not apply to the order items")
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package pricing
+
+import (
+	"testing"
+
+	"github.com/XBozorg/bookstore/entity/order"
+)
+
+var (
+	otherBook = Book{ID: 8, PublisherID: 4}
+
+	lines = []Line{
+		{Book: book, Format: FormatDigital, Price: 900},
+		{Book: book, Format: FormatPhysical, Price: 3000, Campaign: true},
+		{Book: otherBook, Format: FormatDigital, Price: 2000},
+	}
+)
+
+func TestPromoDiscount(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		promo order.Promo
+		want  uint
+	}{
+		{"percentage", order.Promo{Percentage: 10}, 590},
+		{"capped percentage", order.Promo{Percentage: 10, MaxPrice: 500}, 500},
+		{"full price ignores the cap", order.Promo{Percentage: 100, MaxPrice: 500}, 5900},
+		{"fixed amount", order.Promo{Amount: 1000}, 1000},
+		{"fixed amount up to the eligible price", order.Promo{Amount: 1000, Scope: TargetBooks, ScopeIDs: []uint{book.ID}, DigitalOnly: true}, 900},
+		{"exclusive skips campaign lines", order.Promo{Percentage: 10, Stacking: order.StackExclusive}, 290},
+		{"digital only", order.Promo{Percentage: 10, DigitalOnly: true}, 290},
+		{"publisher scope", order.Promo{Percentage: 50, Scope: TargetPublisher, ScopeIDs: []uint{4}}, 1000},
+		{"scope without a match", order.Promo{Percentage: 50, Scope: TargetTopic, ScopeIDs: []uint{99}}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PromoDiscount(tt.promo, lines); got != tt.want {
+				t.Errorf("PromoDiscount() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGiftCard(t *testing.T) {
+
+	card := Line{Format: FormatDigital, Price: 10000, GiftCard: true}
+	withCard := append([]Line{card}, lines...)
+
+	if got := PromoDiscount(order.Promo{Percentage: 10}, withCard); got != 590 {
+		t.Errorf("PromoDiscount() = %d, want the gift card left out", got)
+	}
+	if err := CheckPromo(order.Promo{Percentage: 10, MinTotal: 6000}, withCard, 500); err == nil {
+		t.Error("CheckPromo() reached the minimum with a gift card")
+	}
+	if got := NewTotal([]Line{card}, nil, 500); got.Total != 10000 {
+		t.Errorf("NewTotal() = %+v, want no shipping for a gift card", got)
+	}
+}
+
+func TestInPromoBoxSet(t *testing.T) {
+
+	set := Line{Book: book, Format: FormatPhysical, Price: 5000, Campaign: true, BoxSetID: 2}
+
+	if !InPromo(order.Promo{Percentage: 10}, set) {
+		t.Error("a box set is in a promo for the whole order")
+	}
+	if InPromo(order.Promo{Percentage: 10, Scope: TargetBooks, ScopeIDs: []uint{book.ID}}, set) {
+		t.Error("a box set is not in a promo scoped to books")
+	}
+}
+
+func TestNewTotal(t *testing.T) {
+
+	digital := lines[:1]
+
+	tests := []struct {
+		name  string
+		lines []Line
+		promo *order.Promo
+		want  Total
+	}{
+		{"no promo", lines, nil, Total{Items: 5900, Shipping: 500, Total: 6400}},
+		{"no shipping for digital items", digital, nil, Total{Items: 900, Total: 900}},
+		{"promo", lines, &order.Promo{Amount: 400}, Total{Items: 5900, Discount: 400, Shipping: 500, Total: 6000}},
+		{"free shipping", lines, &order.Promo{FreeShipping: true}, Total{Items: 5900, Total: 5900}},
+		{"below the minimum", lines, &order.Promo{Amount: 400, FreeShipping: true, MinTotal: 6000}, Total{Items: 5900, Shipping: 500, Total: 6400}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewTotal(tt.lines, tt.promo, 500); got != tt.want {
+				t.Errorf("NewTotal() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckPromo(t *testing.T) {
+
+	digital := lines[:1]
+
+	tests := []struct {
+		name  string
+		lines []Line
+		promo order.Promo
+		ok    bool
+	}{
+		{"applies", lines, order.Promo{Percentage: 10}, true},
+		{"below the minimum", lines, order.Promo{Percentage: 10, MinTotal: 10000}, false},
+		{"out of scope", lines, order.Promo{Percentage: 10, Scope: TargetAuthor, ScopeIDs: []uint{99}}, false},
+		{"free shipping", lines, order.Promo{FreeShipping: true}, true},
+		{"free shipping without physical items", digital, order.Promo{FreeShipping: true}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckPromo(tt.promo, tt.lines, 500); (err == nil) != tt.ok {
+				t.Errorf("CheckPromo() = %v, want ok %v", err, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package pricing
+
+import (
+	"sort"
+
+	"github.com/XBozorg/bookstore/entity/order"
+)
+
+// how the catalog shows the prices
+const (
+	DisplayInclusive string = "inclusive" // with the tax
+	DisplayExclusive string = "exclusive" // without the tax
+)
+
+var Displays = []string{DisplayInclusive, DisplayExclusive}
+
+// TaxRule is the VAT of the store, the rates are percents by format
+type TaxRule struct {
+	Digital      uint
+	Physical     uint
+	ExemptTopics []uint // the books of these topics and their subtopics aren't taxed
+	Inclusive    bool   // the prices include the tax, otherwise it's added on top
+}
+
+// Tax is the tax of an order, Rates is its breakdown by rate
+type Tax struct {
+	Total uint
+	Rates []order.TaxRate
+}
+
+// Rate returns the tax rate of the line, gift cards aren't taxed until they're spent
+func (r TaxRule) Rate(l Line) uint {
+
+	if l.GiftCard || r.exempt(l.Book) {
+		return 0
+	}
+	if l.Format == FormatPhysical {
+		return r.Physical
+	}
+	return r.Digital
+}
+
+func (r TaxRule) exempt(b Book) bool {
+	for _, topicID := range b.TopicIDs {
+		for _, exempt := range r.ExemptTopics {
+			if topicID == exempt {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// split returns the tax in the amount at the rate and the amount without it
+func (r TaxRule) split(amount, rate uint) (net, tax uint) {
+	if r.Inclusive {
+		tax = amount * rate / (100 + rate)
+		return amount - tax, tax
+	}
+	return amount, amount * rate / 100
+}
+
+// NewTax taxes each line of an order on its price less its share of the discounts. The discount
+// of the promo, nil for an order without one, is shared among the lines the promo covers by price,
+// the points among all the goods by what is left of their price.
+func NewTax(r TaxRule, lines []Line, promo *order.Promo, discount, points uint) Tax {
+
+	prices := make([]uint, len(lines))
+	covered := make([]uint, len(lines))
+	for i, l := range lines {
+		if l.GiftCard {
+			continue
+		}
+		prices[i] = l.Price
+		if promo != nil && InPromo(*promo, l) {
+			covered[i] = l.Price
+		}
+	}
+
+	for i, share := range shares(covered, discount) {
+		prices[i] -= share
+	}
+	for i, share := range shares(prices, points) {
+		prices[i] -= share
+	}
+
+	byRate := map[uint]*order.TaxRate{}
+	for i, l := range lines {
+		if l.GiftCard {
+			continue
+		}
+
+		rate := r.Rate(l)
+		_, tax := r.split(prices[i], rate)
+
+		t, ok := byRate[rate]
+		if !ok {
+			t = &order.TaxRate{Rate: rate}
+			byRate[rate] = t
+		}
+		t.Base += prices[i]
+		t.Tax += tax
+	}
+
+	total := Tax{Rates: []order.TaxRate{}}
+	for _, t := range byRate {
+		total.Total += t.Tax
+		total.Rates = append(total.Rates, *t)
+	}
+	sort.Slice(total.Rates, func(i, j int) bool { return total.Rates[i].Rate < total.Rates[j].Rate })
+
+	return total
+}
+
+// shares splits the amount among the prices by price, up to their total. What the rounding
+// leaves goes to the last prices with room for it.
+func shares(prices []uint, amount uint) []uint {
+
+	split := make([]uint, len(prices))
+
+	var all uint
+	for _, p := range prices {
+		all += p
+	}
+	if all == 0 {
+		return split
+	}
+	if amount > all {
+		amount = all
+	}
+
+	left := amount
+	for i, p := range prices {
+		split[i] = uint(uint64(amount) * uint64(p) / uint64(all))
+		left -= split[i]
+	}
+
+	for i := len(prices) - 1; i >= 0 && left > 0; i-- {
+		room := prices[i] - split[i]
+		if room > left {
+			room = left
+		}
+		split[i] += room
+		left -= room
+	}
+
+	return split
+}
+
+// QuoteTax sets the tax of each format of the quote and the price the catalog shows
+// with display, DisplayInclusive or DisplayExclusive
+func QuoteTax(r TaxRule, q Quote, b Book, display string) Quote {
+
+	digital := r.Rate(Line{Book: b, Format: FormatDigital})
+	physical := r.Rate(Line{Book: b, Format: FormatPhysical})
+
+	q.Display = display
+	q.Digital = r.priceTax(q.Digital, digital, display)
+	q.Physical = r.priceTax(q.Physical, physical, display)
+
+	// a bundle is taxed as its digital and physical lines
+	d := r.priceTax(Price{Price: line(q.Digital.Price, 1, true, q.BundleDiscount)}, digital, display)
+	p := r.priceTax(Price{Price: line(q.Physical.Price, 1, true, q.BundleDiscount)}, physical, display)
+	q.Bundle.Tax = d.Tax + p.Tax
+	q.Bundle.Shown = d.Shown + p.Shown
+
+	return q
+}
+
+func (r TaxRule) priceTax(p Price, rate uint, display string) Price {
+
+	net, tax := r.split(p.Price, rate)
+	p.Tax = tax
+	p.Shown = net
+	if display == DisplayInclusive {
+		p.Shown = net + tax
+	}
+
+	return p
+}
@@ -0,0 +1,91 @@
+package pricing
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/XBozorg/bookstore/entity/order"
+)
+
+func TestNewTax(t *testing.T) {
+
+	card := Line{Format: FormatDigital, Price: 10000, GiftCard: true}
+
+	tests := []struct {
+		name     string
+		rule     TaxRule
+		lines    []Line
+		promo    *order.Promo
+		discount uint
+		points   uint
+		want     Tax
+	}{
+		{"added on top", TaxRule{Digital: 10, Physical: 9}, lines, nil, 0, 0,
+			Tax{Total: 560, Rates: []order.TaxRate{{Rate: 9, Base: 3000, Tax: 270}, {Rate: 10, Base: 2900, Tax: 290}}}},
+		{"included in the prices", TaxRule{Digital: 10, Physical: 9, Inclusive: true}, lines, nil, 0, 0,
+			Tax{Total: 509, Rates: []order.TaxRate{{Rate: 9, Base: 3000, Tax: 247}, {Rate: 10, Base: 2900, Tax: 262}}}},
+		{"exempt topic", TaxRule{Digital: 10, Physical: 9, ExemptTopics: []uint{5}}, lines, nil, 0, 0,
+			Tax{Total: 200, Rates: []order.TaxRate{{Rate: 0, Base: 3900, Tax: 0}, {Rate: 10, Base: 2000, Tax: 200}}}},
+		{"points shared by price", TaxRule{Digital: 10, Physical: 9}, lines, nil, 0, 590,
+			Tax{Total: 504, Rates: []order.TaxRate{{Rate: 9, Base: 2700, Tax: 243}, {Rate: 10, Base: 2610, Tax: 261}}}},
+		{"promo shared by the lines it covers", TaxRule{Digital: 10, Physical: 9}, lines, &order.Promo{Percentage: 10, DigitalOnly: true}, 290, 0,
+			Tax{Total: 531, Rates: []order.TaxRate{{Rate: 9, Base: 3000, Tax: 270}, {Rate: 10, Base: 2610, Tax: 261}}}},
+		{"free order", TaxRule{Digital: 10, Physical: 9}, []Line{{Format: FormatDigital}, {Format: FormatPhysical}}, &order.Promo{Percentage: 100}, 0, 0,
+			Tax{Rates: []order.TaxRate{{Rate: 9}, {Rate: 10}}}},
+		{"gift cards are left out", TaxRule{Digital: 10, Physical: 9}, append([]Line{card}, lines...), nil, 0, 0,
+			Tax{Total: 560, Rates: []order.TaxRate{{Rate: 9, Base: 3000, Tax: 270}, {Rate: 10, Base: 2900, Tax: 290}}}},
+		{"nothing to tax", TaxRule{Digital: 10, Physical: 9}, []Line{card}, nil, 0, 0,
+			Tax{Rates: []order.TaxRate{}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewTax(tt.rule, tt.lines, tt.promo, tt.discount, tt.points); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewTax() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuoteTax(t *testing.T) {
+
+	q := Quote{
+		BookID:         book.ID,
+		Digital:        Price{Price: 900},
+		Physical:       Price{Price: 3000},
+		BundleDiscount: 10,
+	}
+	rule := TaxRule{Digital: 10, Physical: 9}
+
+	tests := []struct {
+		name                   string
+		rule                   TaxRule
+		display                string
+		digital, physical      Price
+		bundleTax, bundleShown uint
+	}{
+		{"shown with the tax", rule, DisplayInclusive,
+			Price{Price: 900, Tax: 90, Shown: 990}, Price{Price: 3000, Tax: 270, Shown: 3270}, 324, 3834},
+		{"shown without the tax", rule, DisplayExclusive,
+			Price{Price: 900, Tax: 90, Shown: 900}, Price{Price: 3000, Tax: 270, Shown: 3000}, 324, 3510},
+		{"prices with the tax shown without it", TaxRule{Digital: 10, Physical: 9, Inclusive: true}, DisplayExclusive,
+			Price{Price: 900, Tax: 81, Shown: 819}, Price{Price: 3000, Tax: 247, Shown: 2753}, 295, 3215},
+		{"exempt book", TaxRule{Digital: 10, Physical: 9, ExemptTopics: []uint{1}}, DisplayInclusive,
+			Price{Price: 900, Shown: 900}, Price{Price: 3000, Shown: 3000}, 0, 3510},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := QuoteTax(tt.rule, q, book, tt.display)
+			if got.Digital != tt.digital || got.Physical != tt.physical {
+				t.Errorf("QuoteTax() = %+v %+v, want %+v %+v", got.Digital, got.Physical, tt.digital, tt.physical)
+			}
+			if got.Bundle.Tax != tt.bundleTax || got.Bundle.Shown != tt.bundleShown {
+				t.Errorf("QuoteTax() bundle = %d %d, want %d %d", got.Bundle.Tax, got.Bundle.Shown, tt.bundleTax, tt.bundleShown)
+			}
+			if got.Display != tt.display {
+				t.Errorf("QuoteTax() display = %s, want %s", got.Display, tt.display)
+			}
+		})
+	}
+}
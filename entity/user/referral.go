@@ -0,0 +1,72 @@
+package user
+
+import (
+	"strings"
+
+	"github.com/XBozorg/bookstore/entity/code"
+)
+
+const (
+	ReferralPending  string = "pending" // the referee hasn't paid an order yet
+	ReferralRewarded string = "rewarded"
+	ReferralRejected string = "rejected" // the first paid order looked like the referrer's own
+)
+
+// Referral attributes the signup of the referee to the referrer whose code it used,
+// the referrer is credited Credit once the referee pays an order
+type Referral struct {
+	ID           uint   `json:"id"`
+	ReferrerID   string `json:"referrerID"`
+	RefereeID    string `json:"refereeID"`
+	Referee      string `json:"referee"` // username of the referee
+	PromoID      uint   `json:"promoID"` // welcome promo of the referee
+	Status       string `json:"status"`
+	Reason       string `json:"reason,omitempty"`
+	OrderID      uint   `json:"orderID,omitempty"`
+	Credit       uint   `json:"credit"`
+	CreationDate string `json:"creationDate"`
+	SettleDate   string `json:"settleDate,omitempty"`
+}
+
+type ReferralInfo struct {
+	Code      string     `json:"code"`
+	Referrals []Referral `json:"referrals"`
+}
+
+// ReferralReport sums up the referrals of a referrer
+type ReferralReport struct {
+	ReferrerID string `json:"referrerID"`
+	Username   string `json:"username"`
+	Email      string `json:"email"`
+	Invited    uint   `json:"invited"`
+	Pending    uint   `json:"pending"`
+	Rewarded   uint   `json:"rewarded"`
+	Rejected   uint   `json:"rejected"`
+	Credit     uint   `json:"credit"`
+}
+
+const ReferralCodeLength = 8
+
+// NewReferralCode returns ReferralCodeLength random characters
+func NewReferralCode() (string, error) {
+	return code.Random(ReferralCodeLength)
+}
+
+// NormalizeEmail lowercases the email and drops the +tag of its local part,
+// the addresses of one mailbox come out the same
+func NormalizeEmail(email string) string {
+
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return email
+	}
+
+	local, domain := email[:at], email[at:]
+	if plus := strings.Index(local, "+"); plus >= 0 {
+		local = local[:plus]
+	}
+
+	return local + domain
+}
@@ -10,4 +10,5 @@ type User struct {
 	PhoneNumbers []PhoneNumber `json:"phoneNumbers"`
 	Addresses    []Address     `json:"addresses"`
 	RegDate      string        `json:"regDate"` //Registration Date
+	ReferralCode string        `json:"referralCode,omitempty"`
 }
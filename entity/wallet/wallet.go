@@ -0,0 +1,63 @@
+package wallet
+
+import (
+	"github.com/XBozorg/bookstore/entity/code"
+)
+
+// the kinds of wallet entries
+const (
+	EntryGiftCard string = "gift_card" // a gift card redeemed into the wallet
+	EntryOrder    string = "order"     // taken to pay a part of an order
+	EntryRelease  string = "release"   // given back by an order before it's paid
+	EntryRefund   string = "refund"    // a refund of a paid or cancelled order
+	EntryReferral string = "referral"  // credit for a referred user's first paid order
+)
+
+// Entry is a line of the ledger of a wallet, a debit has a negative amount
+type Entry struct {
+	ID         uint   `json:"id"`
+	UserID     string `json:"userID"`
+	Amount     int    `json:"amount"`
+	Balance    uint   `json:"balance"` // after the entry
+	Type       string `json:"type"`
+	OrderID    uint   `json:"orderID,omitempty"`
+	GiftCardID uint   `json:"giftCardID,omitempty"`
+	Note       string `json:"note,omitempty"`
+	Date       string `json:"date"`
+}
+
+type Wallet struct {
+	UserID  string  `json:"userID"`
+	Balance uint    `json:"balance"`
+	Entries []Entry `json:"entries"`
+}
+
+const (
+	GiftCardPending  string = "pending" // in an order not paid yet
+	GiftCardActive   string = "active"  // paid, the code can be redeemed
+	GiftCardRedeemed string = "redeemed"
+	GiftCardVoid     string = "void" // its order was cancelled
+)
+
+// GiftCard is a code worth Amount in a wallet, bought in an order and sent to RecipientEmail
+type GiftCard struct {
+	ID             uint   `json:"id"`
+	Code           string `json:"code,omitempty"` // hidden until the order is paid
+	Amount         uint   `json:"amount"`
+	BuyerID        string `json:"buyerID"`
+	OrderID        uint   `json:"orderID"`
+	RecipientEmail string `json:"recipientEmail"`
+	Message        string `json:"message"`
+	Status         string `json:"status"`
+	Delivered      bool   `json:"delivered"`
+	CreationDate   string `json:"creationDate"`
+	RedeemedBy     string `json:"redeemedBy,omitempty"`
+	RedeemedAt     string `json:"redeemedAt,omitempty"`
+}
+
+const CodeLength = 16
+
+// NewGiftCardCode returns CodeLength random characters
+func NewGiftCardCode() (string, error) {
+	return code.Random(CodeLength)
+}
@@ -30,10 +30,9 @@ func LoggerInit(logger *logrus.Logger, level logrus.Level, formatter logrus.Form
 		log.Panic(err)
 	}
 
-	x := logrus.New()
-	x.SetOutput(logFile)
-	x.SetLevel(level)
-	x.SetFormatter(formatter)
-
-	*logger = *x
+	logger.SetOutput(logFile)
+	logger.SetLevel(level)
+	logger.SetFormatter(formatter)
+	logger.Hooks = make(logrus.LevelHooks)
+	logger.ExitFunc = os.Exit
 }
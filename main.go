@@ -1,8 +1,11 @@
 package main
 
 import (
+	"context"
+
 	v1 "github.com/XBozorg/bookstore/adapter/delivery/http/v1"
 	"github.com/XBozorg/bookstore/adapter/repository"
+	"github.com/XBozorg/bookstore/adapter/scheduler"
 	"github.com/XBozorg/bookstore/config"
 	"github.com/XBozorg/bookstore/log"
 	"github.com/labstack/echo/v4/middleware"
@@ -40,6 +43,19 @@ func main() {
 	defer e.Close()
 	defer repo.Close()
 
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	jobs := scheduler.New()
+	jobs.Add(scheduler.LowStock(repo))
+	jobs.Add(scheduler.Preorders(repo))
+	jobs.Add(scheduler.Campaigns(repo))
+	jobs.Add(scheduler.Promos(repo))
+	jobs.Add(scheduler.GiftCards(repo))
+	jobs.Add(scheduler.Gifts(repo))
+	jobs.Add(scheduler.Points(repo))
+	jobs.Start(ctx) // background jobs
+
 	e.Use(middleware.Recover())
 
 	e.Use(middleware.LoggerWithConfig(
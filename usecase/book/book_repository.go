@@ -4,44 +4,117 @@ import (
 	"context"
 
 	"github.com/XBozorg/bookstore/entity/book"
+	"github.com/XBozorg/bookstore/entity/pricing"
 )
 
 type Repository interface {
 	AddAuthor(ctx context.Context, authorName string) (book.Author, error)
 	GetAuthor(ctx context.Context, authorID uint) (book.Author, error)
+	GetAuthorByName(ctx context.Context, authorName string) (book.Author, error)
 	GetAuthors(ctx context.Context) ([]book.Author, error)
+	EditAuthor(ctx context.Context, a book.Author) (book.Author, error)
+	GetAuthorCredits(ctx context.Context, authorID uint) ([]book.Credit, error)
 	DeleteAuthor(ctx context.Context, authorID uint) error
 
 	AddPublisher(ctx context.Context, publisherName string) (book.Publisher, error)
 	GetPublisher(ctx context.Context, publisherID uint) (book.Publisher, error)
+	GetPublisherByName(ctx context.Context, publisherName string) (book.Publisher, error)
 	GetPublishers(ctx context.Context) ([]book.Publisher, error)
+	EditPublisher(ctx context.Context, p book.Publisher) (book.Publisher, error)
 	DeletePublisher(ctx context.Context, publisherId uint) error
 
-	AddTopic(ctx context.Context, topicName string) (book.Topic, error)
+	AddTopic(ctx context.Context, topicName string, parentID uint) (book.Topic, error)
 	GetTopic(ctx context.Context, topicID uint) (book.Topic, error)
+	GetTopicByName(ctx context.Context, topicName string) (book.Topic, error)
 	GetTopics(ctx context.Context) ([]book.Topic, error)
+	MoveTopic(ctx context.Context, topicID, parentID, position uint) (book.Topic, error)
 	DeleteTopic(ctx context.Context, topicID uint) error
 
 	AddLanguage(ctx context.Context, langCode string) (book.Language, error)
 	GetLanguage(ctx context.Context, langID uint) (book.Language, error)
+	GetLanguageByCode(ctx context.Context, langCode string) (book.Language, error)
 	GetLanguages(ctx context.Context) ([]book.Language, error)
 	DeleteLanguage(ctx context.Context, langID uint) error
 
 	AddBook(ctx context.Context, b book.Book) (book.Book, error)
+	AddBookWithNames(ctx context.Context, b book.Book) (book.Book, error)
 	SetBookDiscount(ctx context.Context, bookID, digital, physical uint) error
 	GetBook(ctx context.Context, bookID uint) (book.Book, error)
 	GetBookAuthors(ctx context.Context, bookID uint) ([]book.Author, error)
 	GetBookTopics(ctx context.Context, bookID uint) ([]book.Topic, error)
 	EditBook(ctx context.Context, b book.Book) (book.Book, error)
+	SetAvailabilityOverride(ctx context.Context, bookID uint, override string) error
 	GetAllBooks(ctx context.Context) ([]book.Book, error)
 	GetAllBooksFull(ctx context.Context) ([]book.Book, error)
 	GetAuthorBooks(ctx context.Context, authorID uint) ([]book.Book, error)
 	GetPublisherBooks(ctx context.Context, publisherID uint) ([]book.Book, error)
-	GetTopicBooks(ctx context.Context, topicID uint) ([]book.Book, error)
+	GetTopicBooks(ctx context.Context, topicID uint, descendants bool) ([]book.Book, error)
 	GetLangBooks(ctx context.Context, langID uint) ([]book.Book, error)
+	SearchBooks(ctx context.Context, query string) ([]book.Book, error)
+	GetPriceQuotes(ctx context.Context, bookIDs []uint, display string) ([]pricing.Quote, error)
 	DeleteBook(ctx context.Context, bookID uint) error
 
 	GetUserDigitalBooks(ctx context.Context, userID string) ([]book.Book, error)
+
+	GetBookFiles(ctx context.Context, bookID uint) (book.Digital, error)
+	AddDownload(ctx context.Context, d book.Download, limit uint) error
+	CountUserDownloads(ctx context.Context, userID string, bookID uint) (uint, error)
+	GetDownloadLimit(ctx context.Context, userID string, bookID uint) (uint, error)
+	SetDownloadLimit(ctx context.Context, limit book.DownloadLimit) error
+	GetDownloads(ctx context.Context) ([]book.Download, error)
+	GetUserDownloads(ctx context.Context, userID string) ([]book.Download, error)
+	GetDownloadActivity(ctx context.Context, minIPs uint) ([]book.DownloadActivity, error)
+	GetWatermark(ctx context.Context, userID string, bookID uint) (book.Watermark, error)
+
+	GetBookIDByISBN(ctx context.Context, isbn string) (uint, error)
+	ImportBook(ctx context.Context, b book.Book, fields book.ImportFields) (bool, error)
+	GetCatalog(ctx context.Context) ([]book.Book, error)
+	CreateImportJob(ctx context.Context, job book.ImportJob) (book.ImportJob, error)
+	FinishImportJob(ctx context.Context, job book.ImportJob) error
+	AddImportError(ctx context.Context, jobID uint, e book.ImportError) error
+	GetImportJob(ctx context.Context, jobID uint) (book.ImportJob, error)
+	GetImportJobs(ctx context.Context) ([]book.ImportJob, error)
+
+	AddSeries(ctx context.Context, seriesName string) (book.Series, error)
+	GetSeries(ctx context.Context, seriesID uint) (book.Series, error)
+	GetAllSeries(ctx context.Context) ([]book.Series, error)
+	DeleteSeries(ctx context.Context, seriesID uint) error
+	AddWork(ctx context.Context, w book.Work) (book.Work, error)
+	EditWork(ctx context.Context, w book.Work) (book.Work, error)
+	GetWork(ctx context.Context, workID uint) (book.Work, error)
+	GetWorks(ctx context.Context) ([]book.Work, error)
+	DeleteWork(ctx context.Context, workID uint) error
+	SetBookWork(ctx context.Context, bookID, workID uint, edition string) error
+	AddContributor(ctx context.Context, bookID uint, c book.Contributor) error
+	RemoveContributor(ctx context.Context, bookID uint, c book.Contributor) error
+	GetBookContributors(ctx context.Context, bookID uint) ([]book.Contributor, error)
+
+	Merge(ctx context.Context, kind string, fromID, intoID uint) error
+	GetRedirect(ctx context.Context, kind string, oldID uint) (book.Redirect, error)
+	GetRedirects(ctx context.Context) ([]book.Redirect, error)
+
+	GetTrash(ctx context.Context, kind string) ([]book.Trashed, error)
+	Restore(ctx context.Context, kind string, id uint) error
+	Purge(ctx context.Context, kind string, id uint) error
+
+	MoveStock(ctx context.Context, m book.Movement) (book.Movement, error)
+	Stocktake(ctx context.Context, counts []book.StockCount, reason, adminID string) ([]book.Movement, error)
+	GetMovements(ctx context.Context, bookID uint) ([]book.Movement, error)
+
+	SetReorderThreshold(ctx context.Context, bookID, threshold uint) error
+	UpdateSalesVelocity(ctx context.Context, days uint) error
+	GetStockAlerts(ctx context.Context) ([]book.StockAlert, error)
+	SetStockAlerted(ctx context.Context, bookID uint, alerted bool) error
+
+	ReleasePreorders(ctx context.Context, today string) (book.PreorderRelease, error)
+	GetPreorderDemand(ctx context.Context) ([]book.PreorderDemand, error)
+
+	AddBoxSet(ctx context.Context, s book.BoxSet) (book.BoxSet, error)
+	EditBoxSet(ctx context.Context, s book.BoxSet) (book.BoxSet, error)
+	DeleteBoxSet(ctx context.Context, boxSetID uint) error
+	GetBoxSet(ctx context.Context, boxSetID uint) (book.BoxSet, error)
+	GetBoxSets(ctx context.Context) ([]book.BoxSet, error)
+	RefreshOpenOrders(ctx context.Context) error
 }
 
 type ValidatorRepo interface {
@@ -51,4 +124,11 @@ type ValidatorRepo interface {
 	DoesLanguageExist(ctx context.Context, langID uint) (bool, error)
 	DoesBookExist(ctx context.Context, bookID uint) (bool, error)
 	DoesUserAccessBook(ctx context.Context, userID string, bookID uint) (bool, error)
+	DoesImportJobExist(ctx context.Context, jobID uint) (bool, error)
+	DoesWorkExist(ctx context.Context, workID uint) (bool, error)
+	DoesSeriesExist(ctx context.Context, seriesID uint) (bool, error)
+	DoesBoxSetExist(ctx context.Context, boxSetID uint) (bool, error)
+	IsBoxSetInOpenOrders(ctx context.Context, boxSetID uint) (bool, error)
+	IsTrashed(ctx context.Context, kind string, id uint) (bool, error)
+	GetTopics(ctx context.Context) ([]book.Topic, error)
 }
@@ -2,24 +2,36 @@ package book
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"time"
 
 	"github.com/XBozorg/bookstore/dto"
+	"github.com/XBozorg/bookstore/entity/book"
+	"github.com/XBozorg/bookstore/log"
 )
 
 type UseCase interface {
 	AddAuthor(ctx context.Context, req dto.AddAuthorRequest) (dto.AddAuthorResponse, error)
 	GetAuthor(ctx context.Context, req dto.GetAuthorRequest) (dto.GetAuthorResponse, error)
 	GetAuthors(ctx context.Context, req dto.GetAuthorsRequest) (dto.GetAuthorsResponse, error)
+	EditAuthor(ctx context.Context, req dto.EditAuthorRequest) (dto.EditAuthorResponse, error)
+	GetAuthorPage(ctx context.Context, req dto.GetAuthorPageRequest) (dto.GetAuthorPageResponse, error)
 	DeleteAuthor(ctx context.Context, req dto.DeleteAuthorRequest) (dto.DeleteAuthorResponse, error)
 
 	AddPublisher(ctx context.Context, req dto.AddPublisherRequest) (dto.AddPublisherResponse, error)
 	GetPublisher(ctx context.Context, req dto.GetPublisherRequest) (dto.GetPublisherResponse, error)
 	GetPublishers(ctx context.Context, req dto.GetPublishersRequest) (dto.GetPublishersResponse, error)
+	EditPublisher(ctx context.Context, req dto.EditPublisherRequest) (dto.EditPublisherResponse, error)
+	GetPublisherPage(ctx context.Context, req dto.GetPublisherPageRequest) (dto.GetPublisherPageResponse, error)
 	DeletePublisher(ctx context.Context, req dto.DeletePublisherRequest) (dto.DeletePublisherResponse, error)
 
 	AddTopic(ctx context.Context, req dto.AddTopicRequest) (dto.AddTopicResponse, error)
 	GetTopic(ctx context.Context, req dto.GetTopicRequest) (dto.GetTopicResponse, error)
 	GetTopics(ctx context.Context, req dto.GetTopicsRequest) (dto.GetTopicsResponse, error)
+	GetTopicTree(ctx context.Context, req dto.GetTopicTreeRequest) (dto.GetTopicTreeResponse, error)
+	MoveTopic(ctx context.Context, req dto.MoveTopicRequest) (dto.MoveTopicResponse, error)
+	ReorderTopic(ctx context.Context, req dto.ReorderTopicRequest) (dto.ReorderTopicResponse, error)
 	DeleteTopic(ctx context.Context, req dto.DeleteTopicRequest) (dto.DeleteTopicResponse, error)
 
 	AddLanguage(ctx context.Context, req dto.AddLanguageRequest) (dto.AddLanguageResponse, error)
@@ -31,15 +43,66 @@ type UseCase interface {
 	SetBookDiscount(ctx context.Context, req dto.SetBookDiscountRequest) (dto.SetBookDiscountResponse, error)
 	GetBook(ctx context.Context, req dto.GetBookRequest) (dto.GetBookResponse, error)
 	EditBook(ctx context.Context, req dto.EditBookRequest) (dto.EditBookResponse, error)
+	SetAvailabilityOverride(ctx context.Context, req dto.SetAvailabilityOverrideRequest) (dto.SetAvailabilityOverrideResponse, error)
 	GetAllBooks(ctx context.Context, req dto.GetAllBooksRequest) (dto.GetAllBooksResponse, error)
 	GetAuthorBooks(ctx context.Context, req dto.GetAuthorBooksRequest) (dto.GetAuthorBooksResponse, error)
 	GetPublisherBooks(ctx context.Context, req dto.GetPublisherBooksRequest) (dto.GetPublisherBooksResponse, error)
 	GetTopicBooks(ctx context.Context, req dto.GetTopicBooksRequest) (dto.GetTopicBooksResponse, error)
 	GetLangBooks(ctx context.Context, req dto.GetLangBooksRequest) (dto.GetLangBooksResponse, error)
+	SearchBooks(ctx context.Context, req dto.SearchBooksRequest) (dto.SearchBooksResponse, error)
 	DeleteBook(ctx context.Context, req dto.DeleteBookRequest) (dto.DeleteBookResponse, error)
 
 	GetUserDigitalBooks(ctx context.Context, req dto.GetUserDigitalBooksRequest) (dto.GetUserDigitalBooksResponse, error)
+	ExtractBookMetadata(ctx context.Context, req dto.ExtractBookMetadataRequest) (dto.ExtractBookMetadataResponse, error)
+	ConfirmBookMetadata(ctx context.Context, req dto.ConfirmBookMetadataRequest) (dto.ConfirmBookMetadataResponse, error)
+	ImportCatalog(ctx context.Context, req dto.ImportCatalogRequest) (dto.ImportCatalogResponse, error)
+	GetImportJob(ctx context.Context, req dto.GetImportJobRequest) (dto.GetImportJobResponse, error)
+	GetImportJobs(ctx context.Context, req dto.GetImportJobsRequest) (dto.GetImportJobsResponse, error)
+	ExportCatalog(ctx context.Context, req dto.ExportCatalogRequest) (dto.ExportCatalogResponse, error)
+	CreateDownloadLinks(ctx context.Context, req dto.CreateDownloadLinksRequest) (dto.CreateDownloadLinksResponse, error)
 	DownloadBook(ctx context.Context, req dto.DownloadBookRequest) (dto.DownloadBookResponse, error)
+	SetDownloadLimit(ctx context.Context, req dto.SetDownloadLimitRequest) (dto.SetDownloadLimitResponse, error)
+	GetDownloads(ctx context.Context, req dto.GetDownloadsRequest) (dto.GetDownloadsResponse, error)
+	GetUserDownloads(ctx context.Context, req dto.GetUserDownloadsRequest) (dto.GetUserDownloadsResponse, error)
+	GetDownloadActivity(ctx context.Context, req dto.GetDownloadActivityRequest) (dto.GetDownloadActivityResponse, error)
+
+	AddSeries(ctx context.Context, req dto.AddSeriesRequest) (dto.AddSeriesResponse, error)
+	GetSeries(ctx context.Context, req dto.GetSeriesRequest) (dto.GetSeriesResponse, error)
+	GetAllSeries(ctx context.Context, req dto.GetAllSeriesRequest) (dto.GetAllSeriesResponse, error)
+	DeleteSeries(ctx context.Context, req dto.DeleteSeriesRequest) (dto.DeleteSeriesResponse, error)
+	AddWork(ctx context.Context, req dto.AddWorkRequest) (dto.AddWorkResponse, error)
+	EditWork(ctx context.Context, req dto.EditWorkRequest) (dto.EditWorkResponse, error)
+	GetWork(ctx context.Context, req dto.GetWorkRequest) (dto.GetWorkResponse, error)
+	GetWorks(ctx context.Context, req dto.GetWorksRequest) (dto.GetWorksResponse, error)
+	DeleteWork(ctx context.Context, req dto.DeleteWorkRequest) (dto.DeleteWorkResponse, error)
+	SetBookWork(ctx context.Context, req dto.SetBookWorkRequest) (dto.SetBookWorkResponse, error)
+	AddContributor(ctx context.Context, req dto.AddContributorRequest) (dto.AddContributorResponse, error)
+	RemoveContributor(ctx context.Context, req dto.RemoveContributorRequest) (dto.RemoveContributorResponse, error)
+
+	GetDuplicates(ctx context.Context, req dto.GetDuplicatesRequest) (dto.GetDuplicatesResponse, error)
+	Merge(ctx context.Context, req dto.MergeRequest) (dto.MergeResponse, error)
+	GetRedirect(ctx context.Context, req dto.GetRedirectRequest) (dto.GetRedirectResponse, error)
+	GetRedirects(ctx context.Context, req dto.GetRedirectsRequest) (dto.GetRedirectsResponse, error)
+
+	GetTrash(ctx context.Context, req dto.GetTrashRequest) (dto.GetTrashResponse, error)
+	Restore(ctx context.Context, req dto.RestoreRequest) (dto.RestoreResponse, error)
+	Purge(ctx context.Context, req dto.PurgeRequest) (dto.PurgeResponse, error)
+
+	ReceiveStock(ctx context.Context, req dto.ReceiveStockRequest) (dto.ReceiveStockResponse, error)
+	AdjustStock(ctx context.Context, req dto.AdjustStockRequest) (dto.AdjustStockResponse, error)
+	Stocktake(ctx context.Context, req dto.StocktakeRequest) (dto.StocktakeResponse, error)
+	GetMovements(ctx context.Context, req dto.GetMovementsRequest) (dto.GetMovementsResponse, error)
+	SetReorderThreshold(ctx context.Context, req dto.SetReorderThresholdRequest) (dto.SetReorderThresholdResponse, error)
+	CheckLowStock(ctx context.Context, req dto.CheckLowStockRequest) (dto.CheckLowStockResponse, error)
+	GetLowStockReport(ctx context.Context, req dto.GetLowStockReportRequest) (dto.GetLowStockReportResponse, error)
+	ReleasePreorders(ctx context.Context, req dto.ReleasePreordersRequest) (dto.ReleasePreordersResponse, error)
+	GetPreorderDemand(ctx context.Context, req dto.GetPreorderDemandRequest) (dto.GetPreorderDemandResponse, error)
+
+	AddBoxSet(ctx context.Context, req dto.AddBoxSetRequest) (dto.AddBoxSetResponse, error)
+	EditBoxSet(ctx context.Context, req dto.EditBoxSetRequest) (dto.EditBoxSetResponse, error)
+	DeleteBoxSet(ctx context.Context, req dto.DeleteBoxSetRequest) (dto.DeleteBoxSetResponse, error)
+	GetBoxSet(ctx context.Context, req dto.GetBoxSetRequest) (dto.GetBoxSetResponse, error)
+	GetBoxSets(ctx context.Context, req dto.GetBoxSetsRequest) (dto.GetBoxSetsResponse, error)
 }
 
 type UseCaseRepo struct {
@@ -80,6 +143,44 @@ func (u UseCaseRepo) GetAuthors(ctx context.Context, req dto.GetAuthorsRequest)
 	return dto.GetAuthorsResponse{Authors: authors}, nil
 }
 
+func (u UseCaseRepo) EditAuthor(ctx context.Context, req dto.EditAuthorRequest) (dto.EditAuthorResponse, error) {
+
+	author, err := u.repo.EditAuthor(ctx, req.Author)
+	if err != nil {
+		return dto.EditAuthorResponse{}, err
+	}
+
+	return dto.EditAuthorResponse{Author: author}, nil
+}
+
+func (u UseCaseRepo) GetAuthorPage(ctx context.Context, req dto.GetAuthorPageRequest) (dto.GetAuthorPageResponse, error) {
+
+	author, err := u.repo.GetAuthor(ctx, req.AuthorID)
+	if err != nil {
+		return dto.GetAuthorPageResponse{}, err
+	}
+
+	credits, err := u.repo.GetAuthorCredits(ctx, req.AuthorID)
+	if err != nil {
+		return dto.GetAuthorPageResponse{}, err
+	}
+
+	page := book.AuthorPage{
+		Author:     author,
+		Credits:    credits,
+		RoleCounts: map[string]uint{},
+	}
+
+	books := map[uint]bool{}
+	for _, c := range credits {
+		page.RoleCounts[c.Role]++
+		books[c.Book.ID] = true
+	}
+	page.BookCount = uint(len(books))
+
+	return dto.GetAuthorPageResponse{Page: page}, nil
+}
+
 func (u UseCaseRepo) DeleteAuthor(ctx context.Context, req dto.DeleteAuthorRequest) (dto.DeleteAuthorResponse, error) {
 
 	err := u.repo.DeleteAuthor(ctx, req.AuthorID)
@@ -120,6 +221,35 @@ func (u UseCaseRepo) GetPublishers(ctx context.Context, req dto.GetPublishersReq
 	return dto.GetPublishersResponse{Publishers: publishers}, nil
 }
 
+func (u UseCaseRepo) EditPublisher(ctx context.Context, req dto.EditPublisherRequest) (dto.EditPublisherResponse, error) {
+
+	publisher, err := u.repo.EditPublisher(ctx, req.Publisher)
+	if err != nil {
+		return dto.EditPublisherResponse{}, err
+	}
+
+	return dto.EditPublisherResponse{Publisher: publisher}, nil
+}
+
+func (u UseCaseRepo) GetPublisherPage(ctx context.Context, req dto.GetPublisherPageRequest) (dto.GetPublisherPageResponse, error) {
+
+	publisher, err := u.repo.GetPublisher(ctx, req.PublisherID)
+	if err != nil {
+		return dto.GetPublisherPageResponse{}, err
+	}
+
+	books, err := u.repo.GetPublisherBooks(ctx, req.PublisherID)
+	if err != nil {
+		return dto.GetPublisherPageResponse{}, err
+	}
+
+	return dto.GetPublisherPageResponse{Page: book.PublisherPage{
+		Publisher: publisher,
+		Books:     books,
+		BookCount: uint(len(books)),
+	}}, nil
+}
+
 func (u UseCaseRepo) DeletePublisher(ctx context.Context, req dto.DeletePublisherRequest) (dto.DeletePublisherResponse, error) {
 
 	err := u.repo.DeletePublisher(ctx, req.PublisherID)
@@ -132,7 +262,7 @@ func (u UseCaseRepo) DeletePublisher(ctx context.Context, req dto.DeletePublishe
 
 func (u UseCaseRepo) AddTopic(ctx context.Context, req dto.AddTopicRequest) (dto.AddTopicResponse, error) {
 
-	topic, err := u.repo.AddTopic(ctx, req.Name)
+	topic, err := u.repo.AddTopic(ctx, req.Name, req.ParentID)
 	if err != nil {
 		return dto.AddTopicResponse{}, err
 	}
@@ -147,6 +277,13 @@ func (u UseCaseRepo) GetTopic(ctx context.Context, req dto.GetTopicRequest) (dto
 		return dto.GetTopicResponse{}, err
 	}
 
+	topics, err := u.repo.GetTopics(ctx)
+	if err != nil {
+		return dto.GetTopicResponse{}, err
+	}
+	topic.Path = book.TopicPath(topics, topic.ID)
+	topic.Children = book.TopicTree(topics, topic.ID)
+
 	return dto.GetTopicResponse{Topic: topic}, nil
 }
 
@@ -160,6 +297,42 @@ func (u UseCaseRepo) GetTopics(ctx context.Context, req dto.GetTopicsRequest) (d
 	return dto.GetTopicsResponse{Topics: topics}, nil
 }
 
+func (u UseCaseRepo) GetTopicTree(ctx context.Context, req dto.GetTopicTreeRequest) (dto.GetTopicTreeResponse, error) {
+
+	topics, err := u.repo.GetTopics(ctx)
+	if err != nil {
+		return dto.GetTopicTreeResponse{}, err
+	}
+
+	return dto.GetTopicTreeResponse{Topics: book.TopicTree(topics, 0)}, nil
+}
+
+func (u UseCaseRepo) MoveTopic(ctx context.Context, req dto.MoveTopicRequest) (dto.MoveTopicResponse, error) {
+
+	topic, err := u.repo.MoveTopic(ctx, req.TopicID, req.ParentID, req.Position)
+	if err != nil {
+		return dto.MoveTopicResponse{}, err
+	}
+
+	return dto.MoveTopicResponse{Topic: topic}, nil
+}
+
+// ReorderTopic moves the topic among its current siblings
+func (u UseCaseRepo) ReorderTopic(ctx context.Context, req dto.ReorderTopicRequest) (dto.ReorderTopicResponse, error) {
+
+	topic, err := u.repo.GetTopic(ctx, req.TopicID)
+	if err != nil {
+		return dto.ReorderTopicResponse{}, err
+	}
+
+	topic, err = u.repo.MoveTopic(ctx, req.TopicID, topic.ParentID, req.Position)
+	if err != nil {
+		return dto.ReorderTopicResponse{}, err
+	}
+
+	return dto.ReorderTopicResponse{Topic: topic}, nil
+}
+
 func (u UseCaseRepo) DeleteTopic(ctx context.Context, req dto.DeleteTopicRequest) (dto.DeleteTopicResponse, error) {
 
 	err := u.repo.DeleteTopic(ctx, req.TopicID)
@@ -212,12 +385,298 @@ func (u UseCaseRepo) DeleteLanguage(ctx context.Context, req dto.DeleteLanguageR
 
 func (u UseCaseRepo) AddBook(ctx context.Context, req dto.AddBookRequest) (dto.AddBookResponse, error) {
 
-	book, err := u.repo.AddBook(ctx, req.Book)
+	req.Book.Override = book.OverrideNone
+
+	b, err := u.repo.AddBook(ctx, req.Book)
 	if err != nil {
 		return dto.AddBookResponse{}, err
 	}
+	b.Availability = b.ComputeAvailability()
 
-	return dto.AddBookResponse{Book: book}, nil
+	return dto.AddBookResponse{Book: b}, nil
+}
+
+// ExtractBookMetadata turns the extracted metadata into an AddBook proposal, resolving known
+// authors, publisher and language to their IDs
+func (u UseCaseRepo) ExtractBookMetadata(ctx context.Context, req dto.ExtractBookMetadataRequest) (dto.ExtractBookMetadataResponse, error) {
+
+	m := req.Metadata
+	b := book.Book{
+		Title:       m.Title,
+		ISBN:        m.ISBN,
+		Pages:       m.Pages,
+		Description: m.Description,
+		Year:        m.Year,
+		CoverFront:  m.Cover,
+		Authors:     []book.Author{},
+		Topics:      []book.Topic{},
+		Digital:     book.Digital{PDF: req.PDF, EPUB: req.EPUB},
+	}
+
+	for _, name := range m.Authors {
+		author, err := u.repo.GetAuthorByName(ctx, name)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return dto.ExtractBookMetadataResponse{}, err
+		}
+		author.Name = name
+		b.Authors = append(b.Authors, author)
+	}
+
+	if m.Publisher != "" {
+		publisher, err := u.repo.GetPublisherByName(ctx, m.Publisher)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return dto.ExtractBookMetadataResponse{}, err
+		}
+		publisher.Name = m.Publisher
+		b.Publisher = publisher
+	}
+
+	if m.Language != "" {
+		lang, err := u.repo.GetLanguageByCode(ctx, m.Language)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return dto.ExtractBookMetadataResponse{}, err
+		}
+		lang.Code = m.Language
+		b.Language = lang
+	}
+
+	return dto.ExtractBookMetadataResponse{
+		Proposal: dto.AddBookRequest{Book: b},
+		Metadata: m,
+	}, nil
+}
+
+// ConfirmBookMetadata adds the book of the proposal along with its authors, publisher
+// and language that don't exist yet, all or nothing
+func (u UseCaseRepo) ConfirmBookMetadata(ctx context.Context, req dto.ConfirmBookMetadataRequest) (dto.ConfirmBookMetadataResponse, error) {
+
+	// a proposal is added like any new book
+	req.Proposal.Book.Override = book.OverrideNone
+
+	added, err := u.repo.AddBookWithNames(ctx, req.Proposal.Book)
+	if err != nil {
+		return dto.ConfirmBookMetadataResponse{}, err
+	}
+	added.Availability = added.ComputeAvailability()
+
+	return dto.ConfirmBookMetadataResponse{Book: added}, nil
+}
+
+// resolveBook looks up the authors, topics, publisher and language given by name only.
+// Missing ones are created when create is set, otherwise left with a zero ID.
+func (u UseCaseRepo) resolveBook(ctx context.Context, b book.Book, create bool) (book.Book, error) {
+
+	authors := make([]book.Author, len(b.Authors))
+	for i, author := range b.Authors {
+		authors[i] = author
+		if author.ID != 0 {
+			continue
+		}
+
+		a, err := u.repo.GetAuthorByName(ctx, author.Name)
+		if errors.Is(err, sql.ErrNoRows) {
+			if !create {
+				continue
+			}
+			a, err = u.repo.AddAuthor(ctx, author.Name)
+		}
+		if err != nil {
+			return book.Book{}, err
+		}
+		authors[i] = a
+	}
+	b.Authors = authors
+
+	topics := make([]book.Topic, len(b.Topics))
+	for i, topic := range b.Topics {
+		topics[i] = topic
+		if topic.ID != 0 {
+			continue
+		}
+
+		t, err := u.repo.GetTopicByName(ctx, topic.Name)
+		if errors.Is(err, sql.ErrNoRows) {
+			if !create {
+				continue
+			}
+			t, err = u.repo.AddTopic(ctx, topic.Name, 0)
+		}
+		if err != nil {
+			return book.Book{}, err
+		}
+		topics[i] = t
+	}
+	b.Topics = topics
+
+	if b.Publisher.ID == 0 {
+		p, err := u.repo.GetPublisherByName(ctx, b.Publisher.Name)
+		if errors.Is(err, sql.ErrNoRows) && create {
+			p, err = u.repo.AddPublisher(ctx, b.Publisher.Name)
+		}
+		if err == nil {
+			b.Publisher = p
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return book.Book{}, err
+		}
+	}
+
+	if b.Language.ID == 0 {
+		l, err := u.repo.GetLanguageByCode(ctx, b.Language.Code)
+		if errors.Is(err, sql.ErrNoRows) && create {
+			l, err = u.repo.AddLanguage(ctx, b.Language.Code)
+		}
+		if err == nil {
+			b.Language = l
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return book.Book{}, err
+		}
+	}
+
+	return b, nil
+}
+
+// ImportCatalog records an import job and upserts its rows by ISBN in the background,
+// the job is polled with GetImportJob. A dry run only reports what would change.
+func (u UseCaseRepo) ImportCatalog(ctx context.Context, req dto.ImportCatalogRequest) (dto.ImportCatalogResponse, error) {
+
+	job, err := u.repo.CreateImportJob(ctx, book.ImportJob{
+		Format: req.Format,
+		DryRun: req.DryRun,
+		Total:  uint(len(req.Rows) + len(req.Errors)),
+	})
+	if err != nil {
+		return dto.ImportCatalogResponse{}, err
+	}
+
+	for _, e := range req.Errors {
+		if err = u.repo.AddImportError(ctx, job.ID, e); err != nil {
+			return dto.ImportCatalogResponse{}, err
+		}
+	}
+	job.Failed = uint(len(req.Errors))
+	job.Errors = req.Errors
+
+	go u.runImport(job, req.Rows)
+
+	return dto.ImportCatalogResponse{Job: job}, nil
+}
+
+func (u UseCaseRepo) runImport(job book.ImportJob, rows []book.ImportRow) {
+
+	ctx := context.Background()
+
+	// a panic fails the job instead of taking the server down with it
+	defer func() {
+		if r := recover(); r != nil {
+			log.E.WithField("importJob", job.ID).Errorln(r)
+
+			job.Status = book.ImportFailed
+			if err := u.repo.FinishImportJob(ctx, job); err != nil {
+				log.E.WithField("importJob", job.ID).Errorln(err)
+			}
+		}
+	}()
+
+	for _, row := range rows {
+		created, err := u.importRow(ctx, row, job.DryRun)
+		if err != nil {
+			job.Failed++
+			if err = u.repo.AddImportError(ctx, job.ID, book.ImportError{
+				Row:     row.Row,
+				ISBN:    row.Book.ISBN,
+				Message: err.Error(),
+			}); err != nil {
+				job.Status = book.ImportFailed
+				break
+			}
+			continue
+		}
+
+		if created {
+			job.Created++
+		} else {
+			job.Updated++
+		}
+	}
+
+	if job.Status != book.ImportFailed {
+		job.Status = book.ImportDone
+	}
+
+	if err := u.repo.FinishImportJob(ctx, job); err != nil {
+		log.E.WithField("importJob", job.ID).Errorln(err)
+	}
+}
+
+func (u UseCaseRepo) importRow(ctx context.Context, row book.ImportRow, dryRun bool) (bool, error) {
+
+	b := row.Book
+
+	if dryRun {
+		if _, err := u.resolveBook(ctx, b, false); err != nil {
+			return false, err
+		}
+
+		_, err := u.repo.GetBookIDByISBN(ctx, b.ISBN)
+		if errors.Is(err, sql.ErrNoRows) {
+			return true, nil
+		}
+		return false, err
+	}
+
+	b, err := u.resolveBook(ctx, b, true)
+	if err != nil {
+		return false, err
+	}
+
+	// files aren't part of the catalog formats, so an update keeps the uploaded ones
+	bookID, err := u.repo.GetBookIDByISBN(ctx, b.ISBN)
+	if err == nil {
+		files, err := u.repo.GetBookFiles(ctx, bookID)
+		if err != nil {
+			return false, err
+		}
+		for _, format := range book.Formats {
+			if b.Digital.Path(format) == "" {
+				b.Digital.SetPath(format, files.Path(format))
+			}
+		}
+		b.Availability = b.ComputeAvailability()
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return false, err
+	}
+
+	return u.repo.ImportBook(ctx, b, row.Fields)
+}
+
+func (u UseCaseRepo) GetImportJob(ctx context.Context, req dto.GetImportJobRequest) (dto.GetImportJobResponse, error) {
+
+	job, err := u.repo.GetImportJob(ctx, req.JobID)
+	if err != nil {
+		return dto.GetImportJobResponse{}, err
+	}
+
+	return dto.GetImportJobResponse{Job: job}, nil
+}
+
+func (u UseCaseRepo) GetImportJobs(ctx context.Context, req dto.GetImportJobsRequest) (dto.GetImportJobsResponse, error) {
+
+	jobs, err := u.repo.GetImportJobs(ctx)
+	if err != nil {
+		return dto.GetImportJobsResponse{}, err
+	}
+
+	return dto.GetImportJobsResponse{Jobs: jobs}, nil
+}
+
+func (u UseCaseRepo) ExportCatalog(ctx context.Context, req dto.ExportCatalogRequest) (dto.ExportCatalogResponse, error) {
+
+	books, err := u.repo.GetCatalog(ctx)
+	if err != nil {
+		return dto.ExportCatalogResponse{}, err
+	}
+
+	return dto.ExportCatalogResponse{Books: books}, nil
 }
 
 func (u UseCaseRepo) SetBookDiscount(ctx context.Context, req dto.SetBookDiscountRequest) (dto.SetBookDiscountResponse, error) {
@@ -232,22 +691,68 @@ func (u UseCaseRepo) SetBookDiscount(ctx context.Context, req dto.SetBookDiscoun
 
 func (u UseCaseRepo) GetBook(ctx context.Context, req dto.GetBookRequest) (dto.GetBookResponse, error) {
 
-	book, err := u.repo.GetBook(ctx, req.BookID)
+	b, err := u.repo.GetBook(ctx, req.BookID)
 	if err != nil {
 		return dto.GetBookResponse{}, err
 	}
 
-	return dto.GetBookResponse{Book: book}, nil
+	files, err := u.repo.GetBookFiles(ctx, req.BookID)
+	if err != nil {
+		return dto.GetBookResponse{}, err
+	}
+	withFiles := b
+	for _, format := range book.Formats {
+		withFiles.Digital.SetPath(format, files.Path(format))
+	}
+	status := withFiles.ComputeStatus(time.Now().Format("2006-01-02"))
+	b.Status = &status
+
+	quotes, err := u.repo.GetPriceQuotes(ctx, []uint{b.ID}, "")
+	if err != nil {
+		return dto.GetBookResponse{}, err
+	}
+	b.Quote = &quotes[0]
+
+	if len(b.Topics) > 0 {
+		topics, err := u.repo.GetTopics(ctx)
+		if err != nil {
+			return dto.GetBookResponse{}, err
+		}
+		for i := range b.Topics {
+			b.Topics[i].Path = book.TopicPath(topics, b.Topics[i].ID)
+		}
+	}
+
+	return dto.GetBookResponse{Book: b}, nil
 }
 
 func (u UseCaseRepo) EditBook(ctx context.Context, req dto.EditBookRequest) (dto.EditBookResponse, error) {
 
-	book, err := u.repo.EditBook(ctx, req.Book)
+	if _, err := u.repo.EditBook(ctx, req.Book); err != nil {
+		return dto.EditBookResponse{}, err
+	}
+
+	// read it back for the availability the database derived from the new stock and files
+	b, err := u.repo.GetBook(ctx, req.Book.ID)
+	if err != nil {
+		return dto.EditBookResponse{}, err
+	}
+
+	return dto.EditBookResponse{Book: b}, nil
+}
+
+func (u UseCaseRepo) SetAvailabilityOverride(ctx context.Context, req dto.SetAvailabilityOverrideRequest) (dto.SetAvailabilityOverrideResponse, error) {
+
+	if err := u.repo.SetAvailabilityOverride(ctx, req.BookID, req.Override); err != nil {
+		return dto.SetAvailabilityOverrideResponse{}, err
+	}
+
+	resp, err := u.GetBook(ctx, dto.GetBookRequest{BookID: req.BookID})
 	if err != nil {
-		return dto.EditBookResponse{}, nil
+		return dto.SetAvailabilityOverrideResponse{}, err
 	}
 
-	return dto.EditBookResponse{Book: book}, nil
+	return dto.SetAvailabilityOverrideResponse{Book: resp.Book}, nil
 }
 
 func (u UseCaseRepo) GetAllBooksFull(ctx context.Context, req dto.GetAllBooksRequest) (dto.GetAllBooksResponse, error) {
@@ -267,6 +772,10 @@ func (u UseCaseRepo) GetAllBooks(ctx context.Context, req dto.GetAllBooksRequest
 		return dto.GetAllBooksResponse{}, err
 	}
 
+	if err = u.setQuotes(ctx, books); err != nil {
+		return dto.GetAllBooksResponse{}, err
+	}
+
 	return dto.GetAllBooksResponse{Books: books}, nil
 }
 
@@ -277,16 +786,24 @@ func (u UseCaseRepo) GetAuthorBooks(ctx context.Context, req dto.GetAuthorBooksR
 		return dto.GetAuthorBooksResponse{}, err
 	}
 
+	if err = u.setQuotes(ctx, books); err != nil {
+		return dto.GetAuthorBooksResponse{}, err
+	}
+
 	return dto.GetAuthorBooksResponse{Books: books}, nil
 }
 
 func (u UseCaseRepo) GetTopicBooks(ctx context.Context, req dto.GetTopicBooksRequest) (dto.GetTopicBooksResponse, error) {
 
-	books, err := u.repo.GetTopicBooks(ctx, req.TopicID)
+	books, err := u.repo.GetTopicBooks(ctx, req.TopicID, req.Descendants)
 	if err != nil {
 		return dto.GetTopicBooksResponse{}, err
 	}
 
+	if err = u.setQuotes(ctx, books); err != nil {
+		return dto.GetTopicBooksResponse{}, err
+	}
+
 	return dto.GetTopicBooksResponse{Books: books}, nil
 }
 
@@ -297,6 +814,10 @@ func (u UseCaseRepo) GetPublisherBooks(ctx context.Context, req dto.GetPublisher
 		return dto.GetPublisherBooksResponse{}, err
 	}
 
+	if err = u.setQuotes(ctx, books); err != nil {
+		return dto.GetPublisherBooksResponse{}, err
+	}
+
 	return dto.GetPublisherBooksResponse{Books: books}, nil
 }
 
@@ -306,9 +827,48 @@ func (u UseCaseRepo) GetLangBooks(ctx context.Context, req dto.GetLangBooksReque
 	if err != nil {
 		return dto.GetLangBooksResponse{}, err
 	}
+
+	if err = u.setQuotes(ctx, books); err != nil {
+		return dto.GetLangBooksResponse{}, err
+	}
 	return dto.GetLangBooksResponse{Books: books}, nil
 }
 
+func (u UseCaseRepo) SearchBooks(ctx context.Context, req dto.SearchBooksRequest) (dto.SearchBooksResponse, error) {
+
+	books, err := u.repo.SearchBooks(ctx, req.Query)
+	if err != nil {
+		return dto.SearchBooksResponse{}, err
+	}
+
+	if err = u.setQuotes(ctx, books); err != nil {
+		return dto.SearchBooksResponse{}, err
+	}
+
+	return dto.SearchBooksResponse{Books: books}, nil
+}
+
+// setQuotes prices the books of a catalog listing with the campaigns running now,
+// their tax shown as configured
+func (u UseCaseRepo) setQuotes(ctx context.Context, books []book.Book) error {
+
+	ids := make([]uint, len(books))
+	for i, b := range books {
+		ids[i] = b.ID
+	}
+
+	quotes, err := u.repo.GetPriceQuotes(ctx, ids, "")
+	if err != nil {
+		return err
+	}
+
+	for i := range books {
+		books[i].Quote = &quotes[i]
+	}
+
+	return nil
+}
+
 func (u UseCaseRepo) DeleteBook(ctx context.Context, req dto.DeleteBookRequest) (dto.DeleteBookResponse, error) {
 
 	err := u.repo.DeleteBook(ctx, req.BookID)
@@ -328,3 +888,538 @@ func (u UseCaseRepo) GetUserDigitalBooks(ctx context.Context, req dto.GetUserDig
 
 	return dto.GetUserDigitalBooksResponse{Books: books}, nil
 }
+
+func (u UseCaseRepo) CreateDownloadLinks(ctx context.Context, req dto.CreateDownloadLinksRequest) (dto.CreateDownloadLinksResponse, error) {
+
+	files, err := u.repo.GetBookFiles(ctx, req.BookID)
+	if err != nil {
+		return dto.CreateDownloadLinksResponse{}, err
+	}
+
+	links := []book.DownloadLink{}
+	for _, format := range book.Formats {
+		if files.Path(format) != "" {
+			links = append(links, book.DownloadLink{Format: format})
+		}
+	}
+	if len(links) == 0 {
+		return dto.CreateDownloadLinksResponse{}, errors.New("no downloadable format")
+	}
+
+	remaining, err := u.remainingDownloads(ctx, req.UserID, req.BookID)
+	if err != nil {
+		return dto.CreateDownloadLinksResponse{}, err
+	}
+
+	return dto.CreateDownloadLinksResponse{Links: links, Remaining: remaining}, nil
+}
+
+func (u UseCaseRepo) DownloadBook(ctx context.Context, req dto.DownloadBookRequest) (dto.DownloadBookResponse, error) {
+
+	files, err := u.repo.GetBookFiles(ctx, req.BookID)
+	if err != nil {
+		return dto.DownloadBookResponse{}, err
+	}
+
+	path := files.Path(req.Format)
+	if path == "" {
+		return dto.DownloadBookResponse{}, errors.New("format not available")
+	}
+
+	watermark, err := u.repo.GetWatermark(ctx, req.UserID, req.BookID)
+	if err != nil {
+		return dto.DownloadBookResponse{}, err
+	}
+
+	limit, err := u.repo.GetDownloadLimit(ctx, req.UserID, req.BookID)
+	if err != nil {
+		return dto.DownloadBookResponse{}, err
+	}
+
+	if err = u.repo.AddDownload(ctx, book.Download{
+		UserID:    req.UserID,
+		BookID:    req.BookID,
+		Format:    req.Format,
+		IP:        req.IP,
+		UserAgent: req.UserAgent,
+		Token:     req.Token,
+	}, limit); err != nil {
+		return dto.DownloadBookResponse{}, err
+	}
+
+	return dto.DownloadBookResponse{Path: path, Watermark: watermark}, nil
+}
+
+// remainingDownloads returns 0 for unlimited downloads and an error when the limit is reached
+func (u UseCaseRepo) remainingDownloads(ctx context.Context, userID string, bookID uint) (uint, error) {
+
+	limit, err := u.repo.GetDownloadLimit(ctx, userID, bookID)
+	if err != nil {
+		return 0, err
+	}
+	if limit == 0 {
+		return 0, nil
+	}
+
+	count, err := u.repo.CountUserDownloads(ctx, userID, bookID)
+	if err != nil {
+		return 0, err
+	}
+	if count >= limit {
+		return 0, errors.New("download limit reached")
+	}
+
+	return limit - count, nil
+}
+
+func (u UseCaseRepo) SetDownloadLimit(ctx context.Context, req dto.SetDownloadLimitRequest) (dto.SetDownloadLimitResponse, error) {
+
+	err := u.repo.SetDownloadLimit(ctx, req.Limit)
+	if err != nil {
+		return dto.SetDownloadLimitResponse{}, err
+	}
+
+	return dto.SetDownloadLimitResponse{}, nil
+}
+
+func (u UseCaseRepo) GetDownloads(ctx context.Context, req dto.GetDownloadsRequest) (dto.GetDownloadsResponse, error) {
+
+	downloads, err := u.repo.GetDownloads(ctx)
+	if err != nil {
+		return dto.GetDownloadsResponse{}, err
+	}
+
+	return dto.GetDownloadsResponse{Downloads: downloads}, nil
+}
+
+func (u UseCaseRepo) GetUserDownloads(ctx context.Context, req dto.GetUserDownloadsRequest) (dto.GetUserDownloadsResponse, error) {
+
+	downloads, err := u.repo.GetUserDownloads(ctx, req.UserID)
+	if err != nil {
+		return dto.GetUserDownloadsResponse{}, err
+	}
+
+	return dto.GetUserDownloadsResponse{Downloads: downloads}, nil
+}
+
+func (u UseCaseRepo) GetDownloadActivity(ctx context.Context, req dto.GetDownloadActivityRequest) (dto.GetDownloadActivityResponse, error) {
+
+	activities, err := u.repo.GetDownloadActivity(ctx, req.MinIPs)
+	if err != nil {
+		return dto.GetDownloadActivityResponse{}, err
+	}
+
+	return dto.GetDownloadActivityResponse{Activities: activities}, nil
+}
+
+func (u UseCaseRepo) AddSeries(ctx context.Context, req dto.AddSeriesRequest) (dto.AddSeriesResponse, error) {
+
+	series, err := u.repo.AddSeries(ctx, req.Name)
+	if err != nil {
+		return dto.AddSeriesResponse{}, err
+	}
+
+	return dto.AddSeriesResponse{Series: series}, nil
+}
+
+func (u UseCaseRepo) GetSeries(ctx context.Context, req dto.GetSeriesRequest) (dto.GetSeriesResponse, error) {
+
+	series, err := u.repo.GetSeries(ctx, req.SeriesID)
+	if err != nil {
+		return dto.GetSeriesResponse{}, err
+	}
+
+	return dto.GetSeriesResponse{Series: series}, nil
+}
+
+func (u UseCaseRepo) GetAllSeries(ctx context.Context, req dto.GetAllSeriesRequest) (dto.GetAllSeriesResponse, error) {
+
+	series, err := u.repo.GetAllSeries(ctx)
+	if err != nil {
+		return dto.GetAllSeriesResponse{}, err
+	}
+
+	return dto.GetAllSeriesResponse{Series: series}, nil
+}
+
+func (u UseCaseRepo) DeleteSeries(ctx context.Context, req dto.DeleteSeriesRequest) (dto.DeleteSeriesResponse, error) {
+
+	if err := u.repo.DeleteSeries(ctx, req.SeriesID); err != nil {
+		return dto.DeleteSeriesResponse{}, err
+	}
+
+	return dto.DeleteSeriesResponse{}, nil
+}
+
+func (u UseCaseRepo) AddWork(ctx context.Context, req dto.AddWorkRequest) (dto.AddWorkResponse, error) {
+
+	work, err := u.repo.AddWork(ctx, req.Work)
+	if err != nil {
+		return dto.AddWorkResponse{}, err
+	}
+
+	return dto.AddWorkResponse{Work: work}, nil
+}
+
+func (u UseCaseRepo) EditWork(ctx context.Context, req dto.EditWorkRequest) (dto.EditWorkResponse, error) {
+
+	work, err := u.repo.EditWork(ctx, req.Work)
+	if err != nil {
+		return dto.EditWorkResponse{}, err
+	}
+
+	return dto.EditWorkResponse{Work: work}, nil
+}
+
+func (u UseCaseRepo) GetWork(ctx context.Context, req dto.GetWorkRequest) (dto.GetWorkResponse, error) {
+
+	work, err := u.repo.GetWork(ctx, req.WorkID)
+	if err != nil {
+		return dto.GetWorkResponse{}, err
+	}
+
+	return dto.GetWorkResponse{Work: work}, nil
+}
+
+func (u UseCaseRepo) GetWorks(ctx context.Context, req dto.GetWorksRequest) (dto.GetWorksResponse, error) {
+
+	works, err := u.repo.GetWorks(ctx)
+	if err != nil {
+		return dto.GetWorksResponse{}, err
+	}
+
+	return dto.GetWorksResponse{Works: works}, nil
+}
+
+func (u UseCaseRepo) DeleteWork(ctx context.Context, req dto.DeleteWorkRequest) (dto.DeleteWorkResponse, error) {
+
+	if err := u.repo.DeleteWork(ctx, req.WorkID); err != nil {
+		return dto.DeleteWorkResponse{}, err
+	}
+
+	return dto.DeleteWorkResponse{}, nil
+}
+
+func (u UseCaseRepo) SetBookWork(ctx context.Context, req dto.SetBookWorkRequest) (dto.SetBookWorkResponse, error) {
+
+	if err := u.repo.SetBookWork(ctx, req.BookID, req.WorkID, req.Edition); err != nil {
+		return dto.SetBookWorkResponse{}, err
+	}
+
+	return dto.SetBookWorkResponse{}, nil
+}
+
+func (u UseCaseRepo) AddContributor(ctx context.Context, req dto.AddContributorRequest) (dto.AddContributorResponse, error) {
+
+	contributor := book.Contributor{Author: book.Author{ID: req.AuthorID}, Role: req.Role}
+	if err := u.repo.AddContributor(ctx, req.BookID, contributor); err != nil {
+		return dto.AddContributorResponse{}, err
+	}
+
+	return dto.AddContributorResponse{}, nil
+}
+
+func (u UseCaseRepo) RemoveContributor(ctx context.Context, req dto.RemoveContributorRequest) (dto.RemoveContributorResponse, error) {
+
+	contributor := book.Contributor{Author: book.Author{ID: req.AuthorID}, Role: req.Role}
+	if err := u.repo.RemoveContributor(ctx, req.BookID, contributor); err != nil {
+		return dto.RemoveContributorResponse{}, err
+	}
+
+	return dto.RemoveContributorResponse{}, nil
+}
+
+func (u UseCaseRepo) GetDuplicates(ctx context.Context, req dto.GetDuplicatesRequest) (dto.GetDuplicatesResponse, error) {
+
+	members := []book.DuplicateMember{}
+
+	switch req.Kind {
+	case book.KindAuthor:
+		authors, err := u.repo.GetAuthors(ctx)
+		if err != nil {
+			return dto.GetDuplicatesResponse{}, err
+		}
+		for _, a := range authors {
+			members = append(members, book.DuplicateMember{ID: a.ID, Name: a.Name})
+		}
+
+	case book.KindPublisher:
+		publishers, err := u.repo.GetPublishers(ctx)
+		if err != nil {
+			return dto.GetDuplicatesResponse{}, err
+		}
+		for _, p := range publishers {
+			members = append(members, book.DuplicateMember{ID: p.ID, Name: p.Name})
+		}
+
+	case book.KindTopic:
+		topics, err := u.repo.GetTopics(ctx)
+		if err != nil {
+			return dto.GetDuplicatesResponse{}, err
+		}
+		for _, t := range topics {
+			members = append(members, book.DuplicateMember{ID: t.ID, Name: t.Name})
+		}
+	}
+
+	return dto.GetDuplicatesResponse{Groups: findDuplicates(req.Kind, members)}, nil
+}
+
+func (u UseCaseRepo) Merge(ctx context.Context, req dto.MergeRequest) (dto.MergeResponse, error) {
+
+	if err := u.repo.Merge(ctx, req.Kind, req.FromID, req.IntoID); err != nil {
+		return dto.MergeResponse{}, err
+	}
+
+	redirect, err := u.repo.GetRedirect(ctx, req.Kind, req.FromID)
+	if err != nil {
+		return dto.MergeResponse{}, err
+	}
+
+	return dto.MergeResponse{Redirect: redirect}, nil
+}
+
+func (u UseCaseRepo) GetRedirect(ctx context.Context, req dto.GetRedirectRequest) (dto.GetRedirectResponse, error) {
+
+	redirect, err := u.repo.GetRedirect(ctx, req.Kind, req.OldID)
+	if err != nil {
+		return dto.GetRedirectResponse{}, err
+	}
+
+	return dto.GetRedirectResponse{Redirect: redirect}, nil
+}
+
+func (u UseCaseRepo) GetRedirects(ctx context.Context, req dto.GetRedirectsRequest) (dto.GetRedirectsResponse, error) {
+
+	redirects, err := u.repo.GetRedirects(ctx)
+	if err != nil {
+		return dto.GetRedirectsResponse{}, err
+	}
+
+	return dto.GetRedirectsResponse{Redirects: redirects}, nil
+}
+
+func (u UseCaseRepo) GetTrash(ctx context.Context, req dto.GetTrashRequest) (dto.GetTrashResponse, error) {
+
+	records, err := u.repo.GetTrash(ctx, req.Kind)
+	if err != nil {
+		return dto.GetTrashResponse{}, err
+	}
+
+	return dto.GetTrashResponse{Records: records}, nil
+}
+
+func (u UseCaseRepo) Restore(ctx context.Context, req dto.RestoreRequest) (dto.RestoreResponse, error) {
+
+	if err := u.repo.Restore(ctx, req.Kind, req.RecordID); err != nil {
+		return dto.RestoreResponse{}, err
+	}
+
+	return dto.RestoreResponse{}, nil
+}
+
+func (u UseCaseRepo) Purge(ctx context.Context, req dto.PurgeRequest) (dto.PurgeResponse, error) {
+
+	if err := u.repo.Purge(ctx, req.Kind, req.RecordID); err != nil {
+		return dto.PurgeResponse{}, err
+	}
+
+	return dto.PurgeResponse{}, nil
+}
+
+func (u UseCaseRepo) ReceiveStock(ctx context.Context, req dto.ReceiveStockRequest) (dto.ReceiveStockResponse, error) {
+
+	movement, err := u.repo.MoveStock(ctx, book.Movement{
+		BookID:   req.BookID,
+		Type:     book.MovementReceipt,
+		Quantity: int(req.Quantity),
+		Reason:   req.Reason,
+		AdminID:  req.AdminID,
+	})
+	if err != nil {
+		return dto.ReceiveStockResponse{}, err
+	}
+
+	return dto.ReceiveStockResponse{Movement: movement}, nil
+}
+
+func (u UseCaseRepo) AdjustStock(ctx context.Context, req dto.AdjustStockRequest) (dto.AdjustStockResponse, error) {
+
+	movement, err := u.repo.MoveStock(ctx, book.Movement{
+		BookID:   req.BookID,
+		Type:     req.Type,
+		Quantity: req.Quantity,
+		Reason:   req.Reason,
+		AdminID:  req.AdminID,
+	})
+	if err != nil {
+		return dto.AdjustStockResponse{}, err
+	}
+
+	return dto.AdjustStockResponse{Movement: movement}, nil
+}
+
+func (u UseCaseRepo) Stocktake(ctx context.Context, req dto.StocktakeRequest) (dto.StocktakeResponse, error) {
+
+	movements, err := u.repo.Stocktake(ctx, req.Counts, req.Reason, req.AdminID)
+	if err != nil {
+		return dto.StocktakeResponse{}, err
+	}
+
+	return dto.StocktakeResponse{Movements: movements}, nil
+}
+
+func (u UseCaseRepo) GetMovements(ctx context.Context, req dto.GetMovementsRequest) (dto.GetMovementsResponse, error) {
+
+	movements, err := u.repo.GetMovements(ctx, req.BookID)
+	if err != nil {
+		return dto.GetMovementsResponse{}, err
+	}
+
+	return dto.GetMovementsResponse{Movements: movements}, nil
+}
+
+func (u UseCaseRepo) SetReorderThreshold(ctx context.Context, req dto.SetReorderThresholdRequest) (dto.SetReorderThresholdResponse, error) {
+
+	if err := u.repo.SetReorderThreshold(ctx, req.BookID, req.Threshold); err != nil {
+		return dto.SetReorderThresholdResponse{}, err
+	}
+
+	return dto.SetReorderThresholdResponse{}, nil
+}
+
+// CheckLowStock refreshes the sales velocities and returns the books whose stock
+// crossed their threshold since the last check, each book is reported once until it recovers
+func (u UseCaseRepo) CheckLowStock(ctx context.Context, req dto.CheckLowStockRequest) (dto.CheckLowStockResponse, error) {
+
+	if err := u.repo.UpdateSalesVelocity(ctx, req.VelocityDays); err != nil {
+		return dto.CheckLowStockResponse{}, err
+	}
+
+	alerts, err := u.repo.GetStockAlerts(ctx)
+	if err != nil {
+		return dto.CheckLowStockResponse{}, err
+	}
+
+	crossed := []book.StockAlert{}
+	for _, a := range alerts {
+		low := a.Threshold > 0 && a.Stock <= a.Threshold
+
+		if low == a.Alerted {
+			continue
+		}
+
+		if err = u.repo.SetStockAlerted(ctx, a.BookID, low); err != nil {
+			return dto.CheckLowStockResponse{}, err
+		}
+
+		if low {
+			a.Alerted = true
+			crossed = append(crossed, a)
+		}
+	}
+
+	return dto.CheckLowStockResponse{Crossed: crossed}, nil
+}
+
+// GetLowStockReport groups the low books by publisher with the copies to reorder
+func (u UseCaseRepo) GetLowStockReport(ctx context.Context, req dto.GetLowStockReportRequest) (dto.GetLowStockReportResponse, error) {
+
+	alerts, err := u.repo.GetStockAlerts(ctx)
+	if err != nil {
+		return dto.GetLowStockReportResponse{}, err
+	}
+
+	groups := []book.LowStockGroup{}
+	for _, a := range alerts {
+		if !a.Low(req.LeadDays) {
+			continue
+		}
+		a.Suggested = a.SuggestReorder(req.LeadDays, req.CoverDays)
+
+		// alerts come ordered by publisher
+		if len(groups) == 0 || groups[len(groups)-1].Publisher.ID != a.Publisher.ID {
+			groups = append(groups, book.LowStockGroup{Publisher: a.Publisher, Books: []book.StockAlert{}})
+		}
+		g := &groups[len(groups)-1]
+		g.Books = append(g.Books, a)
+		g.Suggested += a.Suggested
+	}
+
+	return dto.GetLowStockReportResponse{Publishers: groups}, nil
+}
+
+func (u UseCaseRepo) ReleasePreorders(ctx context.Context, req dto.ReleasePreordersRequest) (dto.ReleasePreordersResponse, error) {
+
+	release, err := u.repo.ReleasePreorders(ctx, req.Today)
+	if err != nil {
+		return dto.ReleasePreordersResponse{}, err
+	}
+
+	return dto.ReleasePreordersResponse{Release: release}, nil
+}
+
+func (u UseCaseRepo) GetPreorderDemand(ctx context.Context, req dto.GetPreorderDemandRequest) (dto.GetPreorderDemandResponse, error) {
+
+	demand, err := u.repo.GetPreorderDemand(ctx)
+	if err != nil {
+		return dto.GetPreorderDemandResponse{}, err
+	}
+
+	return dto.GetPreorderDemandResponse{Books: demand}, nil
+}
+
+func (u UseCaseRepo) AddBoxSet(ctx context.Context, req dto.AddBoxSetRequest) (dto.AddBoxSetResponse, error) {
+
+	s, err := u.repo.AddBoxSet(ctx, req.BoxSet)
+	if err != nil {
+		return dto.AddBoxSetResponse{}, err
+	}
+
+	return dto.AddBoxSetResponse{BoxSet: s}, nil
+}
+
+// EditBoxSet saves the box set and reprices the open carts holding it,
+// the carts keep the books the set had when it was added
+func (u UseCaseRepo) EditBoxSet(ctx context.Context, req dto.EditBoxSetRequest) (dto.EditBoxSetResponse, error) {
+
+	s, err := u.repo.EditBoxSet(ctx, req.BoxSet)
+	if err != nil {
+		return dto.EditBoxSetResponse{}, err
+	}
+
+	if err = u.repo.RefreshOpenOrders(ctx); err != nil {
+		return dto.EditBoxSetResponse{}, err
+	}
+
+	return dto.EditBoxSetResponse{BoxSet: s}, nil
+}
+
+func (u UseCaseRepo) DeleteBoxSet(ctx context.Context, req dto.DeleteBoxSetRequest) (dto.DeleteBoxSetResponse, error) {
+
+	if err := u.repo.DeleteBoxSet(ctx, req.BoxSetID); err != nil {
+		return dto.DeleteBoxSetResponse{}, err
+	}
+
+	return dto.DeleteBoxSetResponse{}, nil
+}
+
+func (u UseCaseRepo) GetBoxSet(ctx context.Context, req dto.GetBoxSetRequest) (dto.GetBoxSetResponse, error) {
+
+	s, err := u.repo.GetBoxSet(ctx, req.BoxSetID)
+	if err != nil {
+		return dto.GetBoxSetResponse{}, err
+	}
+
+	return dto.GetBoxSetResponse{BoxSet: s}, nil
+}
+
+func (u UseCaseRepo) GetBoxSets(ctx context.Context, req dto.GetBoxSetsRequest) (dto.GetBoxSetsResponse, error) {
+
+	sets, err := u.repo.GetBoxSets(ctx)
+	if err != nil {
+		return dto.GetBoxSetsResponse{}, err
+	}
+
+	return dto.GetBoxSetsResponse{BoxSets: sets}, nil
+}
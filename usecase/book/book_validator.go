@@ -7,36 +7,81 @@ import (
 )
 
 type (
-	ValidateAddAuthor    func(ctx context.Context, req dto.AddAuthorRequest) error
-	ValidateGetAuthor    func(ctx context.Context, req dto.GetAuthorRequest) error
-	ValidateDeleteAuthor func(ctx context.Context, req dto.DeleteAuthorRequest) error
+	ValidateAddAuthor     func(ctx context.Context, req dto.AddAuthorRequest) error
+	ValidateGetAuthor     func(ctx context.Context, req dto.GetAuthorRequest) error
+	ValidateEditAuthor    func(ctx context.Context, req dto.EditAuthorRequest) error
+	ValidateGetAuthorPage func(ctx context.Context, req dto.GetAuthorPageRequest) error
+	ValidateDeleteAuthor  func(ctx context.Context, req dto.DeleteAuthorRequest) error
 
-	ValidateAddPublisher    func(ctx context.Context, req dto.AddPublisherRequest) error
-	ValidateGetPublisher    func(ctx context.Context, req dto.GetPublisherRequest) error
-	ValidateGetPublishers   func(ctx context.Context, req dto.GetPublishersRequest) error
-	ValidateDeletePublisher func(ctx context.Context, req dto.DeletePublisherRequest) error
+	ValidateAddPublisher     func(ctx context.Context, req dto.AddPublisherRequest) error
+	ValidateGetPublisher     func(ctx context.Context, req dto.GetPublisherRequest) error
+	ValidateGetPublishers    func(ctx context.Context, req dto.GetPublishersRequest) error
+	ValidateEditPublisher    func(ctx context.Context, req dto.EditPublisherRequest) error
+	ValidateGetPublisherPage func(ctx context.Context, req dto.GetPublisherPageRequest) error
+	ValidateDeletePublisher  func(ctx context.Context, req dto.DeletePublisherRequest) error
 
-	ValidateAddTopic    func(ctx context.Context, req dto.AddTopicRequest) error
-	ValidateGetTopic    func(ctx context.Context, req dto.GetTopicRequest) error
-	ValidateGetTopics   func(ctx context.Context, req dto.GetTopicsRequest) error
-	ValidateDeleteTopic func(ctx context.Context, req dto.DeleteTopicRequest) error
+	ValidateAddTopic     func(ctx context.Context, req dto.AddTopicRequest) error
+	ValidateGetTopic     func(ctx context.Context, req dto.GetTopicRequest) error
+	ValidateGetTopics    func(ctx context.Context, req dto.GetTopicsRequest) error
+	ValidateMoveTopic    func(ctx context.Context, req dto.MoveTopicRequest) error
+	ValidateReorderTopic func(ctx context.Context, req dto.ReorderTopicRequest) error
+	ValidateDeleteTopic  func(ctx context.Context, req dto.DeleteTopicRequest) error
 
 	ValidateAddLanguage    func(ctx context.Context, req dto.AddLanguageRequest) error
 	ValidateGetLanguage    func(ctx context.Context, req dto.GetLanguageRequest) error
 	ValidateGetLanguages   func(ctx context.Context, req dto.GetLanguagesRequest) error
 	ValidateDeleteLanguage func(ctx context.Context, req dto.DeleteLanguageRequest) error
 
-	ValidateAddBook           func(ctx context.Context, req dto.AddBookRequest) error
-	ValidateGetBook           func(ctx context.Context, req dto.GetBookRequest) error
-	ValidateEditBook          func(ctx context.Context, req dto.EditBookRequest) error
-	ValidateSetBookDiscount   func(ctx context.Context, req dto.SetBookDiscountRequest) error
-	ValidateGetAllBooks       func(ctx context.Context, req dto.GetAllBooksRequest) error
-	ValidateGetAuthorBooks    func(ctx context.Context, req dto.GetAuthorBooksRequest) error
-	ValidateGetPublisherBooks func(ctx context.Context, req dto.GetPublisherBooksRequest) error
-	ValidateGetTopicBooks     func(ctx context.Context, req dto.GetTopicBooksRequest) error
-	ValidateGetLangBooks      func(ctx context.Context, req dto.GetLangBooksRequest) error
-	ValidateDeleteBook        func(ctx context.Context, req dto.DeleteBookRequest) error
+	ValidateAddBook                 func(ctx context.Context, req dto.AddBookRequest) error
+	ValidateGetBook                 func(ctx context.Context, req dto.GetBookRequest) error
+	ValidateEditBook                func(ctx context.Context, req dto.EditBookRequest) error
+	ValidateSetAvailabilityOverride func(ctx context.Context, req dto.SetAvailabilityOverrideRequest) error
+	ValidateSetBookDiscount         func(ctx context.Context, req dto.SetBookDiscountRequest) error
+	ValidateGetAllBooks             func(ctx context.Context, req dto.GetAllBooksRequest) error
+	ValidateGetAuthorBooks          func(ctx context.Context, req dto.GetAuthorBooksRequest) error
+	ValidateGetPublisherBooks       func(ctx context.Context, req dto.GetPublisherBooksRequest) error
+	ValidateGetTopicBooks           func(ctx context.Context, req dto.GetTopicBooksRequest) error
+	ValidateGetLangBooks            func(ctx context.Context, req dto.GetLangBooksRequest) error
+	ValidateSearchBooks             func(ctx context.Context, req dto.SearchBooksRequest) error
+	ValidateDeleteBook              func(ctx context.Context, req dto.DeleteBookRequest) error
 
 	ValidateGetUserDigitalBooks func(ctx context.Context, req dto.GetUserDigitalBooksRequest) error
+	ValidateExtractBookMetadata func(ctx context.Context, req dto.ExtractBookMetadataRequest) error
+	ValidateConfirmBookMetadata func(ctx context.Context, req dto.ConfirmBookMetadataRequest) error
+	ValidateImportCatalog       func(ctx context.Context, req dto.ImportCatalogRequest) error
+	ValidateGetImportJob        func(ctx context.Context, req dto.GetImportJobRequest) error
+	ValidateExportCatalog       func(ctx context.Context, req dto.ExportCatalogRequest) error
+	ValidateCreateDownloadLinks func(ctx context.Context, req dto.CreateDownloadLinksRequest) error
 	ValidateDownloadBook        func(ctx context.Context, req dto.DownloadBookRequest) error
+	ValidateSetDownloadLimit    func(ctx context.Context, req dto.SetDownloadLimitRequest) error
+	ValidateGetUserDownloads    func(ctx context.Context, req dto.GetUserDownloadsRequest) error
+
+	ValidateAddSeries         func(ctx context.Context, req dto.AddSeriesRequest) error
+	ValidateGetSeries         func(ctx context.Context, req dto.GetSeriesRequest) error
+	ValidateDeleteSeries      func(ctx context.Context, req dto.DeleteSeriesRequest) error
+	ValidateAddWork           func(ctx context.Context, req dto.AddWorkRequest) error
+	ValidateEditWork          func(ctx context.Context, req dto.EditWorkRequest) error
+	ValidateGetWork           func(ctx context.Context, req dto.GetWorkRequest) error
+	ValidateDeleteWork        func(ctx context.Context, req dto.DeleteWorkRequest) error
+	ValidateSetBookWork       func(ctx context.Context, req dto.SetBookWorkRequest) error
+	ValidateAddContributor    func(ctx context.Context, req dto.AddContributorRequest) error
+	ValidateRemoveContributor func(ctx context.Context, req dto.RemoveContributorRequest) error
+
+	ValidateGetDuplicates func(ctx context.Context, req dto.GetDuplicatesRequest) error
+	ValidateMerge         func(ctx context.Context, req dto.MergeRequest) error
+
+	ValidateGetTrash func(ctx context.Context, req dto.GetTrashRequest) error
+	ValidateRestore  func(ctx context.Context, req dto.RestoreRequest) error
+	ValidatePurge    func(ctx context.Context, req dto.PurgeRequest) error
+
+	ValidateReceiveStock        func(ctx context.Context, req dto.ReceiveStockRequest) error
+	ValidateAdjustStock         func(ctx context.Context, req dto.AdjustStockRequest) error
+	ValidateStocktake           func(ctx context.Context, req dto.StocktakeRequest) error
+	ValidateGetMovements        func(ctx context.Context, req dto.GetMovementsRequest) error
+	ValidateSetReorderThreshold func(ctx context.Context, req dto.SetReorderThresholdRequest) error
+
+	ValidateAddBoxSet    func(ctx context.Context, req dto.AddBoxSetRequest) error
+	ValidateEditBoxSet   func(ctx context.Context, req dto.EditBoxSetRequest) error
+	ValidateDeleteBoxSet func(ctx context.Context, req dto.DeleteBoxSetRequest) error
+	ValidateGetBoxSet    func(ctx context.Context, req dto.GetBoxSetRequest) error
 )
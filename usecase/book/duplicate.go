@@ -0,0 +1,166 @@
+package book
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/XBozorg/bookstore/entity/book"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Arabic code points that Persian text commonly contains in place of their Persian forms
+var unifier = strings.NewReplacer(
+	"ي", "ی", "ى", "ی", "ئ", "ی",
+	"ك", "ک",
+	"ة", "ه", "ۀ", "ه",
+	"أ", "ا", "إ", "ا", "آ", "ا", "ٱ", "ا",
+	"ؤ", "و",
+	"\u0640", "", // tatweel
+	"\u200c", " ", // zero width non-joiner
+)
+
+// normalizeName folds case, accents, diacritics, punctuation and Arabic/Persian letter
+// variants, and joins runs of initials so "J. R. R. Tolkien" and "J.R.R. Tolkien" compare equal
+func normalizeName(name string) string {
+
+	var b strings.Builder
+	for _, r := range norm.NFD.String(unifier.Replace(name)) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			b.WriteRune(' ')
+		}
+	}
+
+	words := strings.Fields(unifier.Replace(norm.NFC.String(b.String())))
+	joined := []string{}
+	for i, w := range words {
+		if i > 0 && len([]rune(w)) == 1 && len([]rune(words[i-1])) == 1 {
+			joined[len(joined)-1] += w
+			continue
+		}
+		joined = append(joined, w)
+	}
+
+	return strings.Join(joined, " ")
+}
+
+func levenshtein(a, b string) uint {
+
+	s, t := []rune(a), []rune(b)
+	prev := make([]uint, len(t)+1)
+	cur := make([]uint, len(t)+1)
+	for j := range prev {
+		prev[j] = uint(j)
+	}
+
+	for i := 1; i <= len(s); i++ {
+		cur[0] = uint(i)
+		for j := 1; j <= len(t); j++ {
+			cost := uint(1)
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(t)]
+}
+
+func min(values ...uint) uint {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
+
+// maxDistance allows one edit per eight characters of the shorter name, names of up to
+// three characters must match exactly
+func maxDistance(a, b string) uint {
+	n := len([]rune(a))
+	if m := len([]rune(b)); m < n {
+		n = m
+	}
+	if n <= 3 {
+		return 0
+	}
+	return uint(n+7) / 8
+}
+
+// findDuplicates links records whose normalized names are close enough and returns the
+// connected groups with more than one member
+func findDuplicates(kind string, members []book.DuplicateMember) []book.DuplicateGroup {
+
+	normalized := make([]string, len(members))
+	parent := make([]int, len(members))
+	for i, m := range members {
+		normalized[i] = normalizeName(m.Name)
+		parent[i] = i
+	}
+
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	distance := map[int]uint{}
+	for i := range members {
+		for j := i + 1; j < len(members); j++ {
+			d := levenshtein(normalized[i], normalized[j])
+			if d > maxDistance(normalized[i], normalized[j]) {
+				continue
+			}
+
+			ri, rj := find(i), find(j)
+			if d < distance[ri] {
+				d = distance[ri]
+			}
+			if d < distance[rj] {
+				d = distance[rj]
+			}
+			parent[rj] = ri
+			distance[ri] = d
+		}
+	}
+
+	index := map[int]int{}
+	groups := []book.DuplicateGroup{}
+	for i, m := range members {
+		root := find(i)
+		g, ok := index[root]
+		if !ok {
+			g = len(groups)
+			index[root] = g
+			groups = append(groups, book.DuplicateGroup{
+				Kind:       kind,
+				Normalized: normalized[root],
+				Distance:   distance[root],
+				Members:    []book.DuplicateMember{},
+			})
+		}
+		groups[g].Members = append(groups[g].Members, m)
+	}
+
+	duplicates := []book.DuplicateGroup{}
+	for _, g := range groups {
+		if len(g.Members) > 1 {
+			duplicates = append(duplicates, g)
+		}
+	}
+	sort.Slice(duplicates, func(i, j int) bool {
+		return duplicates[i].Distance < duplicates[j].Distance
+	})
+
+	return duplicates
+}
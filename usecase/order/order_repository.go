@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/XBozorg/bookstore/entity/order"
+	"github.com/XBozorg/bookstore/entity/user"
 )
 
 type Repository interface {
@@ -18,13 +19,16 @@ type Repository interface {
 
 	CreatePromoCode(ctx context.Context, promo order.Promo, userID string) error
 	DeletePromoCode(ctx context.Context, promoID uint) error
+	CreatePromoBatch(ctx context.Context, batch order.PromoBatch) (order.PromoBatch, error)
 
 	SetOrderStatus(ctx context.Context, status, orderID uint) error
 	GetOrderStatus(ctx context.Context, orderID uint) (uint, error)
 	SetOrderSTN(ctx context.Context, stn string, orderID uint) error
 	SetOrderPromo(ctx context.Context, orderID uint, promoCode, userID string) error
 	SetOrderReceiptDate(ctx context.Context, orderID uint) error
+	HasQueuedPreorders(ctx context.Context, orderID uint) (bool, error)
 	RemoveOrderPromo(ctx context.Context, orderID uint) error
+	ReleaseAbandonedPromos(ctx context.Context, before string) (uint, error)
 	DeleteOrder(ctx context.Context, orderID uint) error
 
 	GetAllOrders(ctx context.Context) ([]order.Order, error)
@@ -37,10 +41,22 @@ type Repository interface {
 	GetAllPromos(ctx context.Context) ([]order.Promo, error)
 	GetPromoByOrder(ctx context.Context, orderID uint) (order.Promo, error)
 	GetUserPromos(ctx context.Context, userID string) ([]order.Promo, error)
+	GetPromoBatch(ctx context.Context, batchID uint) (order.PromoBatch, error)
+	GetPromoBatchReports(ctx context.Context) ([]order.PromoBatchReport, error)
+	GetPromoRedemptions(ctx context.Context, promoID uint) ([]order.Redemption, error)
 
 	GetOrderPaymentInfo(ctx context.Context, orderID uint) (order.OrderPaymentInfo, error)
 	GetOrderTotal(ctx context.Context, orderID uint) (uint, error)
 
+	SetItemGift(ctx context.Context, gift order.Gift) (order.Gift, error)
+	RemoveItemGift(ctx context.Context, itemID, orderID uint, userID string) error
+	GetUserGifts(ctx context.Context, userID string) ([]order.Gift, []order.Gift, error)
+	GetGift(ctx context.Context, token string) (order.Gift, error)
+	ClaimGift(ctx context.Context, token, userID string) (order.Gift, error)
+	ClaimGiftWithSignup(ctx context.Context, token string, u user.User, referralCode string) (user.User, order.Gift, error)
+	GetDueGifts(ctx context.Context, now string) ([]order.Gift, error)
+	SetGiftSent(ctx context.Context, giftID uint) error
+
 	ZarinpalCreateOpenOrder(ctx context.Context, orderID uint, authority string) error
 	ZarinpalGetOrderByAuthority(ctx context.Context, authority string) (order.ZarinpalOrder, error)
 	ZarinpalSetOrderPayment(ctx context.Context, zarinpalOrderID uint, authority string, refID, code int) error
@@ -50,6 +66,8 @@ type ValidatorRepo interface {
 	DoesItemExist(ctx context.Context, itemID uint) (bool, error)
 	DoesPromoExist(ctx context.Context, promoID uint) (bool, error)
 	DoesPromoCodeExist(ctx context.Context, promoCode, userID string) (bool, error)
+	IsPromoCodeTaken(ctx context.Context, promoCode string, public bool) (bool, error)
+	DoesPromoBatchExist(ctx context.Context, batchID uint) (bool, error)
 	DoesOrderExist(ctx context.Context, orderID uint) (bool, error)
 	DoesOrderOpen(ctx context.Context, orderID uint) (bool, error)
 }
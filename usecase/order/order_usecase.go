@@ -2,8 +2,11 @@ package order
 
 import (
 	"context"
+	"strings"
 
 	"github.com/XBozorg/bookstore/dto"
+	eo "github.com/XBozorg/bookstore/entity/order"
+	eu "github.com/XBozorg/bookstore/entity/user"
 )
 
 type UseCase interface {
@@ -18,6 +21,7 @@ type UseCase interface {
 
 	CreatePromoCode(ctx context.Context, req dto.CreatePromoCodeRequest) (dto.CreatePromoCodeResponse, error)
 	DeletePromoCode(ctx context.Context, req dto.CreatePromoCodeRequest) (dto.CreatePromoCodeResponse, error)
+	CreatePromoBatch(ctx context.Context, req dto.CreatePromoBatchRequest) (dto.CreatePromoBatchResponse, error)
 
 	SetOrderStatus(ctx context.Context, req dto.SetOrderStatusRequest) (dto.SetOrderStatusResponse, error)
 	GetOrderStatus(ctx context.Context, req dto.GetOrderStatusRequest) (dto.GetOrderStatusResponse, error)
@@ -25,6 +29,7 @@ type UseCase interface {
 	SetOrderPromo(ctx context.Context, req dto.SetOrderPromoRequest) (dto.SetOrderPromoResponse, error)
 	SetOrderReceiptDate(ctx context.Context, req dto.SetOrderReceiptDateRequest) (dto.SetOrderReceiptDateResponse, error)
 	RemoveOrderPromo(ctx context.Context, req dto.RemoveOrderPromoRequest) (dto.RemoveOrderPromoResponse, error)
+	ReleaseAbandonedPromos(ctx context.Context, req dto.ReleaseAbandonedPromosRequest) (dto.ReleaseAbandonedPromosResponse, error)
 	DeleteOrder(ctx context.Context, req dto.DeleteOrderRequest) (dto.DeleteOrderResponse, error)
 
 	GetAllOrders(ctx context.Context, req dto.GetAllOrdersRequest) (dto.GetAllOrdersResponse, error)
@@ -37,10 +42,22 @@ type UseCase interface {
 	GetAllPromos(ctx context.Context, req dto.GetAllPromosRequest) (dto.GetAllPromosResponse, error)
 	GetPromoByOrder(ctx context.Context, req dto.GetPromoByOrderRequest) (dto.GetPromoByOrderResponse, error)
 	GetUserPromos(ctx context.Context, req dto.GetUserPromosRequest) (dto.GetUserPromosResponse, error)
+	GetPromoBatch(ctx context.Context, req dto.GetPromoBatchRequest) (dto.GetPromoBatchResponse, error)
+	GetPromoBatchReports(ctx context.Context, req dto.GetPromoBatchReportsRequest) (dto.GetPromoBatchReportsResponse, error)
+	GetPromoRedemptions(ctx context.Context, req dto.GetPromoRedemptionsRequest) (dto.GetPromoRedemptionsResponse, error)
 
 	GetOrderPaymentInfo(ctx context.Context, req dto.GetOrderPaymentInfoRequest) (dto.GetOrderPaymentInfoResponse, error)
 	GetOrderTotal(ctx context.Context, req dto.GetOrderTotalRequest) (dto.GetOrderTotalResponse, error)
 
+	SetItemGift(ctx context.Context, req dto.SetItemGiftRequest) (dto.SetItemGiftResponse, error)
+	RemoveItemGift(ctx context.Context, req dto.RemoveItemGiftRequest) (dto.RemoveItemGiftResponse, error)
+	GetUserGifts(ctx context.Context, req dto.GetUserGiftsRequest) (dto.GetUserGiftsResponse, error)
+	GetGift(ctx context.Context, req dto.GetGiftRequest) (dto.GetGiftResponse, error)
+	ClaimGift(ctx context.Context, req dto.ClaimGiftRequest) (dto.ClaimGiftResponse, error)
+	ClaimGiftWithSignup(ctx context.Context, req dto.ClaimGiftWithSignupRequest) (dto.ClaimGiftWithSignupResponse, error)
+	DeliverGifts(ctx context.Context, req dto.DeliverGiftsRequest) (dto.DeliverGiftsResponse, error)
+	SetGiftSent(ctx context.Context, req dto.SetGiftSentRequest) (dto.SetGiftSentResponse, error)
+
 	ZarinpalCreateOpenOrder(ctx context.Context, req dto.ZarinpalCreateOpenOrderRequest) (dto.ZarinpalCreateOpenOrderResponse, error)
 	ZarinpalGetOrderByAuthority(ctx context.Context, req dto.ZarinpalGetOrderByAuthorityRequest) (dto.ZarinpalGetOrderByAuthorityResponse, error)
 	ZarinpalSetOrderPayment(ctx context.Context, req dto.ZarinpalSetOrderPaymentRequest) (dto.ZarinpalSetOrderPaymentResponse, error)
@@ -106,6 +123,10 @@ func (u UseCaseRepo) RemoveItem(ctx context.Context, req dto.RemoveItemRequest)
 
 func (u UseCaseRepo) CreatePromoCode(ctx context.Context, req dto.CreatePromoCodeRequest) (dto.CreatePromoCodeResponse, error) {
 
+	if req.Promo.Stacking == "" {
+		req.Promo.Stacking = eo.StackCampaigns
+	}
+
 	err := u.repo.CreatePromoCode(ctx, req.Promo, req.UserID)
 	if err != nil {
 		return dto.CreatePromoCodeResponse{}, err
@@ -114,6 +135,21 @@ func (u UseCaseRepo) CreatePromoCode(ctx context.Context, req dto.CreatePromoCod
 	return dto.CreatePromoCodeResponse{}, nil
 }
 
+func (u UseCaseRepo) CreatePromoBatch(ctx context.Context, req dto.CreatePromoBatchRequest) (dto.CreatePromoBatchResponse, error) {
+
+	if req.Batch.Promo.Stacking == "" {
+		req.Batch.Promo.Stacking = eo.StackCampaigns
+	}
+	req.Batch.Prefix = strings.ToUpper(req.Batch.Prefix)
+
+	batch, err := u.repo.CreatePromoBatch(ctx, req.Batch)
+	if err != nil {
+		return dto.CreatePromoBatchResponse{}, err
+	}
+
+	return dto.CreatePromoBatchResponse{Batch: batch}, nil
+}
+
 func (u UseCaseRepo) DeletePromoCode(ctx context.Context, req dto.DeletePromoCodeRequest) (dto.DeletePromoCodeResponse, error) {
 
 	err := u.repo.DeletePromoCode(ctx, req.PromoID)
@@ -184,6 +220,16 @@ func (u UseCaseRepo) RemoveOrderPromo(ctx context.Context, req dto.RemoveOrderPr
 	return dto.RemoveOrderPromoResponse{}, nil
 }
 
+func (u UseCaseRepo) ReleaseAbandonedPromos(ctx context.Context, req dto.ReleaseAbandonedPromosRequest) (dto.ReleaseAbandonedPromosResponse, error) {
+
+	released, err := u.repo.ReleaseAbandonedPromos(ctx, req.Before)
+	if err != nil {
+		return dto.ReleaseAbandonedPromosResponse{}, err
+	}
+
+	return dto.ReleaseAbandonedPromosResponse{Released: released}, nil
+}
+
 func (u UseCaseRepo) DeleteOrder(ctx context.Context, req dto.DeleteOrderRequest) (dto.DeleteOrderResponse, error) {
 
 	err := u.repo.DeleteOrder(ctx, req.OrderID)
@@ -284,6 +330,36 @@ func (u UseCaseRepo) GetPromoByOrder(ctx context.Context, req dto.GetPromoByOrde
 	return dto.GetPromoByOrderResponse{Promo: promo}, nil
 }
 
+func (u UseCaseRepo) GetPromoBatch(ctx context.Context, req dto.GetPromoBatchRequest) (dto.GetPromoBatchResponse, error) {
+
+	batch, err := u.repo.GetPromoBatch(ctx, req.BatchID)
+	if err != nil {
+		return dto.GetPromoBatchResponse{}, err
+	}
+
+	return dto.GetPromoBatchResponse{Batch: batch}, nil
+}
+
+func (u UseCaseRepo) GetPromoBatchReports(ctx context.Context, req dto.GetPromoBatchReportsRequest) (dto.GetPromoBatchReportsResponse, error) {
+
+	reports, err := u.repo.GetPromoBatchReports(ctx)
+	if err != nil {
+		return dto.GetPromoBatchReportsResponse{}, err
+	}
+
+	return dto.GetPromoBatchReportsResponse{Reports: reports}, nil
+}
+
+func (u UseCaseRepo) GetPromoRedemptions(ctx context.Context, req dto.GetPromoRedemptionsRequest) (dto.GetPromoRedemptionsResponse, error) {
+
+	redemptions, err := u.repo.GetPromoRedemptions(ctx, req.PromoID)
+	if err != nil {
+		return dto.GetPromoRedemptionsResponse{}, err
+	}
+
+	return dto.GetPromoRedemptionsResponse{Redemptions: redemptions}, nil
+}
+
 func (u UseCaseRepo) SetOrderPhone(ctx context.Context, req dto.SetOrderPhoneRequest) (dto.SetOrderPhoneResponse, error) {
 
 	err := u.repo.SetOrderPhone(ctx, req.OrderID, req.PhoneID)
@@ -312,9 +388,11 @@ func (u UseCaseRepo) GetOrderPaymentInfo(ctx context.Context, req dto.GetOrderPa
 	}
 
 	return dto.GetOrderPaymentInfoResponse{
-		Total: info.Total,
-		Email: info.Email,
-		Phone: info.Phone,
+		Total:  info.Total,
+		Wallet: info.Wallet,
+		Due:    info.Due,
+		Email:  info.Email,
+		Phone:  info.Phone,
 	}, nil
 }
 
@@ -328,6 +406,98 @@ func (u UseCaseRepo) GetOrderTotal(ctx context.Context, req dto.GetOrderTotalReq
 	return dto.GetOrderTotalResponse{Total: total}, nil
 }
 
+func (u UseCaseRepo) SetItemGift(ctx context.Context, req dto.SetItemGiftRequest) (dto.SetItemGiftResponse, error) {
+
+	req.Gift.ItemID, req.Gift.OrderID, req.Gift.BuyerID = req.ItemID, req.OrderID, req.UserID
+
+	gift, err := u.repo.SetItemGift(ctx, req.Gift)
+	if err != nil {
+		return dto.SetItemGiftResponse{}, err
+	}
+
+	return dto.SetItemGiftResponse{Gift: gift}, nil
+}
+
+func (u UseCaseRepo) RemoveItemGift(ctx context.Context, req dto.RemoveItemGiftRequest) (dto.RemoveItemGiftResponse, error) {
+
+	err := u.repo.RemoveItemGift(ctx, req.ItemID, req.OrderID, req.UserID)
+	if err != nil {
+		return dto.RemoveItemGiftResponse{}, err
+	}
+
+	return dto.RemoveItemGiftResponse{}, nil
+}
+
+func (u UseCaseRepo) GetUserGifts(ctx context.Context, req dto.GetUserGiftsRequest) (dto.GetUserGiftsResponse, error) {
+
+	sent, received, err := u.repo.GetUserGifts(ctx, req.UserID)
+	if err != nil {
+		return dto.GetUserGiftsResponse{}, err
+	}
+
+	return dto.GetUserGiftsResponse{Sent: sent, Received: received}, nil
+}
+
+func (u UseCaseRepo) GetGift(ctx context.Context, req dto.GetGiftRequest) (dto.GetGiftResponse, error) {
+
+	gift, err := u.repo.GetGift(ctx, req.Token)
+	if err != nil {
+		return dto.GetGiftResponse{}, err
+	}
+
+	return dto.GetGiftResponse{Gift: gift}, nil
+}
+
+func (u UseCaseRepo) ClaimGift(ctx context.Context, req dto.ClaimGiftRequest) (dto.ClaimGiftResponse, error) {
+
+	gift, err := u.repo.ClaimGift(ctx, req.Token, req.UserID)
+	if err != nil {
+		return dto.ClaimGiftResponse{}, err
+	}
+
+	return dto.ClaimGiftResponse{Gift: gift}, nil
+}
+
+func (u UseCaseRepo) ClaimGiftWithSignup(ctx context.Context, req dto.ClaimGiftWithSignupRequest) (dto.ClaimGiftWithSignupResponse, error) {
+
+	recipient := eu.User{
+		Email:        req.User.Email,
+		Password:     req.User.Password,
+		Username:     req.User.Username,
+		FirstName:    req.User.FirstName,
+		LastName:     req.User.LastName,
+		PhoneNumbers: req.User.PhoneNumbers,
+		Addresses:    req.User.Addresses,
+	}
+
+	createdUser, gift, err := u.repo.ClaimGiftWithSignup(ctx, req.Token, recipient, req.User.ReferralCode)
+	if err != nil {
+		return dto.ClaimGiftWithSignupResponse{}, err
+	}
+
+	return dto.ClaimGiftWithSignupResponse{User: createdUser, Gift: gift}, nil
+}
+
+func (u UseCaseRepo) DeliverGifts(ctx context.Context, req dto.DeliverGiftsRequest) (dto.DeliverGiftsResponse, error) {
+
+	gifts, err := u.repo.GetDueGifts(ctx, req.Now)
+	if err != nil {
+		return dto.DeliverGiftsResponse{}, err
+	}
+
+	return dto.DeliverGiftsResponse{Gifts: gifts}, nil
+}
+
+func (u UseCaseRepo) SetGiftSent(ctx context.Context, req dto.SetGiftSentRequest) (dto.SetGiftSentResponse, error) {
+
+	err := u.repo.SetGiftSent(ctx, req.GiftID)
+	if err != nil {
+		return dto.SetGiftSentResponse{}, err
+	}
+
+	return dto.SetGiftSentResponse{}, nil
+}
+
 func (u UseCaseRepo) ZarinpalCreateOpenOrder(ctx context.Context, req dto.ZarinpalCreateOpenOrderRequest) (dto.ZarinpalCreateOpenOrderResponse, error) {
 
 	err := u.repo.ZarinpalCreateOpenOrder(ctx, req.OrderID, req.Authority)
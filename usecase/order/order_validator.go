@@ -13,8 +13,9 @@ type (
 	ValidateGetOrderItems    func(ctx context.Context, req dto.GetOrderItemsRequest) error
 	ValidateRemoveItem       func(ctx context.Context, req dto.RemoveItemRequest) error
 
-	ValidateCreatePromoCode func(ctx context.Context, req dto.CreatePromoCodeRequest) error
-	ValidateDeletePromoCode func(ctx context.Context, req dto.DeletePromoCodeRequest) error
+	ValidateCreatePromoCode  func(ctx context.Context, req dto.CreatePromoCodeRequest) error
+	ValidateDeletePromoCode  func(ctx context.Context, req dto.DeletePromoCodeRequest) error
+	ValidateCreatePromoBatch func(ctx context.Context, req dto.CreatePromoBatchRequest) error
 
 	ValidateSetOrderStatus   func(ctx context.Context, req dto.SetOrderStatusRequest) error
 	ValidateSetOrderSTN      func(ctx context.Context, req dto.SetOrderSTNRequest) error
@@ -28,11 +29,18 @@ type (
 	ValidateGetDateOrders         func(ctx context.Context, req dto.GetDateOrdersRequest) error
 	ValidateGetDateOrdersByStatus func(ctx context.Context, req dto.GetDateOrdersByStatusRequest) error
 
-	ValidateGetUserPromos   func(ctx context.Context, req dto.GetUserPromosRequest) error
-	ValidateGetPromoByOrder func(ctx context.Context, req dto.GetPromoByOrderRequest) error
+	ValidateGetUserPromos       func(ctx context.Context, req dto.GetUserPromosRequest) error
+	ValidateGetPromoByOrder     func(ctx context.Context, req dto.GetPromoByOrderRequest) error
+	ValidateGetPromoBatch       func(ctx context.Context, req dto.GetPromoBatchRequest) error
+	ValidateGetPromoRedemptions func(ctx context.Context, req dto.GetPromoRedemptionsRequest) error
 
 	ValidateSetOrderPhone   func(ctx context.Context, req dto.SetOrderPhoneRequest) error
 	ValidateSetOrderAddress func(ctx context.Context, req dto.SetOrderAddressRequest) error
 
 	ValidateGetOrderPaymentInfo func(ctx context.Context, req dto.GetOrderPaymentInfoRequest) error
+
+	ValidateSetItemGift    func(ctx context.Context, req dto.SetItemGiftRequest) error
+	ValidateRemoveItemGift func(ctx context.Context, req dto.RemoveItemGiftRequest) error
+	ValidateGetGift        func(ctx context.Context, req dto.GetGiftRequest) error
+	ValidateClaimGift      func(ctx context.Context, req dto.ClaimGiftRequest) error
 )
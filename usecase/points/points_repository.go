@@ -0,0 +1,24 @@
+package points
+
+import (
+	"context"
+
+	"github.com/XBozorg/bookstore/entity/points"
+)
+
+type Repository interface {
+	GetPoints(ctx context.Context, userID string) (points.Points, error)
+	SetOrderPoints(ctx context.Context, orderID uint, userID string, amount uint) error
+	ExpirePoints(ctx context.Context, now string) (uint, error)
+
+	GetTopicMultipliers(ctx context.Context) ([]points.TopicMultiplier, error)
+	SetTopicMultiplier(ctx context.Context, m points.TopicMultiplier) error
+	DeleteTopicMultiplier(ctx context.Context, topicID uint) error
+}
+
+type ValidatorRepo interface {
+	DoesUserOrderOpen(ctx context.Context, orderID uint, userID string) (bool, error)
+	IsOrderEmpty(ctx context.Context, orderID uint) (bool, error)
+	DoesTopicExist(ctx context.Context, topicID uint) (bool, error)
+	DoesTopicMultiplierExist(ctx context.Context, topicID uint) (bool, error)
+}
@@ -0,0 +1,86 @@
+package points
+
+import (
+	"context"
+
+	"github.com/XBozorg/bookstore/dto"
+	"github.com/XBozorg/bookstore/entity/points"
+)
+
+type UseCase interface {
+	GetPoints(ctx context.Context, req dto.GetPointsRequest) (dto.GetPointsResponse, error)
+	SetOrderPoints(ctx context.Context, req dto.SetOrderPointsRequest) (dto.SetOrderPointsResponse, error)
+	ExpirePoints(ctx context.Context, req dto.ExpirePointsRequest) (dto.ExpirePointsResponse, error)
+
+	GetTopicMultipliers(ctx context.Context, req dto.GetTopicMultipliersRequest) (dto.GetTopicMultipliersResponse, error)
+	SetTopicMultiplier(ctx context.Context, req dto.SetTopicMultiplierRequest) (dto.SetTopicMultiplierResponse, error)
+	DeleteTopicMultiplier(ctx context.Context, req dto.DeleteTopicMultiplierRequest) (dto.DeleteTopicMultiplierResponse, error)
+}
+
+type UseCaseRepo struct {
+	repo Repository
+}
+
+func New(r Repository) UseCaseRepo {
+	return UseCaseRepo{repo: r}
+}
+
+func (u UseCaseRepo) GetPoints(ctx context.Context, req dto.GetPointsRequest) (dto.GetPointsResponse, error) {
+
+	p, err := u.repo.GetPoints(ctx, req.UserID)
+	if err != nil {
+		return dto.GetPointsResponse{}, err
+	}
+
+	return dto.GetPointsResponse{Points: p}, nil
+}
+
+func (u UseCaseRepo) SetOrderPoints(ctx context.Context, req dto.SetOrderPointsRequest) (dto.SetOrderPointsResponse, error) {
+
+	err := u.repo.SetOrderPoints(ctx, req.OrderID, req.UserID, req.Points)
+	if err != nil {
+		return dto.SetOrderPointsResponse{}, err
+	}
+
+	return dto.SetOrderPointsResponse{}, nil
+}
+
+func (u UseCaseRepo) ExpirePoints(ctx context.Context, req dto.ExpirePointsRequest) (dto.ExpirePointsResponse, error) {
+
+	expired, err := u.repo.ExpirePoints(ctx, req.Now)
+	if err != nil {
+		return dto.ExpirePointsResponse{}, err
+	}
+
+	return dto.ExpirePointsResponse{Expired: expired}, nil
+}
+
+func (u UseCaseRepo) GetTopicMultipliers(ctx context.Context, req dto.GetTopicMultipliersRequest) (dto.GetTopicMultipliersResponse, error) {
+
+	multipliers, err := u.repo.GetTopicMultipliers(ctx)
+	if err != nil {
+		return dto.GetTopicMultipliersResponse{}, err
+	}
+
+	return dto.GetTopicMultipliersResponse{Multipliers: multipliers}, nil
+}
+
+func (u UseCaseRepo) SetTopicMultiplier(ctx context.Context, req dto.SetTopicMultiplierRequest) (dto.SetTopicMultiplierResponse, error) {
+
+	err := u.repo.SetTopicMultiplier(ctx, points.TopicMultiplier{TopicID: req.TopicID, Multiplier: req.Multiplier})
+	if err != nil {
+		return dto.SetTopicMultiplierResponse{}, err
+	}
+
+	return dto.SetTopicMultiplierResponse{}, nil
+}
+
+func (u UseCaseRepo) DeleteTopicMultiplier(ctx context.Context, req dto.DeleteTopicMultiplierRequest) (dto.DeleteTopicMultiplierResponse, error) {
+
+	err := u.repo.DeleteTopicMultiplier(ctx, req.TopicID)
+	if err != nil {
+		return dto.DeleteTopicMultiplierResponse{}, err
+	}
+
+	return dto.DeleteTopicMultiplierResponse{}, nil
+}
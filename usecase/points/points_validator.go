@@ -0,0 +1,14 @@
+package points
+
+import (
+	"context"
+
+	"github.com/XBozorg/bookstore/dto"
+)
+
+type (
+	ValidateGetPoints             func(ctx context.Context, req dto.GetPointsRequest) error
+	ValidateSetOrderPoints        func(ctx context.Context, req dto.SetOrderPointsRequest) error
+	ValidateSetTopicMultiplier    func(ctx context.Context, req dto.SetTopicMultiplierRequest) error
+	ValidateDeleteTopicMultiplier func(ctx context.Context, req dto.DeleteTopicMultiplierRequest) error
+)
@@ -0,0 +1,30 @@
+package pricing
+
+import (
+	"context"
+
+	"github.com/XBozorg/bookstore/entity/pricing"
+)
+
+type Repository interface {
+	AddCampaign(ctx context.Context, c pricing.Campaign) (pricing.Campaign, error)
+	EditCampaign(ctx context.Context, c pricing.Campaign) (pricing.Campaign, error)
+	DeleteCampaign(ctx context.Context, campaignID uint) error
+	GetCampaign(ctx context.Context, campaignID uint) (pricing.Campaign, error)
+	GetCampaigns(ctx context.Context) ([]pricing.Campaign, error)
+	PreviewCampaign(ctx context.Context, c pricing.Campaign, at string) ([]pricing.Preview, error)
+
+	GetPriceQuote(ctx context.Context, bookID uint, display string) (pricing.Quote, error)
+
+	SetBundleRule(ctx context.Context, rule pricing.BundleRule) error
+	DeleteBundleRule(ctx context.Context, scope string, scopeID uint) error
+	GetBundleRules(ctx context.Context) ([]pricing.BundleRule, error)
+
+	CountCampaignBoundaries(ctx context.Context, since, until string) (uint, error)
+	RefreshOpenOrders(ctx context.Context) error
+}
+
+type ValidatorRepo interface {
+	DoesCampaignExist(ctx context.Context, campaignID uint) (bool, error)
+	DoesBundleRuleExist(ctx context.Context, scope string, scopeID uint) (bool, error)
+}
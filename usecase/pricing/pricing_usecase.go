@@ -0,0 +1,207 @@
+package pricing
+
+import (
+	"context"
+	"time"
+
+	"github.com/XBozorg/bookstore/dto"
+	"github.com/XBozorg/bookstore/entity/pricing"
+)
+
+type UseCase interface {
+	AddCampaign(ctx context.Context, req dto.AddCampaignRequest) (dto.AddCampaignResponse, error)
+	EditCampaign(ctx context.Context, req dto.EditCampaignRequest) (dto.EditCampaignResponse, error)
+	DeleteCampaign(ctx context.Context, req dto.DeleteCampaignRequest) (dto.DeleteCampaignResponse, error)
+	GetCampaign(ctx context.Context, req dto.GetCampaignRequest) (dto.GetCampaignResponse, error)
+	GetCampaigns(ctx context.Context, req dto.GetCampaignsRequest) (dto.GetCampaignsResponse, error)
+	PreviewCampaign(ctx context.Context, req dto.PreviewCampaignRequest) (dto.PreviewCampaignResponse, error)
+
+	GetPriceQuote(ctx context.Context, req dto.GetPriceQuoteRequest) (dto.GetPriceQuoteResponse, error)
+
+	SetBundleRule(ctx context.Context, req dto.SetBundleRuleRequest) (dto.SetBundleRuleResponse, error)
+	DeleteBundleRule(ctx context.Context, req dto.DeleteBundleRuleRequest) (dto.DeleteBundleRuleResponse, error)
+	GetBundleRules(ctx context.Context, req dto.GetBundleRulesRequest) (dto.GetBundleRulesResponse, error)
+
+	RefreshCampaigns(ctx context.Context, req dto.RefreshCampaignsRequest) (dto.RefreshCampaignsResponse, error)
+}
+
+type UseCaseRepo struct {
+	repo Repository
+}
+
+func New(r Repository) UseCaseRepo {
+	return UseCaseRepo{repo: r}
+}
+
+func now() string { return time.Now().Format("2006-01-02 15:04:05") }
+
+// refreshIfRunning recomputes the open carts when one of the campaigns runs right now
+func (u UseCaseRepo) refreshIfRunning(ctx context.Context, campaigns ...pricing.Campaign) error {
+
+	t := now()
+	for _, c := range campaigns {
+		if c.Active(t) {
+			return u.repo.RefreshOpenOrders(ctx)
+		}
+	}
+
+	return nil
+}
+
+func (u UseCaseRepo) AddCampaign(ctx context.Context, req dto.AddCampaignRequest) (dto.AddCampaignResponse, error) {
+
+	c, err := u.repo.AddCampaign(ctx, req.Campaign)
+	if err != nil {
+		return dto.AddCampaignResponse{}, err
+	}
+
+	if err = u.refreshIfRunning(ctx, c); err != nil {
+		return dto.AddCampaignResponse{}, err
+	}
+
+	return dto.AddCampaignResponse{Campaign: c}, nil
+}
+
+func (u UseCaseRepo) EditCampaign(ctx context.Context, req dto.EditCampaignRequest) (dto.EditCampaignResponse, error) {
+
+	old, err := u.repo.GetCampaign(ctx, req.Campaign.ID)
+	if err != nil {
+		return dto.EditCampaignResponse{}, err
+	}
+
+	c, err := u.repo.EditCampaign(ctx, req.Campaign)
+	if err != nil {
+		return dto.EditCampaignResponse{}, err
+	}
+
+	if err = u.refreshIfRunning(ctx, old, c); err != nil {
+		return dto.EditCampaignResponse{}, err
+	}
+
+	return dto.EditCampaignResponse{Campaign: c}, nil
+}
+
+func (u UseCaseRepo) DeleteCampaign(ctx context.Context, req dto.DeleteCampaignRequest) (dto.DeleteCampaignResponse, error) {
+
+	old, err := u.repo.GetCampaign(ctx, req.CampaignID)
+	if err != nil {
+		return dto.DeleteCampaignResponse{}, err
+	}
+
+	if err = u.repo.DeleteCampaign(ctx, req.CampaignID); err != nil {
+		return dto.DeleteCampaignResponse{}, err
+	}
+
+	if err = u.refreshIfRunning(ctx, old); err != nil {
+		return dto.DeleteCampaignResponse{}, err
+	}
+
+	return dto.DeleteCampaignResponse{}, nil
+}
+
+func (u UseCaseRepo) GetCampaign(ctx context.Context, req dto.GetCampaignRequest) (dto.GetCampaignResponse, error) {
+
+	c, err := u.repo.GetCampaign(ctx, req.CampaignID)
+	if err != nil {
+		return dto.GetCampaignResponse{}, err
+	}
+
+	return dto.GetCampaignResponse{Campaign: c}, nil
+}
+
+func (u UseCaseRepo) GetCampaigns(ctx context.Context, req dto.GetCampaignsRequest) (dto.GetCampaignsResponse, error) {
+
+	campaigns, err := u.repo.GetCampaigns(ctx)
+	if err != nil {
+		return dto.GetCampaignsResponse{}, err
+	}
+
+	return dto.GetCampaignsResponse{Campaigns: campaigns}, nil
+}
+
+// PreviewCampaign prices the targeted books at req.At, by default when the campaign starts
+// or right now for a running campaign
+func (u UseCaseRepo) PreviewCampaign(ctx context.Context, req dto.PreviewCampaignRequest) (dto.PreviewCampaignResponse, error) {
+
+	at := req.At
+	if at == "" {
+		at = req.Campaign.Start
+		if t := now(); req.Campaign.Active(t) {
+			at = t
+		}
+	}
+
+	books, err := u.repo.PreviewCampaign(ctx, req.Campaign, at)
+	if err != nil {
+		return dto.PreviewCampaignResponse{}, err
+	}
+
+	return dto.PreviewCampaignResponse{At: at, Books: books}, nil
+}
+
+func (u UseCaseRepo) GetPriceQuote(ctx context.Context, req dto.GetPriceQuoteRequest) (dto.GetPriceQuoteResponse, error) {
+
+	q, err := u.repo.GetPriceQuote(ctx, req.BookID, req.Display)
+	if err != nil {
+		return dto.GetPriceQuoteResponse{}, err
+	}
+
+	return dto.GetPriceQuoteResponse{Quote: q}, nil
+}
+
+// SetBundleRule saves the rule, the open carts are repriced with it
+func (u UseCaseRepo) SetBundleRule(ctx context.Context, req dto.SetBundleRuleRequest) (dto.SetBundleRuleResponse, error) {
+
+	if err := u.repo.SetBundleRule(ctx, req.Rule); err != nil {
+		return dto.SetBundleRuleResponse{}, err
+	}
+
+	if err := u.repo.RefreshOpenOrders(ctx); err != nil {
+		return dto.SetBundleRuleResponse{}, err
+	}
+
+	return dto.SetBundleRuleResponse{Rule: req.Rule}, nil
+}
+
+// DeleteBundleRule removes the rule, the bundles it covered fall back to a wider scope
+func (u UseCaseRepo) DeleteBundleRule(ctx context.Context, req dto.DeleteBundleRuleRequest) (dto.DeleteBundleRuleResponse, error) {
+
+	if err := u.repo.DeleteBundleRule(ctx, req.Scope, req.ScopeID); err != nil {
+		return dto.DeleteBundleRuleResponse{}, err
+	}
+
+	if err := u.repo.RefreshOpenOrders(ctx); err != nil {
+		return dto.DeleteBundleRuleResponse{}, err
+	}
+
+	return dto.DeleteBundleRuleResponse{}, nil
+}
+
+func (u UseCaseRepo) GetBundleRules(ctx context.Context, req dto.GetBundleRulesRequest) (dto.GetBundleRulesResponse, error) {
+
+	rules, err := u.repo.GetBundleRules(ctx)
+	if err != nil {
+		return dto.GetBundleRulesResponse{}, err
+	}
+
+	return dto.GetBundleRulesResponse{Rules: rules}, nil
+}
+
+// RefreshCampaigns recomputes the open carts when a campaign started or ended in (since, until]
+func (u UseCaseRepo) RefreshCampaigns(ctx context.Context, req dto.RefreshCampaignsRequest) (dto.RefreshCampaignsResponse, error) {
+
+	count, err := u.repo.CountCampaignBoundaries(ctx, req.Since, req.Until)
+	if err != nil {
+		return dto.RefreshCampaignsResponse{}, err
+	}
+
+	if count == 0 {
+		return dto.RefreshCampaignsResponse{}, nil
+	}
+
+	if err = u.repo.RefreshOpenOrders(ctx); err != nil {
+		return dto.RefreshCampaignsResponse{}, err
+	}
+
+	return dto.RefreshCampaignsResponse{Changed: count}, nil
+}
@@ -0,0 +1,18 @@
+package pricing
+
+import (
+	"context"
+
+	"github.com/XBozorg/bookstore/dto"
+)
+
+type (
+	ValidateAddCampaign      func(ctx context.Context, req dto.AddCampaignRequest) error
+	ValidateEditCampaign     func(ctx context.Context, req dto.EditCampaignRequest) error
+	ValidateDeleteCampaign   func(ctx context.Context, req dto.DeleteCampaignRequest) error
+	ValidateGetCampaign      func(ctx context.Context, req dto.GetCampaignRequest) error
+	ValidatePreviewCampaign  func(ctx context.Context, req dto.PreviewCampaignRequest) error
+	ValidateGetPriceQuote    func(ctx context.Context, req dto.GetPriceQuoteRequest) error
+	ValidateSetBundleRule    func(ctx context.Context, req dto.SetBundleRuleRequest) error
+	ValidateDeleteBundleRule func(ctx context.Context, req dto.DeleteBundleRuleRequest) error
+)
@@ -7,7 +7,7 @@ import (
 )
 
 type Repository interface {
-	CreateUser(ctx context.Context, user user.User) (user.User, error)
+	CreateUser(ctx context.Context, user user.User, referralCode string) (user.User, error)
 	GetUser(ctx context.Context, userID string) (user.User, error)
 	GetUsers(ctx context.Context) ([]user.User, error)
 	DeleteUser(ctx context.Context, userID string) error
@@ -27,10 +27,15 @@ type Repository interface {
 	GetAddress(ctx context.Context, userID string, addressID uint) (user.Address, error)
 	GetAddresses(ctx context.Context, userID string) ([]user.Address, error)
 	DeleteAddress(ctx context.Context, userID string, addressID uint) error
+
+	GetReferralInfo(ctx context.Context, userID string) (user.ReferralInfo, error)
+	GetUserReferrals(ctx context.Context, referrerID string) ([]user.Referral, error)
+	GetReferralReport(ctx context.Context) ([]user.ReferralReport, error)
 }
 
 type ValidatorRepo interface {
 	DoesUserExist(ctx context.Context, userID string) (bool, error)
 	DoesPhoneExist(ctx context.Context, phoneID uint) (bool, error)
 	DoesAddressExist(ctx context.Context, addressID uint) (bool, error)
+	DoesReferralCodeExist(ctx context.Context, code string) (bool, error)
 }
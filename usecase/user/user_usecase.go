@@ -28,6 +28,10 @@ type UseCase interface {
 	GetAddress(ctx context.Context, req dto.GetAddressRequest) (dto.GetAddressResponse, error)
 	GetAddresses(ctx context.Context, req dto.GetAddressesRequest) (dto.GetAddressesResponse, error)
 	DeleteAddress(ctx context.Context, req dto.DeleteAddressRequest) (dto.DeleteAddressResponse, error)
+
+	GetReferral(ctx context.Context, req dto.GetReferralRequest) (dto.GetReferralResponse, error)
+	GetUserReferrals(ctx context.Context, req dto.GetUserReferralsRequest) (dto.GetUserReferralsResponse, error)
+	GetReferralReport(ctx context.Context, req dto.GetReferralReportRequest) (dto.GetReferralReportResponse, error)
 }
 
 type UseCaseRepo struct {
@@ -48,7 +52,7 @@ func (u UseCaseRepo) CreateUser(ctx context.Context, req dto.CreateUserRequest)
 		PhoneNumbers: req.PhoneNumbers,
 		Addresses:    req.Addresses,
 	}
-	createdUser, err := u.repo.CreateUser(ctx, user)
+	createdUser, err := u.repo.CreateUser(ctx, user, req.ReferralCode)
 
 	if err != nil {
 		return dto.CreateUserResponse{}, err
@@ -185,3 +189,27 @@ func (u UseCaseRepo) DeleteAddress(ctx context.Context, req dto.DeleteAddressReq
 	}
 	return dto.DeleteAddressResponse{}, nil
 }
+
+func (u UseCaseRepo) GetReferral(ctx context.Context, req dto.GetReferralRequest) (dto.GetReferralResponse, error) {
+	info, err := u.repo.GetReferralInfo(ctx, req.UserID)
+	if err != nil {
+		return dto.GetReferralResponse{}, err
+	}
+	return dto.GetReferralResponse{Referral: info}, nil
+}
+
+func (u UseCaseRepo) GetUserReferrals(ctx context.Context, req dto.GetUserReferralsRequest) (dto.GetUserReferralsResponse, error) {
+	referrals, err := u.repo.GetUserReferrals(ctx, req.UserID)
+	if err != nil {
+		return dto.GetUserReferralsResponse{}, err
+	}
+	return dto.GetUserReferralsResponse{Referrals: referrals}, nil
+}
+
+func (u UseCaseRepo) GetReferralReport(ctx context.Context, req dto.GetReferralReportRequest) (dto.GetReferralReportResponse, error) {
+	reports, err := u.repo.GetReferralReport(ctx)
+	if err != nil {
+		return dto.GetReferralReportResponse{}, err
+	}
+	return dto.GetReferralReportResponse{Reports: reports}, nil
+}
@@ -7,7 +7,7 @@ import (
 )
 
 type (
-	ValidateCreateUser func(req dto.CreateUserRequest) error
+	ValidateCreateUser func(ctx context.Context, req dto.CreateUserRequest) error
 	ValidateGetUser    func(ctx context.Context, req dto.GetUserRequest) error
 	ValidateDeleteUser func(ctx context.Context, req dto.DeleteUserRequest) error
 
@@ -25,4 +25,7 @@ type (
 	ValidateGetAddress    func(ctx context.Context, req dto.GetAddressRequest) error
 	ValidateGetAddresses  func(ctx context.Context, req dto.GetAddressesRequest) error
 	ValidateDeleteAddress func(ctx context.Context, req dto.DeleteAddressRequest) error
+
+	ValidateGetReferral      func(ctx context.Context, req dto.GetReferralRequest) error
+	ValidateGetUserReferrals func(ctx context.Context, req dto.GetUserReferralsRequest) error
 )
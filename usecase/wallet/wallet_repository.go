@@ -0,0 +1,29 @@
+package wallet
+
+import (
+	"context"
+
+	"github.com/XBozorg/bookstore/entity/wallet"
+)
+
+type Repository interface {
+	GetWallet(ctx context.Context, userID string) (wallet.Wallet, error)
+
+	AddGiftCard(ctx context.Context, card wallet.GiftCard) (wallet.GiftCard, error)
+	RemoveGiftCard(ctx context.Context, giftCardID uint, userID string) error
+	GetUserGiftCards(ctx context.Context, userID string) ([]wallet.GiftCard, error)
+	RedeemGiftCard(ctx context.Context, code, userID string) (wallet.Entry, error)
+	GetUndeliveredGiftCards(ctx context.Context) ([]wallet.GiftCard, error)
+	SetGiftCardDelivered(ctx context.Context, giftCardID uint) error
+
+	SetOrderWallet(ctx context.Context, orderID uint, userID string, amount uint) error
+	PayOrderWithWallet(ctx context.Context, orderID uint) error
+	RefundOrder(ctx context.Context, orderID, amount uint, note string) (wallet.Entry, error)
+}
+
+type ValidatorRepo interface {
+	DoesGiftCardExist(ctx context.Context, giftCardID uint, userID string) (bool, error)
+	DoesUserOrderOpen(ctx context.Context, orderID uint, userID string) (bool, error)
+	IsOrderEmpty(ctx context.Context, orderID uint) (bool, error)
+	IsOrderPaid(ctx context.Context, orderID uint) (bool, error)
+}
@@ -0,0 +1,134 @@
+package wallet
+
+import (
+	"context"
+
+	"github.com/XBozorg/bookstore/dto"
+)
+
+type UseCase interface {
+	GetWallet(ctx context.Context, req dto.GetWalletRequest) (dto.GetWalletResponse, error)
+
+	AddGiftCard(ctx context.Context, req dto.AddGiftCardRequest) (dto.AddGiftCardResponse, error)
+	RemoveGiftCard(ctx context.Context, req dto.RemoveGiftCardRequest) (dto.RemoveGiftCardResponse, error)
+	GetUserGiftCards(ctx context.Context, req dto.GetUserGiftCardsRequest) (dto.GetUserGiftCardsResponse, error)
+	RedeemGiftCard(ctx context.Context, req dto.RedeemGiftCardRequest) (dto.RedeemGiftCardResponse, error)
+	DeliverGiftCards(ctx context.Context, req dto.DeliverGiftCardsRequest) (dto.DeliverGiftCardsResponse, error)
+	SetGiftCardDelivered(ctx context.Context, req dto.SetGiftCardDeliveredRequest) (dto.SetGiftCardDeliveredResponse, error)
+
+	SetOrderWallet(ctx context.Context, req dto.SetOrderWalletRequest) (dto.SetOrderWalletResponse, error)
+	PayOrderWithWallet(ctx context.Context, req dto.PayOrderWithWalletRequest) (dto.PayOrderWithWalletResponse, error)
+	RefundOrder(ctx context.Context, req dto.RefundOrderRequest) (dto.RefundOrderResponse, error)
+}
+
+type UseCaseRepo struct {
+	repo Repository
+}
+
+func New(r Repository) UseCaseRepo {
+	return UseCaseRepo{repo: r}
+}
+
+func (u UseCaseRepo) GetWallet(ctx context.Context, req dto.GetWalletRequest) (dto.GetWalletResponse, error) {
+
+	w, err := u.repo.GetWallet(ctx, req.UserID)
+	if err != nil {
+		return dto.GetWalletResponse{}, err
+	}
+
+	return dto.GetWalletResponse{Wallet: w}, nil
+}
+
+func (u UseCaseRepo) AddGiftCard(ctx context.Context, req dto.AddGiftCardRequest) (dto.AddGiftCardResponse, error) {
+
+	req.GiftCard.BuyerID = req.UserID
+
+	card, err := u.repo.AddGiftCard(ctx, req.GiftCard)
+	if err != nil {
+		return dto.AddGiftCardResponse{}, err
+	}
+
+	return dto.AddGiftCardResponse{GiftCard: card}, nil
+}
+
+func (u UseCaseRepo) RemoveGiftCard(ctx context.Context, req dto.RemoveGiftCardRequest) (dto.RemoveGiftCardResponse, error) {
+
+	err := u.repo.RemoveGiftCard(ctx, req.GiftCardID, req.UserID)
+	if err != nil {
+		return dto.RemoveGiftCardResponse{}, err
+	}
+
+	return dto.RemoveGiftCardResponse{}, nil
+}
+
+func (u UseCaseRepo) GetUserGiftCards(ctx context.Context, req dto.GetUserGiftCardsRequest) (dto.GetUserGiftCardsResponse, error) {
+
+	cards, err := u.repo.GetUserGiftCards(ctx, req.UserID)
+	if err != nil {
+		return dto.GetUserGiftCardsResponse{}, err
+	}
+
+	return dto.GetUserGiftCardsResponse{GiftCards: cards}, nil
+}
+
+func (u UseCaseRepo) RedeemGiftCard(ctx context.Context, req dto.RedeemGiftCardRequest) (dto.RedeemGiftCardResponse, error) {
+
+	entry, err := u.repo.RedeemGiftCard(ctx, req.Code, req.UserID)
+	if err != nil {
+		return dto.RedeemGiftCardResponse{}, err
+	}
+
+	return dto.RedeemGiftCardResponse{Entry: entry}, nil
+}
+
+func (u UseCaseRepo) DeliverGiftCards(ctx context.Context, req dto.DeliverGiftCardsRequest) (dto.DeliverGiftCardsResponse, error) {
+
+	cards, err := u.repo.GetUndeliveredGiftCards(ctx)
+	if err != nil {
+		return dto.DeliverGiftCardsResponse{}, err
+	}
+
+	return dto.DeliverGiftCardsResponse{GiftCards: cards}, nil
+}
+
+func (u UseCaseRepo) SetGiftCardDelivered(ctx context.Context, req dto.SetGiftCardDeliveredRequest) (dto.SetGiftCardDeliveredResponse, error) {
+
+	err := u.repo.SetGiftCardDelivered(ctx, req.GiftCardID)
+	if err != nil {
+		return dto.SetGiftCardDeliveredResponse{}, err
+	}
+
+	return dto.SetGiftCardDeliveredResponse{}, nil
+}
+
+func (u UseCaseRepo) SetOrderWallet(ctx context.Context, req dto.SetOrderWalletRequest) (dto.SetOrderWalletResponse, error) {
+
+	err := u.repo.SetOrderWallet(ctx, req.OrderID, req.UserID, req.Amount)
+	if err != nil {
+		return dto.SetOrderWalletResponse{}, err
+	}
+
+	return dto.SetOrderWalletResponse{}, nil
+}
+
+// PayOrderWithWallet checks out an order the wallet covers entirely, as the payment
+// gateway would after its verification
+func (u UseCaseRepo) PayOrderWithWallet(ctx context.Context, req dto.PayOrderWithWalletRequest) (dto.PayOrderWithWalletResponse, error) {
+
+	err := u.repo.PayOrderWithWallet(ctx, req.OrderID)
+	if err != nil {
+		return dto.PayOrderWithWalletResponse{}, err
+	}
+
+	return dto.PayOrderWithWalletResponse{}, nil
+}
+
+func (u UseCaseRepo) RefundOrder(ctx context.Context, req dto.RefundOrderRequest) (dto.RefundOrderResponse, error) {
+
+	entry, err := u.repo.RefundOrder(ctx, req.OrderID, req.Amount, req.Note)
+	if err != nil {
+		return dto.RefundOrderResponse{}, err
+	}
+
+	return dto.RefundOrderResponse{Entry: entry}, nil
+}
@@ -0,0 +1,17 @@
+package wallet
+
+import (
+	"context"
+
+	"github.com/XBozorg/bookstore/dto"
+)
+
+type (
+	ValidateGetWallet          func(ctx context.Context, req dto.GetWalletRequest) error
+	ValidateAddGiftCard        func(ctx context.Context, req dto.AddGiftCardRequest) error
+	ValidateRemoveGiftCard     func(ctx context.Context, req dto.RemoveGiftCardRequest) error
+	ValidateRedeemGiftCard     func(ctx context.Context, req dto.RedeemGiftCardRequest) error
+	ValidateSetOrderWallet     func(ctx context.Context, req dto.SetOrderWalletRequest) error
+	ValidatePayOrderWithWallet func(ctx context.Context, req dto.PayOrderWithWalletRequest) error
+	ValidateRefundOrder        func(ctx context.Context, req dto.RefundOrderRequest) error
+)
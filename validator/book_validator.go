@@ -2,10 +2,14 @@ package validator
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"os"
+	"regexp"
 
 	"github.com/XBozorg/bookstore/adapter/repository"
 	"github.com/XBozorg/bookstore/dto"
+	bookEntity "github.com/XBozorg/bookstore/entity/book"
 	"github.com/XBozorg/bookstore/usecase/book"
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/go-ozzo/ozzo-validation/v4/is"
@@ -75,6 +79,23 @@ func doesTopicExist(ctx context.Context, repo book.ValidatorRepo) validation.Rul
 	}
 }
 
+// isNotDescendant rejects the topics lying in the subtree of topicID, including topicID itself
+func isNotDescendant(ctx context.Context, repo book.ValidatorRepo, topicID uint) validation.RuleFunc {
+	return func(value interface{}) error {
+		id := value.(uint)
+
+		topics, err := repo.GetTopics(ctx)
+		if err != nil {
+			return err
+		}
+
+		if bookEntity.IsDescendant(topics, id, topicID) {
+			return errors.New("topic cannot be placed under itself or its descendants")
+		}
+		return nil
+	}
+}
+
 func doesLangExist(ctx context.Context, repo book.ValidatorRepo) validation.RuleFunc {
 	return func(value interface{}) error {
 		langID := value.(uint)
@@ -97,6 +118,9 @@ func doesUserAccessBook(ctx context.Context, repo book.ValidatorRepo, userID str
 
 		ok, err := repo.DoesUserAccessBook(ctx, userID, bookID)
 		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return errors.New("access denied")
+			}
 			return err
 		}
 
@@ -108,6 +132,37 @@ func doesUserAccessBook(ctx context.Context, repo book.ValidatorRepo, userID str
 	}
 }
 
+func doesImportJobExist(ctx context.Context, repo book.ValidatorRepo) validation.RuleFunc {
+	return func(value interface{}) error {
+		jobID := value.(uint)
+
+		ok, err := repo.DoesImportJobExist(ctx, jobID)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			return errors.New("import job does not exist")
+		}
+
+		return nil
+	}
+}
+
+func doesFileExist(value interface{}) error {
+	path := value.(string)
+	if path == "" {
+		return nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return errors.New("file does not exist")
+	}
+
+	return nil
+}
+
 func ValidateAddAuthor(storage repository.Storage) book.ValidateAddAuthor {
 	return func(ctx context.Context, req dto.AddAuthorRequest) error {
 		return validation.ValidateStruct(&req,
@@ -124,6 +179,27 @@ func ValidateGetAuthor(storage repository.Storage) book.ValidateGetAuthor {
 	}
 }
 
+func ValidateEditAuthor(storage repository.Storage) book.ValidateEditAuthor {
+	return func(ctx context.Context, req dto.EditAuthorRequest) error {
+		return validation.ValidateStruct(&req.Author,
+			validation.Field(&req.Author.ID, validation.Required, validation.By(doesAuthorExist(ctx, storage))),
+			validation.Field(&req.Author.Name, validation.Required, is.ASCII, validation.Length(4, 100)),
+			validation.Field(&req.Author.Biography, validation.RuneLength(0, 2000)),
+			validation.Field(&req.Author.Photo, is.ASCII, validation.Length(10, 150)),
+			validation.Field(&req.Author.Website, is.URL, validation.Length(0, 150)),
+			validation.Field(&req.Author.Country, is.CountryCode2),
+		)
+	}
+}
+
+func ValidateGetAuthorPage(storage repository.Storage) book.ValidateGetAuthorPage {
+	return func(ctx context.Context, req dto.GetAuthorPageRequest) error {
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.AuthorID, validation.Required, validation.By(doesAuthorExist(ctx, storage))),
+		)
+	}
+}
+
 func ValidateDeleteAuthor(storage repository.Storage) book.ValidateDeleteAuthor {
 	return func(ctx context.Context, req dto.DeleteAuthorRequest) error {
 		return validation.ValidateStruct(&req,
@@ -148,6 +224,27 @@ func ValidateGetPublisher(storage repository.Storage) book.ValidateGetPublisher
 	}
 }
 
+func ValidateEditPublisher(storage repository.Storage) book.ValidateEditPublisher {
+	return func(ctx context.Context, req dto.EditPublisherRequest) error {
+		return validation.ValidateStruct(&req.Publisher,
+			validation.Field(&req.Publisher.ID, validation.Required, validation.By(doesPublisherExist(ctx, storage))),
+			validation.Field(&req.Publisher.Name, validation.Required, is.ASCII, validation.Length(1, 100)),
+			validation.Field(&req.Publisher.Description, validation.RuneLength(0, 2000)),
+			validation.Field(&req.Publisher.Logo, is.ASCII, validation.Length(10, 150)),
+			validation.Field(&req.Publisher.Website, is.URL, validation.Length(0, 150)),
+			validation.Field(&req.Publisher.Country, is.CountryCode2),
+		)
+	}
+}
+
+func ValidateGetPublisherPage(storage repository.Storage) book.ValidateGetPublisherPage {
+	return func(ctx context.Context, req dto.GetPublisherPageRequest) error {
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.PublisherID, validation.Required, validation.By(doesPublisherExist(ctx, storage))),
+		)
+	}
+}
+
 func ValidateDeletePublisher(storage repository.Storage) book.ValidateDeletePublisher {
 	return func(ctx context.Context, req dto.DeletePublisherRequest) error {
 		return validation.ValidateStruct(&req,
@@ -160,6 +257,30 @@ func ValidateAddTopic(storage repository.Storage) book.ValidateAddTopic {
 	return func(ctx context.Context, req dto.AddTopicRequest) error {
 		return validation.ValidateStruct(&req,
 			validation.Field(&req.Name, validation.Required, is.Alpha, validation.Length(2, 30)),
+			validation.Field(&req.ParentID, validation.When(req.ParentID != 0, validation.By(doesTopicExist(ctx, storage)))),
+		)
+	}
+}
+
+func ValidateMoveTopic(storage repository.Storage) book.ValidateMoveTopic {
+	return func(ctx context.Context, req dto.MoveTopicRequest) error {
+		if err := validation.ValidateStruct(&req,
+			validation.Field(&req.TopicID, validation.Required, validation.By(doesTopicExist(ctx, storage))),
+			validation.Field(&req.ParentID, validation.When(req.ParentID != 0, validation.By(doesTopicExist(ctx, storage)))),
+		); err != nil {
+			return err
+		}
+
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.ParentID, validation.When(req.ParentID != 0, validation.By(isNotDescendant(ctx, storage, req.TopicID)))),
+		)
+	}
+}
+
+func ValidateReorderTopic(storage repository.Storage) book.ValidateReorderTopic {
+	return func(ctx context.Context, req dto.ReorderTopicRequest) error {
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.TopicID, validation.Required, validation.By(doesTopicExist(ctx, storage))),
 		)
 	}
 }
@@ -204,40 +325,47 @@ func ValidateDeleteLanguage(storage repository.Storage) book.ValidateDeleteLangu
 	}
 }
 
-func ValidateAddBook(storage repository.Storage) book.ValidateAddBook {
-	return func(ctx context.Context, req dto.AddBookRequest) error {
-		if errBook := validation.ValidateStruct(&req.Book,
-			validation.Field(&req.Book.Title, validation.Required, is.ASCII, validation.Length(1, 100)),
-			validation.Field(&req.Book.ISBN, validation.Required, is.ISBN13),
-			validation.Field(&req.Book.Pages, validation.Required),
-			validation.Field(&req.Book.Description, is.ASCII, validation.Length(0, 500)),
-			validation.Field(&req.Book.Year, validation.Required, validation.Date("2006")),
-			validation.Field(&req.Book.CreationDate, validation.Required, validation.Date("2006-01-02 15:04:05")),
-			validation.Field(&req.Book.CoverFront, validation.Required, is.ASCII, validation.Length(10, 150)),
-			validation.Field(&req.Book.CoverBack, validation.Required, is.ASCII, validation.Length(10, 150)),
-		); errBook != nil {
-			return errBook
-		}
+// validateBookDetails checks the fields of a new book but its authors, topics, publisher and language
+func validateBookDetails(b *bookEntity.Book) error {
 
-		if errDigital := validation.ValidateStruct(&req.Book.Digital,
-			validation.Field(&req.Book.Digital.Price, validation.Required),
-			validation.Field(&req.Book.Digital.Discount, validation.Max(100)),
-			validation.Field(&req.Book.Digital.PDF, is.ASCII, validation.Length(10, 150)),
-			validation.Field(&req.Book.Digital.EPUB, is.ASCII, validation.Length(10, 150)),
-			validation.Field(&req.Book.Digital.DJVU, is.ASCII, validation.Length(10, 150)),
-			validation.Field(&req.Book.Digital.AZW, is.ASCII, validation.Length(10, 150)),
-			validation.Field(&req.Book.Digital.TXT, is.ASCII, validation.Length(10, 150)),
-			validation.Field(&req.Book.Digital.DOCX, is.ASCII, validation.Length(10, 150)),
-		); errDigital != nil {
-			return errDigital
-		}
+	if errBook := validation.ValidateStruct(b,
+		validation.Field(&b.Title, validation.Required, is.ASCII, validation.Length(1, 100)),
+		validation.Field(&b.ISBN, validation.Required, is.ISBN13),
+		validation.Field(&b.Pages, validation.Required),
+		validation.Field(&b.Description, is.ASCII, validation.Length(0, 500)),
+		validation.Field(&b.Year, validation.Required, validation.Date("2006")),
+		validation.Field(&b.CreationDate, validation.Required, validation.Date("2006-01-02 15:04:05")),
+		validation.Field(&b.ReleaseDate, validation.Date("2006-01-02")),
+		validation.Field(&b.CoverFront, validation.Required, is.ASCII, validation.Length(10, 150)),
+		validation.Field(&b.CoverBack, validation.Required, is.ASCII, validation.Length(10, 150)),
+	); errBook != nil {
+		return errBook
+	}
 
-		if errPhysical := validation.ValidateStruct(&req.Book.Physical,
-			validation.Field(&req.Book.Physical.Price, validation.Required),
-			validation.Field(&req.Book.Physical.Discount, validation.Max(100)),
-			validation.Field(&req.Book.Physical.Stock, validation.Required),
-		); errPhysical != nil {
-			return errPhysical
+	if errDigital := validation.ValidateStruct(&b.Digital,
+		validation.Field(&b.Digital.Price, validation.Required),
+		validation.Field(&b.Digital.Discount, validation.Max(100)),
+		validation.Field(&b.Digital.PDF, is.ASCII, validation.Length(10, 150)),
+		validation.Field(&b.Digital.EPUB, is.ASCII, validation.Length(10, 150)),
+		validation.Field(&b.Digital.DJVU, is.ASCII, validation.Length(10, 150)),
+		validation.Field(&b.Digital.AZW, is.ASCII, validation.Length(10, 150)),
+		validation.Field(&b.Digital.TXT, is.ASCII, validation.Length(10, 150)),
+		validation.Field(&b.Digital.DOCX, is.ASCII, validation.Length(10, 150)),
+	); errDigital != nil {
+		return errDigital
+	}
+
+	return validation.ValidateStruct(&b.Physical,
+		validation.Field(&b.Physical.Price, validation.Required),
+		validation.Field(&b.Physical.Discount, validation.Max(100)),
+		validation.Field(&b.Physical.Stock, validation.Required),
+	)
+}
+
+func ValidateAddBook(storage repository.Storage) book.ValidateAddBook {
+	return func(ctx context.Context, req dto.AddBookRequest) error {
+		if err := validateBookDetails(&req.Book); err != nil {
+			return err
 		}
 
 		if errLang := validation.ValidateStruct(&req.Book.Language,
@@ -256,6 +384,71 @@ func ValidateAddBook(storage repository.Storage) book.ValidateAddBook {
 	}
 }
 
+func ValidateExtractBookMetadata(storage repository.Storage) book.ValidateExtractBookMetadata {
+	return func(ctx context.Context, req dto.ExtractBookMetadataRequest) error {
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.PDF, validation.When(req.EPUB == "", validation.Required), is.ASCII, validation.Length(10, 150),
+				validation.Match(regexp.MustCompile(`(?i)\.pdf$`)), validation.By(doesFileExist)),
+			validation.Field(&req.EPUB, is.ASCII, validation.Length(10, 150),
+				validation.Match(regexp.MustCompile(`(?i)\.epub$`)), validation.By(doesFileExist)),
+		)
+	}
+}
+
+func ValidateConfirmBookMetadata(storage repository.Storage) book.ValidateConfirmBookMetadata {
+	return func(ctx context.Context, req dto.ConfirmBookMetadataRequest) error {
+		b := &req.Proposal.Book
+
+		if err := validateBookDetails(b); err != nil {
+			return err
+		}
+
+		if errAuthors := validation.ValidateStruct(b,
+			validation.Field(&b.Authors, validation.Required),
+		); errAuthors != nil {
+			return errAuthors
+		}
+
+		// entities without an ID are created on confirmation, so they need a name instead
+		for i := range b.Authors {
+			a := &b.Authors[i]
+			if errAuthor := validation.ValidateStruct(a,
+				validation.Field(&a.ID, validation.When(a.ID != 0, validation.By(doesAuthorExist(ctx, storage)))),
+				validation.Field(&a.Name, validation.When(a.ID == 0, validation.Required, validation.Length(4, 100))),
+			); errAuthor != nil {
+				return errAuthor
+			}
+		}
+
+		// with the rules of AddTopic
+		for i := range b.Topics {
+			t := &b.Topics[i]
+			if errTopic := validation.ValidateStruct(t,
+				validation.Field(&t.ID, validation.When(t.ID != 0, validation.By(doesTopicExist(ctx, storage)))),
+				validation.Field(&t.Name, validation.When(t.ID == 0, validation.Required, is.Alpha, validation.Length(2, 30))),
+			); errTopic != nil {
+				return errTopic
+			}
+		}
+
+		if errPub := validation.ValidateStruct(&b.Publisher,
+			validation.Field(&b.Publisher.ID, validation.When(b.Publisher.ID != 0, validation.By(doesPublisherExist(ctx, storage)))),
+			validation.Field(&b.Publisher.Name, validation.When(b.Publisher.ID == 0, validation.Required, validation.Length(1, 100))),
+		); errPub != nil {
+			return errPub
+		}
+
+		if errLang := validation.ValidateStruct(&b.Language,
+			validation.Field(&b.Language.ID, validation.When(b.Language.ID != 0, validation.By(doesLangExist(ctx, storage)))),
+			validation.Field(&b.Language.Code, validation.When(b.Language.ID == 0, validation.Required, is.Alpha, validation.Length(2, 2))),
+		); errLang != nil {
+			return errLang
+		}
+
+		return nil
+	}
+}
+
 func ValidateGetBook(storage repository.Storage) book.ValidateGetBook {
 	return func(ctx context.Context, req dto.GetBookRequest) error {
 		return validation.ValidateStruct(&req,
@@ -274,6 +467,7 @@ func ValidateEditBook(storage repository.Storage) book.ValidateEditBook {
 			validation.Field(&req.Book.Description, is.ASCII, validation.Length(0, 500)),
 			validation.Field(&req.Book.Year, validation.Required, validation.Date("2006")),
 			validation.Field(&req.Book.CreationDate, validation.Required, validation.Date("2006-01-02 15:04:05")),
+			validation.Field(&req.Book.ReleaseDate, validation.Date("2006-01-02")),
 			validation.Field(&req.Book.CoverFront, validation.Required, is.ASCII, validation.Length(10, 150)),
 			validation.Field(&req.Book.CoverBack, validation.Required, is.ASCII, validation.Length(10, 150)),
 		); errBook != nil {
@@ -317,6 +511,15 @@ func ValidateEditBook(storage repository.Storage) book.ValidateEditBook {
 	}
 }
 
+func ValidateSetAvailabilityOverride(storage repository.Storage) book.ValidateSetAvailabilityOverride {
+	return func(ctx context.Context, req dto.SetAvailabilityOverrideRequest) error {
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.BookID, validation.Required, validation.By(doesBookExist(ctx, storage))),
+			validation.Field(&req.Override, validation.In(overrides()...)),
+		)
+	}
+}
+
 func ValidateSetBookDiscount(storage repository.Storage) book.ValidateSetBookDiscount {
 	return func(ctx context.Context, req dto.SetBookDiscountRequest) error {
 		return validation.ValidateStruct(&req,
@@ -359,6 +562,14 @@ func ValidateGetLangBooks(storage repository.Storage) book.ValidateGetLangBooks
 	}
 }
 
+func ValidateSearchBooks(storage repository.Storage) book.ValidateSearchBooks {
+	return func(ctx context.Context, req dto.SearchBooksRequest) error {
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.Query, validation.Required, validation.RuneLength(2, 100)),
+		)
+	}
+}
+
 func ValidateDeleteBook(storage repository.Storage) book.ValidateDeleteBook {
 	return func(ctx context.Context, req dto.DeleteBookRequest) error {
 		return validation.ValidateStruct(&req,
@@ -375,14 +586,520 @@ func ValidateGetUserDigitalBooks(storage repository.Storage) book.ValidateGetUse
 	}
 }
 
+func ValidateImportCatalog(storage repository.Storage) book.ValidateImportCatalog {
+	return func(ctx context.Context, req dto.ImportCatalogRequest) error {
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.Format, validation.Required, validation.In(bookEntity.CatalogCSV, bookEntity.CatalogONIX)),
+		)
+	}
+}
+
+func ValidateGetImportJob(storage repository.Storage) book.ValidateGetImportJob {
+	return func(ctx context.Context, req dto.GetImportJobRequest) error {
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.JobID, validation.Required, validation.By(doesImportJobExist(ctx, storage))),
+		)
+	}
+}
+
+func ValidateExportCatalog(storage repository.Storage) book.ValidateExportCatalog {
+	return func(ctx context.Context, req dto.ExportCatalogRequest) error {
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.Format, validation.Required, validation.In(bookEntity.CatalogCSV, bookEntity.CatalogONIX)),
+		)
+	}
+}
+
+func ValidateCreateDownloadLinks(storage repository.Storage) book.ValidateCreateDownloadLinks {
+	return func(ctx context.Context, req dto.CreateDownloadLinksRequest) error {
+		return validation.ValidateStruct(&req,
+			// trashed books stay downloadable for the users who bought them
+			validation.Field(&req.BookID, validation.Required,
+				validation.By(doesUserAccessBook(ctx, storage, req.UserID))),
+		)
+	}
+}
+
 func ValidateDownloadBook(storage repository.Storage) book.ValidateDownloadBook {
 	return func(ctx context.Context, req dto.DownloadBookRequest) error {
 		return validation.ValidateStruct(&req,
+			validation.Field(&req.UserID, validation.Required, is.UUIDv4),
+			// trashed books stay downloadable for the users who bought them
 			validation.Field(&req.BookID, validation.Required,
-				validation.By(doesBookExist(ctx, storage)),
 				validation.By(doesUserAccessBook(ctx, storage, req.UserID))),
 
-			validation.Field(&req.Path, is.ASCII, validation.Length(10, 150)),
+			validation.Field(&req.Format, validation.Required, validation.In(formats()...)),
+		)
+	}
+}
+
+func ValidateSetDownloadLimit(storage repository.Storage) book.ValidateSetDownloadLimit {
+	return func(ctx context.Context, req dto.SetDownloadLimitRequest) error {
+		return validation.ValidateStruct(&req.Limit,
+			validation.Field(&req.Limit.BookID, validation.Required, validation.By(doesBookExist(ctx, storage))),
+			validation.Field(&req.Limit.UserID, is.UUIDv4),
+		)
+	}
+}
+
+func ValidateGetUserDownloads(storage repository.Storage) book.ValidateGetUserDownloads {
+	return func(ctx context.Context, req dto.GetUserDownloadsRequest) error {
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.UserID, validation.Required, is.UUIDv4, validation.By(doesUserExist(ctx, storage))),
+		)
+	}
+}
+
+func doesWorkExist(ctx context.Context, repo book.ValidatorRepo) validation.RuleFunc {
+	return func(value interface{}) error {
+		workID := value.(uint)
+
+		ok, err := repo.DoesWorkExist(ctx, workID)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			return errors.New("work does not exist")
+		}
+
+		return nil
+	}
+}
+
+func doesSeriesExist(ctx context.Context, repo book.ValidatorRepo) validation.RuleFunc {
+	return func(value interface{}) error {
+		seriesID := value.(uint)
+
+		ok, err := repo.DoesSeriesExist(ctx, seriesID)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			return errors.New("series does not exist")
+		}
+
+		return nil
+	}
+}
+
+func ValidateAddSeries(storage repository.Storage) book.ValidateAddSeries {
+	return func(ctx context.Context, req dto.AddSeriesRequest) error {
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.Name, validation.Required, validation.RuneLength(1, 100)),
+		)
+	}
+}
+
+func ValidateGetSeries(storage repository.Storage) book.ValidateGetSeries {
+	return func(ctx context.Context, req dto.GetSeriesRequest) error {
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.SeriesID, validation.Required, validation.By(doesSeriesExist(ctx, storage))),
+		)
+	}
+}
+
+func ValidateDeleteSeries(storage repository.Storage) book.ValidateDeleteSeries {
+	return func(ctx context.Context, req dto.DeleteSeriesRequest) error {
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.SeriesID, validation.Required, validation.By(doesSeriesExist(ctx, storage))),
+		)
+	}
+}
+
+func validateWork(ctx context.Context, storage repository.Storage, w *bookEntity.Work) error {
+	return validation.ValidateStruct(w,
+		validation.Field(&w.Title, validation.Required, validation.RuneLength(1, 100)),
+		validation.Field(&w.Description, validation.RuneLength(0, 500)),
+		validation.Field(&w.SeriesID, validation.When(w.SeriesID != 0, validation.By(doesSeriesExist(ctx, storage)))),
+		validation.Field(&w.SeriesNumber, validation.When(w.SeriesID == 0, validation.Empty.Error("series number needs a series"))),
+	)
+}
+
+func ValidateAddWork(storage repository.Storage) book.ValidateAddWork {
+	return func(ctx context.Context, req dto.AddWorkRequest) error {
+		return validateWork(ctx, storage, &req.Work)
+	}
+}
+
+func ValidateEditWork(storage repository.Storage) book.ValidateEditWork {
+	return func(ctx context.Context, req dto.EditWorkRequest) error {
+		if err := validation.ValidateStruct(&req.Work,
+			validation.Field(&req.Work.ID, validation.Required, validation.By(doesWorkExist(ctx, storage))),
+		); err != nil {
+			return err
+		}
+
+		return validateWork(ctx, storage, &req.Work)
+	}
+}
+
+func ValidateGetWork(storage repository.Storage) book.ValidateGetWork {
+	return func(ctx context.Context, req dto.GetWorkRequest) error {
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.WorkID, validation.Required, validation.By(doesWorkExist(ctx, storage))),
+		)
+	}
+}
+
+func ValidateDeleteWork(storage repository.Storage) book.ValidateDeleteWork {
+	return func(ctx context.Context, req dto.DeleteWorkRequest) error {
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.WorkID, validation.Required, validation.By(doesWorkExist(ctx, storage))),
+		)
+	}
+}
+
+func ValidateSetBookWork(storage repository.Storage) book.ValidateSetBookWork {
+	return func(ctx context.Context, req dto.SetBookWorkRequest) error {
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.BookID, validation.Required, validation.By(doesBookExist(ctx, storage))),
+			validation.Field(&req.WorkID, validation.When(req.WorkID != 0, validation.By(doesWorkExist(ctx, storage)))),
+			validation.Field(&req.Edition, validation.RuneLength(0, 50)),
+		)
+	}
+}
+
+func ValidateAddContributor(storage repository.Storage) book.ValidateAddContributor {
+	return func(ctx context.Context, req dto.AddContributorRequest) error {
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.BookID, validation.Required, validation.By(doesBookExist(ctx, storage))),
+			validation.Field(&req.AuthorID, validation.Required, validation.By(doesAuthorExist(ctx, storage))),
+			validation.Field(&req.Role, validation.Required, validation.In(roles()...)),
+		)
+	}
+}
+
+func ValidateRemoveContributor(storage repository.Storage) book.ValidateRemoveContributor {
+	return func(ctx context.Context, req dto.RemoveContributorRequest) error {
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.BookID, validation.Required, validation.By(doesBookExist(ctx, storage))),
+			validation.Field(&req.AuthorID, validation.Required, validation.By(doesAuthorExist(ctx, storage))),
+			validation.Field(&req.Role, validation.Required, validation.In(roles()...)),
+		)
+	}
+}
+
+// doesRecordExist checks an author, publisher or topic depending on the merge kind
+func doesRecordExist(ctx context.Context, repo book.ValidatorRepo, kind string) validation.RuleFunc {
+	return func(value interface{}) error {
+		id := value.(uint)
+
+		var ok bool
+		var err error
+
+		switch kind {
+		case bookEntity.KindAuthor:
+			ok, err = repo.DoesAuthorExist(ctx, id)
+		case bookEntity.KindPublisher:
+			ok, err = repo.DoesPublisherExist(ctx, id)
+		case bookEntity.KindTopic:
+			ok, err = repo.DoesTopicExist(ctx, id)
+		}
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			return errors.New(kind + " does not exist")
+		}
+
+		return nil
+	}
+}
+
+func ValidateGetDuplicates(storage repository.Storage) book.ValidateGetDuplicates {
+	return func(ctx context.Context, req dto.GetDuplicatesRequest) error {
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.Kind, validation.Required, validation.In(mergeKinds()...)),
+		)
+	}
+}
+
+func ValidateMerge(storage repository.Storage) book.ValidateMerge {
+	return func(ctx context.Context, req dto.MergeRequest) error {
+		if err := validation.ValidateStruct(&req,
+			validation.Field(&req.Kind, validation.Required, validation.In(mergeKinds()...)),
+		); err != nil {
+			return err
+		}
+
+		if err := validation.ValidateStruct(&req,
+			validation.Field(&req.FromID, validation.Required, validation.By(doesRecordExist(ctx, storage, req.Kind))),
+			validation.Field(&req.IntoID, validation.Required, validation.NotIn(req.FromID).Error("cannot merge a record into itself"),
+				validation.By(doesRecordExist(ctx, storage, req.Kind))),
+		); err != nil {
+			return err
+		}
+
+		// the children of a merged topic move to the target, which must not be one of them
+		if req.Kind == bookEntity.KindTopic {
+			return validation.ValidateStruct(&req,
+				validation.Field(&req.IntoID, validation.By(isNotDescendant(ctx, storage, req.FromID))),
+			)
+		}
+		return nil
+	}
+}
+
+func isTrashed(ctx context.Context, repo book.ValidatorRepo, kind string) validation.RuleFunc {
+	return func(value interface{}) error {
+		id := value.(uint)
+
+		ok, err := repo.IsTrashed(ctx, kind, id)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			return errors.New(kind + " does not exist in trash")
+		}
+		return nil
+	}
+}
+
+func ValidateGetTrash(storage repository.Storage) book.ValidateGetTrash {
+	return func(ctx context.Context, req dto.GetTrashRequest) error {
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.Kind, validation.Required, validation.In(trashKinds()...)),
+		)
+	}
+}
+
+func ValidateRestore(storage repository.Storage) book.ValidateRestore {
+	return func(ctx context.Context, req dto.RestoreRequest) error {
+		if err := validation.ValidateStruct(&req,
+			validation.Field(&req.Kind, validation.Required, validation.In(trashKinds()...)),
+		); err != nil {
+			return err
+		}
+
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.RecordID, validation.Required, validation.By(isTrashed(ctx, storage, req.Kind))),
+		)
+	}
+}
+
+func ValidatePurge(storage repository.Storage) book.ValidatePurge {
+	return func(ctx context.Context, req dto.PurgeRequest) error {
+		if err := validation.ValidateStruct(&req,
+			validation.Field(&req.Kind, validation.Required, validation.In(trashKinds()...)),
+		); err != nil {
+			return err
+		}
+
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.RecordID, validation.Required, validation.By(isTrashed(ctx, storage, req.Kind))),
+		)
+	}
+}
+
+func ValidateReceiveStock(storage repository.Storage) book.ValidateReceiveStock {
+	return func(ctx context.Context, req dto.ReceiveStockRequest) error {
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.BookID, validation.Required, validation.By(doesBookExist(ctx, storage))),
+			validation.Field(&req.Quantity, validation.Required, validation.Max(uint(100000))),
+			validation.Field(&req.Reason, validation.Length(0, 200)),
+		)
+	}
+}
+
+func ValidateAdjustStock(storage repository.Storage) book.ValidateAdjustStock {
+	return func(ctx context.Context, req dto.AdjustStockRequest) error {
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.BookID, validation.Required, validation.By(doesBookExist(ctx, storage))),
+			validation.Field(&req.Type, validation.Required, validation.In(adjustmentTypes()...)),
+			validation.Field(&req.Quantity, validation.Required, validation.Min(-100000), validation.Max(100000)),
+			validation.Field(&req.Reason, validation.Required, validation.Length(3, 200)),
+		)
+	}
+}
+
+func ValidateStocktake(storage repository.Storage) book.ValidateStocktake {
+	return func(ctx context.Context, req dto.StocktakeRequest) error {
+		if err := validation.ValidateStruct(&req,
+			validation.Field(&req.Counts, validation.Required),
+			validation.Field(&req.Reason, validation.Length(0, 200)),
+		); err != nil {
+			return err
+		}
+
+		for i := range req.Counts {
+			count := &req.Counts[i]
+			if err := validation.ValidateStruct(count,
+				validation.Field(&count.BookID, validation.Required, validation.By(doesBookExist(ctx, storage))),
+				validation.Field(&count.Counted, validation.Max(uint(100000))),
+			); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+func ValidateGetMovements(storage repository.Storage) book.ValidateGetMovements {
+	return func(ctx context.Context, req dto.GetMovementsRequest) error {
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.BookID, validation.Required, validation.By(doesBookExist(ctx, storage))),
+		)
+	}
+}
+
+func ValidateSetReorderThreshold(storage repository.Storage) book.ValidateSetReorderThreshold {
+	return func(ctx context.Context, req dto.SetReorderThresholdRequest) error {
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.BookID, validation.Required, validation.By(doesBookExist(ctx, storage))),
+			validation.Field(&req.Threshold, validation.Max(uint(100000))),
 		)
 	}
 }
+
+func doesBoxSetExist(ctx context.Context, repo book.ValidatorRepo) validation.RuleFunc {
+	return func(value interface{}) error {
+		boxSetID := value.(uint)
+
+		ok, err := repo.DoesBoxSetExist(ctx, boxSetID)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			return errors.New("box set does not exist")
+		}
+
+		return nil
+	}
+}
+
+func isBoxSetNotInOpenOrders(ctx context.Context, repo book.ValidatorRepo) validation.RuleFunc {
+	return func(value interface{}) error {
+		boxSetID := value.(uint)
+
+		inCarts, err := repo.IsBoxSetInOpenOrders(ctx, boxSetID)
+		if err != nil {
+			return err
+		}
+
+		if inCarts {
+			return errors.New("box set is in open orders")
+		}
+
+		return nil
+	}
+}
+
+// validateBoxSet checks the fields of a box set, it's made of at least two different books
+// and sold either at a fixed price or at a percentage off its books
+func validateBoxSet(ctx context.Context, storage repository.Storage, s *bookEntity.BoxSet) error {
+
+	if err := validation.ValidateStruct(s,
+		validation.Field(&s.Title, validation.Required, validation.RuneLength(1, 255)),
+		validation.Field(&s.Price, validation.When(s.Percentage == 0, validation.Required.Error("price or percentage is required"))),
+		validation.Field(&s.Percentage, validation.Max(uint(100)), validation.When(s.Price != 0, validation.Empty.Error("must be blank with a fixed price"))),
+		validation.Field(&s.BookIDs, validation.Required, validation.Length(2, 0), validation.By(isUnique)),
+	); err != nil {
+		return err
+	}
+
+	for i := range s.BookIDs {
+		if err := validation.Validate(s.BookIDs[i], validation.By(doesBookExist(ctx, storage))); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func isUnique(value interface{}) error {
+	seen := map[uint]bool{}
+	for _, id := range value.([]uint) {
+		if seen[id] {
+			return errors.New("must not repeat a book")
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+func ValidateAddBoxSet(storage repository.Storage) book.ValidateAddBoxSet {
+	return func(ctx context.Context, req dto.AddBoxSetRequest) error {
+		return validateBoxSet(ctx, storage, &req.BoxSet)
+	}
+}
+
+func ValidateEditBoxSet(storage repository.Storage) book.ValidateEditBoxSet {
+	return func(ctx context.Context, req dto.EditBoxSetRequest) error {
+		if err := validation.ValidateStruct(&req.BoxSet,
+			validation.Field(&req.BoxSet.ID, validation.Required, validation.By(doesBoxSetExist(ctx, storage))),
+		); err != nil {
+			return err
+		}
+		return validateBoxSet(ctx, storage, &req.BoxSet)
+	}
+}
+
+func ValidateDeleteBoxSet(storage repository.Storage) book.ValidateDeleteBoxSet {
+	return func(ctx context.Context, req dto.DeleteBoxSetRequest) error {
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.BoxSetID, validation.Required, validation.By(doesBoxSetExist(ctx, storage)), validation.By(isBoxSetNotInOpenOrders(ctx, storage))),
+		)
+	}
+}
+
+func ValidateGetBoxSet(storage repository.Storage) book.ValidateGetBoxSet {
+	return func(ctx context.Context, req dto.GetBoxSetRequest) error {
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.BoxSetID, validation.Required, validation.By(doesBoxSetExist(ctx, storage))),
+		)
+	}
+}
+
+func adjustmentTypes() []interface{} {
+	t := make([]interface{}, len(bookEntity.AdjustmentTypes))
+	for i, movement := range bookEntity.AdjustmentTypes {
+		t[i] = movement
+	}
+	return t
+}
+
+func overrides() []interface{} {
+	o := make([]interface{}, len(bookEntity.Overrides))
+	for i, override := range bookEntity.Overrides {
+		o[i] = override
+	}
+	return o
+}
+
+func trashKinds() []interface{} {
+	k := make([]interface{}, len(bookEntity.TrashKinds))
+	for i, kind := range bookEntity.TrashKinds {
+		k[i] = kind
+	}
+	return k
+}
+
+func mergeKinds() []interface{} {
+	k := make([]interface{}, len(bookEntity.MergeKinds))
+	for i, kind := range bookEntity.MergeKinds {
+		k[i] = kind
+	}
+	return k
+}
+
+func roles() []interface{} {
+	r := make([]interface{}, len(bookEntity.Roles))
+	for i, role := range bookEntity.Roles {
+		r[i] = role
+	}
+	return r
+}
+
+func formats() []interface{} {
+	f := make([]interface{}, len(bookEntity.Formats))
+	for i, format := range bookEntity.Formats {
+		f[i] = format
+	}
+	return f
+}
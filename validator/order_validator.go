@@ -3,6 +3,7 @@ package validator
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 
@@ -62,6 +63,40 @@ func doesPromoCodeExist(ctx context.Context, repo order.ValidatorRepo, userID st
 	}
 }
 
+func doesPromoBatchExist(ctx context.Context, repo order.ValidatorRepo) validation.RuleFunc {
+	return func(value interface{}) error {
+		batchID := value.(uint)
+
+		exists, err := repo.DoesPromoBatchExist(ctx, batchID)
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			return errors.New("promo batch does not exist")
+		}
+		return nil
+	}
+}
+
+// isPromoCodeFree keeps public codes unique, a code given to users can repeat
+// as long as no public code has it
+func isPromoCodeFree(ctx context.Context, repo order.ValidatorRepo, public bool) validation.RuleFunc {
+	return func(value interface{}) error {
+		promoCode := value.(string)
+
+		taken, err := repo.IsPromoCodeTaken(ctx, promoCode, public)
+		if err != nil {
+			return err
+		}
+
+		if taken {
+			return errors.New("promo code already exists")
+		}
+		return nil
+	}
+}
+
 func doesOrderExist(ctx context.Context, repo order.ValidatorRepo) validation.RuleFunc {
 	return func(value interface{}) error {
 		orderID := value.(uint)
@@ -110,7 +145,7 @@ func isValidDate(ctx context.Context, repo order.ValidatorRepo) validation.RuleF
 func isValidStatus(ctx context.Context, repo order.ValidatorRepo) validation.RuleFunc {
 	return func(value interface{}) error {
 
-		if status := value.(uint); (status != eo.StatusCreated) && (status != eo.StatusPaid) && (status != eo.StatusVerified) && (status != eo.StatusShipped) {
+		if status := value.(uint); (status != eo.StatusCreated) && (status != eo.StatusPaid) && (status != eo.StatusVerified) && (status != eo.StatusShipped) && (status != eo.StatusCancelled) {
 			return errors.New("invalid order status")
 		}
 
@@ -133,6 +168,26 @@ func checkStatusForSTN(ctx context.Context, repo order.Repository, orderID uint)
 	}
 }
 
+// checkPreordersForShipping keeps orders with queued pre-orders out of the shipping
+func checkPreordersForShipping(ctx context.Context, repo order.Repository, orderID uint) validation.RuleFunc {
+	return func(value interface{}) error {
+
+		if status := value.(uint); status != eo.StatusShipped {
+			return nil
+		}
+
+		queued, err := repo.HasQueuedPreorders(ctx, orderID)
+		if err != nil {
+			return err
+		}
+
+		if queued {
+			return errors.New("order has pre-ordered items waiting for release")
+		}
+		return nil
+	}
+}
+
 func ValidateAddItem(storage repository.Storage) order.ValidateAddItem {
 	return func(ctx context.Context, req dto.AddItemRequest) error {
 
@@ -143,8 +198,9 @@ func ValidateAddItem(storage repository.Storage) order.ValidateAddItem {
 		}
 
 		if errItem := validation.ValidateStruct(&req.Item,
-			validation.Field(&req.Item.BookID, validation.Required, validation.By(doesBookExist(ctx, storage))),
-			validation.Field(&req.Item.Type, validation.NotNil, validation.Min(uint(0)), validation.Max(uint(2))),
+			validation.Field(&req.Item.BookID, validation.When(req.Item.Type != eo.BoxSet, validation.Required, validation.By(doesBookExist(ctx, storage)))),
+			validation.Field(&req.Item.Type, validation.NotNil, validation.Min(uint(0)), validation.Max(eo.BoxSet)),
+			validation.Field(&req.Item.BoxSetID, validation.When(req.Item.Type == eo.BoxSet, validation.Required, validation.By(doesBoxSetExist(ctx, storage)))),
 			validation.Field(&req.Item.Quantity, validation.Required, validation.Min(uint(0))),
 		); errItem != nil {
 			return errItem
@@ -200,23 +256,61 @@ func ValidateRemoveItem(storage repository.Storage) order.ValidateRemoveItem {
 func ValidateCreatePromoCode(storage repository.Storage) order.ValidateCreatePromoCode {
 	return func(ctx context.Context, req dto.CreatePromoCodeRequest) error {
 
+		// a public code is for anyone, the others are given to a user
 		if errUserID := validation.ValidateStruct(&req,
-			validation.Field(&req.UserID, is.UUIDv4, validation.By(doesUserExist(ctx, storage))),
+			validation.Field(&req.UserID, validation.When(!req.Promo.Public, is.UUIDv4, validation.By(doesUserExist(ctx, storage)))),
 		); errUserID != nil {
 			return errUserID
 		}
 
-		if errPromo := validation.ValidateStruct(&req.Promo,
-			validation.Field(&req.Promo.Code, validation.Required, is.Alphanumeric, validation.Length(3, 20)),
-			validation.Field(&req.Promo.Expiration, validation.By(isValidDate(ctx, storage))),
-			validation.Field(&req.Promo.Limit, validation.Required, validation.Min(uint(0))),
-			validation.Field(&req.Promo.Percentage, validation.Required, validation.Min(uint(0)), validation.Max(uint(100))),
-			validation.Field(&req.Promo.MaxPrice, validation.Min(uint(0))),
-		); errPromo != nil {
-			return errPromo
+		if errCode := validation.Validate(req.Promo.Code,
+			validation.Required, is.Alphanumeric, validation.Length(3, 20), validation.By(isPromoCodeFree(ctx, storage, req.Promo.Public)),
+		); errCode != nil {
+			return fmt.Errorf("code: %w", errCode)
 		}
 
-		return nil
+		return validatePromoRules(ctx, storage, &req.Promo)
+	}
+}
+
+// validatePromoRules checks the discount of a promo, everything but its code
+func validatePromoRules(ctx context.Context, storage repository.Storage, p *eo.Promo) error {
+
+	if errPromo := validation.ValidateStruct(p,
+		validation.Field(&p.Expiration, validation.By(isValidDate(ctx, storage))),
+		validation.Field(&p.Limit, validation.Required, validation.Min(uint(0))),
+		validation.Field(&p.Percentage, validation.When(p.Amount == 0 && !p.FreeShipping, validation.Required), validation.Max(uint(100)),
+			validation.When(p.Amount != 0, validation.Empty.Error("must be blank with a fixed amount"))),
+		validation.Field(&p.MaxPrice, validation.Min(uint(0))),
+		validation.Field(&p.Scope, validation.In(targets()...)),
+		validation.Field(&p.ScopeIDs, validation.When(p.Scope != "", validation.Required).Else(validation.Empty)),
+		validation.Field(&p.Stacking, validation.In(stackings()...)),
+	); errPromo != nil {
+		return errPromo
+	}
+
+	for i := range p.ScopeIDs {
+		if err := validation.Validate(p.ScopeIDs[i], validation.By(doesTargetExist(ctx, storage, p.Scope))); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func ValidateCreatePromoBatch(storage repository.Storage) order.ValidateCreatePromoBatch {
+	return func(ctx context.Context, req dto.CreatePromoBatchRequest) error {
+
+		b := &req.Batch
+		if errBatch := validation.ValidateStruct(b,
+			validation.Field(&b.Name, validation.Required, validation.Length(1, 100)),
+			validation.Field(&b.Prefix, is.Alphanumeric, validation.Length(0, 8)),
+			validation.Field(&b.Count, validation.Required, validation.Max(uint(10000))),
+		); errBatch != nil {
+			return errBatch
+		}
+
+		return validatePromoRules(ctx, storage, &b.Promo)
 	}
 }
 
@@ -228,11 +322,28 @@ func ValidateDeletePromoCode(storage repository.Storage) order.ValidateDeletePro
 	}
 }
 
+func ValidateGetPromoBatch(storage repository.Storage) order.ValidateGetPromoBatch {
+	return func(ctx context.Context, req dto.GetPromoBatchRequest) error {
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.BatchID, validation.Required, validation.By(doesPromoBatchExist(ctx, storage))),
+		)
+	}
+}
+
+func ValidateGetPromoRedemptions(storage repository.Storage) order.ValidateGetPromoRedemptions {
+	return func(ctx context.Context, req dto.GetPromoRedemptionsRequest) error {
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.PromoID, validation.Required, validation.By(doesPromoExist(ctx, storage))),
+		)
+	}
+}
+
 func ValidateSetOrderStatus(storage repository.Storage) order.ValidateSetOrderStatus {
 	return func(ctx context.Context, req dto.SetOrderStatusRequest) error {
 		return validation.ValidateStruct(&req,
 			validation.Field(&req.OrderID, validation.Required, validation.By(doesOrderExist(ctx, storage))),
-			validation.Field(&req.Status, validation.Required, validation.By(isValidStatus(ctx, storage))),
+			validation.Field(&req.Status, validation.Required, validation.By(isValidStatus(ctx, storage)),
+				validation.By(checkPreordersForShipping(ctx, storage, req.OrderID))),
 		)
 	}
 }
@@ -356,3 +467,55 @@ func ValidateZarinpalPayment(storage repository.Storage) order.ValidateSetOrderA
 		)
 	}
 }
+
+func ValidateSetItemGift(storage repository.Storage) order.ValidateSetItemGift {
+	return func(ctx context.Context, req dto.SetItemGiftRequest) error {
+		if err := validation.ValidateStruct(&req,
+			validation.Field(&req.UserID, is.UUIDv4),
+			validation.Field(&req.OrderID, validation.Required, validation.By(doesOrderOpen(ctx, storage))),
+			validation.Field(&req.ItemID, validation.Required, validation.By(doesItemExist(ctx, storage))),
+		); err != nil {
+			return err
+		}
+		return validation.ValidateStruct(&req.Gift,
+			validation.Field(&req.Gift.RecipientEmail, validation.Required, is.Email, validation.Length(1, 100)),
+			validation.Field(&req.Gift.Message, validation.Length(0, 500)),
+			validation.Field(&req.Gift.DeliverAt, validation.Date("2006-01-02 15:04:05")),
+		)
+	}
+}
+
+func ValidateRemoveItemGift(storage repository.Storage) order.ValidateRemoveItemGift {
+	return func(ctx context.Context, req dto.RemoveItemGiftRequest) error {
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.UserID, is.UUIDv4),
+			validation.Field(&req.OrderID, validation.Required, validation.By(doesOrderOpen(ctx, storage))),
+			validation.Field(&req.ItemID, validation.Required, validation.By(doesItemExist(ctx, storage))),
+		)
+	}
+}
+
+func ValidateGetGift(storage repository.Storage) order.ValidateGetGift {
+	return func(ctx context.Context, req dto.GetGiftRequest) error {
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.Token, validation.Required, is.Hexadecimal, validation.Length(32, 32)),
+		)
+	}
+}
+
+func ValidateClaimGift(storage repository.Storage) order.ValidateClaimGift {
+	return func(ctx context.Context, req dto.ClaimGiftRequest) error {
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.Token, validation.Required, is.Hexadecimal, validation.Length(32, 32)),
+			validation.Field(&req.UserID, is.UUIDv4, validation.By(doesUserExist(ctx, storage))),
+		)
+	}
+}
+
+func stackings() []interface{} {
+	s := make([]interface{}, len(eo.Stackings))
+	for i, stacking := range eo.Stackings {
+		s[i] = stacking
+	}
+	return s
+}
@@ -0,0 +1,62 @@
+package validator
+
+import (
+	"context"
+	"errors"
+
+	"github.com/XBozorg/bookstore/adapter/repository"
+	"github.com/XBozorg/bookstore/dto"
+	"github.com/XBozorg/bookstore/usecase/points"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+)
+
+func doesTopicMultiplierExist(ctx context.Context, repo points.ValidatorRepo) validation.RuleFunc {
+	return func(value interface{}) error {
+		topicID := value.(uint)
+
+		ok, err := repo.DoesTopicMultiplierExist(ctx, topicID)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			return errors.New("topic multiplier does not exist")
+		}
+		return nil
+	}
+}
+
+func ValidateGetPoints(storage repository.Storage) points.ValidateGetPoints {
+	return func(ctx context.Context, req dto.GetPointsRequest) error {
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.UserID, is.UUIDv4, validation.By(doesUserExist(ctx, storage))),
+		)
+	}
+}
+
+func ValidateSetOrderPoints(storage repository.Storage) points.ValidateSetOrderPoints {
+	return func(ctx context.Context, req dto.SetOrderPointsRequest) error {
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.UserID, is.UUIDv4),
+			validation.Field(&req.OrderID, validation.Required, validation.By(doesUserOrderOpen(ctx, storage, req.UserID))),
+		)
+	}
+}
+
+func ValidateSetTopicMultiplier(storage repository.Storage) points.ValidateSetTopicMultiplier {
+	return func(ctx context.Context, req dto.SetTopicMultiplierRequest) error {
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.TopicID, validation.Required, validation.By(doesTopicExist(ctx, storage))),
+			validation.Field(&req.Multiplier, validation.Required, validation.Max(1000)),
+		)
+	}
+}
+
+func ValidateDeleteTopicMultiplier(storage repository.Storage) points.ValidateDeleteTopicMultiplier {
+	return func(ctx context.Context, req dto.DeleteTopicMultiplierRequest) error {
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.TopicID, validation.Required, validation.By(doesTopicMultiplierExist(ctx, storage))),
+		)
+	}
+}
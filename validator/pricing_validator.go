@@ -0,0 +1,222 @@
+package validator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/XBozorg/bookstore/adapter/repository"
+	"github.com/XBozorg/bookstore/dto"
+	pricingEntity "github.com/XBozorg/bookstore/entity/pricing"
+	"github.com/XBozorg/bookstore/usecase/pricing"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+func doesCampaignExist(ctx context.Context, repo pricing.ValidatorRepo) validation.RuleFunc {
+	return func(value interface{}) error {
+		campaignID := value.(uint)
+
+		ok, err := repo.DoesCampaignExist(ctx, campaignID)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			return errors.New("campaign does not exist")
+		}
+		return nil
+	}
+}
+
+func doesBundleRuleExist(ctx context.Context, repo pricing.ValidatorRepo, scope string) validation.RuleFunc {
+	return func(value interface{}) error {
+		scopeID := value.(uint)
+
+		ok, err := repo.DoesBundleRuleExist(ctx, scope, scopeID)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			return errors.New("bundle rule does not exist")
+		}
+		return nil
+	}
+}
+
+func isAfter(start string) validation.RuleFunc {
+	return func(value interface{}) error {
+		if end := value.(string); end <= start {
+			return errors.New("must be after the start")
+		}
+		return nil
+	}
+}
+
+// validateCampaign checks the fields of a campaign, the target IDs must exist as the target kind
+func validateCampaign(ctx context.Context, storage repository.Storage, c *pricingEntity.Campaign) error {
+
+	if err := validation.ValidateStruct(c,
+		validation.Field(&c.Name, validation.Required, validation.Length(1, 100)),
+		validation.Field(&c.Target, validation.Required, validation.In(targets()...)),
+		validation.Field(&c.TargetIDs, validation.Required),
+		validation.Field(&c.Format, validation.Required, validation.In(pricingFormats()...)),
+		validation.Field(&c.Percentage, validation.Required, validation.Max(uint(100))),
+		validation.Field(&c.Start, validation.Required, validation.Date("2006-01-02 15:04:05")),
+		validation.Field(&c.End, validation.Required, validation.Date("2006-01-02 15:04:05"), validation.By(isAfter(c.Start))),
+	); err != nil {
+		return err
+	}
+
+	for i := range c.TargetIDs {
+		if err := validation.Validate(c.TargetIDs[i], validation.By(doesTargetExist(ctx, storage, c.Target))); err != nil {
+			return fmt.Errorf("targetIDs: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// doesTargetExist checks an ID is a topic, publisher, author or book for the target
+func doesTargetExist(ctx context.Context, storage repository.Storage, target string) validation.RuleFunc {
+	switch target {
+	case pricingEntity.TargetTopic:
+		return doesTopicExist(ctx, storage)
+	case pricingEntity.TargetPublisher:
+		return doesPublisherExist(ctx, storage)
+	case pricingEntity.TargetAuthor:
+		return doesAuthorExist(ctx, storage)
+	default:
+		return doesBookExist(ctx, storage)
+	}
+}
+
+func ValidateAddCampaign(storage repository.Storage) pricing.ValidateAddCampaign {
+	return func(ctx context.Context, req dto.AddCampaignRequest) error {
+		return validateCampaign(ctx, storage, &req.Campaign)
+	}
+}
+
+func ValidateEditCampaign(storage repository.Storage) pricing.ValidateEditCampaign {
+	return func(ctx context.Context, req dto.EditCampaignRequest) error {
+		if err := validation.ValidateStruct(&req.Campaign,
+			validation.Field(&req.Campaign.ID, validation.Required, validation.By(doesCampaignExist(ctx, storage))),
+		); err != nil {
+			return err
+		}
+		return validateCampaign(ctx, storage, &req.Campaign)
+	}
+}
+
+func ValidateDeleteCampaign(storage repository.Storage) pricing.ValidateDeleteCampaign {
+	return func(ctx context.Context, req dto.DeleteCampaignRequest) error {
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.CampaignID, validation.Required, validation.By(doesCampaignExist(ctx, storage))),
+		)
+	}
+}
+
+func ValidateGetCampaign(storage repository.Storage) pricing.ValidateGetCampaign {
+	return func(ctx context.Context, req dto.GetCampaignRequest) error {
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.CampaignID, validation.Required, validation.By(doesCampaignExist(ctx, storage))),
+		)
+	}
+}
+
+func ValidatePreviewCampaign(storage repository.Storage) pricing.ValidatePreviewCampaign {
+	return func(ctx context.Context, req dto.PreviewCampaignRequest) error {
+		if err := validation.ValidateStruct(&req,
+			validation.Field(&req.At, validation.Date("2006-01-02 15:04:05")),
+		); err != nil {
+			return err
+		}
+		return validateCampaign(ctx, storage, &req.Campaign)
+	}
+}
+
+func ValidateGetPriceQuote(storage repository.Storage) pricing.ValidateGetPriceQuote {
+	return func(ctx context.Context, req dto.GetPriceQuoteRequest) error {
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.BookID, validation.Required, validation.By(doesBookExist(ctx, storage))),
+			validation.Field(&req.Display, validation.In(displays()...)),
+		)
+	}
+}
+
+// validateBundleScope checks the scope of a bundle rule, the global rule has no scope ID
+// and the publisher or book of the other scopes must exist
+func validateBundleScope(ctx context.Context, storage repository.Storage, scope *string, scopeID *uint) error {
+
+	if err := validation.Validate(*scope, validation.Required, validation.In(scopes()...)); err != nil {
+		return fmt.Errorf("scope: %w", err)
+	}
+
+	var rules []validation.Rule
+	switch *scope {
+	case pricingEntity.ScopeGlobal:
+		rules = []validation.Rule{validation.Empty}
+	case pricingEntity.ScopePublisher:
+		rules = []validation.Rule{validation.Required, validation.By(doesPublisherExist(ctx, storage))}
+	case pricingEntity.ScopeBook:
+		rules = []validation.Rule{validation.Required, validation.By(doesBookExist(ctx, storage))}
+	}
+
+	if err := validation.Validate(*scopeID, rules...); err != nil {
+		return fmt.Errorf("scopeID: %w", err)
+	}
+
+	return nil
+}
+
+func ValidateSetBundleRule(storage repository.Storage) pricing.ValidateSetBundleRule {
+	return func(ctx context.Context, req dto.SetBundleRuleRequest) error {
+		if err := validation.ValidateStruct(&req.Rule,
+			validation.Field(&req.Rule.Percentage, validation.Max(uint(100))),
+		); err != nil {
+			return err
+		}
+		return validateBundleScope(ctx, storage, &req.Rule.Scope, &req.Rule.ScopeID)
+	}
+}
+
+func ValidateDeleteBundleRule(storage repository.Storage) pricing.ValidateDeleteBundleRule {
+	return func(ctx context.Context, req dto.DeleteBundleRuleRequest) error {
+		if err := validation.Validate(req.Scope, validation.Required, validation.In(scopes()...)); err != nil {
+			return fmt.Errorf("scope: %w", err)
+		}
+		return validation.Validate(req.ScopeID, validation.By(doesBundleRuleExist(ctx, storage, req.Scope)))
+	}
+}
+
+func targets() []interface{} {
+	t := make([]interface{}, len(pricingEntity.Targets))
+	for i, target := range pricingEntity.Targets {
+		t[i] = target
+	}
+	return t
+}
+
+func pricingFormats() []interface{} {
+	f := make([]interface{}, len(pricingEntity.Formats))
+	for i, format := range pricingEntity.Formats {
+		f[i] = format
+	}
+	return f
+}
+
+func scopes() []interface{} {
+	s := make([]interface{}, len(pricingEntity.Scopes))
+	for i, scope := range pricingEntity.Scopes {
+		s[i] = scope
+	}
+	return s
+}
+
+func displays() []interface{} {
+	d := make([]interface{}, len(pricingEntity.Displays))
+	for i, display := range pricingEntity.Displays {
+		d[i] = display
+	}
+	return d
+}
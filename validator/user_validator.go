@@ -59,15 +59,33 @@ func doesAddressExist(ctx context.Context, repo user.ValidatorRepo) validation.R
 	}
 }
 
-func ValidateCreateUser(req dto.CreateUserRequest) error {
-	return validation.ValidateStruct(&req,
-		validation.Field(&req.Email, validation.Required, is.Email),
-		validation.Field(&req.Password, validation.Required, is.ASCII, validation.Length(6, 60)),
-		validation.Field(&req.Username, validation.Required, is.Alphanumeric, validation.Length(6, 40)),
-		validation.Field(&req.FirstName, validation.Required, is.Alpha, validation.Length(1, 80)),
-		validation.Field(&req.LastName, validation.Required, is.Alpha, validation.Length(1, 80)),
-	)
+func doesReferralCodeExist(ctx context.Context, repo user.ValidatorRepo) validation.RuleFunc {
+	return func(value interface{}) error {
+		code := value.(string)
 
+		ok, err := repo.DoesReferralCodeExist(ctx, code)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			return errors.New("referral code does not exist")
+		}
+		return nil
+	}
+}
+
+func ValidateCreateUser(storage repository.Storage) user.ValidateCreateUser {
+	return func(ctx context.Context, req dto.CreateUserRequest) error {
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.Email, validation.Required, is.Email),
+			validation.Field(&req.Password, validation.Required, is.ASCII, validation.Length(6, 60)),
+			validation.Field(&req.Username, validation.Required, is.Alphanumeric, validation.Length(6, 40)),
+			validation.Field(&req.FirstName, validation.Required, is.Alpha, validation.Length(1, 80)),
+			validation.Field(&req.LastName, validation.Required, is.Alpha, validation.Length(1, 80)),
+			validation.Field(&req.ReferralCode, validation.When(req.ReferralCode != "", is.Alphanumeric, validation.Length(8, 8), validation.By(doesReferralCodeExist(ctx, storage)))),
+		)
+	}
 }
 
 func ValidateGetUser(storage repository.Storage) user.ValidateGetUser {
@@ -190,3 +208,19 @@ func ValidateDeleteAddress(storage repository.Storage) user.ValidateDeleteAddres
 		)
 	}
 }
+
+func ValidateGetReferral(storage repository.Storage) user.ValidateGetReferral {
+	return func(ctx context.Context, req dto.GetReferralRequest) error {
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.UserID, is.UUIDv4, validation.By(doesUserExist(ctx, storage))),
+		)
+	}
+}
+
+func ValidateGetUserReferrals(storage repository.Storage) user.ValidateGetUserReferrals {
+	return func(ctx context.Context, req dto.GetUserReferralsRequest) error {
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.UserID, is.UUIDv4, validation.By(doesUserExist(ctx, storage))),
+		)
+	}
+}
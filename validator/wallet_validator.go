@@ -0,0 +1,140 @@
+package validator
+
+import (
+	"context"
+	"errors"
+
+	"github.com/XBozorg/bookstore/adapter/repository"
+	"github.com/XBozorg/bookstore/config"
+	"github.com/XBozorg/bookstore/dto"
+	"github.com/XBozorg/bookstore/usecase/wallet"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+)
+
+func doesGiftCardExist(ctx context.Context, repo wallet.ValidatorRepo, userID string) validation.RuleFunc {
+	return func(value interface{}) error {
+		giftCardID := value.(uint)
+
+		ok, err := repo.DoesGiftCardExist(ctx, giftCardID, userID)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			return errors.New("gift card does not exist")
+		}
+		return nil
+	}
+}
+
+// doesUserOrderOpen checks the order is the open order of the user and has something to pay for
+func doesUserOrderOpen(ctx context.Context, repo wallet.ValidatorRepo, userID string) validation.RuleFunc {
+	return func(value interface{}) error {
+		orderID := value.(uint)
+
+		ok, err := repo.DoesUserOrderOpen(ctx, orderID, userID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("order does not exist")
+		}
+
+		empty, err := repo.IsOrderEmpty(ctx, orderID)
+		if err != nil {
+			return err
+		}
+		if empty {
+			return errors.New("order is empty")
+		}
+		return nil
+	}
+}
+
+func isOrderPaid(ctx context.Context, repo wallet.ValidatorRepo) validation.RuleFunc {
+	return func(value interface{}) error {
+		orderID := value.(uint)
+
+		ok, err := repo.IsOrderPaid(ctx, orderID)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			return errors.New("order is not paid")
+		}
+		return nil
+	}
+}
+
+func ValidateGetWallet(storage repository.Storage) wallet.ValidateGetWallet {
+	return func(ctx context.Context, req dto.GetWalletRequest) error {
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.UserID, is.UUIDv4, validation.By(doesUserExist(ctx, storage))),
+		)
+	}
+}
+
+func ValidateAddGiftCard(storage repository.Storage) wallet.ValidateAddGiftCard {
+	return func(ctx context.Context, req dto.AddGiftCardRequest) error {
+		if err := validation.ValidateStruct(&req,
+			validation.Field(&req.UserID, is.UUIDv4, validation.By(doesUserExist(ctx, storage))),
+		); err != nil {
+			return err
+		}
+
+		conf := config.Conf.GetGiftCardConfig()
+		return validation.ValidateStruct(&req.GiftCard,
+			validation.Field(&req.GiftCard.Amount, validation.Required, validation.Min(conf.MinAmount), validation.Max(conf.MaxAmount)),
+			validation.Field(&req.GiftCard.RecipientEmail, validation.Required, is.Email, validation.Length(1, 100)),
+			validation.Field(&req.GiftCard.Message, validation.Length(0, 500)),
+		)
+	}
+}
+
+func ValidateRemoveGiftCard(storage repository.Storage) wallet.ValidateRemoveGiftCard {
+	return func(ctx context.Context, req dto.RemoveGiftCardRequest) error {
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.UserID, is.UUIDv4),
+			validation.Field(&req.GiftCardID, validation.Required, validation.By(doesGiftCardExist(ctx, storage, req.UserID))),
+		)
+	}
+}
+
+func ValidateRedeemGiftCard(storage repository.Storage) wallet.ValidateRedeemGiftCard {
+	return func(ctx context.Context, req dto.RedeemGiftCardRequest) error {
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.UserID, is.UUIDv4, validation.By(doesUserExist(ctx, storage))),
+			validation.Field(&req.Code, validation.Required, is.Alphanumeric, validation.Length(16, 16)),
+		)
+	}
+}
+
+func ValidateSetOrderWallet(storage repository.Storage) wallet.ValidateSetOrderWallet {
+	return func(ctx context.Context, req dto.SetOrderWalletRequest) error {
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.UserID, is.UUIDv4),
+			validation.Field(&req.OrderID, validation.Required, validation.By(doesUserOrderOpen(ctx, storage, req.UserID))),
+		)
+	}
+}
+
+func ValidatePayOrderWithWallet(storage repository.Storage) wallet.ValidatePayOrderWithWallet {
+	return func(ctx context.Context, req dto.PayOrderWithWalletRequest) error {
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.UserID, is.UUIDv4),
+			validation.Field(&req.OrderID, validation.Required, validation.By(doesUserOrderOpen(ctx, storage, req.UserID))),
+		)
+	}
+}
+
+func ValidateRefundOrder(storage repository.Storage) wallet.ValidateRefundOrder {
+	return func(ctx context.Context, req dto.RefundOrderRequest) error {
+		return validation.ValidateStruct(&req,
+			validation.Field(&req.OrderID, validation.Required, validation.By(doesOrderExist(ctx, storage)), validation.By(isOrderPaid(ctx, storage))),
+			validation.Field(&req.Amount, validation.Required),
+			validation.Field(&req.Note, validation.Length(0, 200)),
+		)
+	}
+}